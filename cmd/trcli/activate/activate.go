@@ -45,7 +45,7 @@ func activate(
 		if err != nil {
 			return xerrors.Errorf("unable to load transfer: %w", err)
 		}
-		transfer.Runtime = rt
+		transfer.Runtime = config.WorkerRuntime(transfer, rt)
 
 		if metricsPrefix != "" {
 			registry = registry.WithPrefix(metricsPrefix)
@@ -75,17 +75,26 @@ func RunActivate(
 	logger.Log.Infof("run activate with: %T", cp)
 	op := new(model.TransferOperation)
 	op.OperationID = transfer.ID + "/activation"
-	err := tasks.ActivateDelivery(
-		context.Background(),
-		op,
-		cp,
-		*transfer,
-		registry.WithTags(map[string]string{
-			"resource_id": transfer.ID,
-			"name":        transfer.TransferName,
-		}),
-	)
-	if err != nil {
+	activate := func() error {
+		return tasks.ActivateDelivery(
+			context.Background(),
+			op,
+			cp,
+			*transfer,
+			registry.WithTags(map[string]string{
+				"resource_id": transfer.ID,
+				"name":        transfer.TransferName,
+			}),
+		)
+	}
+	if !transfer.IsMain() {
+		if err := tasks.RunSecondaryWorker(context.Background(), cp, op.OperationID, transfer.CurrentJobIndex(), activate); err != nil {
+			return xerrors.Errorf("activation of secondary worker failed with: %w", err)
+		}
+		logger.Log.Info("Activation of secondary worker completed")
+		return nil
+	}
+	if err := activate(); err != nil {
 		return xerrors.Errorf("activation failed with: %w", err)
 	}
 
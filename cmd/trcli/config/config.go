@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"reflect"
@@ -50,7 +51,18 @@ func ParseTransfer(yaml []byte) (*model.Transfer, error) {
 		return nil, xerrors.Errorf("failed to construct target: %w", err)
 	}
 
-	transfer := transfer(source, target, tr)
+	secretRefs, err := resolveSecrets(source, target)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to resolve secrets: %w", err)
+	}
+
+	rt, err := runtime(tr)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to construct runtime: %w", err)
+	}
+
+	transfer := transfer(source, target, rt, tr)
+	transfer.SecretRefs = secretRefs
 
 	transfer.FillDependentFields()
 	if tr.Transformation != nil && len(tr.Transformation.Transformers) > 0 {
@@ -132,6 +144,23 @@ func substituteEnv(val interface{}) interface{} {
 	}
 }
 
+// resolveSecrets replaces secret references of endpoints params by secrets. Secrets are resolved only for
+// configs loaded by trcli, so references are never resolved for endpoints constructed from untrusted JSON.
+func resolveSecrets(source model.Source, target model.Destination) (*model.TransferSecretRefs, error) {
+	srcRefs, err := model.ResolveSecrets(context.Background(), source)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to resolve source secrets: %w", err)
+	}
+	dstRefs, err := model.ResolveSecrets(context.Background(), target)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to resolve destination secrets: %w", err)
+	}
+	if len(srcRefs) == 0 && len(dstRefs) == 0 {
+		return nil, nil
+	}
+	return &model.TransferSecretRefs{Src: srcRefs, Dst: dstRefs}, nil
+}
+
 func ParseTransferYaml(rawData []byte) (*TransferYamlView, error) {
 	var transfer TransferYamlView
 	if err := yaml.Unmarshal(rawData, &transfer); err != nil {
@@ -243,9 +272,40 @@ func target(tr *TransferYamlView) (model.Destination, error) {
 	return model.NewDestination(tr.Dst.Type, string(rawJSON))
 }
 
-func transfer(source model.Source, target model.Destination, tr *TransferYamlView) *model.Transfer {
-	rt := new(abstract.LocalRuntime)
+func runtime(tr *TransferYamlView) (abstract.Runtime, error) {
+	if tr.Runtime == nil || tr.Runtime.Type == "" || abstract.RuntimeType(tr.Runtime.Type) == abstract.LocalRuntimeType {
+		rt := new(abstract.LocalRuntime)
+		rt.WithDefaults()
+		return rt, nil
+	}
+	params, err := yaml.Marshal(tr.Runtime.Params)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal runtime params: %w", err)
+	}
+	rt, err := abstract.NewRuntime(abstract.RuntimeType(tr.Runtime.Type), string(params))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to parse runtime: %w", err)
+	}
 	rt.WithDefaults()
+	if err := rt.Validate(); err != nil {
+		return nil, xerrors.Errorf("invalid %s runtime: %w", tr.Runtime.Type, err)
+	}
+	return rt, nil
+}
+
+// WorkerRuntime is runtime of this worker: runtime of transfer drives *main* worker,
+// while secondary workers and transfers with local runtime are driven by job index flags
+func WorkerRuntime(transfer *model.Transfer, flags abstract.Runtime) abstract.Runtime {
+	if _, ok := transfer.Runtime.(*abstract.LocalRuntime); ok || transfer.Runtime == nil {
+		return flags
+	}
+	if rt, ok := flags.(abstract.ShardingTaskRuntime); ok && !rt.IsMain() {
+		return flags
+	}
+	return transfer.Runtime
+}
+
+func transfer(source model.Source, target model.Destination, rt abstract.Runtime, tr *TransferYamlView) *model.Transfer {
 	transfer := new(model.Transfer)
 	transfer.ID = tr.ID
 	transfer.TransferName = tr.TransferName
@@ -261,6 +321,7 @@ func transfer(source model.Source, target model.Destination, tr *TransferYamlVie
 	transfer.RegularSnapshot = tr.RegularSnapshot
 	transfer.DataObjects = tr.DataObjects
 	transfer.TypeSystemVersion = tr.TypeSystemVersion
+	transfer.Record = tr.Record
 	return transfer
 }
 
@@ -284,5 +345,19 @@ func NewYamlView(tr *model.Transfer) *TransferYamlView {
 		Transformation:    transformations,
 		DataObjects:       tr.DataObjects,
 		TypeSystemVersion: tr.TypeSystemVersion,
+		Record:            tr.Record,
+		Runtime:           yamlRuntime(tr.Runtime),
+	}
+}
+
+func yamlRuntime(rt abstract.Runtime) *Runtime {
+	if rt == nil || rt.Type() == abstract.LocalRuntimeType {
+		return nil
+	}
+	// runtime params are parsed from json, so they are kept in the same form
+	var params map[string]any
+	if data, err := json.Marshal(rt); err == nil {
+		_ = json.Unmarshal(data, &params)
 	}
+	return &Runtime{Type: string(rt.Type()), Params: params}
 }
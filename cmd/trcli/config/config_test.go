@@ -12,7 +12,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
 	"github.com/transferia/transferia/pkg/providers/mongo"
+	"github.com/transferia/transferia/pkg/runtime/kubernetes"
 	_ "github.com/transferia/transferia/pkg/transformer/registry"
 )
 
@@ -124,6 +127,66 @@ dst:
 	require.Equal(t, msrc.BatchingParams.BatchFlushInterval, 10*time.Second)
 }
 
+func TestSecrets(t *testing.T) {
+	t.Setenv("CONFIG_TEST_PASSWORD", "secret")
+
+	transfer, err := ParseTransfer([]byte(`
+src:
+  type: mongo
+  params:
+    Password: env://CONFIG_TEST_PASSWORD
+dst:
+  type: stdout
+`))
+	require.NoError(t, err)
+	require.Equal(t, model.SecretString("secret"), transfer.Src.(*mongo.MongoSource).Password)
+	require.Equal(t, &model.TransferSecretRefs{
+		Src: model.SecretRefs{"Password": "env://CONFIG_TEST_PASSWORD"},
+		Dst: model.SecretRefs{},
+	}, transfer.SecretRefs)
+
+	// endpoints constructed from JSON keep references as is
+	src, err := model.NewSource("mongo", `{"Password": "env://CONFIG_TEST_PASSWORD"}`)
+	require.NoError(t, err)
+	require.Equal(t, model.SecretString("env://CONFIG_TEST_PASSWORD"), src.(*mongo.MongoSource).Password)
+}
+
+func TestRuntime(t *testing.T) {
+	view, err := ParseTransferYaml([]byte(`
+src:
+  type: mongo
+dst:
+  type: stdout
+runtime:
+  type: kubernetes
+  params:
+    job_count: 4
+    health_timeout: 1m
+`))
+	require.NoError(t, err)
+	rt, err := runtime(view)
+	require.NoError(t, err)
+	krt, ok := rt.(*kubernetes.Runtime)
+	require.True(t, ok)
+	require.Equal(t, 4, krt.WorkersNum())
+	require.Equal(t, time.Minute, krt.HealthTimeout.Duration)
+
+	transfer := &model.Transfer{Runtime: rt}
+	require.Equal(t, rt, WorkerRuntime(transfer, &abstract.LocalRuntime{CurrentJob: 0}))
+	secondary := &abstract.LocalRuntime{CurrentJob: 2, ShardingUpload: abstract.ShardUploadParams{JobCount: 4, ProcessCount: 1}}
+	require.Equal(t, secondary, WorkerRuntime(transfer, secondary))
+
+	view.Runtime.Params = map[string]any{"job_count": 0}
+	_, err = runtime(view)
+	require.Error(t, err)
+
+	view.Runtime = nil
+	rt, err = runtime(view)
+	require.NoError(t, err)
+	flags := &abstract.LocalRuntime{CurrentJob: 0, ShardingUpload: abstract.ShardUploadParams{JobCount: 2, ProcessCount: 1}}
+	require.Equal(t, flags, WorkerRuntime(&model.Transfer{Runtime: rt}, flags))
+}
+
 func TestTransformer(t *testing.T) {
 	t.Run("valid", func(t *testing.T) {
 		transfer, err := ParseTransferYaml([]byte(`
@@ -28,8 +28,8 @@ func (e Endpoint) RawParams() string {
 }
 
 type Runtime struct {
-	Type   string
-	Params interface{}
+	Type   string      `description:"runtime type, e.g. kubernetes, local by default"`
+	Params interface{} `description:"runtime params, depend on its type"`
 }
 
 type UploadTables struct {
@@ -37,20 +37,22 @@ type UploadTables struct {
 }
 
 type TransferYamlView struct {
-	ID                string
-	TransferName      string
-	Description       string
-	Labels            string
-	Status            model.TransferStatus
-	Type              abstract.TransferType
-	FolderID          string
-	CloudID           string
-	Src               Endpoint
-	Dst               Endpoint
-	RegularSnapshot   *abstract.RegularSnapshot `yaml:"regular_snapshot"`
-	Transformation    *transformer.Transformers `yaml:"transformation"`
-	DataObjects       *model.DataObjects        `yaml:"data_objects"`
-	TypeSystemVersion int                       `yaml:"type_system_version"`
+	ID                string                    `description:"transfer id, also a key of transfer state in coordinator"`
+	TransferName      string                    `description:"human readable transfer name"`
+	Description       string                    `description:"free form description of transfer"`
+	Labels            string                    `description:"transfer labels"`
+	Status            model.TransferStatus      `description:"ignored, transfer status is managed by runtime"`
+	Type              abstract.TransferType     `description:"what transfer does: snapshot, replication or both"`
+	FolderID          string                    `description:"folder of transfer, used for metering"`
+	CloudID           string                    `description:"cloud of transfer, used for metering"`
+	Src               Endpoint                  `description:"source endpoint, params depend on its type"`
+	Dst               Endpoint                  `description:"destination endpoint, params depend on its type"`
+	RegularSnapshot   *abstract.RegularSnapshot `yaml:"regular_snapshot" description:"schedule of regular and incremental snapshots"`
+	Transformation    *transformer.Transformers `yaml:"transformation" description:"transformers applied to every row on its way to destination"`
+	DataObjects       *model.DataObjects        `yaml:"data_objects" description:"tables to transfer, all tables if empty"`
+	TypeSystemVersion int                       `yaml:"type_system_version" description:"version of type mapping between source and destination"`
+	Record            *model.RecordConfig       `yaml:"record" description:"record change item batches into file to replay them later by trcli replay"`
+	Runtime           *Runtime                  `yaml:"runtime" description:"runtime of sharded snapshot, e.g. kubernetes one which starts secondary workers as pods"`
 }
 
 func (v TransferYamlView) Validate() error {
@@ -59,9 +61,13 @@ func (v TransferYamlView) Validate() error {
 	}
 	var errs error
 	for _, tr := range v.Transformation.Transformers {
-		_, err := transformer.New(tr.Type(), tr.Config(), logger.Log, abstract.TransformationRuntimeOpts{JobIndex: 0})
+		constructed, err := transformer.New(tr.Type(), tr.Config(), logger.Log, abstract.TransformationRuntimeOpts{JobIndex: 0})
 		if err != nil {
 			errs = multierr.Append(errs, xerrors.Errorf("unable to construct %s(%s): %w", tr.Type(), tr.ID(), err))
+			continue
+		}
+		if err := transformer.CloseTransformers([]abstract.Transformer{constructed}); err != nil {
+			logger.Log.Warnf("unable to close transformer %s(%s): %v", tr.Type(), tr.ID(), err)
 		}
 	}
 	if errs != nil {
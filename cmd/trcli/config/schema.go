@@ -0,0 +1,389 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/transformer"
+	"github.com/transferia/transferia/pkg/util"
+)
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	// schemaEnums lists values of string types used in transfer yaml, go types do not carry them
+	schemaEnums = map[reflect.Type][]any{
+		reflect.TypeOf(abstract.TransferType("")): {
+			string(abstract.TransferTypeSnapshotAndIncrement),
+			string(abstract.TransferTypeSnapshotOnly),
+			string(abstract.TransferTypeIncrementOnly),
+		},
+		reflect.TypeOf(transformer.OutputType("")): {
+			string(transformer.SinkErrorsOutput),
+			string(transformer.DevnullErrorsOutput),
+		},
+	}
+
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+	unsafeDefNameChars = regexp.MustCompile(`[^A-Za-z0-9_.]`)
+)
+
+// JSONSchema builds JSON Schema of transfer yaml from all registered sources, destinations and transformers.
+// Source and destination are discriminated by their type, params have defaults of the endpoint WithDefaults.
+func JSONSchema() (map[string]any, error) {
+	// transfer yaml is decoded by yaml.v3, while endpoint params and transformer configs are decoded by their json tags
+	yamlSchema := newSchemaBuilder(yamlFieldName, "")
+	jsonSchema := newSchemaBuilder(jsonFieldName, "params.")
+
+	root := yamlSchema.structSchema(reflect.TypeOf(TransferYamlView{}))
+	properties := root["properties"].(map[string]any)
+
+	src, err := endpointsSchema(jsonSchema, model.KnownSources(), func(typ abstract.ProviderType) (model.EndpointParams, error) {
+		factory, ok := model.SourceF(typ)
+		if !ok {
+			return nil, xerrors.Errorf("unknown source provider: %s", typ)
+		}
+		return factory(), nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("unable to build sources schema: %w", err)
+	}
+	src["description"] = properties["src"].(map[string]any)["description"]
+	properties["src"] = src
+
+	dst, err := endpointsSchema(jsonSchema, model.KnownDestinations(), func(typ abstract.ProviderType) (model.EndpointParams, error) {
+		factory, ok := model.DestinationF(typ)
+		if !ok {
+			return nil, xerrors.Errorf("unknown destination provider: %s", typ)
+		}
+		return factory(), nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("unable to build destinations schema: %w", err)
+	}
+	dst["description"] = properties["dst"].(map[string]any)["description"]
+	properties["dst"] = dst
+
+	transformers, err := transformersSchema(jsonSchema)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to build transformers schema: %w", err)
+	}
+	yamlSchema.defs[yamlSchema.defName(reflect.TypeOf(transformer.Transformers{}))].(map[string]any)["properties"].(map[string]any)["transformers"] = map[string]any{
+		"type":  "array",
+		"items": transformers,
+	}
+
+	defs := yamlSchema.defs
+	for name, def := range jsonSchema.defs {
+		defs[name] = def
+	}
+
+	root["$schema"] = schemaDraft
+	root["title"] = "Transfer"
+	root["required"] = []string{"src", "dst"}
+	root["$defs"] = defs
+	return root, nil
+}
+
+func endpointsSchema(b *schemaBuilder, types []string, newEndpoint func(typ abstract.ProviderType) (model.EndpointParams, error)) (map[string]any, error) {
+	variants := make([]any, 0, len(types))
+	for _, typ := range types {
+		endpoint, err := newEndpoint(abstract.ProviderType(typ))
+		if err != nil {
+			return nil, xerrors.Errorf("unable to init %s model: %w", typ, err)
+		}
+		params, err := b.endpointParamsSchema(endpoint)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to build %s params schema: %w", typ, err)
+		}
+		description := abstract.ProviderType(typ).Name()
+		if describable, ok := endpoint.(model.Describable); ok && describable.Describe().Usage != "" {
+			description = describable.Describe().Usage
+		}
+		variants = append(variants, map[string]any{
+			"title":       typ,
+			"description": description,
+			"type":        "object",
+			"properties": map[string]any{
+				"type": map[string]any{"const": typ},
+				// params may also be given as raw yaml or json string
+				"params": map[string]any{"anyOf": []any{params, map[string]any{"type": "string"}}},
+			},
+			"required": []string{"type"},
+		})
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"type":   map[string]any{"type": "string", "enum": toAnySlice(types)},
+			"params": map[string]any{},
+		},
+		"required": []string{"type"},
+		"oneOf":    variants,
+	}, nil
+}
+
+func transformersSchema(b *schemaBuilder) (map[string]any, error) {
+	variants := make([]any, 0)
+	for _, name := range transformer.KnownTransformerNames() {
+		cfg, err := transformer.NewConfig(abstract.TransformerType(name))
+		if err != nil {
+			return nil, xerrors.Errorf("unable to init %s config: %w", name, err)
+		}
+		cfgSchema := map[string]any{}
+		if cfgType := reflect.TypeOf(cfg); cfgType != nil {
+			cfgSchema = b.typeSchema(cfgType)
+		}
+		description := name
+		if describable, ok := cfg.(model.Describable); ok && describable.Describe().Usage != "" {
+			description = describable.Describe().Usage
+		}
+		// transformer type is a key of the item, snakified on parse, so camel case form is accepted too
+		for _, key := range uniqueStrings(name, util.LowerCamelCase(name)) {
+			variants = append(variants, map[string]any{
+				"title":       key,
+				"description": description,
+				"type":        "object",
+				"properties": map[string]any{
+					key:                    cfgSchema,
+					string(transformer.ID): map[string]any{"type": "string"},
+				},
+				"required":             []string{key},
+				"additionalProperties": false,
+			})
+		}
+	}
+	return map[string]any{"oneOf": variants}, nil
+}
+
+type fieldNameFunc func(field reflect.StructField) (name string, inline bool, ok bool)
+
+type schemaBuilder struct {
+	fieldName fieldNameFunc
+	defPrefix string
+	defs      map[string]any
+	defNames  map[reflect.Type]string
+}
+
+func newSchemaBuilder(fieldName fieldNameFunc, defPrefix string) *schemaBuilder {
+	return &schemaBuilder{
+		fieldName: fieldName,
+		defPrefix: defPrefix,
+		defs:      map[string]any{},
+		defNames:  map[reflect.Type]string{},
+	}
+}
+
+// endpointParamsSchema inlines schema of endpoint struct, so defaults of the endpoint are not shared with other endpoints.
+// Endpoint must be created by its factory, without defaults applied.
+func (b *schemaBuilder) endpointParamsSchema(endpoint model.EndpointParams) (map[string]any, error) {
+	typ := reflect.TypeOf(endpoint)
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	result := b.structSchema(typ)
+
+	empty, err := toJSONMap(endpoint)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal empty model: %w", err)
+	}
+	endpoint.WithDefaults()
+	defaults, err := toJSONMap(endpoint)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal model with defaults: %w", err)
+	}
+	properties := result["properties"].(map[string]any)
+	for name, value := range defaults {
+		property, ok := properties[name].(map[string]any)
+		if !ok || value == nil || reflect.DeepEqual(value, empty[name]) {
+			continue
+		}
+		property["default"] = value
+	}
+	return result, nil
+}
+
+func (b *schemaBuilder) typeSchema(typ reflect.Type) map[string]any {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if enum, ok := schemaEnums[typ]; ok {
+		return map[string]any{"type": "string", "enum": enum}
+	}
+	switch typ {
+	case durationType:
+		return map[string]any{"type": []string{"string", "integer"}, "description": "duration, e.g. 10s or 1h30m, or nanoseconds"}
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	pointer := reflect.PointerTo(typ)
+	if typ.Kind() != reflect.String && (typ.Implements(jsonUnmarshalerType) || pointer.Implements(jsonUnmarshalerType)) {
+		return map[string]any{}
+	}
+	if typ.Kind() != reflect.String && (typ.Implements(textUnmarshalerType) || pointer.Implements(textUnmarshalerType)) {
+		return map[string]any{"type": "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string"}
+		}
+		return map[string]any{"type": "array", "items": b.typeSchema(typ.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.typeSchema(typ.Elem())}
+	case reflect.Struct:
+		name := b.defName(typ)
+		if _, ok := b.defs[name]; !ok {
+			// reserve the name first, recursive types refer to themselves
+			b.defs[name] = map[string]any{}
+			b.defs[name] = b.structSchema(typ)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	default:
+		// interfaces are decoded into arbitrary values, funcs and channels are never decoded
+		return map[string]any{}
+	}
+}
+
+func (b *schemaBuilder) structSchema(typ reflect.Type) map[string]any {
+	properties := map[string]any{}
+	b.addFields(typ, properties)
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+func (b *schemaBuilder) addFields(typ reflect.Type, properties map[string]any) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, inline, ok := b.fieldName(field)
+		if !ok {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if inline && fieldType.Kind() == reflect.Struct {
+			b.addFields(fieldType, properties)
+			continue
+		}
+		property := b.typeSchema(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			if _, ok := property["$ref"]; ok {
+				property = map[string]any{"allOf": []any{property}}
+			}
+			property["description"] = description
+		}
+		properties[name] = property
+	}
+}
+
+func (b *schemaBuilder) defName(typ reflect.Type) string {
+	if name, ok := b.defNames[typ]; ok {
+		return name
+	}
+	name := b.defPrefix + unsafeDefNameChars.ReplaceAllString(path.Base(typ.PkgPath())+"."+typ.Name(), "_")
+	for _, other := range b.defNames {
+		if other == name {
+			name = fmt.Sprintf("%s_%d", name, len(b.defNames))
+			break
+		}
+	}
+	b.defNames[typ] = name
+	return name
+}
+
+// yamlFieldName follows yaml.v3: tag name or lowercased field name, embedded structs are not inlined without tag
+func yamlFieldName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" || !field.IsExported() {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if strings.Contains(opts, "inline") {
+		return "", true, true
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, false, true
+}
+
+// jsonFieldName follows encoding/json: tag name or field name, untagged embedded structs are inlined
+func jsonFieldName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if field.Anonymous && name == "" {
+		return "", true, true
+	}
+	if !field.IsExported() {
+		return "", false, false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, false, true
+}
+
+func toJSONMap(value any) (map[string]any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func toAnySlice(values []string) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+func uniqueStrings(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if !slices.Contains(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/require"
+	_ "github.com/transferia/transferia/pkg/providers/postgres"
+	_ "github.com/transferia/transferia/pkg/providers/stdout"
+	sig_yaml "sigs.k8s.io/yaml"
+)
+
+func TestJSONSchema(t *testing.T) {
+	transferSchema, err := JSONSchema()
+	require.NoError(t, err)
+	data, err := json.Marshal(transferSchema)
+	require.NoError(t, err)
+
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource("transfer.json", bytes.NewReader(data)))
+	compiled, err := compiler.Compile("transfer.json")
+	require.NoError(t, err)
+
+	validate := func(transferYaml string) error {
+		raw, err := sig_yaml.YAMLToJSON([]byte(transferYaml))
+		require.NoError(t, err)
+		var doc any
+		require.NoError(t, json.Unmarshal(raw, &doc))
+		return compiled.Validate(doc)
+	}
+
+	valid := `
+id: pg2stdout
+type: SNAPSHOT_ONLY
+src:
+  type: pg
+  params:
+    Hosts: ["localhost"]
+    Port: 5432
+    Database: db
+    User: user
+    Password: ${PG_PASSWORD}
+dst:
+  type: stdout
+  params:
+    ShowData: false
+regular_snapshot:
+  enabled: true
+  interval: 10m
+  incremental:
+  - namespace: public
+    name: orders
+    cursor_field: id
+transformation:
+  transformers:
+  - renameTables:
+      renameTables:
+      - originalName:
+          name: a
+          nameSpace: public
+        newName:
+          name: b
+          nameSpace: public
+    transformerId: rename
+data_objects:
+  include_objects:
+  - public.orders
+`
+	require.NoError(t, validate(valid))
+	require.NoError(t, validate(`
+src:
+  type: pg
+  params: |
+    {"Hosts": ["localhost"]}
+dst:
+  type: stdout
+`))
+
+	require.Error(t, validate(`
+src:
+  type: mango
+dst:
+  type: stdout
+`), "unknown source type")
+	require.Error(t, validate(`
+src:
+  type: pg
+  params:
+    Port: "not a port"
+dst:
+  type: stdout
+`), "invalid param type")
+	require.Error(t, validate(`
+type: SOMETIMES
+src:
+  type: pg
+dst:
+  type: stdout
+`), "unknown transfer type")
+	require.Error(t, validate(`
+src:
+  type: pg
+dst:
+  type: stdout
+transformation:
+  transformers:
+  - boboTables: {}
+`), "unknown transformer")
+	require.Error(t, validate(`
+src:
+  type: pg
+`), "no destination")
+
+	pgParams := transferSchema["properties"].(map[string]any)["src"].(map[string]any)["oneOf"]
+	var pgSchema map[string]any
+	for _, variant := range pgParams.([]any) {
+		if variant.(map[string]any)["title"] == "pg" {
+			pgSchema = variant.(map[string]any)
+		}
+	}
+	require.NotNil(t, pgSchema)
+	params := pgSchema["properties"].(map[string]any)["params"].(map[string]any)["anyOf"].([]any)[0].(map[string]any)
+	require.Equal(t, float64(6432), params["properties"].(map[string]any)["Port"].(map[string]any)["default"])
+}
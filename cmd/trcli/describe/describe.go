@@ -1,10 +1,12 @@
 package describe
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/spf13/cobra"
+	"github.com/transferia/transferia/cmd/trcli/config"
 	"github.com/transferia/transferia/library/go/core/xerrors"
 	"github.com/transferia/transferia/pkg/abstract"
 	"github.com/transferia/transferia/pkg/abstract/model"
@@ -46,6 +48,24 @@ func DescribeCommand() *cobra.Command {
 	}
 	trsfmr.Flags().StringVar(&transformerType, "type", "sql", fmt.Sprintf("Type of transformer to describe, one of: %v", transformer.KnownTransformerNames()))
 
+	schema := &cobra.Command{
+		Use:   "schema",
+		Short: "Print JSON Schema of transfer yaml, for editors autocompletion and validation in CI",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonSchema, err := config.JSONSchema()
+			if err != nil {
+				return xerrors.Errorf("unable to build schema: %w", err)
+			}
+			data, err := json.MarshalIndent(jsonSchema, "", "  ")
+			if err != nil {
+				return xerrors.Errorf("unable to marshal schema: %w", err)
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return err
+		},
+	}
+
 	describe := &cobra.Command{
 		Use:   "describe",
 		Short: "Describe endpoint type",
@@ -53,6 +73,7 @@ func DescribeCommand() *cobra.Command {
 	cobraaux.RegisterCommand(describe, source)
 	cobraaux.RegisterCommand(describe, destination)
 	cobraaux.RegisterCommand(describe, trsfmr)
+	cobraaux.RegisterCommand(describe, schema)
 	return describe
 }
 
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"github.com/transferia/transferia/cmd/trcli/activate"
 	"github.com/transferia/transferia/cmd/trcli/check"
 	"github.com/transferia/transferia/cmd/trcli/describe"
+	"github.com/transferia/transferia/cmd/trcli/plan"
+	"github.com/transferia/transferia/cmd/trcli/replay"
 	"github.com/transferia/transferia/cmd/trcli/replicate"
+	"github.com/transferia/transferia/cmd/trcli/serve"
+	"github.com/transferia/transferia/cmd/trcli/state"
+	"github.com/transferia/transferia/cmd/trcli/throttle"
 	"github.com/transferia/transferia/cmd/trcli/upload"
 	"github.com/transferia/transferia/cmd/trcli/validate"
 	"github.com/transferia/transferia/internal/logger"
@@ -21,6 +30,9 @@ import (
 	"github.com/transferia/transferia/pkg/cobraaux"
 	"github.com/transferia/transferia/pkg/coordinator/s3coordinator"
 	_ "github.com/transferia/transferia/pkg/dataplane"
+	"github.com/transferia/transferia/pkg/middlewares/ratelimit"
+	_ "github.com/transferia/transferia/pkg/runtime/kubernetes"
+	"github.com/transferia/transferia/pkg/secret"
 	"github.com/transferia/transferia/pkg/serverutil"
 	zp "go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -32,6 +44,8 @@ var (
 	defaultLogLevel    = "debug"
 	defaultLogConfig   = "console"
 	defaultCoordinator = "memory"
+
+	defaultShutdownTimeout = 30 * time.Second
 )
 
 func main() {
@@ -41,11 +55,13 @@ func main() {
 	loggerConfig := newLoggerConfig()
 	logger.Log = zap.Must(loggerConfig)
 	hcPort := 0
+	adminAddr := ""
 	logLevel := defaultLogLevel
 	logConfig := defaultLogConfig
 	coordinatorTyp := defaultCoordinator
 	coordinatorS3Bucket := ""
 	runProfiler := false
+	shutdownTimeout := defaultShutdownTimeout
 
 	promRegistry, registry := internal_metrics.NewPrometheusRegistryWithNameProcessor()
 
@@ -109,7 +125,16 @@ func main() {
 				return xerrors.Errorf("unsupported value \"%s\" for --log-level", logLevel)
 			}
 
-			logger.Log = zap.Must(loggerConfig)
+			if strings.Contains(cmd.CommandPath(), "plan") || strings.Contains(cmd.CommandPath(), "state") || strings.Contains(cmd.CommandPath(), "throttle") {
+				// plan, state and throttle print their result to stdout, so logs must not be mixed into it
+				loggerConfig.OutputPaths = []string{"stderr"}
+			}
+
+			zapLogger, err := loggerConfig.Build(zp.WrapCore(secret.NewRedactingCore))
+			if err != nil {
+				return xerrors.Errorf("unable to build logger: %w", err)
+			}
+			logger.Log = zap.NewWithCore(zapLogger.Core(), zp.AddCaller())
 
 			switch coordinatorTyp {
 			case defaultCoordinator:
@@ -126,14 +151,30 @@ func main() {
 			}
 
 			go serverutil.RunHealthCheckOnPort(hcPort)
+			if adminAddr != "" {
+				// admin API changes running transfers, so it is served apart from health check, on localhost by default
+				go func() {
+					adminMux := http.NewServeMux()
+					adminMux.Handle("/rate_limits", ratelimit.AdminHandler(cp))
+					logger.Log.Infof("admin API is uprising on %v", adminAddr)
+					if err := http.ListenAndServe(adminAddr, adminMux); err != nil {
+						logger.Log.Error("failed to serve admin API", log.Error(err))
+					}
+				}()
+			}
 			return nil
 		},
 	}
 
 	cobraaux.RegisterCommand(rootCommand, activate.ActivateCommand(&cp, &rt, registry))
 	cobraaux.RegisterCommand(rootCommand, check.CheckCommand())
-	cobraaux.RegisterCommand(rootCommand, replicate.ReplicateCommand(&cp, &rt, registry))
-	cobraaux.RegisterCommand(rootCommand, upload.UploadCommand(&cp, &rt, registry))
+	cobraaux.RegisterCommand(rootCommand, plan.PlanCommand(registry))
+	cobraaux.RegisterCommand(rootCommand, replay.ReplayCommand(&cp, registry))
+	cobraaux.RegisterCommand(rootCommand, replicate.ReplicateCommand(&cp, &rt, registry, &shutdownTimeout))
+	cobraaux.RegisterCommand(rootCommand, serve.ServeCommand(&cp, &rt, registry, &shutdownTimeout))
+	cobraaux.RegisterCommand(rootCommand, state.StateCommand(&cp, registry))
+	cobraaux.RegisterCommand(rootCommand, throttle.ThrottleCommand(&cp))
+	cobraaux.RegisterCommand(rootCommand, upload.UploadCommand(&cp, &rt, registry, &shutdownTimeout))
 	cobraaux.RegisterCommand(rootCommand, validate.ValidateCommand())
 	cobraaux.RegisterCommand(rootCommand, describe.DescribeCommand())
 
@@ -146,8 +187,12 @@ func main() {
 	rootCommand.PersistentFlags().IntVar(&rt.ShardingUpload.JobCount, "coordinator-job-count", 0, "Worker job count, if more then 1 - run consider as sharded, coordinator is required to be non memory")
 	rootCommand.PersistentFlags().IntVar(&rt.ShardingUpload.ProcessCount, "coordinator-process-count", 1, "Worker process count, how many readers must be opened for each job")
 	rootCommand.PersistentFlags().IntVar(&hcPort, "health-check-port", 3000, "Port to used as health-check API")
+	rootCommand.PersistentFlags().StringVar(&adminAddr, "admin-addr", "localhost:3002", "Address of admin API, e.g. /rate_limits, empty to disable")
+	rootCommand.PersistentFlags().DurationVar(&shutdownTimeout, "shutdown-timeout", defaultShutdownTimeout, "How long to wait for in-flight data to be pushed and committed on SIGTERM/SIGINT before a forced stop")
 
-	err := rootCommand.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	err := rootCommand.ExecuteContext(ctx)
+	stop()
 	if err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,269 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/transferia/transferia/cmd/trcli/config"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/providers"
+	"github.com/transferia/transferia/pkg/storage"
+	"github.com/transferia/transferia/pkg/transformer"
+	"github.com/transferia/transferia/pkg/transformer/registry/rename"
+	"github.com/transferia/transferia/pkg/worker/tasks"
+)
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+type Column struct {
+	Name         string `json:"name"`
+	DataType     string `json:"data_type"`
+	OriginalType string `json:"original_type,omitempty"`
+	PrimaryKey   bool   `json:"primary_key,omitempty"`
+}
+
+type TablePlan struct {
+	Source       string   `json:"source"`
+	Target       string   `json:"target"`
+	EtaRows      uint64   `json:"eta_rows"`
+	Parts        int      `json:"parts"`
+	Transformers []string `json:"transformers,omitempty"`
+	Schema       []Column `json:"schema"`
+	DDL          []string `json:"ddl"`
+}
+
+// Plan is a preview of what transfer does on activation: which tables are uploaded,
+// how they are transformed and which DDL is issued on destination.
+type Plan struct {
+	TransferID  string                `json:"transfer_id"`
+	Type        abstract.TransferType `json:"type"`
+	Source      abstract.ProviderType `json:"source"`
+	Destination abstract.ProviderType `json:"destination"`
+	// DDLRendered is false if destination can not render its DDL, tables DDL are empty then
+	DDLRendered bool        `json:"ddl_rendered"`
+	Tables      []TablePlan `json:"tables"`
+}
+
+func PlanCommand(registry metrics.Registry) *cobra.Command {
+	var transferParams string
+	var output string
+	planCommand := &cobra.Command{
+		Use:   "plan",
+		Short: "Preview tables, schemas and destination DDL of transfer without writing anything",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE:  plan(&transferParams, &output, registry),
+	}
+	planCommand.Flags().StringVar(&transferParams, "transfer", "./transfer.yaml", "path to yaml file with transfer configuration")
+	planCommand.Flags().StringVar(&output, "output", OutputText, fmt.Sprintf("output format, one of: %v, %v", OutputText, OutputJSON))
+	return planCommand
+}
+
+func plan(transferYaml *string, output *string, registry metrics.Registry) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		transfer, err := config.TransferFromYaml(transferYaml)
+		if err != nil {
+			return xerrors.Errorf("unable to load transfer: %w", err)
+		}
+		return RunPlan(cmd.Context(), transfer, registry, *output, cmd.OutOrStdout())
+	}
+}
+
+func RunPlan(ctx context.Context, transfer *model.Transfer, registry metrics.Registry, output string, out io.Writer) error {
+	if output != OutputText && output != OutputJSON {
+		return xerrors.Errorf("unsupported output format: %s", output)
+	}
+	result, err := BuildPlan(ctx, transfer, registry)
+	if err != nil {
+		return xerrors.Errorf("unable to build plan: %w", err)
+	}
+	if output == OutputJSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return xerrors.Errorf("unable to marshal plan: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+	_, err = io.WriteString(out, result.String())
+	return err
+}
+
+// BuildPlan resolves tables of the transfer source and renders the result of their upload, nothing is written to destination
+func BuildPlan(ctx context.Context, transfer *model.Transfer, registry metrics.Registry) (*Plan, error) {
+	tables, err := tasks.ObtainAllSrcTables(transfer, registry)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to obtain source tables: %w", err)
+	}
+	parts, err := splitTables(ctx, transfer, registry, tables)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to split tables: %w", err)
+	}
+	transformers, owned, err := transformationChain(ctx, transfer, registry)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to init transformers: %w", err)
+	}
+	defer func() {
+		if err := transformer.CloseTransformers(owned); err != nil {
+			logger.Log.Warnf("unable to close transformers: %v", err)
+		}
+	}()
+	renderer, ddlRendered := providers.Destination[providers.DDLRenderer](logger.Log, registry, coordinator.NewFakeClient(), transfer)
+
+	result := &Plan{
+		TransferID:  transfer.ID,
+		Type:        transfer.Type,
+		Source:      transfer.SrcType(),
+		Destination: transfer.DstType(),
+		DDLRendered: ddlRendered,
+		Tables:      make([]TablePlan, 0, len(tables)),
+	}
+	for _, tableID := range sortedTableIDs(tables) {
+		info := tables[tableID]
+		tablePlan := TablePlan{
+			Source:       tableID.Fqtn(),
+			Target:       tableID.Fqtn(),
+			EtaRows:      info.EtaRow,
+			Parts:        parts[tableID],
+			Transformers: nil,
+			Schema:       nil,
+			DDL:          nil,
+		}
+
+		target := tableID
+		schema := info.Schema
+		if schema == nil {
+			schema = abstract.NewTableSchema(nil)
+		}
+		for _, tr := range transformers {
+			if !tr.Suitable(tableID, schema) {
+				continue
+			}
+			resultSchema, err := tr.ResultSchema(schema)
+			if err != nil {
+				return nil, xerrors.Errorf("unable to build result schema of table %s for: %s: %w", tableID.Fqtn(), tr.Description(), err)
+			}
+			schema = resultSchema
+			if renamer, ok := tr.(*rename.RenameTableTransformer); ok {
+				if altName, ok := renamer.AltNames[target]; ok {
+					target = altName
+				}
+			}
+			tablePlan.Transformers = append(tablePlan.Transformers, tr.Description())
+		}
+		tablePlan.Target = target.Fqtn()
+		for _, col := range schema.Columns() {
+			tablePlan.Schema = append(tablePlan.Schema, Column{
+				Name:         col.ColumnName,
+				DataType:     col.DataType,
+				OriginalType: col.OriginalType,
+				PrimaryKey:   col.PrimaryKey,
+			})
+		}
+
+		if ddlRendered {
+			tablePlan.DDL, err = renderer.RenderDDL(target, schema)
+			if err != nil {
+				return nil, xerrors.Errorf("unable to render DDL of table %s: %w", target.Fqtn(), err)
+			}
+		}
+		result.Tables = append(result.Tables, tablePlan)
+	}
+	return result, nil
+}
+
+func splitTables(ctx context.Context, transfer *model.Transfer, registry metrics.Registry, tables abstract.TableMap) (map[abstract.TableID]int, error) {
+	srcStorage, err := storage.NewStorage(transfer, coordinator.NewFakeClient(), registry)
+	if err != nil {
+		return nil, xerrors.Errorf(tasks.ResolveStorageErrorText, err)
+	}
+	defer srcStorage.Close()
+
+	snapshotLoader := tasks.NewSnapshotLoader(coordinator.NewFakeClient(), "", transfer, registry)
+	tablesParts, err := snapshotLoader.SplitTables(ctx, logger.Log, tables.ConvertToTableDescriptions(), srcStorage)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to split tables: %w", err)
+	}
+	result := map[abstract.TableID]int{}
+	for _, part := range tablesParts {
+		result[*part.ToTableID()]++
+	}
+	return result, nil
+}
+
+// transformationChain builds transformers in the same order as transformation middleware does
+// transformationChain returns all transformers of the transfer and the ones made by this call, which must be closed
+func transformationChain(ctx context.Context, transfer *model.Transfer, registry metrics.Registry) ([]abstract.Transformer, []abstract.Transformer, error) {
+	if err := tasks.AddExtraTransformers(ctx, transfer, registry); err != nil {
+		return nil, nil, xerrors.Errorf("unable to add extra transformers: %w", err)
+	}
+	var owned []abstract.Transformer
+	for _, cfg := range transfer.TransformationConfigs() {
+		tr, err := transformer.New(cfg.Type(), cfg.Config(), logger.Log, abstract.TransformationRuntimeOpts{JobIndex: 0})
+		if err != nil {
+			_ = transformer.CloseTransformers(owned)
+			return nil, nil, xerrors.Errorf("unable to init: %s: %w", cfg.Type(), err)
+		}
+		owned = append(owned, tr)
+	}
+	result := append([]abstract.Transformer{}, owned...)
+	if transfer.Transformation != nil {
+		result = append(result, transfer.Transformation.ExtraTransformers...)
+	}
+	return result, owned, nil
+}
+
+func sortedTableIDs(tables abstract.TableMap) []abstract.TableID {
+	result := make([]abstract.TableID, 0, len(tables))
+	for tableID := range tables {
+		result = append(result, tableID)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Fqtn() < result[j].Fqtn()
+	})
+	return result
+}
+
+func (p *Plan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "transfer %s (%s): %s -> %s, %d tables\n", p.TransferID, p.Type, p.Source, p.Destination, len(p.Tables))
+	if !p.DDLRendered {
+		fmt.Fprintf(&b, "destination %s does not support DDL preview\n", p.Destination)
+	}
+	for _, table := range p.Tables {
+		fmt.Fprintf(&b, "\ntable %s: ~%d rows, %d parts\n", table.Source, table.EtaRows, table.Parts)
+		if table.Target != table.Source {
+			fmt.Fprintf(&b, "  target: %s\n", table.Target)
+		}
+		for _, tr := range table.Transformers {
+			fmt.Fprintf(&b, "  transformer: %s\n", tr)
+		}
+		b.WriteString("  schema:\n")
+		for _, col := range table.Schema {
+			keyMark := ""
+			if col.PrimaryKey {
+				keyMark = " key"
+			}
+			fmt.Fprintf(&b, "    %s %s (%s)%s\n", col.Name, col.DataType, col.OriginalType, keyMark)
+		}
+		if p.DDLRendered && len(table.DDL) == 0 {
+			b.WriteString("  ddl: tables are not created by destination\n")
+		}
+		for _, ddl := range table.DDL {
+			fmt.Fprintf(&b, "  ddl: %s\n", ddl)
+		}
+	}
+	return b.String()
+}
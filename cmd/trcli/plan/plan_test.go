@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/providers/clickhouse"
+	chmodel "github.com/transferia/transferia/pkg/providers/clickhouse/model"
+	"github.com/transferia/transferia/pkg/transformer"
+	"github.com/transferia/transferia/pkg/transformer/registry/rename"
+)
+
+var (
+	usersTable  = abstract.TableID{Namespace: "public", Name: "users"}
+	ordersTable = abstract.TableID{Namespace: "public", Name: "orders"}
+	usersSchema = abstract.NewTableSchema([]abstract.ColSchema{
+		{ColumnName: "id", DataType: "int64", PrimaryKey: true, Required: true},
+		{ColumnName: "name", DataType: "utf8"},
+	})
+)
+
+type planStorage struct {
+	abstract.Storage
+}
+
+func (s *planStorage) TableList(abstract.IncludeTableList) (abstract.TableMap, error) {
+	return abstract.TableMap{
+		usersTable:  abstract.TableInfo{EtaRow: 10, IsView: false, Schema: usersSchema},
+		ordersTable: abstract.TableInfo{EtaRow: 20, IsView: false, Schema: usersSchema},
+	}, nil
+}
+
+func (s *planStorage) Close() {}
+
+func TestBuildPlan(t *testing.T) {
+	dst := &chmodel.ChDestination{
+		Database:      "db",
+		ChClusterName: "",
+	}
+	dst.WithDefaults()
+	transfer := &model.Transfer{
+		ID:   "test-transfer",
+		Type: abstract.TransferTypeSnapshotOnly,
+		Src: &model.MockSource{
+			StorageFactory:   func() abstract.Storage { return &planStorage{} },
+			AllTablesFactory: func() abstract.TableMap { return nil },
+		},
+		Dst: dst,
+		DataObjects: &model.DataObjects{
+			IncludeObjects: []string{"public.users"},
+		},
+		Transformation: &model.Transformation{
+			Transformers: &transformer.Transformers{
+				Transformers: []transformer.Transformer{{
+					rename.RenameTablesTransformerType: rename.Config{
+						RenameTables: []rename.RenameTable{{
+							OriginalName: rename.Table{Namespace: "public", Name: "users"},
+							NewName:      rename.Table{Namespace: "public", Name: "customers"},
+						}},
+					},
+				}},
+			},
+		},
+	}
+	require.Equal(t, clickhouse.ProviderType, transfer.DstType())
+
+	result, err := BuildPlan(context.Background(), transfer, solomon.NewRegistry(nil))
+	require.NoError(t, err)
+	require.True(t, result.DDLRendered)
+	require.Len(t, result.Tables, 1)
+
+	table := result.Tables[0]
+	require.Equal(t, usersTable.Fqtn(), table.Source)
+	require.Equal(t, abstract.TableID{Namespace: "public", Name: "customers"}.Fqtn(), table.Target)
+	require.Equal(t, uint64(10), table.EtaRows)
+	require.Equal(t, 1, table.Parts)
+	require.Len(t, table.Transformers, 1)
+	require.Equal(t, []Column{
+		{Name: "id", DataType: "int64", OriginalType: "", PrimaryKey: true},
+		{Name: "name", DataType: "utf8", OriginalType: "", PrimaryKey: false},
+	}, table.Schema)
+	require.Equal(t, []string{
+		"CREATE TABLE IF NOT EXISTS `customers` (`id` Int64, `name` Nullable(String)) ENGINE=MergeTree() ORDER BY (`id`)",
+	}, table.DDL)
+
+	var out bytes.Buffer
+	require.NoError(t, RunPlan(context.Background(), transfer, solomon.NewRegistry(nil), OutputJSON, &out))
+	var decoded Plan
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Equal(t, result.Tables, decoded.Tables)
+
+	require.Error(t, RunPlan(context.Background(), transfer, solomon.NewRegistry(nil), "yaml", &out))
+}
@@ -0,0 +1,135 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/transferia/transferia/cmd/trcli/config"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/dataplane/provideradapter"
+	"github.com/transferia/transferia/pkg/middlewares"
+	"github.com/transferia/transferia/pkg/record"
+	"github.com/transferia/transferia/pkg/sink"
+)
+
+func ReplayCommand(cp *coordinator.Coordinator, registry metrics.Registry) *cobra.Command {
+	var transferParams string
+	var from string
+	var speed float64
+	var tables []string
+	replayCommand := &cobra.Command{
+		Use:   "replay",
+		Short: "Push recorded change items into transfer destination",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			transfer, err := config.TransferFromYaml(&transferParams)
+			if err != nil {
+				return xerrors.Errorf("unable to load transfer: %w", err)
+			}
+			return RunReplay(cmd.Context(), *cp, transfer, registry, from, ReplayOptions{
+				Speed:  speed,
+				Tables: tables,
+			})
+		},
+	}
+	replayCommand.Flags().StringVar(&transferParams, "transfer", "./transfer.yaml", "path to yaml file with transfer configuration")
+	replayCommand.Flags().StringVar(&from, "from", "./transfer.rec", "path to recording made by trcli replicate --record")
+	replayCommand.Flags().Float64Var(&speed, "speed", 1, "speed factor relative to recorded pace, 0 pushes batches as fast as possible")
+	replayCommand.Flags().StringArrayVar(&tables, "table", nil, "replay only given tables, e.g. public.users or public.*, may be repeated")
+	return replayCommand
+}
+
+type ReplayOptions struct {
+	// Speed is a speed factor relative to recorded pace, zero disables delays between batches
+	Speed float64
+	// Tables to replay, all tables if empty. Items without table, e.g. transaction control events, are always replayed
+	Tables []string
+}
+
+// RunReplay pushes recorded batches into transfer destination through the same transformations and middlewares as replication does.
+func RunReplay(ctx context.Context, cp coordinator.Coordinator, transfer *model.Transfer, registry metrics.Registry, from string, opts ReplayOptions) error {
+	if opts.Speed < 0 {
+		return xerrors.Errorf("speed factor must not be negative: %v", opts.Speed)
+	}
+	tables, err := abstract.ParseTableIDs(opts.Tables...)
+	if err != nil {
+		return xerrors.Errorf("unable to parse tables: %w", err)
+	}
+	if err := provideradapter.ApplyForTransfer(transfer); err != nil {
+		return xerrors.Errorf("unable to adapt transfer: %w", err)
+	}
+	// replayed batches must not be recorded again
+	transfer.Record = nil
+
+	reader, err := record.NewReader(from)
+	if err != nil {
+		return xerrors.Errorf("unable to open recording: %w", err)
+	}
+	defer reader.Close()
+
+	asyncSink, err := sink.MakeAsyncSink(transfer, logger.Log, registry, cp, middlewares.MakeConfig(middlewares.AtReplicationStage))
+	if err != nil {
+		return xerrors.Errorf("unable to make sink: %w", err)
+	}
+	defer asyncSink.Close()
+
+	var prevTime time.Time
+	var batches, items int
+	for {
+		batch, err := reader.Next()
+		if xerrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return xerrors.Errorf("unable to read recording: %w", err)
+		}
+		if opts.Speed > 0 && !prevTime.IsZero() && batch.Time.After(prevTime) {
+			delay := time.Duration(float64(batch.Time.Sub(prevTime)) / opts.Speed)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		prevTime = batch.Time
+
+		filtered := filterTables(batch.ChangeItems(), tables)
+		if len(filtered) == 0 {
+			continue
+		}
+		if err := <-asyncSink.AsyncPush(filtered); err != nil {
+			return xerrors.Errorf("unable to push batch %v recorded at %v: %w", batches, batch.Time, err)
+		}
+		batches++
+		items += len(filtered)
+	}
+	logger.Log.Infof("replayed %v items in %v batches from %v", items, batches, from)
+	return nil
+}
+
+func filterTables(items []abstract.ChangeItem, tables []abstract.TableID) []abstract.ChangeItem {
+	if len(tables) == 0 {
+		return items
+	}
+	filtered := items[:0]
+	for _, item := range items {
+		if item.Table == "" {
+			filtered = append(filtered, item)
+			continue
+		}
+		for _, table := range tables {
+			if table.Includes(item.TableID()) {
+				filtered = append(filtered, item)
+				break
+			}
+		}
+	}
+	return filtered
+}
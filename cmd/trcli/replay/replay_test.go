@@ -0,0 +1,81 @@
+package replay
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/middlewares"
+	"github.com/transferia/transferia/pkg/sink"
+)
+
+type mockSink struct {
+	mu    sync.Mutex
+	items []abstract.ChangeItem
+}
+
+func (s *mockSink) Close() error {
+	return nil
+}
+
+func (s *mockSink) Push(items []abstract.ChangeItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, items...)
+	return nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transfer.rec")
+	registry := solomon.NewRegistry(nil)
+	cp := coordinator.NewFakeClient()
+	schema := abstract.NewTableSchema([]abstract.ColSchema{
+		{ColumnName: "id", DataType: "int64", PrimaryKey: true},
+	})
+	row := func(table string, id int64) abstract.ChangeItem {
+		return abstract.ChangeItem{
+			Kind:         abstract.InsertKind,
+			Schema:       "public",
+			Table:        table,
+			ColumnNames:  []string{"id"},
+			ColumnValues: []any{id},
+			TableSchema:  schema,
+		}
+	}
+
+	recorded := new(mockSink)
+	transfer := &model.Transfer{
+		ID:     "test-transfer",
+		Src:    &model.MockSource{},
+		Dst:    &model.MockDestination{SinkerFactory: func() abstract.Sinker { return recorded }},
+		Record: &model.RecordConfig{Path: path},
+	}
+	asyncSink, err := sink.MakeAsyncSink(transfer, logger.Log, registry, cp, middlewares.MakeConfig(middlewares.AtReplicationStage))
+	require.NoError(t, err)
+	require.NoError(t, <-asyncSink.AsyncPush([]abstract.ChangeItem{row("users", 1), row("orders", 1)}))
+	require.NoError(t, <-asyncSink.AsyncPush([]abstract.ChangeItem{row("users", 2)}))
+	require.NoError(t, asyncSink.Close())
+	require.Len(t, recorded.items, 3)
+
+	replayed := new(mockSink)
+	transfer.Dst = &model.MockDestination{SinkerFactory: func() abstract.Sinker { return replayed }}
+	require.NoError(t, RunReplay(context.Background(), cp, transfer, registry, path, ReplayOptions{Speed: 0, Tables: []string{"public.users"}}))
+	require.Len(t, replayed.items, 2)
+	for i, item := range replayed.items {
+		require.Equal(t, "users", item.Table)
+		require.Equal(t, []any{int64(i + 1)}, item.ColumnValues)
+		require.Equal(t, schema.Columns(), item.TableSchema.Columns())
+	}
+
+	// replay does not record replayed batches again
+	replayed.items = nil
+	require.NoError(t, RunReplay(context.Background(), cp, transfer, registry, path, ReplayOptions{Speed: 0, Tables: nil}))
+	require.Len(t, replayed.items, 3)
+}
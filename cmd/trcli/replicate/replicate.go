@@ -1,8 +1,10 @@
 package replicate
 
 import (
+	"context"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/spf13/cobra"
 	"github.com/transferia/transferia/cmd/trcli/activate"
 	"github.com/transferia/transferia/cmd/trcli/config"
@@ -14,85 +16,204 @@ import (
 	"github.com/transferia/transferia/pkg/abstract/model"
 	"github.com/transferia/transferia/pkg/dataplane/provideradapter"
 	"github.com/transferia/transferia/pkg/runtime/local"
+	"github.com/transferia/transferia/pkg/secret"
+	"go.ytsaurus.tech/library/go/core/log"
 )
 
-func ReplicateCommand(cp *coordinator.Coordinator, rt abstract.Runtime, registry metrics.Registry) *cobra.Command {
+func ReplicateCommand(cp *coordinator.Coordinator, rt abstract.Runtime, registry metrics.Registry, shutdownTimeout *time.Duration) *cobra.Command {
 	var transferParams string
 	var metricsPrefix string
+	var recordPath string
 
 	replicationCommand := &cobra.Command{
 		Use:   "replicate",
 		Short: "Start local replication",
-		RunE:  replicate(cp, rt, &transferParams, registry, metricsPrefix),
+		RunE:  replicate(cp, rt, &transferParams, registry, metricsPrefix, &recordPath, shutdownTimeout),
 	}
 	replicationCommand.Flags().StringVar(&transferParams, "transfer", "./transfer.yaml", "path to yaml file with transfer configuration")
 	replicationCommand.Flags().StringVar(&metricsPrefix, "metrics-prefix", "", "Optional prefix por Prometheus metrics")
+	replicationCommand.Flags().StringVar(&recordPath, "record", "", "path to file to record change item batches into, see trcli replay")
 	return replicationCommand
 }
 
-func replicate(cp *coordinator.Coordinator, rt abstract.Runtime, transferYaml *string, registry metrics.Registry, metricsPrefix string) func(cmd *cobra.Command, args []string) error {
+func replicate(cp *coordinator.Coordinator, rt abstract.Runtime, transferYaml *string, registry metrics.Registry, metricsPrefix string, recordPath *string, shutdownTimeout *time.Duration) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		transfer, err := config.TransferFromYaml(transferYaml)
 		if err != nil {
 			return xerrors.Errorf("unable to load transfer: %w", err)
 		}
-		transfer.Runtime = rt
+		transfer.Runtime = config.WorkerRuntime(transfer, rt)
+		if *recordPath != "" {
+			transfer.Record = &model.RecordConfig{Path: *recordPath}
+		}
 
 		if metricsPrefix != "" {
 			registry = registry.WithPrefix(metricsPrefix)
 		}
 
-		return RunReplication(*cp, transfer, registry)
+		return RunReplication(cmd.Context(), *cp, transfer, registry, *shutdownTimeout)
 	}
 }
 
-func RunReplication(cp coordinator.Coordinator, transfer *model.Transfer, registry metrics.Registry) error {
+// RunReplication replicates the transfer until ctx is done or a fatal error occurs.
+// Once ctx is done, the worker is shut down gracefully within shutdownTimeout.
+func RunReplication(ctx context.Context, cp coordinator.Coordinator, transfer *model.Transfer, registry metrics.Registry, shutdownTimeout time.Duration) error {
+	return Replicate(ctx, cp, transfer, registry, ReplicationOptions{
+		Logger:          logger.Log,
+		ShutdownTimeout: shutdownTimeout,
+		RestartBackoff:  backoff.NewConstantBackOff(10 * time.Second),
+		StableRun:       0,
+		OnWorkerStart:   nil,
+		OnWorkerFailure: nil,
+	})
+}
+
+type ReplicationOptions struct {
+	Logger          log.Logger
+	ShutdownTimeout time.Duration
+	// RestartBackoff gives delays between restarts of failed worker, backoff.Stop means the transfer is crash looping
+	RestartBackoff backoff.BackOff
+	// StableRun resets RestartBackoff once worker runs longer than that, zero disables reset
+	StableRun time.Duration
+	// OnWorkerStart and OnWorkerFailure are optional hooks to track the worker status
+	OnWorkerStart   func()
+	OnWorkerFailure func(err error, restartIn time.Duration)
+}
+
+// Replicate is RunReplication with configurable logger and restart policy.
+// Failed activation of the transfer is retried with the same restart policy as failed worker.
+func Replicate(ctx context.Context, cp coordinator.Coordinator, transfer *model.Transfer, registry metrics.Registry, opts ReplicationOptions) error {
 	if err := provideradapter.ApplyForTransfer(transfer); err != nil {
 		return xerrors.Errorf("unable to adapt transfer: %w", err)
 	}
+
+	opts.RestartBackoff.Reset()
+	for restarts := 0; ; restarts++ {
+		startedAt := time.Now()
+		err := activateOnce(cp, transfer, registry)
+		if err != nil {
+			if abstract.IsFatal(err) {
+				return err
+			}
+		} else {
+			var done bool
+			done, err = runWorker(ctx, cp, transfer, registry, opts)
+			if done {
+				return err
+			}
+		}
+		if secret.IsAuthError(err) {
+			refreshSecrets(ctx, transfer, opts.Logger)
+		}
+		if opts.StableRun > 0 && time.Since(startedAt) > opts.StableRun {
+			opts.RestartBackoff.Reset()
+			restarts = 0
+		}
+		delay := opts.RestartBackoff.NextBackOff()
+		if delay == backoff.Stop {
+			return xerrors.Errorf("transfer is crash looping, worker failed %d times in a row: %w", restarts+1, err)
+		}
+		if opts.OnWorkerFailure != nil {
+			opts.OnWorkerFailure(err, delay)
+		}
+		opts.Logger.Warnf("worker failed: %v, restart in %v", err, delay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// activateOnce activates the transfer unless it is already activated by previous run
+func activateOnce(cp coordinator.Coordinator, transfer *model.Transfer, registry metrics.Registry) error {
 	st, err := cp.GetTransferState(transfer.ID)
 	if err != nil {
 		return xerrors.Errorf("unable to get transfer state: %w", err)
 	}
-	if stt, ok := st["status"]; !ok || stt.Generic == nil {
-		if err := activate.RunActivate(cp, transfer, registry, 0); err != nil {
-			return xerrors.Errorf("unable to activate transfer: %w", err)
+	if stt, ok := st["status"]; ok && stt.Generic != nil {
+		return nil
+	}
+	if err := activate.RunActivate(cp, transfer, registry, 0); err != nil {
+		return xerrors.Errorf("unable to activate transfer: %w", err)
+	}
+	if err := cp.SetTransferState(transfer.ID, map[string]*coordinator.TransferStateData{
+		"status": {
+			Generic:             "activated",
+			IncrementalTables:   nil,
+			OraclePosition:      nil,
+			MysqlGtid:           nil,
+			MysqlBinlogPosition: nil,
+			YtStaticPart:        nil,
+		},
+	}); err != nil {
+		return xerrors.Errorf("unable to set transfer state: %w", err)
+	}
+	return nil
+}
+
+// runWorker runs replication worker until it fails or ctx is done,
+// done is set if the worker must not be restarted
+func runWorker(ctx context.Context, cp coordinator.Coordinator, transfer *model.Transfer, registry metrics.Registry, opts ReplicationOptions) (done bool, err error) {
+	worker := local.NewLocalWorker(
+		cp,
+		transfer,
+		registry.WithTags(map[string]string{
+			"resource_id": transfer.ID,
+			"name":        transfer.TransferName,
+		}),
+		opts.Logger,
+	)
+	if opts.OnWorkerStart != nil {
+		opts.OnWorkerStart()
+	}
+	runErrCh := make(chan error, 1)
+	go func() {
+		runErrCh <- worker.Run()
+	}()
+	select {
+	case err = <-runErrCh:
+	case <-ctx.Done():
+		opts.Logger.Infof("shutdown requested, stopping worker within %v", opts.ShutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+		err := worker.Shutdown(shutdownCtx)
+		cancel()
+		if err != nil {
+			return true, xerrors.Errorf("unable to shutdown worker gracefully: %w", err)
 		}
-		if err := cp.SetTransferState(transfer.ID, map[string]*coordinator.TransferStateData{
-			"status": {
-				Generic:             "activated",
-				IncrementalTables:   nil,
-				OraclePosition:      nil,
-				MysqlGtid:           nil,
-				MysqlBinlogPosition: nil,
-				YtStaticPart:        nil,
-			},
-		}); err != nil {
-			return xerrors.Errorf("unable to set transfer state: %w", err)
+		return true, nil
+	}
+	if abstract.IsFatal(err) {
+		if err := cp.RemoveTransferState(transfer.ID, []string{"status"}); err != nil {
+			return true, xerrors.Errorf("unable to cleanup status state: %w", err)
 		}
+		return true, err
 	}
+	if err := worker.Stop(); err != nil {
+		opts.Logger.Warnf("unable to stop worker: %v", err)
+	}
+	return false, err
+}
 
-	for {
-		worker := local.NewLocalWorker(
-			cp,
-			transfer,
-			registry.WithTags(map[string]string{
-				"resource_id": transfer.ID,
-				"name":        transfer.TransferName,
-			}),
-			logger.Log,
-		)
-		err := worker.Run()
-		if abstract.IsFatal(err) {
-			if err := (cp).RemoveTransferState(transfer.ID, []string{"status"}); err != nil {
-				return xerrors.Errorf("unable to cleanup status state: %w", err)
-			}
-			return err
+// refreshSecrets resolves secret references of transfer endpoints again, so rotated secrets are used by the next worker
+func refreshSecrets(ctx context.Context, transfer *model.Transfer, lgr log.Logger) {
+	if transfer.SecretRefs == nil {
+		return
+	}
+	for _, endpoint := range []struct {
+		params model.EndpointParams
+		refs   model.SecretRefs
+	}{
+		{params: transfer.Src, refs: transfer.SecretRefs.Src},
+		{params: transfer.Dst, refs: transfer.SecretRefs.Dst},
+	} {
+		changed, err := model.RefreshSecrets(ctx, endpoint.params, endpoint.refs)
+		if err != nil {
+			lgr.Warn("unable to refresh secrets", log.Error(err))
+			continue
 		}
-		if err := worker.Stop(); err != nil {
-			logger.Log.Warnf("unable to stop worker: %v", err)
+		if changed {
+			lgr.Infof("secrets of %s endpoint are rotated", endpoint.params.GetProviderType())
 		}
-		logger.Log.Warnf("worker failed: %v, restart", err)
-		time.Sleep(10 * time.Second)
 	}
 }
@@ -40,7 +40,7 @@ func TestReplicate(t *testing.T) {
 	transfer.Dst = dst
 
 	go func() {
-		require.NoError(t, replicate.RunReplication(coordinator.NewStatefulFakeClient(), transfer, solomon.NewRegistry(solomon.NewRegistryOpts())))
+		require.NoError(t, replicate.RunReplication(context.Background(), coordinator.NewStatefulFakeClient(), transfer, solomon.NewRegistry(solomon.NewRegistryOpts()), time.Minute))
 	}()
 
 	time.Sleep(5 * time.Second)
@@ -0,0 +1,57 @@
+package serve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+func ServeCommand(cp *coordinator.Coordinator, rt abstract.Runtime, registry metrics.Registry, shutdownTimeout *time.Duration) *cobra.Command {
+	var configDir string
+	var reloadInterval time.Duration
+	var statusAddr string
+	policy := RestartPolicy{
+		InitialInterval: 0,
+		MaxInterval:     0,
+		MaxRestarts:     0,
+		StableRun:       0,
+	}
+
+	serveCommand := &cobra.Command{
+		Use:   "serve",
+		Short: "Start local replication of all transfers from config directory",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configDir == "" {
+				return xerrors.New("--config-dir is required")
+			}
+			supervisor := NewSupervisor(configDir, *cp, rt, registry, policy, *shutdownTimeout)
+			if statusAddr != "" {
+				go func() {
+					rootMux := http.NewServeMux()
+					rootMux.HandleFunc("/status", supervisor.StatusHandler)
+					logger.Log.Infof("status is uprising on %v", statusAddr)
+					if err := http.ListenAndServe(statusAddr, rootMux); err != nil {
+						logger.Log.Error("failed to serve status", log.Error(err))
+					}
+				}()
+			}
+			return supervisor.Run(cmd.Context(), reloadInterval)
+		},
+	}
+	serveCommand.Flags().StringVar(&configDir, "config-dir", "", "path to directory with yaml files of transfer configurations, one transfer per file")
+	serveCommand.Flags().DurationVar(&reloadInterval, "reload-interval", 10*time.Second, "how often config directory is checked for changes")
+	serveCommand.Flags().StringVar(&statusAddr, "status-addr", ":3001", "address of aggregated status endpoint /status, empty to disable")
+	serveCommand.Flags().DurationVar(&policy.InitialInterval, "restart-initial-interval", 10*time.Second, "delay before the first restart of failed transfer")
+	serveCommand.Flags().DurationVar(&policy.MaxInterval, "restart-max-interval", 10*time.Minute, "max delay between restarts of failed transfer")
+	serveCommand.Flags().Uint64Var(&policy.MaxRestarts, "restart-limit", 10, "restarts in a row after which transfer is considered crash looping and stopped until its config changes")
+	serveCommand.Flags().DurationVar(&policy.StableRun, "restart-reset-after", 30*time.Minute, "run duration after which restarts counter of transfer is reset")
+	return serveCommand
+}
@@ -0,0 +1,455 @@
+package serve
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/transferia/transferia/cmd/trcli/config"
+	"github.com/transferia/transferia/cmd/trcli/replicate"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const (
+	StateRunning    = "running"
+	StateRestarting = "restarting"
+	StateFailed     = "failed"
+	StateStopped    = "stopped"
+	StateInvalid    = "invalid"
+)
+
+// RestartPolicy is an exponential backoff of failed transfer restarts.
+type RestartPolicy struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// MaxRestarts in a row after which the transfer is considered crash looping and is not restarted until its config changes
+	MaxRestarts uint64
+	// StableRun of worker resets restarts counter and interval
+	StableRun time.Duration
+}
+
+func (p RestartPolicy) backoff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = p.InitialInterval
+	b.MaxInterval = p.MaxInterval
+	b.MaxElapsedTime = 0
+	return backoff.WithMaxRetries(b, p.MaxRestarts)
+}
+
+type TransferStatus struct {
+	File        string    `json:"file"`
+	TransferID  string    `json:"transfer_id"`
+	Name        string    `json:"name,omitempty"`
+	State       string    `json:"state"`
+	Restarts    int       `json:"restarts"`
+	LastError   string    `json:"last_error,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	NextRestart time.Time `json:"next_restart,omitempty"`
+}
+
+type Status struct {
+	Transfers []TransferStatus `json:"transfers"`
+	States    map[string]int   `json:"states"`
+}
+
+type runFunc func(ctx context.Context, transfer *model.Transfer, opts replicate.ReplicationOptions) error
+
+type supervisedTransfer struct {
+	hash     [sha256.Size]byte
+	transfer *model.Transfer
+	// runtime of the transfer as parsed from its config, transfer.Runtime is the runtime of the worker
+	runtime abstract.Runtime
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu     sync.Mutex
+	status TransferStatus
+}
+
+func (t *supervisedTransfer) update(f func(status *TransferStatus)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f(&t.status)
+}
+
+func (t *supervisedTransfer) failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status.State == StateFailed
+}
+
+func (t *supervisedTransfer) stop() {
+	if t.cancel == nil {
+		return
+	}
+	t.cancel()
+	<-t.done
+	t.cancel = nil
+}
+
+// Supervisor runs replication of every transfer described by yaml files of config directory in one process
+// and keeps running transfers in sync with the directory.
+type Supervisor struct {
+	configDir       string
+	rt              abstract.Runtime
+	policy          RestartPolicy
+	shutdownTimeout time.Duration
+	run             runFunc
+
+	// reloadMu serializes reloads, while mu guards transfers and is never held while workers are stopped
+	reloadMu  sync.Mutex
+	mu        sync.Mutex
+	transfers map[string]*supervisedTransfer
+}
+
+func NewSupervisor(
+	configDir string,
+	cp coordinator.Coordinator,
+	rt abstract.Runtime,
+	registry metrics.Registry,
+	policy RestartPolicy,
+	shutdownTimeout time.Duration,
+) *Supervisor {
+	return &Supervisor{
+		configDir:       configDir,
+		rt:              rt,
+		policy:          policy,
+		shutdownTimeout: shutdownTimeout,
+		run: func(ctx context.Context, transfer *model.Transfer, opts replicate.ReplicationOptions) error {
+			return replicate.Replicate(ctx, cp, transfer, registry, opts)
+		},
+		reloadMu:  sync.Mutex{},
+		mu:        sync.Mutex{},
+		transfers: map[string]*supervisedTransfer{},
+	}
+}
+
+// Run reloads config directory each reloadInterval until ctx is done, then stops all transfers.
+func (s *Supervisor) Run(ctx context.Context, reloadInterval time.Duration) error {
+	defer s.stopAll()
+	if err := s.Reload(ctx); err != nil {
+		return xerrors.Errorf("unable to load transfers: %w", err)
+	}
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Log.Info("shutdown requested, stopping all transfers")
+			return nil
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				logger.Log.Error("unable to reload transfers", log.Error(err))
+			}
+		}
+	}
+}
+
+// Reload starts transfers of new files, stops transfers of removed ones and restarts transfers of changed files
+// if runtime or endpoints differ. Other changes are applied on the next restart of the supervisor.
+func (s *Supervisor) Reload(ctx context.Context) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	files, err := s.configFiles()
+	if err != nil {
+		return xerrors.Errorf("unable to list config directory: %w", err)
+	}
+
+	stopping, starting := s.plan(files)
+
+	// workers are stopped outside of s.mu, so that status is served while they shut down
+	var wg sync.WaitGroup
+	for _, current := range stopping {
+		wg.Add(1)
+		go func(current *supervisedTransfer) {
+			defer wg.Done()
+			current.stop()
+		}(current)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, next := range starting {
+		s.transfers[next.status.File] = s.start(ctx, next)
+	}
+	return nil
+}
+
+// plan applies changes of config files which do not require running workers to be stopped
+// and returns transfers to stop and transfers to start once they are stopped.
+func (s *Supervisor) plan(files map[string][sha256.Size]byte) (stopping []*supervisedTransfer, starting []*supervisedTransfer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for file, current := range s.transfers {
+		if _, ok := files[file]; !ok {
+			logger.Log.Infof("config %s is removed, stopping its transfer", file)
+			stopping = append(stopping, current)
+			delete(s.transfers, file)
+		}
+	}
+
+	transferFiles := map[string]string{}
+	for _, file := range sortedKeys(files) {
+		if current, ok := s.transfers[file]; ok && current.transfer != nil {
+			transferFiles[current.transfer.ID] = file
+		}
+	}
+	for _, file := range sortedKeys(files) {
+		hash := files[file]
+		current, ok := s.transfers[file]
+		if ok && current.hash == hash {
+			continue
+		}
+
+		transfer, err := config.TransferFromYaml(&file)
+		if err == nil {
+			if otherFile, ok := transferFiles[transfer.ID]; ok && otherFile != file {
+				err = xerrors.Errorf("transfer %s is already defined in %s", transfer.ID, otherFile)
+			}
+		}
+		if err != nil {
+			logger.Log.Error("invalid transfer config", log.String("file", file), log.Error(err))
+			if ok {
+				// keep running previous version of the transfer
+				current.hash = hash
+				current.update(func(status *TransferStatus) { status.LastError = err.Error() })
+				continue
+			}
+			s.transfers[file] = &supervisedTransfer{
+				hash:     hash,
+				transfer: nil,
+				runtime:  nil,
+				cancel:   nil,
+				done:     nil,
+				mu:       sync.Mutex{},
+				status:   TransferStatus{File: file, State: StateInvalid, LastError: err.Error()},
+			}
+			continue
+		}
+		runtime := transfer.Runtime
+		transfer.Runtime = config.WorkerRuntime(transfer, s.rt)
+
+		if ok && current.transfer != nil {
+			if !current.failed() && !needRestart(current, transfer, runtime) {
+				logger.Log.Infof("config %s of transfer %s is changed, restart is not required", file, transfer.ID)
+				current.hash = hash
+				continue
+			}
+			logger.Log.Infof("config %s of transfer %s is changed, restarting", file, transfer.ID)
+			stopping = append(stopping, current)
+			delete(transferFiles, current.transfer.ID)
+		}
+		transferFiles[transfer.ID] = file
+		starting = append(starting, &supervisedTransfer{
+			hash:     hash,
+			transfer: transfer,
+			runtime:  runtime,
+			cancel:   nil,
+			done:     nil,
+			mu:       sync.Mutex{},
+			status:   TransferStatus{File: file},
+		})
+	}
+	return stopping, starting
+}
+
+func (s *Supervisor) start(ctx context.Context, next *supervisedTransfer) *supervisedTransfer {
+	ctx, cancel := context.WithCancel(ctx)
+	transfer := next.transfer
+	result := &supervisedTransfer{
+		hash:     next.hash,
+		transfer: transfer,
+		runtime:  next.runtime,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		mu:       sync.Mutex{},
+		status: TransferStatus{
+			File:       next.status.File,
+			TransferID: transfer.ID,
+			Name:       transfer.TransferName,
+			State:      StateRunning,
+			StartedAt:  time.Now(),
+		},
+	}
+	lgr := log.With(logger.Log, log.String("transfer_id", transfer.ID), log.String("transfer_name", transfer.TransferName))
+	opts := replicate.ReplicationOptions{
+		Logger:          lgr,
+		ShutdownTimeout: s.shutdownTimeout,
+		RestartBackoff:  s.policy.backoff(),
+		StableRun:       s.policy.StableRun,
+		OnWorkerStart: func() {
+			result.update(func(status *TransferStatus) {
+				status.State = StateRunning
+				status.NextRestart = time.Time{}
+			})
+		},
+		OnWorkerFailure: func(err error, restartIn time.Duration) {
+			result.update(func(status *TransferStatus) {
+				status.State = StateRestarting
+				status.Restarts++
+				status.LastError = err.Error()
+				status.NextRestart = time.Now().Add(restartIn)
+			})
+		},
+	}
+	go func() {
+		defer close(result.done)
+		err := s.run(ctx, transfer, opts)
+		result.update(func(status *TransferStatus) {
+			status.NextRestart = time.Time{}
+			if err == nil || ctx.Err() != nil {
+				status.State = StateStopped
+				return
+			}
+			status.State = StateFailed
+			status.LastError = err.Error()
+		})
+		if err != nil && ctx.Err() == nil {
+			lgr.Error("transfer is failed and will not be restarted until its config is changed", log.Error(err))
+		}
+	}()
+	return result
+}
+
+func (s *Supervisor) stopAll() {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	s.mu.Lock()
+	transfers := make([]*supervisedTransfer, 0, len(s.transfers))
+	for _, current := range s.transfers {
+		transfers = append(transfers, current)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, current := range transfers {
+		wg.Add(1)
+		go func(current *supervisedTransfer) {
+			defer wg.Done()
+			current.stop()
+		}(current)
+	}
+	wg.Wait()
+}
+
+func (s *Supervisor) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := Status{
+		Transfers: make([]TransferStatus, 0, len(s.transfers)),
+		States:    map[string]int{},
+	}
+	for _, file := range sortedKeys(s.transfers) {
+		current := s.transfers[file]
+		current.mu.Lock()
+		status := current.status
+		current.mu.Unlock()
+		result.Transfers = append(result.Transfers, status)
+		result.States[status.State]++
+	}
+	return result
+}
+
+// StatusHandler serves aggregated status of all supervised transfers as JSON.
+func (s *Supervisor) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := json.Marshal(s.Status())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(res); err != nil {
+		logger.Log.Error("unable to write", log.Error(err))
+	}
+}
+
+func (s *Supervisor) configFiles() (map[string][sha256.Size]byte, error) {
+	entries, err := os.ReadDir(s.configDir)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read directory: %w", err)
+	}
+	result := map[string][sha256.Size]byte{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		file := filepath.Join(s.configDir, entry.Name())
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to read %s: %w", file, err)
+		}
+		result[file] = sha256.Sum256(data)
+	}
+	return result, nil
+}
+
+// needRestart reports whether running transfer must be restarted to apply the changed config,
+// runtime is the one parsed from the changed config.
+func needRestart(current *supervisedTransfer, changed *model.Transfer, runtime abstract.Runtime) bool {
+	if current.transfer.ID != changed.ID {
+		return true
+	}
+	if runtimesDiffer(current.runtime, runtime) {
+		return true
+	}
+	return endpointsDiffer(current.transfer, changed)
+}
+
+func runtimesDiffer(current, changed abstract.Runtime) bool {
+	if current == nil || changed == nil {
+		return current != changed
+	}
+	if current.Type() != changed.Type() || changed.NeedRestart(current) {
+		return true
+	}
+	currentRuntime, err := json.Marshal(current)
+	if err != nil {
+		return true
+	}
+	changedRuntime, err := json.Marshal(changed)
+	if err != nil {
+		return true
+	}
+	return string(currentRuntime) != string(changedRuntime)
+}
+
+func endpointsDiffer(current, changed *model.Transfer) bool {
+	if current.SrcType() != changed.SrcType() || current.DstType() != changed.DstType() {
+		return true
+	}
+	currentEndpoints, err := json.Marshal([]any{current.Src, current.Dst})
+	if err != nil {
+		return true
+	}
+	changedEndpoints, err := json.Marshal([]any{changed.Src, changed.Dst})
+	if err != nil {
+		return true
+	}
+	return string(currentEndpoints) != string(changedEndpoints)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
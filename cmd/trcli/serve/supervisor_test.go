@@ -0,0 +1,230 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/cmd/trcli/replicate"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	_ "github.com/transferia/transferia/pkg/providers/postgres"
+	_ "github.com/transferia/transferia/pkg/providers/stdout"
+	"github.com/transferia/transferia/pkg/runtime/kubernetes"
+)
+
+type fakeRuns struct {
+	mu      sync.Mutex
+	started map[string]int
+	running map[string]bool
+	fail    map[string]error
+	// runtimes are runtimes of the last runs
+	runtimes map[string]abstract.Runtime
+	// stopDelay is how long a run takes to stop once its ctx is done
+	stopDelay time.Duration
+}
+
+func (f *fakeRuns) run(ctx context.Context, transfer *model.Transfer, opts replicate.ReplicationOptions) error {
+	f.mu.Lock()
+	f.started[transfer.ID]++
+	if f.runtimes != nil {
+		f.runtimes[transfer.ID] = transfer.Runtime
+	}
+	err := f.fail[transfer.ID]
+	f.running[transfer.ID] = err == nil
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	opts.OnWorkerStart()
+	<-ctx.Done()
+	time.Sleep(f.stopDelay)
+	f.mu.Lock()
+	f.running[transfer.ID] = false
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeRuns) state(id string) (int, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.started[id], f.running[id]
+}
+
+func writeTransfer(t *testing.T, dir, file, id, name, database string) {
+	writeTransferWithRuntime(t, dir, file, id, name, database, "")
+}
+
+func writeTransferWithRuntime(t *testing.T, dir, file, id, name, database, runtime string) {
+	yaml := fmt.Sprintf(`
+id: %s
+transfername: %s
+type: INCREMENT_ONLY
+src:
+  type: pg
+  params:
+    Hosts: ["localhost"]
+    Database: %s
+    User: user
+dst:
+  type: stdout
+  params:
+    ShowData: false
+%s`, id, name, database, runtime)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(yaml), 0o644))
+}
+
+func transferStates(s *Supervisor) map[string]string {
+	result := map[string]string{}
+	for _, status := range s.Status().Transfers {
+		result[filepath.Base(status.File)] = status.State
+	}
+	return result
+}
+
+func TestSupervisorReload(t *testing.T) {
+	dir := t.TempDir()
+	runs := &fakeRuns{started: map[string]int{}, running: map[string]bool{}, fail: map[string]error{}}
+	supervisor := NewSupervisor(dir, nil, new(abstract.LocalRuntime), nil, RestartPolicy{}, time.Second)
+	supervisor.run = runs.run
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writeTransfer(t, dir, "a.yaml", "a", "a", "db")
+	writeTransfer(t, dir, "b.yaml", "b", "b", "db")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not a transfer"), 0o644))
+	require.NoError(t, supervisor.Reload(ctx))
+	require.Eventually(t, func() bool {
+		_, aRunning := runs.state("a")
+		_, bRunning := runs.state("b")
+		return aRunning && bRunning
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, map[string]string{"a.yaml": StateRunning, "b.yaml": StateRunning}, transferStates(supervisor))
+
+	// name change does not touch endpoints, so transfer is not restarted
+	writeTransfer(t, dir, "b.yaml", "b", "renamed", "db")
+	require.NoError(t, supervisor.Reload(ctx))
+	started, running := runs.state("b")
+	require.Equal(t, 1, started)
+	require.True(t, running)
+
+	writeTransfer(t, dir, "b.yaml", "b", "renamed", "other_db")
+	require.NoError(t, supervisor.Reload(ctx))
+	require.Eventually(t, func() bool {
+		started, running := runs.state("b")
+		return started == 2 && running
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.yaml")))
+	writeTransfer(t, dir, "c.yaml", "b", "duplicate", "db")
+	require.NoError(t, supervisor.Reload(ctx))
+	_, running = runs.state("a")
+	require.False(t, running)
+	require.Equal(t, map[string]string{"b.yaml": StateRunning, "c.yaml": StateInvalid}, transferStates(supervisor))
+
+	runs.mu.Lock()
+	runs.fail["c"] = abstract.NewFatalError(fmt.Errorf("boom"))
+	runs.mu.Unlock()
+	writeTransfer(t, dir, "c.yaml", "c", "c", "db")
+	require.NoError(t, supervisor.Reload(ctx))
+	require.Eventually(t, func() bool {
+		return transferStates(supervisor)["c.yaml"] == StateFailed
+	}, time.Second, 10*time.Millisecond)
+	status := supervisor.Status()
+	require.Equal(t, map[string]int{StateRunning: 1, StateFailed: 1}, status.States)
+
+	// failed transfer is restarted on any change of its config
+	runs.mu.Lock()
+	delete(runs.fail, "c")
+	runs.mu.Unlock()
+	writeTransfer(t, dir, "c.yaml", "c", "fixed", "db")
+	require.NoError(t, supervisor.Reload(ctx))
+	require.Eventually(t, func() bool {
+		started, running := runs.state("c")
+		return started == 2 && running
+	}, time.Second, 10*time.Millisecond)
+
+	supervisor.stopAll()
+	_, running = runs.state("b")
+	require.False(t, running)
+}
+
+func TestSupervisorReloadRuntime(t *testing.T) {
+	dir := t.TempDir()
+	runs := &fakeRuns{started: map[string]int{}, running: map[string]bool{}, fail: map[string]error{}, runtimes: map[string]abstract.Runtime{}}
+	supervisor := NewSupervisor(dir, nil, new(abstract.LocalRuntime), nil, RestartPolicy{}, time.Second)
+	supervisor.run = runs.run
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer supervisor.stopAll()
+
+	kubernetesRuntime := func(jobCount int) string {
+		return fmt.Sprintf("runtime:\n  type: kubernetes\n  params:\n    job_count: %d\n    process_count: 1\n", jobCount)
+	}
+	writeTransferWithRuntime(t, dir, "a.yaml", "a", "a", "db", kubernetesRuntime(2))
+	require.NoError(t, supervisor.Reload(ctx))
+	require.Eventually(t, func() bool {
+		_, running := runs.state("a")
+		return running
+	}, time.Second, 10*time.Millisecond)
+	runs.mu.Lock()
+	require.IsType(t, new(kubernetes.Runtime), runs.runtimes["a"], "runtime of config drives the worker")
+	runs.mu.Unlock()
+
+	writeTransferWithRuntime(t, dir, "a.yaml", "a", "renamed", "db", kubernetesRuntime(2))
+	require.NoError(t, supervisor.Reload(ctx))
+	started, _ := runs.state("a")
+	require.Equal(t, 1, started)
+
+	writeTransferWithRuntime(t, dir, "a.yaml", "a", "renamed", "db", kubernetesRuntime(3))
+	require.NoError(t, supervisor.Reload(ctx))
+	require.Eventually(t, func() bool {
+		started, running := runs.state("a")
+		return started == 2 && running
+	}, time.Second, 10*time.Millisecond)
+
+	writeTransfer(t, dir, "a.yaml", "a", "renamed", "db")
+	require.NoError(t, supervisor.Reload(ctx))
+	require.Eventually(t, func() bool {
+		started, running := runs.state("a")
+		return started == 3 && running
+	}, time.Second, 10*time.Millisecond)
+	runs.mu.Lock()
+	require.Same(t, supervisor.rt, runs.runtimes["a"], "runtime of flags drives the worker of local runtime transfer")
+	runs.mu.Unlock()
+}
+
+func TestSupervisorStatusWhileStopping(t *testing.T) {
+	dir := t.TempDir()
+	runs := &fakeRuns{started: map[string]int{}, running: map[string]bool{}, fail: map[string]error{}, stopDelay: time.Second}
+	supervisor := NewSupervisor(dir, nil, new(abstract.LocalRuntime), nil, RestartPolicy{}, time.Second)
+	supervisor.run = runs.run
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writeTransfer(t, dir, "a.yaml", "a", "a", "db")
+	require.NoError(t, supervisor.Reload(ctx))
+	require.Eventually(t, func() bool {
+		_, running := runs.state("a")
+		return running
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.yaml")))
+	reloaded := make(chan error, 1)
+	go func() {
+		reloaded <- supervisor.Reload(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return len(supervisor.Status().Transfers) == 0
+	}, 500*time.Millisecond, 10*time.Millisecond, "status is served while transfer is stopped")
+	_, running := runs.state("a")
+	require.True(t, running, "transfer is still stopping")
+	require.NoError(t, <-reloaded)
+	_, running = runs.state("a")
+	require.False(t, running)
+}
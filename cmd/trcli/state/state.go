@@ -0,0 +1,314 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/transferia/transferia/cmd/trcli/config"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/cobraaux"
+	"github.com/transferia/transferia/pkg/providers"
+	"github.com/transferia/transferia/pkg/storage"
+	"github.com/transferia/transferia/pkg/worker/tasks"
+)
+
+func StateCommand(cp *coordinator.Coordinator, registry metrics.Registry) *cobra.Command {
+	var transferParams string
+	stateCommand := &cobra.Command{
+		Use:   "state",
+		Short: "Inspect and change transfer state stored in coordinator",
+	}
+	stateCommand.PersistentFlags().StringVar(&transferParams, "transfer", "./transfer.yaml", "path to yaml file with transfer configuration")
+
+	var showKey string
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "Show transfer state",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: withTransfer(cp, &transferParams, func(cmd *cobra.Command, cp coordinator.Coordinator, transfer *model.Transfer) error {
+			return RunShow(cp, transfer, showKey, cmd.OutOrStdout())
+		}),
+	}
+	show.Flags().StringVar(&showKey, "key", "", "show only given state key")
+
+	var exportFile string
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export transfer state as JSON",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: withTransfer(cp, &transferParams, func(cmd *cobra.Command, cp coordinator.Coordinator, transfer *model.Transfer) error {
+			if exportFile == "" {
+				return RunShow(cp, transfer, "", cmd.OutOrStdout())
+			}
+			f, err := os.Create(exportFile)
+			if err != nil {
+				return xerrors.Errorf("unable to create file: %w", err)
+			}
+			defer f.Close()
+			return RunShow(cp, transfer, "", f)
+		}),
+	}
+	export.Flags().StringVar(&exportFile, "file", "", "path to output JSON file, stdout if not set")
+
+	var importFile string
+	var importReplace bool
+	importCommand := &cobra.Command{
+		Use:   "import",
+		Short: "Import transfer state from JSON produced by export",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: withTransfer(cp, &transferParams, func(cmd *cobra.Command, cp coordinator.Coordinator, transfer *model.Transfer) error {
+			data, err := os.ReadFile(importFile)
+			if err != nil {
+				return xerrors.Errorf("unable to read file: %w", err)
+			}
+			return RunImport(cp, transfer, registry, data, importReplace)
+		}),
+	}
+	importCommand.Flags().StringVar(&importFile, "file", "./state.json", "path to JSON file with transfer state")
+	importCommand.Flags().BoolVar(&importReplace, "replace", false, "remove state keys absent in imported file")
+
+	var position map[string]string
+	var table string
+	var cursor string
+	setPosition := &cobra.Command{
+		Use:   "set-position",
+		Short: "Set replication position of source or cursor of incremental table",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: withTransfer(cp, &transferParams, func(cmd *cobra.Command, cp coordinator.Coordinator, transfer *model.Transfer) error {
+			if table != "" {
+				tableID, err := abstract.ParseTableID(table)
+				if err != nil {
+					return xerrors.Errorf("unable to parse table: %w", err)
+				}
+				return RunSetCursor(cp, transfer, registry, *tableID, cursor)
+			}
+			return RunSetPosition(cp, transfer, registry, position)
+		}),
+	}
+	setPosition.Flags().StringToStringVar(&position, "position", nil, "source specific replication position, e.g. file=mysql-bin.000003,pos=4 for MySQL or lsn=0/16B6C50 for PostgreSQL")
+	setPosition.Flags().StringVar(&table, "table", "", "incremental table to set cursor for, e.g. public.orders")
+	setPosition.Flags().StringVar(&cursor, "cursor", "", "cursor value of incremental table, rows with greater cursor are uploaded by the next snapshot")
+
+	var resetKeys []string
+	reset := &cobra.Command{
+		Use:   "reset",
+		Short: "Remove transfer state, so transfer starts from scratch",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: withTransfer(cp, &transferParams, func(cmd *cobra.Command, cp coordinator.Coordinator, transfer *model.Transfer) error {
+			return RunReset(cp, transfer, resetKeys)
+		}),
+	}
+	reset.Flags().StringSliceVar(&resetKeys, "key", nil, "remove only given state keys")
+
+	cobraaux.RegisterCommand(stateCommand, show)
+	cobraaux.RegisterCommand(stateCommand, export)
+	cobraaux.RegisterCommand(stateCommand, importCommand)
+	cobraaux.RegisterCommand(stateCommand, setPosition)
+	cobraaux.RegisterCommand(stateCommand, reset)
+	return stateCommand
+}
+
+func withTransfer(
+	cp *coordinator.Coordinator,
+	transferYaml *string,
+	f func(cmd *cobra.Command, cp coordinator.Coordinator, transfer *model.Transfer) error,
+) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		transfer, err := config.TransferFromYaml(transferYaml)
+		if err != nil {
+			return xerrors.Errorf("unable to load transfer: %w", err)
+		}
+		if _, ok := (*cp).(*coordinator.CoordinatorInMemory); ok {
+			logger.Log.Warn("memory coordinator keeps state only while trcli process is running, use persistent coordinator, e.g. --coordinator s3")
+		}
+		return f(cmd, *cp, transfer)
+	}
+}
+
+func RunShow(cp coordinator.Coordinator, transfer *model.Transfer, key string, out io.Writer) error {
+	state, err := cp.GetTransferState(transfer.ID)
+	if err != nil {
+		return xerrors.Errorf("unable to get transfer state: %w", err)
+	}
+	var result any = state
+	if key != "" {
+		value, ok := state[key]
+		if !ok {
+			return xerrors.Errorf("state key %s not found, known keys: %v", key, stateKeys(state))
+		}
+		result = value
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("unable to marshal transfer state: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+func RunImport(cp coordinator.Coordinator, transfer *model.Transfer, registry metrics.Registry, data []byte, replace bool) error {
+	var state map[string]*coordinator.TransferStateData
+	if err := json.Unmarshal(data, &state); err != nil {
+		return xerrors.Errorf("unable to parse transfer state: %w", err)
+	}
+	if err := ValidateState(transfer, registry, state); err != nil {
+		return xerrors.Errorf("invalid transfer state: %w", err)
+	}
+	if replace {
+		current, err := cp.GetTransferState(transfer.ID)
+		if err != nil {
+			return xerrors.Errorf("unable to get transfer state: %w", err)
+		}
+		var absentKeys []string
+		for _, key := range stateKeys(current) {
+			if _, ok := state[key]; !ok {
+				absentKeys = append(absentKeys, key)
+			}
+		}
+		if len(absentKeys) > 0 {
+			if err := cp.RemoveTransferState(transfer.ID, absentKeys); err != nil {
+				return xerrors.Errorf("unable to remove transfer state keys %v: %w", absentKeys, err)
+			}
+		}
+	}
+	if len(state) == 0 {
+		return nil
+	}
+	if err := cp.SetTransferState(transfer.ID, state); err != nil {
+		return xerrors.Errorf("unable to set transfer state: %w", err)
+	}
+	logger.Log.Infof("imported state keys %v of transfer %s", stateKeys(state), transfer.ID)
+	return nil
+}
+
+func RunSetPosition(cp coordinator.Coordinator, transfer *model.Transfer, registry metrics.Registry, position map[string]string) error {
+	stateManager, ok := providers.Source[providers.StateManager](logger.Log, registry, cp, transfer)
+	if !ok {
+		return xerrors.Errorf("setting of replication position is not supported by %s source", transfer.SrcType())
+	}
+	state, err := stateManager.PositionState(position)
+	if err != nil {
+		return xerrors.Errorf("unable to build position state: %w", err)
+	}
+	if err := cp.SetTransferState(transfer.ID, state); err != nil {
+		return xerrors.Errorf("unable to set transfer state: %w", err)
+	}
+	logger.Log.Infof("set position %v of transfer %s", position, transfer.ID)
+	return nil
+}
+
+// RunSetCursor sets cursor of incremental table, filter of the next snapshot is built by source storage from the cursor
+// the same way as from initial state of incremental table, so the cursor must be a literal of source query language.
+func RunSetCursor(cp coordinator.Coordinator, transfer *model.Transfer, registry metrics.Registry, tableID abstract.TableID, cursor string) error {
+	if cursor == "" {
+		return xerrors.New("cursor must be specified")
+	}
+	var incremental *abstract.IncrementalTable
+	if transfer.RegularSnapshot != nil {
+		for _, table := range transfer.RegularSnapshot.Incremental {
+			if table.TableID() == tableID {
+				incremental = &table
+				break
+			}
+		}
+	}
+	if incremental == nil || incremental.CursorField == "" {
+		return xerrors.Errorf("table %s is not incremental in transfer %s", tableID.Fqtn(), transfer.ID)
+	}
+	incremental.InitialState = cursor
+
+	srcStorage, err := storage.NewStorage(transfer, cp, registry)
+	if err != nil {
+		return xerrors.Errorf(tasks.ResolveStorageErrorText, err)
+	}
+	defer srcStorage.Close()
+	incrementalStorage, ok := srcStorage.(abstract.IncrementalStorage)
+	if !ok {
+		return xerrors.Errorf("incremental tables are not supported by %s source", transfer.SrcType())
+	}
+	tables := []abstract.TableDescription{{Name: tableID.Name, Schema: tableID.Namespace, Filter: "", EtaRow: 0, Offset: 0}}
+	incrementalStorage.SetInitialState(tables, []abstract.IncrementalTable{*incremental})
+
+	state, err := cp.GetTransferState(transfer.ID)
+	if err != nil {
+		return xerrors.Errorf("unable to get transfer state: %w", err)
+	}
+	for _, table := range state[tasks.TablesFilterStateKey].GetIncrementalTables() {
+		if table.ID() != tableID {
+			tables = append(tables, table)
+		}
+	}
+	if err := cp.SetTransferState(transfer.ID, map[string]*coordinator.TransferStateData{
+		tasks.TablesFilterStateKey: {IncrementalTables: tables},
+	}); err != nil {
+		return xerrors.Errorf("unable to set transfer state: %w", err)
+	}
+	logger.Log.Infof("set cursor of table %s: %s", tableID.Fqtn(), tables[0].Filter)
+	return nil
+}
+
+func RunReset(cp coordinator.Coordinator, transfer *model.Transfer, keys []string) error {
+	if len(keys) == 0 {
+		state, err := cp.GetTransferState(transfer.ID)
+		if err != nil {
+			return xerrors.Errorf("unable to get transfer state: %w", err)
+		}
+		keys = stateKeys(state)
+	}
+	if len(keys) == 0 {
+		logger.Log.Infof("transfer %s has no state", transfer.ID)
+		return nil
+	}
+	if err := cp.RemoveTransferState(transfer.ID, keys); err != nil {
+		return xerrors.Errorf("unable to remove transfer state: %w", err)
+	}
+	logger.Log.Infof("removed state keys %v of transfer %s", keys, transfer.ID)
+	return nil
+}
+
+// ValidateState checks state before it is stored: incremental tables must belong to the transfer,
+// state keys of source are validated by source provider if it supports it.
+func ValidateState(transfer *model.Transfer, registry metrics.Registry, state map[string]*coordinator.TransferStateData) error {
+	for key, value := range state {
+		if value == nil {
+			return xerrors.Errorf("state key %s has no value", key)
+		}
+	}
+	if value, ok := state[tasks.TablesFilterStateKey]; ok {
+		incrementalTables := map[abstract.TableID]bool{}
+		if transfer.RegularSnapshot != nil {
+			for _, table := range transfer.RegularSnapshot.Incremental {
+				incrementalTables[table.TableID()] = true
+			}
+		}
+		for _, table := range value.GetIncrementalTables() {
+			if !incrementalTables[table.ID()] {
+				return xerrors.Errorf("table %s is not incremental in transfer %s", table.Fqtn(), transfer.ID)
+			}
+		}
+	}
+	if stateManager, ok := providers.Source[providers.StateManager](logger.Log, registry, coordinator.NewFakeClient(), transfer); ok {
+		if err := stateManager.ValidateState(state); err != nil {
+			return xerrors.Errorf("invalid %s source state: %w", transfer.SrcType(), err)
+		}
+	}
+	return nil
+}
+
+func stateKeys(state map[string]*coordinator.TransferStateData) []string {
+	keys := make([]string, 0, len(state))
+	for key := range state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,68 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/providers/mysql"
+	"github.com/transferia/transferia/pkg/worker/tasks"
+)
+
+func TestExportImportReset(t *testing.T) {
+	cp := coordinator.NewStatefulFakeClient()
+	transfer := &model.Transfer{
+		ID:  "test-transfer",
+		Src: new(mysql.MysqlSource),
+		Dst: &model.MockDestination{},
+	}
+	registry := solomon.NewRegistry(nil)
+
+	require.NoError(t, RunSetPosition(cp, transfer, registry, map[string]string{"file": "mysql-bin.000003", "pos": "4"}))
+	require.Error(t, RunSetPosition(cp, transfer, registry, map[string]string{"file": "mysql-bin.000003", "pos": "-1"}))
+	require.Error(t, RunSetPosition(cp, transfer, registry, map[string]string{"lsn": "0/16B6C50"}))
+
+	var out bytes.Buffer
+	require.NoError(t, RunShow(cp, transfer, "", &out))
+	var exported map[string]*coordinator.TransferStateData
+	require.NoError(t, json.Unmarshal(out.Bytes(), &exported))
+	require.Equal(t, &coordinator.MysqlBinlogPositionState{File: "mysql-bin.000003", Position: 4}, exported["binlog"].GetMysqlBinlogPosition())
+	require.Error(t, RunShow(cp, transfer, "gtidset", &out))
+
+	require.NoError(t, RunReset(cp, transfer, nil))
+	state, err := cp.GetTransferState(transfer.ID)
+	require.NoError(t, err)
+	require.Empty(t, state)
+
+	require.NoError(t, RunImport(cp, transfer, registry, out.Bytes(), true))
+	state, err = cp.GetTransferState(transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, exported, state)
+
+	require.Error(t, RunImport(cp, transfer, registry, []byte(`{"gtidset": {"MysqlGtid": {"Gtid": "not a gtid", "Flavor": "mysql"}}}`), false))
+}
+
+func TestValidateIncrementalTables(t *testing.T) {
+	transfer := &model.Transfer{
+		ID:  "test-transfer",
+		Src: &model.MockSource{},
+		Dst: &model.MockDestination{},
+		RegularSnapshot: &abstract.RegularSnapshot{
+			Incremental: []abstract.IncrementalTable{{Namespace: "public", Name: "orders", CursorField: "id"}},
+		},
+	}
+	registry := solomon.NewRegistry(nil)
+
+	require.NoError(t, ValidateState(transfer, registry, map[string]*coordinator.TransferStateData{
+		tasks.TablesFilterStateKey: {IncrementalTables: []abstract.TableDescription{{Schema: "public", Name: "orders", Filter: `"id" > 10`}}},
+	}))
+	require.Error(t, ValidateState(transfer, registry, map[string]*coordinator.TransferStateData{
+		tasks.TablesFilterStateKey: {IncrementalTables: []abstract.TableDescription{{Schema: "public", Name: "users", Filter: `"id" > 10`}}},
+	}))
+	require.Error(t, RunSetCursor(coordinator.NewStatefulFakeClient(), transfer, registry, abstract.TableID{Namespace: "public", Name: "users"}, "10"))
+}
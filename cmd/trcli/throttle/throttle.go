@@ -0,0 +1,150 @@
+package throttle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/transferia/transferia/cmd/trcli/config"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/middlewares/ratelimit"
+)
+
+func ThrottleCommand(cp *coordinator.Coordinator) *cobra.Command {
+	var transferParams string
+	throttleCommand := &cobra.Command{
+		Use:   "throttle",
+		Short: "Pause, resume and limit rate of running transfer",
+	}
+	throttleCommand.PersistentFlags().StringVar(&transferParams, "transfer", "./transfer.yaml", "path to yaml file with transfer configuration")
+
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "Show rate limits of transfer",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: withTransferID(cp, &transferParams, func(cmd *cobra.Command, cp coordinator.Coordinator, transferID string) error {
+			return RunShow(cp, transferID, cmd.OutOrStdout())
+		}),
+	}
+
+	var rowsPerSecond, bytesPerSecond float64
+	var table string
+	set := &cobra.Command{
+		Use:   "set",
+		Short: "Set rate limits of transfer or its table, zero removes the limit",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: withTransferID(cp, &transferParams, func(cmd *cobra.Command, cp coordinator.Coordinator, transferID string) error {
+			var rows, bytes *float64
+			if cmd.Flags().Changed("rows-per-second") {
+				rows = &rowsPerSecond
+			}
+			if cmd.Flags().Changed("bytes-per-second") {
+				bytes = &bytesPerSecond
+			}
+			return RunSet(cp, transferID, table, rows, bytes)
+		}),
+	}
+	set.Flags().Float64Var(&rowsPerSecond, "rows-per-second", 0, "limit of rows pushed into destination per second")
+	set.Flags().Float64Var(&bytesPerSecond, "bytes-per-second", 0, "limit of bytes pushed into destination per second")
+	set.Flags().StringVar(&table, "table", "", "set limits of given table, e.g. public.orders or public.*, instead of the whole transfer")
+
+	pause := &cobra.Command{
+		Use:   "pause",
+		Short: "Stop pushing into destination, source stops reading but keeps its replication slot or consumer group",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: withTransferID(cp, &transferParams, func(cmd *cobra.Command, cp coordinator.Coordinator, transferID string) error {
+			return RunPause(cp, transferID, true)
+		}),
+	}
+	resume := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume paused transfer",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		RunE: withTransferID(cp, &transferParams, func(cmd *cobra.Command, cp coordinator.Coordinator, transferID string) error {
+			return RunPause(cp, transferID, false)
+		}),
+	}
+
+	throttleCommand.AddCommand(show, set, pause, resume)
+	return throttleCommand
+}
+
+func withTransferID(
+	cp *coordinator.Coordinator,
+	transferYaml *string,
+	f func(cmd *cobra.Command, cp coordinator.Coordinator, transferID string) error,
+) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		transfer, err := config.TransferFromYaml(transferYaml)
+		if err != nil {
+			return xerrors.Errorf("unable to load transfer: %w", err)
+		}
+		if _, ok := (*cp).(*coordinator.CoordinatorInMemory); ok {
+			logger.Log.Warn("memory coordinator is not shared with running transfer, use persistent coordinator, e.g. --coordinator s3, or PUT /rate_limits of admin API, see --admin-addr")
+		}
+		return f(cmd, *cp, transfer.ID)
+	}
+}
+
+func RunShow(cp coordinator.Coordinator, transferID string, out io.Writer) error {
+	limits, err := ratelimit.GetLimits(cp, transferID)
+	if err != nil {
+		return xerrors.Errorf("unable to get rate limits: %w", err)
+	}
+	res, err := json.MarshalIndent(limits, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("unable to marshal rate limits: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(res))
+	return err
+}
+
+// RunSet changes given limits of transfer or its table, other limits are kept
+func RunSet(cp coordinator.Coordinator, transferID string, table string, rowsPerSecond, bytesPerSecond *float64) error {
+	limits, err := ratelimit.GetLimits(cp, transferID)
+	if err != nil {
+		return xerrors.Errorf("unable to get rate limits: %w", err)
+	}
+	if table == "" {
+		if rowsPerSecond != nil {
+			limits.RowsPerSecond = *rowsPerSecond
+		}
+		if bytesPerSecond != nil {
+			limits.BytesPerSecond = *bytesPerSecond
+		}
+	} else {
+		if limits.Tables == nil {
+			limits.Tables = map[string]ratelimit.TableLimits{}
+		}
+		tableLimits := limits.Tables[table]
+		if rowsPerSecond != nil {
+			tableLimits.RowsPerSecond = *rowsPerSecond
+		}
+		if bytesPerSecond != nil {
+			tableLimits.BytesPerSecond = *bytesPerSecond
+		}
+		limits.Tables[table] = tableLimits
+		if tableLimits == (ratelimit.TableLimits{}) {
+			delete(limits.Tables, table)
+		}
+	}
+	if err := ratelimit.SetLimits(cp, transferID, limits); err != nil {
+		return xerrors.Errorf("unable to set rate limits: %w", err)
+	}
+	return nil
+}
+
+func RunPause(cp coordinator.Coordinator, transferID string, paused bool) error {
+	limits, err := ratelimit.GetLimits(cp, transferID)
+	if err != nil {
+		return xerrors.Errorf("unable to get rate limits: %w", err)
+	}
+	limits.Paused = paused
+	if err := ratelimit.SetLimits(cp, transferID, limits); err != nil {
+		return xerrors.Errorf("unable to set rate limits: %w", err)
+	}
+	return nil
+}
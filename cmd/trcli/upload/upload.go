@@ -2,9 +2,11 @@ package upload
 
 import (
 	"context"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/transferia/transferia/cmd/trcli/config"
+	"github.com/transferia/transferia/internal/logger"
 	"github.com/transferia/transferia/library/go/core/metrics"
 	"github.com/transferia/transferia/library/go/core/xerrors"
 	"github.com/transferia/transferia/pkg/abstract"
@@ -13,7 +15,7 @@ import (
 	"github.com/transferia/transferia/pkg/worker/tasks"
 )
 
-func UploadCommand(cp *coordinator.Coordinator, rt abstract.Runtime, registry metrics.Registry) *cobra.Command {
+func UploadCommand(cp *coordinator.Coordinator, rt abstract.Runtime, registry metrics.Registry, shutdownTimeout *time.Duration) *cobra.Command {
 	var transferParams string
 	var uploadParams string
 	var metricsPrefix string
@@ -22,7 +24,7 @@ func UploadCommand(cp *coordinator.Coordinator, rt abstract.Runtime, registry me
 		Use:     "upload",
 		Short:   "Upload tables",
 		Example: "./trcli upload --transfer ./transfer.yaml --tables tables.yaml",
-		RunE:    upload(cp, rt, &transferParams, &uploadParams, registry, metricsPrefix),
+		RunE:    upload(cp, rt, &transferParams, &uploadParams, registry, metricsPrefix, shutdownTimeout),
 	}
 	uploadCommand.Flags().StringVar(&transferParams, "transfer", "./transfer.yaml", "path to yaml file with transfer configuration")
 	uploadCommand.Flags().StringVar(&uploadParams, "tables", "./tables.yaml", "path to yaml file with uploadable table params")
@@ -31,14 +33,14 @@ func UploadCommand(cp *coordinator.Coordinator, rt abstract.Runtime, registry me
 	return uploadCommand
 }
 
-func upload(cp *coordinator.Coordinator, rt abstract.Runtime, transferYaml, uploadTablesYaml *string, registry metrics.Registry, metricsPrefix string) func(cmd *cobra.Command, args []string) error {
+func upload(cp *coordinator.Coordinator, rt abstract.Runtime, transferYaml, uploadTablesYaml *string, registry metrics.Registry, metricsPrefix string, shutdownTimeout *time.Duration) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		transfer, err := config.TransferFromYaml(transferYaml)
 		if err != nil {
 			return xerrors.Errorf("unable to load transfer: %w", err)
 		}
 
-		transfer.Runtime = rt
+		transfer.Runtime = config.WorkerRuntime(transfer, rt)
 
 		tables, err := config.TablesFromYaml(uploadTablesYaml)
 		if err != nil {
@@ -49,20 +51,58 @@ func upload(cp *coordinator.Coordinator, rt abstract.Runtime, transferYaml, uplo
 			registry = registry.WithPrefix(metricsPrefix)
 		}
 
-		return RunUpload(*cp, transfer, tables, registry)
+		return RunUpload(cmd.Context(), *cp, transfer, tables, registry, *shutdownTimeout)
 	}
 }
 
-func RunUpload(cp coordinator.Coordinator, transfer *model.Transfer, tables *config.UploadTables, registry metrics.Registry) error {
-	return tasks.Upload(
-		context.Background(),
-		cp,
-		*transfer,
-		nil,
-		tasks.UploadSpec{Tables: tables.Tables},
-		registry.WithTags(map[string]string{
-			"resource_id": transfer.ID,
-			"name":        transfer.TransferName,
-		}),
-	)
+// RunUpload uploads the tables until they are loaded or ctx is done.
+// Once ctx is done, the upload is given shutdownTimeout to complete, then it is interrupted.
+func RunUpload(ctx context.Context, cp coordinator.Coordinator, transfer *model.Transfer, tables *config.UploadTables, registry metrics.Registry, shutdownTimeout time.Duration) error {
+	op := new(model.TransferOperation)
+	// operation is keyed by requested tables, so uploads of different tables neither collide nor resume each other
+	op.OperationID = transfer.ID + "/upload/" + tasks.TablesFingerprint(tables.Tables)
+	// the upload is not canceled by ctx directly, so table parts being loaded are pushed within the shutdown timeout
+	uploadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	upload := func() error {
+		return tasks.Upload(
+			uploadCtx,
+			cp,
+			*transfer,
+			op,
+			tasks.UploadSpec{Tables: tables.Tables},
+			registry.WithTags(map[string]string{
+				"resource_id": transfer.ID,
+				"name":        transfer.TransferName,
+			}),
+		)
+	}
+	run := upload
+	if !transfer.IsMain() {
+		run = func() error {
+			return tasks.RunSecondaryWorker(uploadCtx, cp, op.OperationID, transfer.CurrentJobIndex(), upload)
+		}
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- run()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	logger.Log.Infof("shutdown requested, waiting for upload to complete within %v", shutdownTimeout)
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(shutdownTimeout):
+	}
+	logger.Log.Warnf("upload is not completed in %v, interrupting it", shutdownTimeout)
+	cancel()
+	if err := <-errCh; err != nil {
+		return xerrors.Errorf("upload is interrupted: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/library/go/yandex/cloud/filter/grammar"
+)
+
+// Assignment is a named expression: `name = expression`
+type Assignment struct {
+	Name       string
+	Expression Expression
+}
+
+// Expression is an operand or a comparison of two operands
+type Expression struct {
+	Left Operand
+	// Operator and Right are set for comparisons only
+	Operator OperatorType
+	Right    *Operand
+}
+
+func (e Expression) IsComparison() bool {
+	return e.Right != nil
+}
+
+// Operand is exactly one of a value, a function call or an attribute
+type Operand struct {
+	Value     *Value
+	Call      *Call
+	Attribute string
+}
+
+type Call struct {
+	Function  string
+	Arguments []Expression
+}
+
+// ParseAssignment parses `name = expression`, where expression extends the filter syntax with function calls:
+//
+//	full_name = concat(first_name, ' ', last_name)
+//	is_big = amount > 1000
+func ParseAssignment(assignment string) (Assignment, error) {
+	parsed, err := grammar.ParseAssignment(assignment)
+	if err != nil {
+		var syntaxErr *grammar.SyntaxError
+		if xerrors.As(err, &syntaxErr) {
+			return Assignment{}, newSyntaxError(syntaxErr.Pos, syntaxErr.Error())
+		}
+		return Assignment{}, xerrors.Errorf("unexpected parse error: %w", err)
+	}
+	expr, err := expressionFromParsed(parsed.Expression)
+	if err != nil {
+		return Assignment{}, err
+	}
+	return Assignment{
+		Name:       parsed.Name,
+		Expression: expr,
+	}, nil
+}
+
+func expressionFromParsed(e *grammar.Expression) (Expression, error) {
+	left, err := operandFromParsed(e.Left)
+	if err != nil {
+		return Expression{}, err
+	}
+	if e.Right == nil {
+		if left.Value != nil && len(left.Value.parsed.List) > 0 {
+			return Expression{}, newSyntaxError(e.Pos, "list values require [ NOT ] IN operator")
+		}
+		return Expression{Left: left, Operator: Equals, Right: nil}, nil
+	}
+
+	op, err := opFromG(e.Operator)
+	if err != nil {
+		return Expression{}, newSyntaxError(e.Pos, err.Error())
+	}
+	right, err := operandFromParsed(e.Right)
+	if err != nil {
+		return Expression{}, err
+	}
+	if left.Value != nil && len(left.Value.parsed.List) > 0 {
+		return Expression{}, newSyntaxError(e.Left.Pos, "list values are allowed on the right side of [ NOT ] IN operator only")
+	}
+	if e.Right.Value != nil {
+		// comparisons with values follow the rules of filter terms
+		if err := validateTerm(grammar.Term{Attribute: "", Operator: e.Operator, Value: e.Right.Value, Pos: e.Pos}, op); err != nil {
+			return Expression{}, err
+		}
+	} else if op == In || op == NotIn {
+		return Expression{}, newSyntaxErrorf(e.Pos, "%s operator expect list value", e.Operator)
+	}
+	return Expression{Left: left, Operator: op, Right: &right}, nil
+}
+
+func operandFromParsed(o *grammar.Operand) (Operand, error) {
+	switch {
+	case o.Value != nil:
+		return Operand{Value: &Value{parsed: *o.Value}, Call: nil, Attribute: ""}, nil
+	case o.Call != nil:
+		args := make([]Expression, len(o.Call.Arguments))
+		for i, arg := range o.Call.Arguments {
+			parsedArg, err := expressionFromParsed(arg)
+			if err != nil {
+				return Operand{}, err
+			}
+			args[i] = parsedArg
+		}
+		return Operand{Value: nil, Call: &Call{Function: o.Call.Function, Arguments: args}, Attribute: ""}, nil
+	default:
+		return Operand{Value: nil, Call: nil, Attribute: o.Attribute}, nil
+	}
+}
@@ -0,0 +1,99 @@
+package grammar
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/participle"
+	"github.com/alecthomas/participle/lexer"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+)
+
+// Assignment extends the filter syntax with named expressions:
+//
+//	full_name = concat(first_name, ' ', last_name)
+//	is_big = amount > 1000
+type Assignment struct {
+	Name       string      `parser:"[ WS ] @Ident [ WS ] '='"`
+	Expression *Expression `parser:"@@"`
+	Pos        lexer.Position
+}
+
+// Expression is an operand or a comparison of two operands with any of the filter operators.
+type Expression struct {
+	Left     *Operand `parser:"[ WS ] @@ [ WS ]"`
+	Operator Operator `parser:"[ @( Operator | 'IN' | 'NOT' { WS } 'IN' ) [ WS ]"`
+	Right    *Operand `parser:"  @@ [ WS ] ]"`
+	Pos      lexer.Position
+}
+
+// Operand is a value of the filter syntax, a function call or an attribute.
+type Operand struct {
+	Value     *Value `parser:"  @@"`
+	Call      *Call  `parser:"| @@"`
+	Attribute string `parser:"| @Ident"`
+	Pos       lexer.Position
+}
+
+type Call struct {
+	Function  string        `parser:"@Ident [ WS ] '('"`
+	Arguments []*Expression `parser:"[ @@ { ',' @@ } ] [ WS ] ')'"`
+	Pos       lexer.Position
+}
+
+var expressionParser = participle.MustBuild(
+	&Assignment{},
+	participle.Lexer(filterLexer),
+	participle.Unquote("String"),
+	participle.CaseInsensitive("Ident"),
+	participle.UseLookahead(3),
+)
+
+// ParseAssignment parses a single `name = expression` assignment.
+func ParseAssignment(assignment string) (*Assignment, error) {
+	result := new(Assignment)
+	if err := expressionParser.ParseString(assignment, result); err != nil {
+		var lexError *lexer.Error
+		if xerrors.As(err, &lexError) {
+			return nil, &SyntaxError{
+				Message: lexError.Message,
+				Pos:     lexError.Pos,
+			}
+		}
+		var tokenError participle.UnexpectedTokenError
+		if xerrors.As(err, &tokenError) {
+			return nil, &SyntaxError{
+				Message: fmt.Sprintf("unexpected token %q", tokenError.Value),
+				Pos:     tokenError.Pos,
+			}
+		}
+		return nil, err
+	}
+	if err := result.Expression.delayedParse(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (e *Expression) delayedParse() error {
+	if err := e.Left.delayedParse(); err != nil {
+		return err
+	}
+	if e.Right != nil {
+		return e.Right.delayedParse()
+	}
+	return nil
+}
+
+func (o *Operand) delayedParse() error {
+	switch {
+	case o.Value != nil:
+		return o.Value.delayedParse()
+	case o.Call != nil:
+		for _, arg := range o.Call.Arguments {
+			if err := arg.delayedParse(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
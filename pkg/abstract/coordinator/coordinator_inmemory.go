@@ -46,6 +46,12 @@ func (f *CoordinatorInMemory) GetTransferState(id string) (map[string]*TransferS
 	return f.state[id], nil
 }
 
+func (f *CoordinatorInMemory) GetTransferStateKey(transferID string, key string) (*TransferStateData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.state[transferID][key], nil
+}
+
 func (f *CoordinatorInMemory) SetTransferState(transferID string, state map[string]*TransferStateData) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
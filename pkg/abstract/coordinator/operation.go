@@ -33,6 +33,13 @@ type OperationStatus interface {
 	FinishOperation(taskID string, shardIndex int, taskErr error) error
 }
 
+// OperationHealthTracker is opt-in interface of coordinators which keep health signals of operation workers,
+// so *main* worker can find dead secondary workers
+type OperationHealthTracker interface {
+	// GetOperationHealth returns the last time of OperationHealth call of each worker, by worker index
+	GetOperationHealth(ctx context.Context, operationID string) (map[int]time.Time, error)
+}
+
 // Sharding coordinate multiple worker for transfer operations
 // transfer utilize MPP aproach, when we have a main (or leader) worker
 // main worker coordinate secondary workers via single coordinator (API or remote storage)
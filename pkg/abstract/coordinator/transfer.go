@@ -44,3 +44,10 @@ type TransferState interface {
 	// RemoveTransferState remove certain state keys from state
 	RemoveTransferState(transferID string, state []string) error
 }
+
+// TransferStateKeyGetter is opt-in interface of coordinators which read a single key of transfer state
+// cheaper than the whole state, e.g. for keys polled periodically
+type TransferStateKeyGetter interface {
+	// GetTransferStateKey returns the value of the key of transfer state, nil if the key is not set
+	GetTransferStateKey(transferID string, key string) (*TransferStateData, error)
+}
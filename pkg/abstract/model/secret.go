@@ -0,0 +1,123 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/secret"
+)
+
+var secretStringType = reflect.TypeOf(SecretString(""))
+
+// SecretRefs are references of resolved SecretString fields keyed by field path, e.g. `Auth.Password` or `Replicas[0].Password`.
+// Paths do not depend on the memory of params, so refs apply to copies of the params as well.
+type SecretRefs map[string]string
+
+// TransferSecretRefs are references of secrets resolved in params of transfer endpoints.
+type TransferSecretRefs struct {
+	Src SecretRefs
+	Dst SecretRefs
+}
+
+// ResolveSecrets replaces secret references in SecretString fields of endpoint params by secrets.
+// Returned references are kept by the caller, so secrets can be resolved again by RefreshSecrets after rotation.
+func ResolveSecrets(ctx context.Context, params any) (SecretRefs, error) {
+	refs := SecretRefs{}
+	err := walkSecrets(params, func(path string, field *SecretString) error {
+		ref := string(*field)
+		if !secret.IsReference(ref) {
+			return nil
+		}
+		value, err := secret.Resolve(ctx, ref)
+		if err != nil {
+			return xerrors.Errorf("unable to resolve secret: %w", err)
+		}
+		refs[path] = ref
+		*field = SecretString(value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// RefreshSecrets resolves references of endpoint params again, it reports whether any secret is changed.
+func RefreshSecrets(ctx context.Context, params any, refs SecretRefs) (bool, error) {
+	if len(refs) == 0 {
+		return false, nil
+	}
+	changed := false
+	err := walkSecrets(params, func(path string, field *SecretString) error {
+		ref, ok := refs[path]
+		if !ok {
+			return nil
+		}
+		value, err := secret.Resolve(ctx, ref)
+		if err != nil {
+			return xerrors.Errorf("unable to resolve secret: %w", err)
+		}
+		if *field != SecretString(value) {
+			*field = SecretString(value)
+			changed = true
+		}
+		return nil
+	})
+	return changed, err
+}
+
+type visitedValue struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+func walkSecrets(params any, f func(path string, field *SecretString) error) error {
+	return walkSecretsValue(reflect.ValueOf(params), "", f, map[visitedValue]bool{})
+}
+
+func walkSecretsValue(value reflect.Value, path string, f func(path string, field *SecretString) error, visited map[visitedValue]bool) error {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+		key := visitedValue{ptr: value.Pointer(), typ: value.Type()}
+		if visited[key] {
+			return nil
+		}
+		visited[key] = true
+		return walkSecretsValue(value.Elem(), path, f, visited)
+	case reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return walkSecretsValue(value.Elem(), path, f, visited)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if !value.Type().Field(i).IsExported() {
+				continue
+			}
+			name := value.Type().Field(i).Name
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := walkSecretsValue(value.Field(i), name, f, visited); err != nil {
+				return xerrors.Errorf("%s: %w", value.Type().Field(i).Name, err)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := walkSecretsValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i), f, visited); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		// secrets of values which are not addressable can not be replaced, e.g. structs stored in interfaces by value
+		if value.Type() == secretStringType && value.CanSet() {
+			return f(path, value.Addr().Interface().(*SecretString))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type secretAuth struct {
+	User     string
+	Password SecretString
+}
+
+type secretParams struct {
+	Password SecretString
+	Auth     *secretAuth
+	Replicas []secretAuth
+	Plain    string
+}
+
+func TestResolveSecrets(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("MODEL_TEST_PASSWORD", "v1")
+	t.Setenv("MODEL_TEST_REPLICA_PASSWORD", "replica")
+
+	params := &secretParams{
+		Password: "env://MODEL_TEST_PASSWORD",
+		Auth:     &secretAuth{User: "user", Password: "env://MODEL_TEST_PASSWORD"},
+		Replicas: []secretAuth{{User: "replica", Password: "env://MODEL_TEST_REPLICA_PASSWORD"}},
+		Plain:    "env://MODEL_TEST_PASSWORD",
+	}
+	refs, err := ResolveSecrets(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, SecretRefs{
+		"Password":             "env://MODEL_TEST_PASSWORD",
+		"Auth.Password":        "env://MODEL_TEST_PASSWORD",
+		"Replicas[0].Password": "env://MODEL_TEST_REPLICA_PASSWORD",
+	}, refs)
+	require.Equal(t, &secretParams{
+		Password: "v1",
+		Auth:     &secretAuth{User: "user", Password: "v1"},
+		Replicas: []secretAuth{{User: "replica", Password: "replica"}},
+		Plain:    "env://MODEL_TEST_PASSWORD",
+	}, params)
+
+	changed, err := RefreshSecrets(ctx, params, refs)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// refs are applied to a copy of params as well
+	copied := *params
+	copied.Auth = &secretAuth{User: params.Auth.User, Password: params.Auth.Password}
+	t.Setenv("MODEL_TEST_PASSWORD", "v2")
+	changed, err = RefreshSecrets(ctx, &copied, refs)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, SecretString("v2"), copied.Password)
+	require.Equal(t, SecretString("v2"), copied.Auth.Password)
+	require.Equal(t, SecretString("replica"), copied.Replicas[0].Password)
+
+	changed, err = RefreshSecrets(ctx, params, nil)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, SecretString("v1"), params.Password)
+
+	_, err = ResolveSecrets(ctx, &secretParams{Password: "env://MODEL_TEST_NOT_SET"})
+	require.Error(t, err)
+}
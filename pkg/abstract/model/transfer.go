@@ -24,6 +24,9 @@ type Transfer struct {
 	DataObjects       *DataObjects
 	TypeSystemVersion int
 	TmpPolicy         *TmpPolicyConfig
+	Record            *RecordConfig
+	// SecretRefs of endpoints params, set if secrets are resolved from references on transfer load
+	SecretRefs *TransferSecretRefs
 
 	// TODO: remove
 	FolderID string
@@ -0,0 +1,10 @@
+package model
+
+// RecordConfig enables recording of change item batches pushed into sink, see pkg/record
+type RecordConfig struct {
+	Path string `json:"path" yaml:"path"`
+}
+
+func (c *RecordConfig) Enabled() bool {
+	return c != nil && c.Path != ""
+}
@@ -23,9 +23,13 @@ func (t Transformation) Validate() error {
 	}
 	var errs error
 	for _, tr := range t.Transformers.Transformers {
-		_, err := transformer.New(tr.Type(), tr.Config(), logger.Log, abstract.TransformationRuntimeOpts{JobIndex: 0})
+		constructed, err := transformer.New(tr.Type(), tr.Config(), logger.Log, abstract.TransformationRuntimeOpts{JobIndex: 0})
 		if err != nil {
 			errs = multierr.Append(errs, xerrors.Errorf("unable to construct: %s(%s): %w", tr.Type(), tr.ID(), err))
+			continue
+		}
+		if err := transformer.CloseTransformers([]abstract.Transformer{constructed}); err != nil {
+			logger.Log.Warnf("unable to close transformer %s(%s): %v", tr.Type(), tr.ID(), err)
 		}
 	}
 	if errs != nil {
@@ -52,3 +52,13 @@ type Source interface {
 type Fetchable interface {
 	Fetch() ([]ChangeItem, error)
 }
+
+// DrainableSource is a Source which supports graceful shutdown.
+//
+// Drain makes the source stop reading new data, wait until everything already read is pushed
+// and commit its position. Run returns once draining is complete. Stop may still be called
+// afterwards (or instead, when draining takes too long).
+type DrainableSource interface {
+	Source
+	Drain()
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/transferia/transferia/internal/logger"
@@ -21,8 +22,10 @@ import (
 )
 
 var (
-	_ coordinator.Sharding      = (*CoordinatorS3)(nil)
-	_ coordinator.TransferState = (*CoordinatorS3)(nil)
+	_ coordinator.Sharding               = (*CoordinatorS3)(nil)
+	_ coordinator.TransferState          = (*CoordinatorS3)(nil)
+	_ coordinator.OperationHealthTracker = (*CoordinatorS3)(nil)
+	_ coordinator.TransferStateKeyGetter = (*CoordinatorS3)(nil)
 )
 
 type CoordinatorS3 struct {
@@ -78,6 +81,27 @@ func (c *CoordinatorS3) GetTransferState(transferID string) (map[string]*coordin
 	return state, nil
 }
 
+// GetTransferStateKey fetches a single state object of the transfer, without listing the others.
+func (c *CoordinatorS3) GetTransferStateKey(transferID string, key string) (*coordinator.TransferStateData, error) {
+	resp, err := c.s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(transferID + "/" + key + ".json"),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var transferData coordinator.TransferStateData
+	if err := json.NewDecoder(resp.Body).Decode(&transferData); err != nil {
+		return nil, xerrors.Errorf("failed to decode object data: %w", err)
+	}
+	return &transferData, nil
+}
+
 // SetTransferState stores the given transfer state into S3 as JSON objects.
 func (c *CoordinatorS3) SetTransferState(transferID string, state map[string]*coordinator.TransferStateData) error {
 	for key, value := range state {
@@ -266,7 +290,7 @@ func (c *CoordinatorS3) AssignOperationTablePart(operationID string, workerIndex
 	return nil, nil
 }
 
-// ClearAssignedTablesParts clears the table parts assigned to a worker.
+// ClearAssignedTablesParts clears the table parts assigned to a worker, completed parts are kept as is.
 func (c *CoordinatorS3) ClearAssignedTablesParts(ctx context.Context, operationID string, workerIndex int) (int64, error) {
 	tables, err := c.GetOperationTablesParts(operationID)
 	if err != nil {
@@ -275,7 +299,7 @@ func (c *CoordinatorS3) ClearAssignedTablesParts(ctx context.Context, operationI
 
 	var clearedCount int64
 	for _, table := range tables {
-		if table.WorkerIndex != nil && *table.WorkerIndex == workerIndex {
+		if table.WorkerIndex != nil && *table.WorkerIndex == workerIndex && !table.Completed {
 			table.WorkerIndex = nil
 
 			key := fmt.Sprintf("%s/table_%v.json", operationID, table.TableKey())
@@ -349,6 +373,48 @@ func (c *CoordinatorS3) FinishOperation(operationID string, shardIndex int, task
 	return nil
 }
 
+// operationHealth is stored as json object, since operation keys are listed by GetTransferState of the transfer too
+type operationHealth struct {
+	WorkerIndex int       `json:"worker_index"`
+	Time        time.Time `json:"time"`
+}
+
+// OperationHealth stores the last time worker signaled alive.
+func (c *CoordinatorS3) OperationHealth(ctx context.Context, operationID string, workerIndex int, workerTime time.Time) error {
+	key := fmt.Sprintf("%s/health_%d.json", operationID, workerIndex)
+	body, err := json.Marshal(operationHealth{WorkerIndex: workerIndex, Time: workerTime})
+	if err != nil {
+		return xerrors.Errorf("failed to marshal worker health: %w", err)
+	}
+	if err := c.putObject(key, body); err != nil {
+		return xerrors.Errorf("failed to store worker health: %w", err)
+	}
+	return nil
+}
+
+// GetOperationHealth fetches the last time each worker of operation signaled alive.
+func (c *CoordinatorS3) GetOperationHealth(ctx context.Context, operationID string) (map[int]time.Time, error) {
+	prefix := operationID + "/health_"
+	objects, err := c.listObjects(prefix)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list prefix: %s: %w", prefix, err)
+	}
+
+	res := map[int]time.Time{}
+	for _, obj := range objects {
+		body, err := c.getObject(*obj.Key)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get key: %s: %w", *obj.Key, err)
+		}
+		var health operationHealth
+		if err := json.Unmarshal(body, &health); err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal worker health: %w", err)
+		}
+		res[health.WorkerIndex] = health.Time
+	}
+	return res, nil
+}
+
 // Utility functions to interact with S3.
 func (c *CoordinatorS3) putObject(key string, body []byte) error {
 	_, err := c.s3Client.PutObject(&s3.PutObjectInput{
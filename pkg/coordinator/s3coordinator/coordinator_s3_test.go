@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/transferia/transferia/pkg/abstract/coordinator"
@@ -64,6 +65,16 @@ func TestCoordinatorS3TransferState(t *testing.T) {
 		}
 		require.Equal(t, expectedData, state)
 	})
+
+	t.Run("GetTransferStateKey", func(t *testing.T) {
+		value, err := cp.GetTransferStateKey(transferID, "file3")
+		require.NoError(t, err)
+		require.Equal(t, &coordinator.TransferStateData{Generic: "3"}, value)
+
+		value, err = cp.GetTransferStateKey(transferID, "file2")
+		require.NoError(t, err)
+		require.Nil(t, value)
+	})
 }
 
 func TestDataplaneServiceShardedTasks(t *testing.T) {
@@ -177,6 +188,16 @@ func TestDataplaneServiceShardedTasks(t *testing.T) {
 		}
 	})
 
+	t.Run("ClearKeepsCompletedParts", func(t *testing.T) {
+		clearedCount, err := dp.ClearAssignedTablesParts(ctx, operationID, 3)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), clearedCount) // both parts of worker 3 are completed
+
+		assigned, err := dp.AssignOperationTablePart(operationID, 3)
+		require.NoError(t, err)
+		require.Nil(t, assigned)
+	})
+
 	t.Run("ValidateWorkerCompletion", func(t *testing.T) {
 		require.NoError(t, dp.FinishOperation(operationID, 1, nil))
 		workers, err := dp.GetOperationWorkers(operationID)
@@ -189,4 +210,14 @@ func TestDataplaneServiceShardedTasks(t *testing.T) {
 			require.Equal(t, expectedCompletion[i], worker.Completed)
 		}
 	})
+
+	t.Run("OperationHealth", func(t *testing.T) {
+		healthAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		require.NoError(t, dp.OperationHealth(ctx, operationID, 2, healthAt))
+		require.NoError(t, dp.OperationHealth(ctx, operationID, 3, healthAt.Add(time.Minute)))
+
+		health, err := dp.GetOperationHealth(ctx, operationID)
+		require.NoError(t, err)
+		require.Equal(t, map[int]time.Time{2: healthAt, 3: healthAt.Add(time.Minute)}, health)
+	})
 }
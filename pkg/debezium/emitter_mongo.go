@@ -0,0 +1,85 @@
+package debezium
+
+import (
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	debeziumcommon "github.com/transferia/transferia/pkg/debezium/common"
+	"github.com/transferia/transferia/pkg/debezium/mongo"
+	"github.com/transferia/transferia/pkg/debezium/packer"
+)
+
+// emitMongoOneDebeziumMessage - the same as emitOneDebeziumMessage, but builds envelopes of the MongoDB connector
+func (m *Emitter) emitMongoOneDebeziumMessage(
+	changeItem *abstract.ChangeItem,
+	payloadTSMS time.Time,
+	snapshot bool,
+	emitType emitType,
+	sessionPackers packer.SessionPackers,
+) (string, *string, error) {
+	var key []byte = nil
+	if !m.dropKeys {
+		var err error
+		key, err = sessionPackers.Packer(true).Pack(
+			changeItem,
+			m.mongoEmitter.KeyPayload,
+			m.mongoEmitter.KeySchema,
+			nil,
+		)
+		if err != nil {
+			return "", nil, xerrors.Errorf("unable to pack key, err: %w", err)
+		}
+	}
+
+	if emitType == tombstoneEventEmitType {
+		return string(key), nil, nil
+	}
+
+	val, err := sessionPackers.Packer(false).Pack(
+		changeItem,
+		func(changeItem *abstract.ChangeItem) ([]byte, error) {
+			return m.mongoEmitter.ValuePayload(changeItem, payloadTSMS, snapshot)
+		},
+		m.mongoEmitter.ValueSchema,
+		nil,
+	)
+	if err != nil {
+		return "", nil, xerrors.Errorf("unable to pack val, err: %w", err)
+	}
+
+	valStr := string(val)
+	return string(key), &valStr, nil
+}
+
+// emitMongoKV - mongo documents can't change '_id', so only deletes produce more than one message
+func (m *Emitter) emitMongoKV(changeItem *abstract.ChangeItem, payloadTSMS time.Time, snapshot bool, sessionPackers packer.SessionPackers) ([]debeziumcommon.KeyValue, error) {
+	if !mongo.IsSupportedKind(changeItem.Kind) {
+		return []debeziumcommon.KeyValue{}, nil
+	}
+
+	if sessionPackers == nil {
+		sessionPackers = packer.NewDefaultSessionPackers(m.keyPacker, m.valuePacker)
+	}
+
+	if changeItem.Kind == abstract.DeleteKind {
+		key0, val0, err := m.emitMongoOneDebeziumMessage(changeItem, payloadTSMS, snapshot, deleteEventEmitType, sessionPackers)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to emit debezium event part 0: %w", err)
+		}
+		if m.skipTombstoneEvent() {
+			return []debeziumcommon.KeyValue{{DebeziumKey: key0, DebeziumVal: val0}}, nil
+		}
+		key1, val1, err := m.emitMongoOneDebeziumMessage(changeItem, payloadTSMS, snapshot, tombstoneEventEmitType, sessionPackers)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to emit debezium event part 1: %w", err)
+		}
+		return []debeziumcommon.KeyValue{{DebeziumKey: key0, DebeziumVal: val0}, {DebeziumKey: key1, DebeziumVal: val1}}, nil
+	}
+
+	key, val, err := m.emitMongoOneDebeziumMessage(changeItem, payloadTSMS, snapshot, regularEmitType, sessionPackers)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to emit debezium event: %w", err)
+	}
+	return []debeziumcommon.KeyValue{{DebeziumKey: key, DebeziumVal: val}}, nil
+}
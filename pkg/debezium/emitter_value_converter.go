@@ -9,6 +9,7 @@ import (
 	"github.com/transferia/transferia/library/go/core/xerrors"
 	"github.com/transferia/transferia/pkg/abstract"
 	debeziumcommon "github.com/transferia/transferia/pkg/debezium/common"
+	"github.com/transferia/transferia/pkg/debezium/mongo"
 	"github.com/transferia/transferia/pkg/debezium/mysql"
 	"github.com/transferia/transferia/pkg/debezium/packer"
 	debeziumparameters "github.com/transferia/transferia/pkg/debezium/parameters"
@@ -34,6 +35,8 @@ type Emitter struct {
 
 	keyPacker   packer.Packer
 	valuePacker packer.Packer
+
+	mongoEmitter *mongo.Emitter // set only for mongo sources, which have their own envelope
 }
 
 var errUnknownSource = xerrors.New("unknown source type")
@@ -624,6 +627,10 @@ func (m *Emitter) emitOneDebeziumMessage(
 
 // EmitKV - main exported method - generates kafka key & kafka value
 func (m *Emitter) emitKV(changeItem *abstract.ChangeItem, payloadTSMS time.Time, snapshot bool, sessionPackers packer.SessionPackers) ([]debeziumcommon.KeyValue, error) {
+	if m.mongoEmitter != nil {
+		return m.emitMongoKV(changeItem, payloadTSMS, snapshot, sessionPackers)
+	}
+
 	if changeItem.Kind != abstract.InsertKind && changeItem.Kind != abstract.UpdateKind && changeItem.Kind != abstract.DeleteKind {
 		return []debeziumcommon.KeyValue{}, nil
 	}
@@ -683,6 +690,8 @@ func (m *Emitter) EmitKV(changeItem *abstract.ChangeItem, payloadTSMS time.Time,
 		m.logger.Info("EXTRA_VALIDATION_ON_TESTS__DEBEZIUM CALLED")
 		if m.ignoreUnknownSources {
 			m.logger.Info("EXTRA VALIDATION SKIPPED BCS OF 'ignoreUnknownSources'")
+		} else if m.mongoEmitter != nil {
+			m.logger.Info("EXTRA VALIDATION SKIPPED FOR MONGO SOURCE")
 		} else {
 			validateOnTests(m.connectorParameters, changeItem)
 		}
@@ -707,6 +716,10 @@ func NewMessagesEmitter(connectorParameters map[string]string, version string, d
 	if err != nil {
 		return nil, xerrors.Errorf("can't create value message processor: %w", err)
 	}
+	var mongoEmitter *mongo.Emitter = nil
+	if debeziumparameters.GetSourceType(connectorParameters) == debeziumparameters.SourceTypeMongo {
+		mongoEmitter = mongo.NewEmitter(debeziumparameters.GetTopicPrefix(connectorParameters), version)
+	}
 	return &Emitter{
 		database:             debeziumparameters.GetDBName(connectorParameters),
 		databaseServerName:   debeziumparameters.GetTopicPrefix(connectorParameters),
@@ -717,5 +730,6 @@ func NewMessagesEmitter(connectorParameters map[string]string, version string, d
 		logger:               logger,
 		valuePacker:          valuePacker,
 		dropKeys:             dropKeys,
+		mongoEmitter:         mongoEmitter,
 	}, nil
 }
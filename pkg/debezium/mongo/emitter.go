@@ -0,0 +1,498 @@
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	mongocommon "github.com/transferia/transferia/pkg/providers/mongo"
+	"github.com/transferia/transferia/pkg/util"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	Connector = "mongodb"
+
+	// keyField is the only field of the Debezium MongoDB connector key, it holds '_id' as an extended JSON string
+	keyField = "id"
+)
+
+func jsonStringSchema(field string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "string",
+		"optional": true,
+		"name":     "io.debezium.data.Json",
+		"version":  1,
+		"field":    field,
+	}
+}
+
+var updateDescriptionSchema = map[string]interface{}{
+	"type": "struct",
+	"fields": []map[string]interface{}{
+		{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":     "string",
+				"optional": false,
+			},
+			"optional": true,
+			"field":    "removedFields",
+		},
+		jsonStringSchema("updatedFields"),
+		{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "struct",
+				"fields": []map[string]interface{}{
+					{
+						"type":     "string",
+						"optional": false,
+						"field":    "field",
+					}, {
+						"type":     "int32",
+						"optional": false,
+						"field":    "size",
+					},
+				},
+				"optional": false,
+				"name":     "io.debezium.connector.mongodb.changestream.truncatedarray",
+				"version":  1,
+			},
+			"optional": true,
+			"field":    "truncatedArrays",
+		},
+	},
+	"optional": true,
+	"name":     "io.debezium.connector.mongodb.changestream.updatedescription",
+	"version":  1,
+	"field":    "updateDescription",
+}
+
+var sourceSchema = map[string]interface{}{
+	"type": "struct",
+	"fields": []map[string]interface{}{
+		{
+			"type":     "string",
+			"optional": false,
+			"field":    "version",
+		}, {
+			"type":     "string",
+			"optional": false,
+			"field":    "connector",
+		}, {
+			"type":     "string",
+			"optional": false,
+			"field":    "name",
+		}, {
+			"type":     "int64",
+			"optional": false,
+			"field":    "ts_ms",
+		}, {
+			"type":     "string",
+			"optional": true,
+			"name":     "io.debezium.data.Enum",
+			"version":  1,
+			"parameters": map[string]interface{}{
+				"allowed": "true,last,false,incremental",
+			},
+			"default": "false",
+			"field":   "snapshot",
+		}, {
+			"type":     "string",
+			"optional": false,
+			"field":    "db",
+		}, {
+			"type":     "string",
+			"optional": true,
+			"field":    "sequence",
+		}, {
+			"type":     "string",
+			"optional": false,
+			"field":    "rs",
+		}, {
+			"type":     "string",
+			"optional": false,
+			"field":    "collection",
+		}, {
+			"type":     "int32",
+			"optional": false,
+			"field":    "ord",
+		}, {
+			"type":     "string",
+			"optional": true,
+			"field":    "lsid",
+		}, {
+			"type":     "int64",
+			"optional": true,
+			"field":    "txnNumber",
+		},
+	},
+	"optional": false,
+	"name":     "io.debezium.connector.mongo.Source",
+	"field":    "source",
+}
+
+var opSchema = map[string]interface{}{
+	"type":     "string",
+	"optional": false,
+	"field":    "op",
+}
+
+var tsMsSchema = map[string]interface{}{
+	"type":     "int64",
+	"optional": true,
+	"field":    "ts_ms",
+}
+
+var transactionSchema = map[string]interface{}{
+	"type": "struct",
+	"fields": []map[string]interface{}{
+		{
+			"type":     "string",
+			"optional": false,
+			"field":    "id",
+		}, {
+			"type":     "int64",
+			"optional": false,
+			"field":    "total_order",
+		}, {
+			"type":     "int64",
+			"optional": false,
+			"field":    "data_collection_order",
+		},
+	},
+	"optional": true,
+	"field":    "transaction",
+}
+
+// Emitter builds the key & value of the Debezium MongoDB connector from the ChangeItems of the mongo source
+//
+// Unlike the relational connectors, 'before' & 'after' are extended JSON strings of the whole document.
+// ChangeItems do not track the replica set name, so 'rs' in 'source' is always empty.
+type Emitter struct {
+	databaseServerName string // "name" in debezium payload - it's prefix for topic_name. "topic.prefix"
+	version            string
+}
+
+// IsSupportedKind reports whether the ChangeItem produces a Debezium event, other kinds are skipped
+func IsSupportedKind(kind abstract.Kind) bool {
+	switch kind {
+	case abstract.InsertKind, abstract.UpdateKind, abstract.MongoUpdateDocumentKind, abstract.DeleteKind:
+		return true
+	default:
+		return false
+	}
+}
+
+func kindToOp(kind abstract.Kind, snapshot bool) (string, error) {
+	switch kind {
+	case abstract.InsertKind:
+		if snapshot {
+			return "r", nil
+		}
+		return "c", nil
+	case abstract.UpdateKind, abstract.MongoUpdateDocumentKind:
+		return "u", nil
+	case abstract.DeleteKind:
+		return "d", nil
+	default:
+		return "", xerrors.Errorf("unsupported kind: %s", kind)
+	}
+}
+
+// documentID returns '_id' of the document, deletes & partial updates carry it in OldKeys only
+func documentID(changeItem *abstract.ChangeItem) (interface{}, error) {
+	if changeItem.Kind == abstract.InsertKind || changeItem.Kind == abstract.UpdateKind {
+		for i, name := range changeItem.ColumnNames {
+			if name == mongocommon.ID {
+				return changeItem.ColumnValues[i], nil
+			}
+		}
+	}
+	for i, name := range changeItem.OldKeys.KeyNames {
+		if name == mongocommon.ID {
+			return changeItem.OldKeys.KeyValues[i], nil
+		}
+	}
+	return nil, xerrors.Errorf("change item of %s has no %s", changeItem.TableID().Fqtn(), mongocommon.ID)
+}
+
+func columnValue(changeItem *abstract.ChangeItem, column string) (interface{}, bool) {
+	for i, name := range changeItem.ColumnNames {
+		if name == column {
+			return changeItem.ColumnValues[i], true
+		}
+	}
+	return nil, false
+}
+
+// toDocument converts the document column of the mongo source into bson.D
+func toDocument(value interface{}) (bson.D, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case mongocommon.DValue:
+		return v.D, nil
+	case bson.D:
+		return v, nil
+	case string:
+		var result bson.D
+		if err := bson.UnmarshalExtJSON([]byte(v), false, &result); err != nil {
+			return nil, xerrors.Errorf("unable to unmarshal extended JSON document: %w", err)
+		}
+		return result, nil
+	case map[string]interface{}:
+		return documentFromMap(v)
+	case bson.M:
+		return documentFromMap(v)
+	default:
+		return nil, xerrors.Errorf("unexpected document type: %T", value)
+	}
+}
+
+func documentFromMap(value map[string]interface{}) (bson.D, error) {
+	raw, err := bson.Marshal(value)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal document: %w", err)
+	}
+	var result bson.D
+	if err := bson.Unmarshal(raw, &result); err != nil {
+		return nil, xerrors.Errorf("unable to unmarshal document: %w", err)
+	}
+	return result, nil
+}
+
+// withID puts '_id' first, as mongo does, the documents of the mongo source are stored without it
+func withID(id interface{}, document bson.D) bson.D {
+	result := make(bson.D, 0, len(document)+1)
+	result = append(result, bson.E{Key: mongocommon.ID, Value: id})
+	for _, el := range document {
+		if el.Key != mongocommon.ID {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+func marshalExtJSON(document bson.D) (string, error) {
+	result, err := bson.MarshalExtJSON(document, false, false)
+	if err != nil {
+		return "", xerrors.Errorf("unable to marshal extended JSON: %w", err)
+	}
+	return string(result), nil
+}
+
+// marshalIDExtJSON marshals a standalone '_id' value the way Debezium puts it into the key
+func marshalIDExtJSON(id interface{}) (string, error) {
+	document, err := bson.MarshalExtJSON(bson.D{{Key: keyField, Value: id}}, false, false)
+	if err != nil {
+		return "", xerrors.Errorf("unable to marshal document id: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(document, &fields); err != nil {
+		return "", xerrors.Errorf("unable to extract document id: %w", err)
+	}
+	return string(fields[keyField]), nil
+}
+
+func (e *Emitter) KeySchema(changeItem *abstract.ChangeItem) ([]byte, error) {
+	resultMap := map[string]interface{}{
+		"type": "struct",
+		"fields": []map[string]interface{}{
+			{
+				"type":     "string",
+				"optional": false,
+				"field":    keyField,
+			},
+		},
+		"optional": false,
+		"name":     fmt.Sprintf("%s.%s.%s.Key", e.databaseServerName, changeItem.Schema, changeItem.Table),
+	}
+	result, err := util.JSONMarshalUnescape(resultMap)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal kafka_schema key, err: %w", err)
+	}
+	return result, nil
+}
+
+func (e *Emitter) KeyPayload(changeItem *abstract.ChangeItem) ([]byte, error) {
+	id, err := documentID(changeItem)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get document id: %w", err)
+	}
+	idStr, err := marshalIDExtJSON(id)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to make key payload: %w", err)
+	}
+	return util.JSONMarshalUnescape(map[string]interface{}{keyField: idStr})
+}
+
+func (e *Emitter) ValueSchema(changeItem *abstract.ChangeItem) ([]byte, error) {
+	resultMap := map[string]interface{}{
+		"type": "struct",
+		"fields": []interface{}{
+			jsonStringSchema("before"),
+			jsonStringSchema("after"),
+			updateDescriptionSchema,
+			sourceSchema,
+			opSchema,
+			tsMsSchema,
+			transactionSchema,
+		},
+		"optional": false,
+		"name":     fmt.Sprintf("%s.%s.%s.Envelope", e.databaseServerName, changeItem.Schema, changeItem.Table),
+	}
+	result, err := util.JSONMarshalUnescape(resultMap)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal kafka_schema val, err: %w", err)
+	}
+	return result, nil
+}
+
+func (e *Emitter) ValuePayload(changeItem *abstract.ChangeItem, payloadTSMS time.Time, snapshot bool) ([]byte, error) {
+	payloadObj, err := e.valPayload(changeItem, payloadTSMS, snapshot)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to make val payload: %w", err)
+	}
+	return util.JSONMarshalUnescape(payloadObj)
+}
+
+// valPayload builds the envelope, 'before' carries at least '_id' whenever 'after' is absent,
+// so the event can be received back without the kafka key
+func (e *Emitter) valPayload(changeItem *abstract.ChangeItem, payloadTSMS time.Time, snapshot bool) (map[string]interface{}, error) {
+	op, err := kindToOp(changeItem.Kind, snapshot)
+	if err != nil {
+		return nil, xerrors.Errorf("unsupported kind: %w", err)
+	}
+	id, err := documentID(changeItem)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get document id: %w", err)
+	}
+
+	var before, after, updateDescription interface{}
+	switch changeItem.Kind {
+	case abstract.InsertKind, abstract.UpdateKind:
+		value, _ := columnValue(changeItem, mongocommon.Document)
+		document, err := toDocument(value)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get document: %w", err)
+		}
+		after, err = marshalExtJSON(withID(id, document))
+		if err != nil {
+			return nil, xerrors.Errorf("unable to build 'after': %w", err)
+		}
+	case abstract.MongoUpdateDocumentKind:
+		updateDescription, err = buildUpdateDescription(changeItem)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to build 'updateDescription': %w", err)
+		}
+		value, _ := columnValue(changeItem, mongocommon.FullDocument)
+		fullDocument, err := toDocument(value)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get full document: %w", err)
+		}
+		if len(fullDocument) > 0 {
+			after, err = marshalExtJSON(withID(id, fullDocument))
+			if err != nil {
+				return nil, xerrors.Errorf("unable to build 'after': %w", err)
+			}
+		}
+	}
+	if after == nil {
+		before, err = marshalExtJSON(withID(id, nil))
+		if err != nil {
+			return nil, xerrors.Errorf("unable to build 'before': %w", err)
+		}
+	}
+
+	tsMs := int64(0)
+	if !payloadTSMS.IsZero() {
+		tsMs = payloadTSMS.UnixNano() / 1000000
+	}
+	return map[string]interface{}{
+		"before":            before,
+		"after":             after,
+		"updateDescription": updateDescription,
+		"source":            e.buildSource(changeItem, snapshot),
+		"op":                op,
+		"ts_ms":             tsMs,
+		"transaction":       nil,
+	}, nil
+}
+
+func buildUpdateDescription(changeItem *abstract.ChangeItem) (map[string]interface{}, error) {
+	updatedFields := bson.D{}
+	if value, ok := columnValue(changeItem, mongocommon.UpdatedFields); ok {
+		document, err := toDocument(value)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get updated fields: %w", err)
+		}
+		updatedFields = document
+	}
+	updatedFieldsStr, err := marshalExtJSON(updatedFields)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal updated fields: %w", err)
+	}
+
+	removedFields := make([]string, 0)
+	if value, ok := columnValue(changeItem, mongocommon.RemovedFields); ok && value != nil {
+		fields, ok := value.([]string)
+		if !ok {
+			return nil, xerrors.Errorf("unexpected type %T for %s", value, mongocommon.RemovedFields)
+		}
+		removedFields = append(removedFields, fields...)
+	}
+
+	truncatedArrays := make([]map[string]interface{}, 0)
+	if value, ok := columnValue(changeItem, mongocommon.TruncatedArrays); ok && value != nil {
+		arrays, ok := value.([]mongocommon.TruncatedArray)
+		if !ok {
+			return nil, xerrors.Errorf("unexpected type %T for %s", value, mongocommon.TruncatedArrays)
+		}
+		for _, array := range arrays {
+			truncatedArrays = append(truncatedArrays, map[string]interface{}{
+				"field": array.Field,
+				"size":  array.NewSize,
+			})
+		}
+	}
+
+	return map[string]interface{}{
+		"removedFields":   removedFields,
+		"updatedFields":   updatedFieldsStr,
+		"truncatedArrays": truncatedArrays,
+	}, nil
+}
+
+func (e *Emitter) buildSource(changeItem *abstract.ChangeItem, snapshot bool) map[string]interface{} {
+	var snapshotVal string
+	if snapshot {
+		snapshotVal = "true"
+	} else {
+		snapshotVal = "false"
+	}
+	return map[string]interface{}{
+		"version":    e.version,
+		"connector":  Connector,
+		"name":       e.databaseServerName,
+		"ts_ms":      changeItem.CommitTime / 1000000,
+		"snapshot":   snapshotVal,
+		"db":         changeItem.Schema,
+		"sequence":   nil,
+		"rs":         "",
+		"collection": changeItem.Table,
+		"ord":        changeItem.Counter,
+		"lsid":       nil,
+		"txnNumber":  nil,
+	}
+}
+
+func NewEmitter(databaseServerName, version string) *Emitter {
+	return &Emitter{
+		databaseServerName: databaseServerName,
+		version:            version,
+	}
+}
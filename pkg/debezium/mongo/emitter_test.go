@@ -0,0 +1,163 @@
+package mongo
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract"
+	mongocommon "github.com/transferia/transferia/pkg/providers/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testID = func() primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex("5f2b5b8e9d1e8a0001a1b2c3")
+	return id
+}()
+
+func changeItem(kind abstract.Kind) abstract.ChangeItem {
+	item := abstract.ChangeItem{
+		ID:           0,
+		LSN:          1600000000,
+		CommitTime:   1600000000 * uint64(time.Second),
+		Counter:      3,
+		Kind:         kind,
+		Schema:       "shop",
+		Table:        "orders",
+		PartID:       "",
+		ColumnNames:  nil,
+		ColumnValues: nil,
+		TableSchema:  mongocommon.DocumentSchema.Columns,
+		OldKeys:      abstract.OldKeysType{KeyNames: nil, KeyTypes: nil, KeyValues: nil},
+		TxID:         "",
+		Query:        "",
+		Size:         abstract.EmptyEventSize(),
+	}
+	document := mongocommon.MakeDValue(bson.D{{Key: "name", Value: "book"}, {Key: "qty", Value: int32(2)}}, true, false)
+	switch kind {
+	case abstract.InsertKind, abstract.UpdateKind:
+		item.ColumnNames = mongocommon.DocumentSchema.ColumnsNames
+		item.ColumnValues = []interface{}{testID, document}
+	case abstract.MongoUpdateDocumentKind:
+		item.TableSchema = mongocommon.UpdateDocumentSchema.Columns
+		item.ColumnNames = mongocommon.UpdateDocumentSchema.ColumnsNames
+		item.ColumnValues = []interface{}{
+			testID,
+			bson.D{{Key: "qty", Value: int32(3)}},
+			[]string{"note"},
+			[]mongocommon.TruncatedArray{{Field: "tags", NewSize: 1}},
+			bson.D{},
+		}
+	}
+	if kind != abstract.InsertKind {
+		item.OldKeys = abstract.OldKeysType{KeyNames: []string{mongocommon.ID}, KeyTypes: nil, KeyValues: []interface{}{testID}}
+	}
+	return item
+}
+
+func TestEmitKey(t *testing.T) {
+	emitter := NewEmitter("fullfillment", "1.1.2.Final")
+	item := changeItem(abstract.DeleteKind)
+
+	key, err := emitter.KeyPayload(&item)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"{\"$oid\":\"5f2b5b8e9d1e8a0001a1b2c3\"}"}`, string(key))
+
+	schema, err := emitter.KeySchema(&item)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"struct","fields":[{"type":"string","optional":false,"field":"id"}],"optional":false,"name":"fullfillment.shop.orders.Key"}`, string(schema))
+}
+
+func TestEmitInsert(t *testing.T) {
+	emitter := NewEmitter("fullfillment", "1.1.2.Final")
+	item := changeItem(abstract.InsertKind)
+
+	val, err := emitter.ValuePayload(&item, time.Unix(1600000001, 0), false)
+	require.NoError(t, err)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(val, &payload))
+	require.Equal(t, "c", payload["op"])
+	require.Nil(t, payload["before"])
+	require.Nil(t, payload["updateDescription"])
+	require.Equal(t, `{"_id":{"$oid":"5f2b5b8e9d1e8a0001a1b2c3"},"name":"book","qty":2}`, payload["after"])
+	require.Equal(t, map[string]interface{}{
+		"version":    "1.1.2.Final",
+		"connector":  "mongodb",
+		"name":       "fullfillment",
+		"ts_ms":      float64(1600000000000),
+		"snapshot":   "false",
+		"db":         "shop",
+		"sequence":   nil,
+		"rs":         "",
+		"collection": "orders",
+		"ord":        float64(3),
+		"lsid":       nil,
+		"txnNumber":  nil,
+	}, payload["source"])
+	require.Equal(t, float64(1600000001000), payload["ts_ms"])
+
+	val, err = emitter.ValuePayload(&item, time.Time{}, true)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(val, &payload))
+	require.Equal(t, "r", payload["op"])
+}
+
+func TestEmitUpdateDocument(t *testing.T) {
+	emitter := NewEmitter("fullfillment", "1.1.2.Final")
+	item := changeItem(abstract.MongoUpdateDocumentKind)
+
+	val, err := emitter.ValuePayload(&item, time.Time{}, false)
+	require.NoError(t, err)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(val, &payload))
+	require.Equal(t, "u", payload["op"])
+	require.Nil(t, payload["after"])
+	require.Equal(t, `{"_id":{"$oid":"5f2b5b8e9d1e8a0001a1b2c3"}}`, payload["before"])
+	require.Equal(t, map[string]interface{}{
+		"removedFields":   []interface{}{"note"},
+		"updatedFields":   `{"qty":3}`,
+		"truncatedArrays": []interface{}{map[string]interface{}{"field": "tags", "size": float64(1)}},
+	}, payload["updateDescription"])
+}
+
+func TestRoundTrip(t *testing.T) {
+	emitter := NewEmitter("fullfillment", "1.1.2.Final")
+	receiver := NewReceiver()
+
+	for _, kind := range []abstract.Kind{abstract.InsertKind, abstract.UpdateKind, abstract.MongoUpdateDocumentKind, abstract.DeleteKind} {
+		t.Run(string(kind), func(t *testing.T) {
+			item := changeItem(kind)
+			val, err := emitter.ValuePayload(&item, time.Time{}, false)
+			require.NoError(t, err)
+			require.True(t, IsMongoPayload(val))
+
+			result, err := receiver.Receive(nil, val)
+			require.NoError(t, err)
+			require.Equal(t, item.Kind, result.Kind)
+			require.Equal(t, item.Schema, result.Schema)
+			require.Equal(t, item.Table, result.Table)
+			require.Equal(t, item.CommitTime, result.CommitTime)
+			require.Equal(t, item.LSN, result.LSN)
+			require.Equal(t, item.Counter, result.Counter)
+			require.Equal(t, item.ColumnNames, result.ColumnNames)
+			require.Equal(t, item.OldKeys, result.OldKeys)
+			require.Equal(t, item.TableSchema, result.TableSchema)
+			require.Equal(t, item.ColumnValues, result.ColumnValues)
+		})
+	}
+}
+
+func TestReceiveIDFromKey(t *testing.T) {
+	receiver := NewReceiver()
+	value := `{"before":null,"after":null,"updateDescription":null,"source":{"connector":"mongodb","db":"shop","collection":"orders","ts_ms":1600000000000,"ord":1},"op":"d","ts_ms":0}`
+
+	_, err := receiver.Receive(nil, []byte(value))
+	require.Error(t, err)
+
+	result, err := receiver.Receive([]byte(`{"id":"{\"$oid\":\"5f2b5b8e9d1e8a0001a1b2c3\"}"}`), []byte(value))
+	require.NoError(t, err)
+	require.Equal(t, abstract.DeleteKind, result.Kind)
+	require.Equal(t, []interface{}{testID}, result.OldKeys.KeyValues)
+}
@@ -0,0 +1,215 @@
+package mongo
+
+import (
+	"encoding/json"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	mongocommon "github.com/transferia/transferia/pkg/providers/mongo"
+	"github.com/transferia/transferia/pkg/util"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type source struct {
+	Connector  string `json:"connector"`
+	DB         string `json:"db"`
+	Collection string `json:"collection"`
+	TSMs       uint64 `json:"ts_ms"`
+	Ord        int    `json:"ord"`
+}
+
+type truncatedArray struct {
+	Field string `json:"field"`
+	Size  int    `json:"size"`
+}
+
+type updateDescription struct {
+	RemovedFields   []string         `json:"removedFields"`
+	UpdatedFields   *string          `json:"updatedFields"`
+	TruncatedArrays []truncatedArray `json:"truncatedArrays"`
+}
+
+type payload struct {
+	Before            *string            `json:"before"`
+	After             *string            `json:"after"`
+	UpdateDescription *updateDescription `json:"updateDescription"`
+	Op                string             `json:"op"`
+	Source            source             `json:"source"`
+}
+
+// IsMongoPayload reports whether the unpacked debezium payload is produced by the MongoDB connector
+func IsMongoPayload(in []byte) bool {
+	var probe struct {
+		Source struct {
+			Connector string `json:"connector"`
+		} `json:"source"`
+	}
+	if err := json.Unmarshal(in, &probe); err != nil {
+		return false
+	}
+	return probe.Source.Connector == Connector
+}
+
+// Receiver turns the Debezium MongoDB connector envelopes back into the ChangeItems of the mongo source
+//
+// Documents are restored in the homogeneous form, so '_id' & values keep their bson types
+type Receiver struct{}
+
+// Receive parses the unpacked value payload, the key payload is optional
+// and is used only when neither 'after' nor 'before' carries '_id'
+func (r *Receiver) Receive(keyPayload, valuePayload []byte) (*abstract.ChangeItem, error) {
+	var in payload
+	if err := json.Unmarshal(valuePayload, &in); err != nil {
+		return nil, xerrors.Errorf("unable to unmarshal json payload: %s, err: %w", string(valuePayload), err)
+	}
+
+	after, err := unmarshalDocument(in.After)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to parse 'after': %w", err)
+	}
+	before, err := unmarshalDocument(in.Before)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to parse 'before': %w", err)
+	}
+	id, err := receiveID(keyPayload, after, before)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get document id: %w", err)
+	}
+
+	result := &abstract.ChangeItem{
+		ID:           0,
+		LSN:          in.Source.TSMs / 1000,
+		CommitTime:   in.Source.TSMs * 1000000,
+		Counter:      in.Source.Ord,
+		Kind:         "",
+		Schema:       in.Source.DB,
+		Table:        in.Source.Collection,
+		PartID:       "",
+		ColumnNames:  nil,
+		ColumnValues: nil,
+		TableSchema:  mongocommon.DocumentSchema.Columns,
+		OldKeys: abstract.OldKeysType{
+			KeyNames:  nil,
+			KeyTypes:  nil,
+			KeyValues: nil,
+		},
+		TxID:  "",
+		Query: "",
+		Size:  abstract.RawEventSize(util.DeepSizeof(valuePayload)),
+	}
+	switch in.Op {
+	case "c", "r":
+		if after == nil {
+			return nil, xerrors.Errorf("'after' is absent for op %s", in.Op)
+		}
+		result.Kind = abstract.InsertKind
+		result.ColumnNames = mongocommon.DocumentSchema.ColumnsNames
+		result.ColumnValues = []interface{}{id, mongocommon.MakeDValue(withoutID(after), true, false)}
+	case "u":
+		result.OldKeys = oldKeys(id)
+		if in.UpdateDescription != nil {
+			values, err := receiveUpdateDescription(id, in.UpdateDescription, after)
+			if err != nil {
+				return nil, xerrors.Errorf("unable to parse 'updateDescription': %w", err)
+			}
+			result.Kind = abstract.MongoUpdateDocumentKind
+			result.TableSchema = mongocommon.UpdateDocumentSchema.Columns
+			result.ColumnNames = mongocommon.UpdateDocumentSchema.ColumnsNames
+			result.ColumnValues = values
+			break
+		}
+		if after == nil {
+			return nil, xerrors.New("both 'after' & 'updateDescription' are absent for update")
+		}
+		result.Kind = abstract.UpdateKind
+		result.ColumnNames = mongocommon.DocumentSchema.ColumnsNames
+		result.ColumnValues = []interface{}{id, mongocommon.MakeDValue(withoutID(after), true, false)}
+	case "d":
+		result.Kind = abstract.DeleteKind
+		result.OldKeys = oldKeys(id)
+	default:
+		return nil, xerrors.Errorf("unknown op: %s", in.Op)
+	}
+	return result, nil
+}
+
+func oldKeys(id interface{}) abstract.OldKeysType {
+	return abstract.OldKeysType{
+		KeyNames:  []string{mongocommon.ID},
+		KeyTypes:  nil,
+		KeyValues: []interface{}{id},
+	}
+}
+
+func receiveUpdateDescription(id interface{}, in *updateDescription, after bson.D) ([]interface{}, error) {
+	updatedFields, err := unmarshalDocument(in.UpdatedFields)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to parse 'updatedFields': %w", err)
+	}
+	if updatedFields == nil {
+		updatedFields = bson.D{}
+	}
+	removedFields := in.RemovedFields
+	if removedFields == nil {
+		removedFields = []string{}
+	}
+	truncatedArrays := make([]mongocommon.TruncatedArray, 0, len(in.TruncatedArrays))
+	for _, array := range in.TruncatedArrays {
+		truncatedArrays = append(truncatedArrays, mongocommon.TruncatedArray{
+			Field:   array.Field,
+			NewSize: array.Size,
+		})
+	}
+	return []interface{}{id, updatedFields, removedFields, truncatedArrays, withoutID(after)}, nil
+}
+
+func receiveID(keyPayload []byte, documents ...bson.D) (interface{}, error) {
+	for _, document := range documents {
+		for _, el := range document {
+			if el.Key == mongocommon.ID {
+				return el.Value, nil
+			}
+		}
+	}
+	if len(keyPayload) == 0 {
+		return nil, xerrors.Errorf("neither the document nor the key contain %s", mongocommon.ID)
+	}
+	var key map[string]string
+	if err := json.Unmarshal(keyPayload, &key); err != nil {
+		return nil, xerrors.Errorf("unable to unmarshal key payload: %w", err)
+	}
+	idStr, ok := key[keyField]
+	if !ok {
+		return nil, xerrors.Errorf("key payload has no field %s", keyField)
+	}
+	var wrapped bson.D
+	if err := bson.UnmarshalExtJSON([]byte(`{"`+keyField+`":`+idStr+`}`), false, &wrapped); err != nil {
+		return nil, xerrors.Errorf("unable to parse document id: %w", err)
+	}
+	return wrapped[0].Value, nil
+}
+
+func unmarshalDocument(in *string) (bson.D, error) {
+	if in == nil {
+		return nil, nil
+	}
+	var result bson.D
+	if err := bson.UnmarshalExtJSON([]byte(*in), false, &result); err != nil {
+		return nil, xerrors.Errorf("unable to unmarshal extended JSON: %w", err)
+	}
+	return result, nil
+}
+
+func withoutID(document bson.D) bson.D {
+	result := bson.D{}
+	for _, el := range document {
+		if el.Key != mongocommon.ID {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+func NewReceiver() *Receiver {
+	return &Receiver{}
+}
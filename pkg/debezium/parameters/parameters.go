@@ -20,7 +20,7 @@ const (
 
 	UnknownTypesPolicy        = "dt.unknown.types.policy" // by default, debezium skips user-defined types. We are failing by default in this case, but can just skip
 	AddOriginalTypes          = "dt.add.original.type.info"
-	SourceType                = "dt.source.type" // common/mysql/pg - to emit database-specific fields in 'source'
+	SourceType                = "dt.source.type" // common/mysql/pg/ydb/mongo - to emit database-specific fields in 'source'
 	MysqlTimeZone             = "dt.mysql.timezone"
 	BatchingMaxSize           = "dt.batching.max.size"
 	WriteIntoOneFullTopicName = "dt.write.into.one.topic"
@@ -68,6 +68,7 @@ const (
 	SourceTypePg    = "pg"
 	SourceTypeMysql = "mysql"
 	SourceTypeYDB   = "ydb"
+	SourceTypeMongo = "mongo"
 
 	MysqlTimeZoneUTC = "UTC"
 
@@ -130,7 +131,7 @@ var connectorSettings = []connectorSetting{
 	{TopicPrefix, []string{}, ""},
 	{UnknownTypesPolicy, []string{UnknownTypesPolicyFail, UnknownTypesPolicySkip, UnknownTypesPolicyToString}, UnknownTypesPolicyFail},
 	{AddOriginalTypes, []string{BoolFalse, BoolTrue}, BoolFalse},
-	{SourceType, []string{"", SourceTypePg, SourceTypeMysql, SourceTypeYDB}, ""},
+	{SourceType, []string{"", SourceTypePg, SourceTypeMysql, SourceTypeYDB, SourceTypeMongo}, ""},
 	{MysqlTimeZone, []string{}, MysqlTimeZoneUTC},
 	{BatchingMaxSize, []string{}, "0"},
 	{WriteIntoOneFullTopicName, []string{BoolFalse, BoolTrue}, BoolFalse},
@@ -2,6 +2,7 @@ package prodstatus
 
 import (
 	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/providers/mongo"
 	"github.com/transferia/transferia/pkg/providers/mysql"
 	"github.com/transferia/transferia/pkg/providers/postgres"
 	"github.com/transferia/transferia/pkg/providers/ydb"
@@ -11,6 +12,7 @@ var supportedSources = map[string]bool{
 	postgres.ProviderType.Name(): true,
 	mysql.ProviderType.Name():    true,
 	ydb.ProviderType.Name():      true,
+	mongo.ProviderType.Name():    true,
 }
 
 func IsSupportedSource(src string, _ abstract.TransferType) bool {
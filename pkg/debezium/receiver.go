@@ -7,6 +7,7 @@ import (
 	"github.com/transferia/transferia/library/go/core/xerrors"
 	"github.com/transferia/transferia/pkg/abstract"
 	debeziumcommon "github.com/transferia/transferia/pkg/debezium/common"
+	"github.com/transferia/transferia/pkg/debezium/mongo"
 	debeziumparameters "github.com/transferia/transferia/pkg/debezium/parameters"
 	"github.com/transferia/transferia/pkg/debezium/unpacker"
 	"github.com/transferia/transferia/pkg/schemaregistry/confluent"
@@ -20,6 +21,7 @@ type Receiver struct {
 	schemaFormat          string
 	tableSchemaCache      map[string]tableSchemaCacheItem
 	tableSchemaCacheMutex *sync.RWMutex
+	mongoReceiver         *mongo.Receiver
 }
 
 type tableSchemaCacheItem struct {
@@ -148,6 +150,14 @@ func (r *Receiver) Receive(in string) (*abstract.ChangeItem, error) {
 }
 
 func (r *Receiver) receive(schema, payload []byte) (*abstract.ChangeItem, error) {
+	if mongo.IsMongoPayload(payload) {
+		// mongo envelope carries documents as extended JSON strings, so it doesn't need the schema
+		changeItem, err := r.mongoReceiver.Receive(nil, payload)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to receive mongo event: %w", err)
+		}
+		return changeItem, nil
+	}
 	payloadStruct, err := debeziumcommon.UnmarshalPayload(payload)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to unmarshal json payload: %s, err: %w", string(payload), err)
@@ -229,5 +239,6 @@ func NewReceiver(originalTypes map[abstract.TableID]map[string]*debeziumcommon.O
 		schemaFormat:          schemaFormat,
 		tableSchemaCache:      make(map[string]tableSchemaCacheItem),
 		tableSchemaCacheMutex: new(sync.RWMutex),
+		mongoReceiver:         mongo.NewReceiver(),
 	}
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/transferia/transferia/pkg/abstract"
 	debeziumcommon "github.com/transferia/transferia/pkg/debezium/common"
 	debeziumparameters "github.com/transferia/transferia/pkg/debezium/parameters"
+	"github.com/transferia/transferia/pkg/providers/mongo"
 	ytschema "go.ytsaurus.tech/yt/go/schema"
 )
 
@@ -122,3 +123,29 @@ func TestUpdateTOAST(t *testing.T) {
 	})
 	require.Equal(t, 1, len(recoveredChangeItems))
 }
+
+func TestMongoDelete(t *testing.T) {
+	changeItem := abstract.ChangeItem{
+		Kind:        abstract.DeleteKind,
+		Schema:      "my_db",
+		Table:       "my_collection",
+		TableSchema: mongo.DocumentSchema.Columns,
+		OldKeys: abstract.OldKeysType{
+			KeyNames:  []string{"_id"},
+			KeyTypes:  nil,
+			KeyValues: []interface{}{"my_id"},
+		},
+	}
+	recoveredChangeItems, kvArr := makeChainConversion(t, []abstract.ChangeItem{changeItem}, map[string]string{
+		debeziumparameters.TopicPrefix: "my_topic",
+		debeziumparameters.SourceType:  "mongo",
+	})
+	require.Equal(t, 2, len(kvArr))
+	require.Equal(t, `{"payload":{"id":"\"my_id\""},"schema":{"fields":[{"field":"id","optional":false,"type":"string"}],"name":"my_topic.my_db.my_collection.Key","optional":false,"type":"struct"}}`, kvArr[0].DebeziumKey)
+	require.Nil(t, kvArr[1].DebeziumVal)
+
+	require.Equal(t, 1, len(recoveredChangeItems))
+	require.Equal(t, abstract.DeleteKind, recoveredChangeItems[0].Kind)
+	require.Equal(t, changeItem.OldKeys, recoveredChangeItems[0].OldKeys)
+	require.Equal(t, changeItem.TableID(), recoveredChangeItems[0].TableID())
+}
@@ -0,0 +1,47 @@
+package async
+
+import (
+	"time"
+
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/record"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+// Recorder writes every batch into recording before pushing it further.
+// Failed recording does not fail the push, since recording is a debug facility.
+func Recorder(writer *record.Writer, logger log.Logger) func(abstract.AsyncSink) abstract.AsyncSink {
+	return func(s abstract.AsyncSink) abstract.AsyncSink {
+		return newRecorder(s, writer, logger)
+	}
+}
+
+type recorder struct {
+	sink   abstract.AsyncSink
+	writer *record.Writer
+	logger log.Logger
+}
+
+func newRecorder(s abstract.AsyncSink, writer *record.Writer, logger log.Logger) *recorder {
+	return &recorder{
+		sink:   s,
+		writer: writer,
+		logger: logger,
+	}
+}
+
+func (r *recorder) Close() error {
+	err := r.sink.Close()
+	if closeErr := r.writer.Close(); closeErr != nil {
+		r.logger.Warn("unable to close recording", log.Error(closeErr))
+	}
+	return err
+}
+
+func (r *recorder) AsyncPush(items []abstract.ChangeItem) chan error {
+	// items are recorded before the push, since middlewares may change them in place
+	if err := r.writer.Write(time.Now(), items); err != nil {
+		r.logger.Warn("unable to record batch", log.Int("len", len(items)), log.Error(err))
+	}
+	return r.sink.AsyncPush(items)
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+// AdminHandler serves rate limits of transfer given by transfer_id query parameter,
+// which may be omitted if the only transfer runs in this process.
+// GET returns current limits, PUT replaces them with limits from request body.
+func AdminHandler(cp coordinator.Coordinator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transferID := r.URL.Query().Get("transfer_id")
+		if transferID == "" {
+			var ok bool
+			if transferID, ok = runningTransfer(); !ok {
+				http.Error(w, "transfer_id is required unless exactly one transfer runs", http.StatusBadRequest)
+				return
+			}
+		}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			limits := new(Limits)
+			if err := json.Unmarshal(data, limits); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := limits.Validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := SetLimits(cp, transferID, limits); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		limits, err := GetLimits(cp, transferID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		res, err := json.Marshal(limits)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(res); err != nil {
+			logger.Log.Error("unable to write", log.Error(err))
+		}
+	})
+}
+
+func runningTransfer() (string, bool) {
+	controllersMu.Lock()
+	defer controllersMu.Unlock()
+	if len(controllers) != 1 {
+		return "", false
+	}
+	for transferID := range controllers {
+		return transferID, true
+	}
+	return "", false
+}
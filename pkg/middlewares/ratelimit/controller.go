@@ -0,0 +1,227 @@
+package ratelimit
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+// RefreshInterval is how often rate limits are reloaded from coordinator
+var RefreshInterval = 10 * time.Second
+
+var errClosed = xerrors.NewSentinel("rate limited sink is closed")
+
+var (
+	controllersMu sync.Mutex
+	controllers   = map[string]*controller{}
+)
+
+// controller keeps rate limits of transfer, it is shared by all sinks of transfer in this process,
+// so limits are applied to the transfer as a whole rather than to each sink
+type controller struct {
+	transferID string
+	cp         coordinator.Coordinator
+	logger     log.Logger
+	refs       int
+	stopCh     chan struct{}
+
+	mu     sync.Mutex
+	limits *Limits
+	tables map[string]abstract.TableID
+	// changed is closed once limits are changed
+	changed chan struct{}
+	buckets map[string]*bucket
+}
+
+func acquire(cp coordinator.Coordinator, transferID string, logger log.Logger) *controller {
+	controllersMu.Lock()
+	defer controllersMu.Unlock()
+	if c, ok := controllers[transferID]; ok {
+		c.refs++
+		return c
+	}
+	c := &controller{
+		transferID: transferID,
+		cp:         cp,
+		logger:     logger,
+		refs:       1,
+		stopCh:     make(chan struct{}),
+		mu:         sync.Mutex{},
+		limits:     new(Limits),
+		tables:     map[string]abstract.TableID{},
+		changed:    make(chan struct{}),
+		buckets:    map[string]*bucket{},
+	}
+	c.refresh()
+	go c.run()
+	controllers[transferID] = c
+	return c
+}
+
+func (c *controller) release() {
+	controllersMu.Lock()
+	defer controllersMu.Unlock()
+	c.refs--
+	if c.refs > 0 {
+		return
+	}
+	delete(controllers, c.transferID)
+	close(c.stopCh)
+}
+
+// apply sets limits of transfer running in this process, if any
+func apply(transferID string, limits *Limits) {
+	controllersMu.Lock()
+	c, ok := controllers[transferID]
+	controllersMu.Unlock()
+	if ok {
+		c.setLimits(limits)
+	}
+}
+
+func (c *controller) run() {
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.refresh()
+		}
+	}
+}
+
+func (c *controller) refresh() {
+	limits, err := GetLimits(c.cp, c.transferID)
+	if err != nil {
+		c.logger.Warn("unable to load rate limits, keep current ones", log.Error(err))
+		return
+	}
+	if err := limits.Validate(); err != nil {
+		c.logger.Warn("invalid rate limits, keep current ones", log.Error(err))
+		return
+	}
+	c.setLimits(limits)
+}
+
+func (c *controller) setLimits(limits *Limits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if reflect.DeepEqual(c.limits, limits) {
+		return
+	}
+	c.logger.Info("rate limits are changed", log.Any("limits", limits))
+	c.limits = limits
+	c.tables = map[string]abstract.TableID{}
+	for table := range limits.Tables {
+		if tid, err := abstract.ParseTableID(table); err == nil {
+			c.tables[table] = *tid
+		}
+	}
+	c.buckets = map[string]*bucket{}
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// wait blocks until items may be pushed according to current limits
+func (c *controller) wait(items []abstract.ChangeItem, done <-chan struct{}) error {
+	pauseLogged := false
+	for {
+		c.mu.Lock()
+		changed := c.changed
+		paused := c.limits.Paused
+		var delay time.Duration
+		if !paused {
+			delay = c.reserve(items, time.Now())
+		}
+		c.mu.Unlock()
+
+		if paused && !pauseLogged {
+			c.logger.Info("transfer is paused, push is blocked until it is resumed", log.Int("len", len(items)))
+			pauseLogged = true
+		}
+		if !paused && delay <= 0 {
+			return nil
+		}
+		var timer <-chan time.Time
+		if !paused {
+			timer = time.After(delay)
+		}
+		select {
+		case <-timer:
+			return nil
+		case <-changed:
+			// limits are changed, reserve again with new ones
+		case <-done:
+			return errClosed
+		}
+	}
+}
+
+// reserve takes items from all matching limits and returns how long to wait before the push
+func (c *controller) reserve(items []abstract.ChangeItem, now time.Time) time.Duration {
+	if c.limits.IsZero() {
+		return 0
+	}
+	var rows, bytes float64
+	tableRows := map[string]float64{}
+	tableBytes := map[string]float64{}
+	for i := range items {
+		if !items[i].IsRowEvent() {
+			continue
+		}
+		size := items[i].Size.Values
+		if size == 0 {
+			size = items[i].Size.Read
+		}
+		rows++
+		bytes += float64(size)
+		for table, tid := range c.tables {
+			if tid.Includes(items[i].TableID()) {
+				tableRows[table]++
+				tableBytes[table] += float64(size)
+			}
+		}
+	}
+
+	delay := c.bucket("rows").reserve(now, rows, c.limits.RowsPerSecond)
+	delay = max(delay, c.bucket("bytes").reserve(now, bytes, c.limits.BytesPerSecond))
+	for table, tableLimits := range c.limits.Tables {
+		delay = max(delay, c.bucket("rows:"+table).reserve(now, tableRows[table], tableLimits.RowsPerSecond))
+		delay = max(delay, c.bucket("bytes:"+table).reserve(now, tableBytes[table], tableLimits.BytesPerSecond))
+	}
+	return delay
+}
+
+func (c *controller) bucket(key string) *bucket {
+	b, ok := c.buckets[key]
+	if !ok {
+		b = new(bucket)
+		c.buckets[key] = b
+	}
+	return b
+}
+
+// bucket lets amount pass immediately and makes next amount wait until the previous one fits into the rate,
+// so batches of any size are allowed
+type bucket struct {
+	next time.Time
+}
+
+func (b *bucket) reserve(now time.Time, amount, perSecond float64) time.Duration {
+	if perSecond <= 0 || amount <= 0 {
+		return 0
+	}
+	if b.next.Before(now) {
+		b.next = now
+	}
+	delay := b.next.Sub(now)
+	b.next = b.next.Add(time.Duration(amount / perSecond * float64(time.Second)))
+	return delay
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/util"
+)
+
+// StateKey is a key of transfer state where rate limits of transfer are stored
+const StateKey = "rate_limits"
+
+// Limits of rows and bytes pushed into destination per second, zero means no limit
+type Limits struct {
+	// Paused stops the push into destination, so source stops reading once its buffers are full
+	Paused         bool    `json:"paused,omitempty"`
+	RowsPerSecond  float64 `json:"rows_per_second,omitempty"`
+	BytesPerSecond float64 `json:"bytes_per_second,omitempty"`
+	// Tables are limits of particular tables, key is a table name like public.orders or public.*
+	Tables map[string]TableLimits `json:"tables,omitempty"`
+}
+
+type TableLimits struct {
+	RowsPerSecond  float64 `json:"rows_per_second,omitempty"`
+	BytesPerSecond float64 `json:"bytes_per_second,omitempty"`
+}
+
+func (l *Limits) Validate() error {
+	if l.RowsPerSecond < 0 || l.BytesPerSecond < 0 {
+		return xerrors.New("limits must not be negative")
+	}
+	for table, tableLimits := range l.Tables {
+		if _, err := abstract.ParseTableID(table); err != nil {
+			return xerrors.Errorf("unable to parse table %s: %w", table, err)
+		}
+		if tableLimits.RowsPerSecond < 0 || tableLimits.BytesPerSecond < 0 {
+			return xerrors.Errorf("limits of table %s must not be negative", table)
+		}
+	}
+	return nil
+}
+
+// IsZero reports whether limits do not restrict the push at all
+func (l *Limits) IsZero() bool {
+	return !l.Paused && l.RowsPerSecond == 0 && l.BytesPerSecond == 0 && len(l.Tables) == 0
+}
+
+// GetLimits returns rate limits of transfer stored in coordinator, zero limits if they are not set
+func GetLimits(cp coordinator.Coordinator, transferID string) (*Limits, error) {
+	value, err := getLimitsState(cp, transferID)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get transfer state: %w", err)
+	}
+	limits := new(Limits)
+	if value == nil || value.GetGeneric() == nil {
+		return limits, nil
+	}
+	if err := util.MapFromJSON(value.GetGeneric(), limits); err != nil {
+		return nil, xerrors.Errorf("unable to parse rate limits: %w", err)
+	}
+	return limits, nil
+}
+
+// getLimitsState reads only the key of rate limits if coordinator supports it, since limits are polled by every worker
+func getLimitsState(cp coordinator.Coordinator, transferID string) (*coordinator.TransferStateData, error) {
+	if getter, ok := cp.(coordinator.TransferStateKeyGetter); ok {
+		return getter.GetTransferStateKey(transferID, StateKey)
+	}
+	state, err := cp.GetTransferState(transferID)
+	if err != nil {
+		return nil, err
+	}
+	return state[StateKey], nil
+}
+
+// SetLimits stores rate limits of transfer in coordinator and applies them to transfer running in this process.
+// Workers of other processes pick limits up within RefreshInterval.
+func SetLimits(cp coordinator.Coordinator, transferID string, limits *Limits) error {
+	if err := limits.Validate(); err != nil {
+		return xerrors.Errorf("invalid limits: %w", err)
+	}
+	if err := cp.SetTransferState(transferID, map[string]*coordinator.TransferStateData{
+		StateKey: {
+			Generic:             limits,
+			IncrementalTables:   nil,
+			OraclePosition:      nil,
+			MysqlGtid:           nil,
+			MysqlBinlogPosition: nil,
+			YtStaticPart:        nil,
+		},
+	}); err != nil {
+		return xerrors.Errorf("unable to set transfer state: %w", err)
+	}
+	apply(transferID, limits)
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package ratelimit limits rows and bytes per second pushed into destination and pauses the push.
+//
+// Limits are stored in coordinator transfer state, so they can be changed at runtime without worker restart.
+package ratelimit
+
+import (
+	"sync"
+
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/util"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+// RateLimiter blocks AsyncPush while transfer is paused or its rate limits are exceeded.
+// Blocked push makes source stop reading, while its replication slot or consumer group is kept.
+func RateLimiter(cp coordinator.Coordinator, transferID string, lgr log.Logger) abstract.AsyncMiddleware {
+	return func(sink abstract.AsyncSink) abstract.AsyncSink {
+		return newRateLimiter(sink, acquire(cp, transferID, lgr))
+	}
+}
+
+type rateLimiter struct {
+	sink       abstract.AsyncSink
+	controller *controller
+	closeOnce  sync.Once
+	closeCh    chan struct{}
+}
+
+func newRateLimiter(sink abstract.AsyncSink, controller *controller) *rateLimiter {
+	return &rateLimiter{
+		sink:       sink,
+		controller: controller,
+		closeOnce:  sync.Once{},
+		closeCh:    make(chan struct{}),
+	}
+}
+
+func (r *rateLimiter) AsyncPush(items []abstract.ChangeItem) chan error {
+	if err := r.controller.wait(items, r.closeCh); err != nil {
+		return util.MakeChanWithError(err)
+	}
+	return r.sink.AsyncPush(items)
+}
+
+func (r *rateLimiter) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closeCh)
+		r.controller.release()
+	})
+	return r.sink.Close()
+}
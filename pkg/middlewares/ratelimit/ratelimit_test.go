@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/util"
+)
+
+type mockAsyncSink struct {
+	pushed chan []abstract.ChangeItem
+}
+
+func (s *mockAsyncSink) AsyncPush(items []abstract.ChangeItem) chan error {
+	s.pushed <- items
+	return util.MakeChanWithError(nil)
+}
+
+func (s *mockAsyncSink) Close() error {
+	return nil
+}
+
+func rows(table string, count int) []abstract.ChangeItem {
+	items := make([]abstract.ChangeItem, count)
+	for i := range items {
+		items[i] = abstract.ChangeItem{Kind: abstract.InsertKind, Schema: "public", Table: table}
+	}
+	return items
+}
+
+func TestPauseResume(t *testing.T) {
+	cp := coordinator.NewStatefulFakeClient()
+	require.NoError(t, SetLimits(cp, "test-pause", &Limits{Paused: true}))
+
+	sink := &mockAsyncSink{pushed: make(chan []abstract.ChangeItem, 1)}
+	limited := RateLimiter(cp, "test-pause", logger.Log)(sink)
+	defer limited.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- <-limited.AsyncPush(rows("orders", 1))
+	}()
+	select {
+	case <-sink.pushed:
+		require.Fail(t, "paused transfer must not push")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, SetLimits(cp, "test-pause", &Limits{Paused: false}))
+	require.Len(t, <-sink.pushed, 1)
+	require.NoError(t, <-errCh)
+}
+
+func TestCloseUnblocksPaused(t *testing.T) {
+	cp := coordinator.NewStatefulFakeClient()
+	require.NoError(t, SetLimits(cp, "test-close", &Limits{Paused: true}))
+	limited := RateLimiter(cp, "test-close", logger.Log)(&mockAsyncSink{pushed: make(chan []abstract.ChangeItem, 1)})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- <-limited.AsyncPush(rows("orders", 1))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, limited.Close())
+	require.ErrorIs(t, <-errCh, errClosed)
+}
+
+func TestRateLimits(t *testing.T) {
+	cp := coordinator.NewStatefulFakeClient()
+	require.NoError(t, SetLimits(cp, "test-rate", &Limits{
+		RowsPerSecond: 1000,
+		Tables:        map[string]TableLimits{"public.orders": {RowsPerSecond: 100}},
+	}))
+	c := acquire(cp, "test-rate", logger.Log)
+	defer c.release()
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// the first batch passes immediately, the next one waits until the previous fits into the rate
+	require.Zero(t, c.reserve(rows("orders", 10), now))
+	// table limit is stricter than the transfer one
+	require.Equal(t, 100*time.Millisecond, c.reserve(rows("orders", 10), now))
+	// other tables are limited by the transfer limit only
+	require.Equal(t, 20*time.Millisecond, c.reserve(rows("users", 100), now))
+	require.Equal(t, 70*time.Millisecond, c.reserve(rows("users", 100), now.Add(50*time.Millisecond)))
+	// non-row items are not limited
+	require.Zero(t, c.reserve([]abstract.ChangeItem{abstract.MakeSynchronizeEvent()}, now))
+}
+
+func TestAdminHandler(t *testing.T) {
+	cp := coordinator.NewStatefulFakeClient()
+	handler := AdminHandler(cp)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/rate_limits?transfer_id=test-admin", strings.NewReader(`{"paused": true, "rows_per_second": 10}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"paused": true, "rows_per_second": 10}`, rec.Body.String())
+
+	limits, err := GetLimits(cp, "test-admin")
+	require.NoError(t, err)
+	require.Equal(t, &Limits{Paused: true, RowsPerSecond: 10}, limits)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/rate_limits?transfer_id=test-admin", strings.NewReader(`{"rows_per_second": -1}`)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// keyOnlyCoordinator fails reads of the whole transfer state
+type keyOnlyCoordinator struct {
+	*coordinator.CoordinatorInMemory
+}
+
+func (c *keyOnlyCoordinator) GetTransferState(string) (map[string]*coordinator.TransferStateData, error) {
+	return nil, errors.New("whole transfer state is read")
+}
+
+func TestGetLimitsReadsOnlyItsKey(t *testing.T) {
+	cp := &keyOnlyCoordinator{CoordinatorInMemory: coordinator.NewStatefulFakeClient()}
+	limits, err := GetLimits(cp, "test-key")
+	require.NoError(t, err)
+	require.True(t, limits.IsZero())
+
+	require.NoError(t, SetLimits(cp, "test-key", &Limits{Paused: false, RowsPerSecond: 5, BytesPerSecond: 0, Tables: nil}))
+	limits, err = GetLimits(cp, "test-key")
+	require.NoError(t, err)
+	require.Equal(t, &Limits{RowsPerSecond: 5}, limits)
+}
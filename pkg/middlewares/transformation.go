@@ -19,11 +19,14 @@ func Transformation(transfer *model.Transfer, logger log.Logger, metrics metrics
 			}
 			transformChain = append(transformChain, tr)
 		}
-		transformChain = append(transformChain, transfer.Transformation.ExtraTransformers...)
+		// transformers of configs are made for this sink only, while extra transformers are shared
+		owned := transformChain
+		transformChain = append(transformChain[:len(transformChain):len(transformChain)], transfer.Transformation.ExtraTransformers...)
 		return transformer.Sinker(
 			nil,
 			abstract.TransformationRuntimeOpts{JobIndex: transfer.CurrentJobIndex()},
 			transformChain,
+			owned,
 			logger,
 			metrics,
 		), nil
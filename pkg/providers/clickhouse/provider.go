@@ -5,6 +5,7 @@ import (
 	"encoding/gob"
 	"time"
 
+	"github.com/blang/semver/v4"
 	"github.com/transferia/transferia/library/go/core/metrics"
 	"github.com/transferia/transferia/library/go/core/xerrors"
 	"github.com/transferia/transferia/pkg/abstract"
@@ -17,6 +18,7 @@ import (
 	ch_async_sink "github.com/transferia/transferia/pkg/providers/clickhouse/async"
 	"github.com/transferia/transferia/pkg/providers/clickhouse/httpclient"
 	"github.com/transferia/transferia/pkg/providers/clickhouse/model"
+	"github.com/transferia/transferia/pkg/providers/clickhouse/topology"
 	sink_registry "github.com/transferia/transferia/pkg/sink"
 	"github.com/transferia/transferia/pkg/targets"
 	"go.ytsaurus.tech/library/go/core/log"
@@ -47,6 +49,7 @@ var (
 	_ providers.Abstract2Sinker   = (*Provider)(nil)
 	_ providers.Tester            = (*Provider)(nil)
 	_ providers.Activator         = (*Provider)(nil)
+	_ providers.DDLRenderer       = (*Provider)(nil)
 )
 
 type Provider struct {
@@ -129,6 +132,36 @@ func (p *Provider) Activate(_ context.Context, _ *dp_model.TransferOperation, ta
 	return nil
 }
 
+// RenderDDL returns DDL of the table as sink creates it. Server version is unknown without connection,
+// so version specific columns are omitted, and ON CLUSTER clause is rendered if cluster name is specified.
+func (p *Provider) RenderDDL(table abstract.TableID, schema *abstract.TableSchema) ([]string, error) {
+	dst, ok := p.transfer.Dst.(*model.ChDestination)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected type: %T", p.transfer.Dst)
+	}
+	if dst.InferSchema {
+		return nil, nil
+	}
+	params := dst.ToSinkParams(p.transfer)
+	tableName := targetTableName(table, params.UseSchemaInTableName(), MakeAltNames(params.MakeChildShardParams(nil)))
+	sinkTable := &sinkTable{
+		server:          nil,
+		tableName:       tableName,
+		config:          params.MakeChildServerParams(""),
+		logger:          p.logger,
+		colTypes:        nil,
+		cols:            schema,
+		metrics:         nil,
+		avgRowSize:      0,
+		cluster:         &sinkCluster{topology: topology.NewTopology(params.ChClusterName(), params.ChClusterName() == "")},
+		timezoneFetched: false,
+		timezone:        nil,
+		version:         semver.Version{},
+	}
+	sch := NewSchema(schema.Columns(), sinkTable.config.SystemColumnsFirst(), tableName)
+	return []string{sinkTable.generateDDL(sch.abstractCols(), params.ChClusterName() != "")}, nil
+}
+
 func (p *Provider) Type() abstract.ProviderType {
 	return ProviderType
 }
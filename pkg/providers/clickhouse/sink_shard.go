@@ -167,15 +167,19 @@ type opStats struct {
 }
 
 func (s *sinkShard) tableName(row abstract.ChangeItem) string {
+	return targetTableName(row.TableID(), s.config.UseSchemaInTableName(), s.altNames)
+}
+
+func targetTableName(tableID abstract.TableID, useSchemaInTableName bool, altNames map[string]string) string {
 	var targetTable string
-	if s.config.UseSchemaInTableName() && row.Schema != "" {
-		targetTable = normalizeTableName(row.Schema + "_" + row.Table)
+	if useSchemaInTableName && tableID.Namespace != "" {
+		targetTable = normalizeTableName(tableID.Namespace + "_" + tableID.Name)
 	} else {
-		targetTable = normalizeTableName(row.Table)
+		targetTable = normalizeTableName(tableID.Name)
 	}
 
-	if s.altNames[targetTable] != "" {
-		targetTable = s.altNames[targetTable]
+	if altNames[targetTable] != "" {
+		targetTable = altNames[targetTable]
 	}
 	return targetTable
 }
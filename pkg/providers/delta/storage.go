@@ -183,7 +183,7 @@ func NewStorage(cfg *DeltaSource, lgr log.Logger, registry metrics.Registry) (*S
 	s3Source.ReadBatchSize = defaultReadBatchSize
 	s3Source.HideSystemCols = cfg.HideSystemCols
 
-	reader, err := s3_reader.NewParquet(s3Source, lgr, sess, stats.NewSourceStats(registry))
+	reader, err := s3_reader.NewParquet(s3Source, lgr, s3.New(sess), stats.NewSourceStats(registry))
 	if err != nil {
 		return nil, xerrors.Errorf("unable to initialize parquet reader: %w", err)
 	}
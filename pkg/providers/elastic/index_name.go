@@ -0,0 +1,228 @@
+package elastic
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+)
+
+const (
+	indexTemplateTable     = "table"
+	indexTemplateNamespace = "namespace"
+)
+
+// dateLayoutTokens maps date tokens used in index name templates (Java-like, as in Logstash/Beats) onto Go layouts.
+// Longer tokens must go first so that "yyyy" is not consumed as two "yy".
+var dateLayoutTokens = []struct {
+	token  string
+	layout string
+	regexp string
+}{
+	{token: "yyyy", layout: "2006", regexp: `\d{4}`},
+	{token: "yy", layout: "06", regexp: `\d{2}`},
+	{token: "MM", layout: "01", regexp: `\d{2}`},
+	{token: "dd", layout: "02", regexp: `\d{2}`},
+	{token: "HH", layout: "15", regexp: `\d{2}`},
+	{token: "mm", layout: "04", regexp: `\d{2}`},
+	{token: "ss", layout: "05", regexp: `\d{2}`},
+}
+
+type indexNamePart struct {
+	literal    string
+	variable   string
+	dateLayout string
+	dateRegexp string
+}
+
+// IndexNameTemplate renders index names like `logs-{table}-{yyyy.MM.dd}`.
+//
+// Supported placeholders are {table}, {namespace} and a date pattern built from yyyy, yy, MM, dd, HH, mm and ss tokens.
+// The date is taken from the configured column or, if it is not set, from the commit time of the change item.
+type IndexNameTemplate struct {
+	parts      []indexNamePart
+	dateColumn string
+}
+
+func NewIndexNameTemplate(template string, dateColumn string) (*IndexNameTemplate, error) {
+	var parts []indexNamePart
+	rest := template
+	for len(rest) > 0 {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			parts = append(parts, indexNamePart{literal: rest, variable: "", dateLayout: "", dateRegexp: ""})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, indexNamePart{literal: rest[:start], variable: "", dateLayout: "", dateRegexp: ""})
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, xerrors.Errorf("unclosed placeholder in index name template %q", template)
+		}
+		placeholder := rest[start+1 : start+end]
+		rest = rest[start+end+1:]
+		switch placeholder {
+		case "":
+			return nil, xerrors.Errorf("empty placeholder in index name template %q", template)
+		case indexTemplateTable, indexTemplateNamespace:
+			parts = append(parts, indexNamePart{literal: "", variable: placeholder, dateLayout: "", dateRegexp: ""})
+		default:
+			layout, layoutRegexp, err := dateLayoutFromPattern(placeholder)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid placeholder {%s} in index name template %q: %w", placeholder, template, err)
+			}
+			parts = append(parts, indexNamePart{literal: "", variable: "", dateLayout: layout, dateRegexp: layoutRegexp})
+		}
+	}
+	return &IndexNameTemplate{
+		parts:      parts,
+		dateColumn: dateColumn,
+	}, nil
+}
+
+// dateLayoutFromPattern returns Go layout of the date pattern and regexp matching dates formatted with it
+func dateLayoutFromPattern(pattern string) (string, string, error) {
+	var layout, layoutRegexp strings.Builder
+	hasToken := false
+	for len(pattern) > 0 {
+		matched := false
+		for _, t := range dateLayoutTokens {
+			if strings.HasPrefix(pattern, t.token) {
+				layout.WriteString(t.layout)
+				layoutRegexp.WriteString(t.regexp)
+				pattern = pattern[len(t.token):]
+				matched = true
+				hasToken = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if strings.ContainsRune("0123456789", rune(pattern[0])) || ('a' <= pattern[0] && pattern[0] <= 'z') || ('A' <= pattern[0] && pattern[0] <= 'Z') {
+			return "", "", xerrors.Errorf("unknown date token at %q", pattern)
+		}
+		layout.WriteByte(pattern[0])
+		layoutRegexp.WriteString(regexp.QuoteMeta(pattern[:1]))
+		pattern = pattern[1:]
+	}
+	if !hasToken {
+		return "", "", xerrors.New("no date tokens found")
+	}
+	return layout.String(), layoutRegexp.String(), nil
+}
+
+// HasDate reports whether the rendered index name depends on the change item time.
+func (t *IndexNameTemplate) HasDate() bool {
+	for _, part := range t.parts {
+		if part.dateLayout != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTable reports whether the rendered index name depends on the table, otherwise all tables share the same indexes.
+func (t *IndexNameTemplate) HasTable() bool {
+	for _, part := range t.parts {
+		if part.variable == indexTemplateTable {
+			return true
+		}
+	}
+	return false
+}
+
+// Render builds the index name for the given change item.
+func (t *IndexNameTemplate) Render(changeItem *abstract.ChangeItem) (string, error) {
+	var ts time.Time
+	if t.HasDate() {
+		var err error
+		ts, err = t.itemTime(changeItem)
+		if err != nil {
+			return "", xerrors.Errorf("unable to get date for index name: %w", err)
+		}
+	}
+	return validateIndexName(t.render(changeItem.TableID(), func(layout string) string {
+		return ts.UTC().Format(layout)
+	}))
+}
+
+// Pattern builds a wildcard pattern which matches all indexes of the given table.
+func (t *IndexNameTemplate) Pattern(tableID abstract.TableID) string {
+	return t.render(tableID, func(string) string {
+		return "*"
+	})
+}
+
+// Matches reports whether the index is rendered for the given table, unlike Pattern it does not match indexes
+// of other tables sharing the same prefix.
+func (t *IndexNameTemplate) Matches(tableID abstract.TableID, indexName string) bool {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, part := range t.parts {
+		switch {
+		case part.variable == indexTemplateTable:
+			pattern.WriteString(regexp.QuoteMeta(strings.ToLower(tableID.Name)))
+		case part.variable == indexTemplateNamespace:
+			pattern.WriteString(regexp.QuoteMeta(strings.ToLower(tableID.Namespace)))
+		case part.dateLayout != "":
+			pattern.WriteString(part.dateRegexp)
+		default:
+			pattern.WriteString(regexp.QuoteMeta(strings.ToLower(part.literal)))
+		}
+	}
+	pattern.WriteString("$")
+	matched, err := regexp.MatchString(pattern.String(), indexName)
+	return err == nil && matched
+}
+
+func (t *IndexNameTemplate) render(tableID abstract.TableID, formatDate func(layout string) string) string {
+	var out strings.Builder
+	for _, part := range t.parts {
+		switch {
+		case part.variable == indexTemplateTable:
+			out.WriteString(tableID.Name)
+		case part.variable == indexTemplateNamespace:
+			out.WriteString(tableID.Namespace)
+		case part.dateLayout != "":
+			out.WriteString(formatDate(part.dateLayout))
+		default:
+			out.WriteString(part.literal)
+		}
+	}
+	return strings.ToLower(out.String())
+}
+
+func (t *IndexNameTemplate) itemTime(changeItem *abstract.ChangeItem) (time.Time, error) {
+	if t.dateColumn == "" {
+		if changeItem.CommitTime == 0 {
+			return time.Now(), nil
+		}
+		return time.Unix(0, int64(changeItem.CommitTime)), nil
+	}
+	idx := changeItem.ColumnNameIndex(t.dateColumn)
+	if idx < 0 {
+		return time.Time{}, xerrors.Errorf("column %q not found in table %s", t.dateColumn, changeItem.TableID().String())
+	}
+	switch v := changeItem.ColumnValues[idx].(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, xerrors.Errorf("unable to parse column %q value %q as RFC3339 time: %w", t.dateColumn, v, err)
+		}
+		return parsed, nil
+	case nil:
+	default:
+		return time.Time{}, xerrors.Errorf("column %q has unsupported type %T for index date", t.dateColumn, v)
+	}
+	return time.Time{}, xerrors.Errorf("column %q is null, unable to choose a date-based index", t.dateColumn)
+}
@@ -0,0 +1,80 @@
+package elastic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+func TestIndexNameTemplate(t *testing.T) {
+	commitTime := time.Date(2024, 3, 7, 15, 4, 5, 0, time.UTC)
+	item := abstract.ChangeItem{
+		Kind:         abstract.InsertKind,
+		Schema:       "Public",
+		Table:        "Events",
+		CommitTime:   uint64(commitTime.UnixNano()),
+		ColumnNames:  []string{"id", "created_at"},
+		ColumnValues: []interface{}{1, time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC)},
+	}
+
+	t.Run("commit time", func(t *testing.T) {
+		template, err := NewIndexNameTemplate("logs-{namespace}-{table}-{yyyy.MM.dd}", "")
+		require.NoError(t, err)
+		require.True(t, template.HasDate())
+		name, err := template.Render(&item)
+		require.NoError(t, err)
+		require.Equal(t, "logs-public-events-2024.03.07", name)
+		require.Equal(t, "logs-public-events-*", template.Pattern(item.TableID()))
+		require.True(t, template.Matches(item.TableID(), "logs-public-events-2023.12.31"))
+		require.False(t, template.Matches(item.TableID(), "logs-public-events-archive-2023.12.31"))
+		require.False(t, template.Matches(item.TableID(), "logs-public-events-2023-12-31"))
+	})
+
+	t.Run("date column", func(t *testing.T) {
+		template, err := NewIndexNameTemplate("{table}_{yyyyMM}", "created_at")
+		require.NoError(t, err)
+		name, err := template.Render(&item)
+		require.NoError(t, err)
+		require.Equal(t, "events_202312", name)
+	})
+
+	t.Run("no date", func(t *testing.T) {
+		template, err := NewIndexNameTemplate("static-{table}", "")
+		require.NoError(t, err)
+		require.False(t, template.HasDate())
+		require.True(t, template.HasTable())
+		name, err := template.Render(&item)
+		require.NoError(t, err)
+		require.Equal(t, "static-events", name)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		template, err := NewIndexNameTemplate("{table}-{yyyy}", "updated_at")
+		require.NoError(t, err)
+		_, err = template.Render(&item)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid templates", func(t *testing.T) {
+		for _, tmpl := range []string{"logs-{table", "logs-{}", "logs-{unknown}", "logs-{yyyy-QQ}"} {
+			_, err := NewIndexNameTemplate(tmpl, "")
+			require.Error(t, err, tmpl)
+		}
+	})
+
+	t.Run("shared index", func(t *testing.T) {
+		template, err := NewIndexNameTemplate("logs-{yyyy}", "")
+		require.NoError(t, err)
+		require.False(t, template.HasTable())
+
+		dst := &ElasticSearchDestination{DataNodes: []ElasticSearchHostPort{{Host: "localhost", Port: 9200}}, IndexNameTemplate: "logs-{yyyy}"}
+		require.NoError(t, dst.Validate())
+		dst.Cleanup = model.Drop
+		require.Error(t, dst.Validate())
+		dst.IndexNameTemplate = "logs-{table}-{yyyy}"
+		require.NoError(t, dst.Validate())
+	})
+}
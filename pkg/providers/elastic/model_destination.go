@@ -11,6 +11,18 @@ type ElasticSearchHostPort struct {
 	Port int
 }
 
+// DocumentIDStrategy defines how the `_id` of the indexed documents is built.
+type DocumentIDStrategy string
+
+const (
+	// DocumentIDPrimaryKey builds the document ID from the primary key values, falling back to an auto-generated ID for keyless tables.
+	DocumentIDPrimaryKey = DocumentIDStrategy("primary_key")
+	// DocumentIDHash builds the document ID as a hash of the table name and row values.
+	DocumentIDHash = DocumentIDStrategy("hash")
+	// DocumentIDAuto lets the cluster generate document IDs.
+	DocumentIDAuto = DocumentIDStrategy("auto")
+)
+
 type ElasticSearchDestination struct {
 	ClusterID        string // Deprecated: new endpoints should be on premise only
 	DataNodes        []ElasticSearchHostPort
@@ -23,6 +35,13 @@ type ElasticSearchDestination struct {
 	Cleanup          model.CleanupType
 
 	SanitizeDocKeys bool
+
+	// IndexNameTemplate overrides the index naming, e.g. `logs-{table}-{yyyy.MM.dd}`. See IndexNameTemplate for the syntax.
+	IndexNameTemplate string
+	// IndexDateColumn is the column the date in IndexNameTemplate is taken from. Commit time is used if it is empty.
+	IndexDateColumn    string
+	DocumentIDStrategy DocumentIDStrategy
+	IngestPipeline     string
 }
 
 var _ model.Destination = (*ElasticSearchDestination)(nil)
@@ -51,10 +70,30 @@ func (d *ElasticSearchDestination) Validate() error {
 	if !d.SSLEnabled && len(d.TLSFile) > 0 {
 		return xerrors.Errorf("can't use CA certificate with disabled SSL")
 	}
+	if d.IndexNameTemplate != "" {
+		template, err := NewIndexNameTemplate(d.IndexNameTemplate, d.IndexDateColumn)
+		if err != nil {
+			return xerrors.Errorf("invalid index name template: %w", err)
+		}
+		if !template.HasTable() && d.Cleanup == model.Drop {
+			// indexes are shared by tables, so dropping indexes of one table drops data of others
+			return xerrors.Errorf("index name template without {table} placeholder can not be used with %s cleanup policy", model.Drop)
+		}
+	} else if d.IndexDateColumn != "" {
+		return xerrors.Errorf("index date column is set without index name template")
+	}
+	switch d.DocumentIDStrategy {
+	case "", DocumentIDPrimaryKey, DocumentIDHash, DocumentIDAuto:
+	default:
+		return xerrors.Errorf("unknown document ID strategy: %q", d.DocumentIDStrategy)
+	}
 	return nil
 }
 
 func (d *ElasticSearchDestination) WithDefaults() {
+	if d.DocumentIDStrategy == "" {
+		d.DocumentIDStrategy = DocumentIDPrimaryKey
+	}
 }
 
 func (d *ElasticSearchDestination) VPCSubnets() []string {
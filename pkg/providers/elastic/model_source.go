@@ -36,6 +36,11 @@ func (s *ElasticSearchSource) SourceToElasticSearchDestination() *ElasticSearchD
 		SecurityGroupIDs: s.SecurityGroupIDs,
 		Cleanup:          "",
 		SanitizeDocKeys:  false,
+
+		IndexNameTemplate:  "",
+		IndexDateColumn:    "",
+		DocumentIDStrategy: DocumentIDPrimaryKey,
+		IngestPipeline:     "",
 	}
 }
 
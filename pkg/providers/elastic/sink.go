@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -30,7 +31,9 @@ type Sink struct {
 	logger log.Logger
 	stats  *stats.SinkerStats
 
-	existsIndexes      *set.Set[abstract.TableID]
+	indexTemplate      *IndexNameTemplate
+	indexDumps         map[abstract.TableID]string
+	existsIndexes      *set.Set[string]
 	existsIndexesMutex sync.RWMutex
 }
 
@@ -43,12 +46,14 @@ func makeIndexNameFromTableID(id abstract.TableID) (string, error) {
 	} else {
 		out = id.Namespace + "." + id.Name
 	}
+	return validateIndexName(strings.ToLower(out))
+}
 
+func validateIndexName(out string) (string, error) {
 	if out == "" || out == "." || out == ".." {
 		return "", xerrors.Errorf("index name (%v) can't be empty, . or ..", out)
 	}
 
-	out = strings.ToLower(out)
 	const illegalSymbols = `\/*?"<>| ,#:`
 	if strings.ContainsAny(out, illegalSymbols) {
 		return "", xerrors.Errorf("index name (%v) can't contains symbols: %v", out, illegalSymbols)
@@ -87,16 +92,34 @@ func (s *Sink) applyIndexDump(item abstract.ChangeItem) error {
 	if item.Kind != abstract.ElasticsearchDumpIndexKind {
 		return nil
 	}
-	tableID := item.TableID()
+	dumpParams, ok := item.ColumnValues[0].(string)
+	if !ok {
+		return xerrors.Errorf("unable to extract the index dump data: %v, %T", item.ColumnValues[0], item.ColumnValues[0])
+	}
+
+	if s.indexTemplate != nil && s.indexTemplate.HasDate() {
+		// date-based indexes are not known in advance, so they are created on the first document
+		s.existsIndexesMutex.Lock()
+		defer s.existsIndexesMutex.Unlock()
+		s.indexDumps[item.TableID()] = dumpParams
+		return nil
+	}
+
+	indexName, err := s.indexName(&item)
+	if err != nil {
+		return xerrors.Errorf("can't make index name from %v: %w", item.TableID().String(), err)
+	}
+	return s.ensureIndex(indexName, dumpParams)
+}
+
+func (s *Sink) ensureIndex(indexName string, dumpParams string) error {
 	s.existsIndexesMutex.RLock()
-	if s.existsIndexes.Contains(tableID) {
+	if s.existsIndexes.Contains(indexName) {
 		s.existsIndexesMutex.RUnlock()
 		return nil
 	}
 	s.existsIndexesMutex.RUnlock()
 
-	indexName, _ := makeIndexNameFromTableID(tableID)
-
 	response, err := s.client.Indices.Exists([]string{indexName})
 	if err != nil {
 		return xerrors.Errorf("unable to check if index %q exists: %w", indexName, err)
@@ -104,20 +127,13 @@ func (s *Sink) applyIndexDump(item abstract.ChangeItem) error {
 	if response.StatusCode == http.StatusOK {
 		s.existsIndexesMutex.Lock()
 		defer s.existsIndexesMutex.Unlock()
-		s.existsIndexes.Add(tableID)
+		s.existsIndexes.Add(indexName)
 		return nil
 	}
 	if response.StatusCode != http.StatusNotFound {
 		return xerrors.Errorf("wrong status code when checking index %q: %s", indexName, response.String())
 	}
 
-	//
-	dumpParams, ok := item.ColumnValues[0].(string)
-
-	if !ok {
-		return xerrors.Errorf("unable to extract the index dump data: %v, %T", item.ColumnValues[0], item.ColumnValues[0])
-	}
-
 	res, err := s.client.Indices.Create(indexName,
 		s.client.Indices.Create.WithMasterTimeout(time.Second*30),
 		s.client.Indices.Create.WithBody(strings.NewReader(dumpParams)),
@@ -137,10 +153,56 @@ func (s *Sink) applyIndexDump(item abstract.ChangeItem) error {
 
 	s.existsIndexesMutex.Lock()
 	defer s.existsIndexesMutex.Unlock()
-	s.existsIndexes.Add(tableID)
+	s.existsIndexes.Add(indexName)
+	return nil
+}
+
+// ensureDatedIndexes creates date-based indexes of the tables with a known index dump
+func (s *Sink) ensureDatedIndexes(indexNames map[string]abstract.TableID) error {
+	for indexName, tableID := range indexNames {
+		s.existsIndexesMutex.RLock()
+		dumpParams, ok := s.indexDumps[tableID]
+		s.existsIndexesMutex.RUnlock()
+		if !ok {
+			continue
+		}
+		if err := s.ensureIndex(indexName, dumpParams); err != nil {
+			return xerrors.Errorf("unable to prepare index %q: %w", indexName, err)
+		}
+	}
 	return nil
 }
 
+func (s *Sink) indexName(changeItem *abstract.ChangeItem) (string, error) {
+	if s.indexTemplate != nil {
+		return s.indexTemplate.Render(changeItem)
+	}
+	return makeIndexNameFromTableID(changeItem.TableID())
+}
+
+func (s *Sink) documentID(changeItem abstract.ChangeItem) (string, error) {
+	switch s.cfg.DocumentIDStrategy {
+	case DocumentIDAuto:
+		return "", nil
+	case DocumentIDHash:
+		return makeHashIDFromChangeItem(changeItem)
+	default:
+		return makeIDFromChangeItem(changeItem), nil
+	}
+}
+
+// makeHashIDFromChangeItem hashes table and row values only, so the same row gets the same ID on retries and re-uploads
+func makeHashIDFromChangeItem(changeItem abstract.ChangeItem) (string, error) {
+	row, err := json.Marshal(changeItem.AsMap())
+	if err != nil {
+		return "", xerrors.Errorf("unable to encode row: %w", err)
+	}
+	h := sha1.New()
+	h.Write([]byte(changeItem.TableID().Fqtn()))
+	h.Write(row)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func makeIndexBodyFromChangeItem(changeItem abstract.ChangeItem) ([]byte, error) {
 	itemMap := changeItem.AsMap()
 	systemInfo := map[string]interface{}{
@@ -282,24 +344,87 @@ func (s *Sink) Push(input []abstract.ChangeItem) error {
 }
 
 func (s *Sink) dropIndex(tableID abstract.TableID) error {
-	indexName, err := makeIndexNameFromTableID(tableID)
-	if err != nil {
-		return xerrors.Errorf("can't make index name from %v: %w", tableID.String(), err)
+	var indexNames []string
+	if s.indexTemplate != nil && !s.indexTemplate.HasTable() {
+		return abstract.NewFatalError(xerrors.Errorf("indexes of index name template without {table} placeholder are shared by all tables, so they can not be dropped for table %v", tableID.String()))
+	}
+	if s.indexTemplate != nil {
+		// templated tables may be spread over many date-based indexes
+		var err error
+		indexNames, err = s.templatedIndexes(tableID)
+		if err != nil {
+			return xerrors.Errorf("unable to resolve indexes of %v: %w", tableID.String(), err)
+		}
+	} else {
+		indexName, err := makeIndexNameFromTableID(tableID)
+		if err != nil {
+			return xerrors.Errorf("can't make index name from %v: %w", tableID.String(), err)
+		}
+		indexNames = []string{indexName}
 	}
-	res, err := s.client.Indices.Delete([]string{indexName})
+	if len(indexNames) > 0 {
+		res, err := s.client.Indices.Delete(indexNames)
+		if err != nil {
+			return xerrors.Errorf("unable to delete indexes, indexes: %v, err: %w", indexNames, err)
+		}
+		if res.IsError() && res.StatusCode != http.StatusNotFound {
+			return xerrors.Errorf("error deleting indexes, indexes: %v, HTTP status: %s, err: %s", indexNames, res.Status(), res.String())
+		}
+	}
+	s.existsIndexesMutex.Lock()
+	defer s.existsIndexesMutex.Unlock()
+	s.existsIndexes = set.New[string]()
+	return nil
+}
+
+// templatedIndexes returns names of existing indexes of the table, since deletion by wildcard may drop indexes
+// of other tables sharing the same prefix and is forbidden by default since Elasticsearch 8
+func (s *Sink) templatedIndexes(tableID abstract.TableID) ([]string, error) {
+	body, err := getResponseBody(s.client.Indices.Get(
+		[]string{s.indexTemplate.Pattern(tableID)},
+		s.client.Indices.Get.WithAllowNoIndices(true),
+		s.client.Indices.Get.WithIgnoreUnavailable(true),
+	))
 	if err != nil {
-		return xerrors.Errorf("unable to delete index, index: %s, err: %w", indexName, err)
+		return nil, xerrors.Errorf("unable to list indexes: %w", err)
 	}
-	if res.IsError() && res.StatusCode != http.StatusNotFound {
-		return xerrors.Errorf("error deleting index, index: %s, HTTP status: %s, err: %s", indexName, res.Status(), res.String())
+	var indexes map[string]json.RawMessage
+	if err := json.Unmarshal(body, &indexes); err != nil {
+		return nil, xerrors.Errorf("unable to decode indexes: %w", err)
 	}
-	return nil
+	var res []string
+	for indexName := range indexes {
+		if s.indexTemplate.Matches(tableID, indexName) {
+			res = append(res, indexName)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
 }
 
 func (s *Sink) pushBatch(changeItems []abstract.ChangeItem) error {
 	if len(changeItems) == 0 {
 		return nil
 	}
+	indexNames := make([]string, len(changeItems))
+	datedIndexes := make(map[string]abstract.TableID)
+	for i := range changeItems {
+		if changeItems[i].Kind != abstract.InsertKind {
+			continue
+		}
+		indexName, err := s.indexName(&changeItems[i])
+		if err != nil {
+			return xerrors.Errorf("can't make index name from %v: %w", changeItems[i].TableID().String(), err)
+		}
+		indexNames[i] = indexName
+		if s.indexTemplate != nil && s.indexTemplate.HasDate() {
+			datedIndexes[indexName] = changeItems[i].TableID()
+		}
+	}
+	if err := s.ensureDatedIndexes(datedIndexes); err != nil {
+		return xerrors.Errorf("unable to prepare indexes: %w", err)
+	}
+
 	indexResult := make(chan error)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -308,25 +433,26 @@ func (s *Sink) pushBatch(changeItems []abstract.ChangeItem) error {
 		indexer, _ := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
 			Client:     s.client,
 			NumWorkers: 1,
+			Pipeline:   s.cfg.IngestPipeline,
 			OnError: func(ctx context.Context, err error) {
 				indexResult <- xerrors.Errorf("indexer error: %w", err)
 			},
 		})
 
-		for _, changeItem := range changeItems {
+		for i, changeItem := range changeItems {
 			if changeItem.Kind != abstract.InsertKind {
 				continue
 			}
 
-			indexName, err := makeIndexNameFromTableID(changeItem.TableID())
+			encodedBody, err := makeIndexBodyFromChangeItem(changeItem)
 			if err != nil {
-				indexResult <- xerrors.Errorf("can't make index name from %v: %w", changeItem.TableID().String(), err)
+				indexResult <- xerrors.Errorf("can't make index request body from change item: %w", err)
 				break
 			}
 
-			encodedBody, err := makeIndexBodyFromChangeItem(changeItem)
+			documentID, err := s.documentID(changeItem)
 			if err != nil {
-				indexResult <- xerrors.Errorf("can't make index request body from change item: %w", err)
+				indexResult <- xerrors.Errorf("can't make document ID from change item: %w", err)
 				break
 			}
 
@@ -339,9 +465,9 @@ func (s *Sink) pushBatch(changeItems []abstract.ChangeItem) error {
 			err = indexer.Add(
 				ctx,
 				esutil.BulkIndexerItem{
-					Index:      indexName,
+					Index:      indexNames[i],
 					Action:     "index",
-					DocumentID: makeIDFromChangeItem(changeItem),
+					DocumentID: documentID,
 					Body:       bytes.NewReader(encodedBody),
 					OnFailure: func(_ context.Context, bulkItem esutil.BulkIndexerItem, responseItem esutil.BulkIndexerResponseItem, err error) {
 						var bulkBody string
@@ -382,12 +508,22 @@ func (s *Sink) Close() error {
 }
 
 func NewSinkImpl(cfg *ElasticSearchDestination, logger log.Logger, registry metrics.Registry, client *elasticsearch.Client) (abstract.Sinker, error) {
+	var indexTemplate *IndexNameTemplate
+	if cfg.IndexNameTemplate != "" {
+		var err error
+		indexTemplate, err = NewIndexNameTemplate(cfg.IndexNameTemplate, cfg.IndexDateColumn)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid index name template: %w", err)
+		}
+	}
 	return &Sink{
 		cfg:                cfg,
 		client:             client,
 		logger:             logger,
 		stats:              stats.NewSinkerStats(registry),
-		existsIndexes:      set.New[abstract.TableID](),
+		indexTemplate:      indexTemplate,
+		indexDumps:         make(map[abstract.TableID]string),
+		existsIndexes:      set.New[string](),
 		existsIndexesMutex: sync.RWMutex{},
 	}, nil
 }
@@ -97,3 +97,24 @@ func TestSanitizeKeysInRawJSON(t *testing.T) {
 	}
 	canon.SaveJSON(t, canonArr)
 }
+
+func TestMakeHashIDFromChangeItem(t *testing.T) {
+	item := makeTestChangeItem(t, []string{"id", "value"}, []interface{}{1, "a"}, []bool{true, false})
+	item.Schema, item.Table = "public", "events"
+	item.ID = 1
+	id, err := makeHashIDFromChangeItem(item)
+	require.NoError(t, err)
+
+	retried := item
+	retried.ID = 2
+	retried.LSN = 100
+	retriedID, err := makeHashIDFromChangeItem(retried)
+	require.NoError(t, err)
+	require.Equal(t, id, retriedID)
+
+	other := item
+	other.Table = "other_events"
+	otherID, err := makeHashIDFromChangeItem(other)
+	require.NoError(t, err)
+	require.NotEqual(t, id, otherID)
+}
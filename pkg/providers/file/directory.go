@@ -0,0 +1,309 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+)
+
+const listLimit = 1000
+
+// directory reads and writes files of the directory for S3 readers and sink: object keys are paths relative to the directory,
+// bucket names are ignored. Files and directories starting with a dot are hidden, they hold incomplete writes.
+//
+// Only the calls made by S3 readers, sources and sink are served, other calls of the S3 API panic.
+type directory struct {
+	s3iface.S3API
+	root string
+}
+
+var (
+	_ s3iface.S3API              = (*directory)(nil)
+	_ s3manageriface.UploaderAPI = (*directory)(nil)
+)
+
+func newDirectory(root string) (*directory, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to resolve directory %s: %w", root, err)
+	}
+	return &directory{S3API: nil, root: root}, nil
+}
+
+// path resolves the key into the path of a file, keys must not point outside of the directory
+func (d *directory) path(key string) (string, error) {
+	path := filepath.Join(d.root, filepath.FromSlash(key))
+	rel, err := filepath.Rel(d.root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", xerrors.Errorf("key %s is outside of the directory", key)
+	}
+	return path, nil
+}
+
+func (d *directory) stat(key string) (string, os.FileInfo, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return "", nil, awserr.New("InvalidArgument", err.Error(), nil)
+	}
+	// os.Stat follows symlinks
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", nil, awserr.NewRequestFailure(awserr.New(aws_s3.ErrCodeNoSuchKey, fmt.Sprintf("file %s not found", key), err), http.StatusNotFound, "")
+	}
+	return path, info, nil
+}
+
+func (d *directory) ListObjects(input *aws_s3.ListObjectsInput) (*aws_s3.ListObjectsOutput, error) {
+	return d.ListObjectsWithContext(context.Background(), input)
+}
+
+func (d *directory) ListObjectsWithContext(ctx context.Context, input *aws_s3.ListObjectsInput, _ ...request.Option) (*aws_s3.ListObjectsOutput, error) {
+	prefix, marker := aws.StringValue(input.Prefix), aws.StringValue(input.Marker)
+	maxKeys := listLimit
+	if input.MaxKeys != nil && *input.MaxKeys > 0 {
+		maxKeys = min(int(*input.MaxKeys), listLimit)
+	}
+
+	var objects []*aws_s3.Object
+	err := filepath.WalkDir(d.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if path != d.root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(d.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) || key <= marker {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		objects = append(objects, &aws_s3.Object{
+			Key:          aws.String(key),
+			LastModified: aws.Time(info.ModTime().UTC()),
+			ETag:         aws.String(etag(info)),
+			Size:         aws.Int64(info.Size()),
+			StorageClass: aws.String(aws_s3.ObjectStorageClassStandard),
+		})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, xerrors.Errorf("unable to list directory %s: %w", d.root, err)
+	}
+	sort.Slice(objects, func(i, j int) bool { return *objects[i].Key < *objects[j].Key })
+
+	truncated := len(objects) > maxKeys
+	if truncated {
+		objects = objects[:maxKeys]
+	}
+	var nextMarker *string
+	if truncated {
+		nextMarker = objects[len(objects)-1].Key
+	}
+	return &aws_s3.ListObjectsOutput{
+		Name:        input.Bucket,
+		Prefix:      input.Prefix,
+		Marker:      input.Marker,
+		MaxKeys:     aws.Int64(int64(maxKeys)),
+		IsTruncated: aws.Bool(truncated),
+		NextMarker:  nextMarker,
+		Contents:    objects,
+	}, nil
+}
+
+func (d *directory) ListObjectsPagesWithContext(ctx context.Context, input *aws_s3.ListObjectsInput, fn func(*aws_s3.ListObjectsOutput, bool) bool, _ ...request.Option) error {
+	page := *input
+	for {
+		output, err := d.ListObjectsWithContext(ctx, &page)
+		if err != nil {
+			return err
+		}
+		last := !aws.BoolValue(output.IsTruncated)
+		if !fn(output, last) || last {
+			return nil
+		}
+		page.Marker = output.NextMarker
+	}
+}
+
+func (d *directory) HeadObjectWithContext(_ context.Context, input *aws_s3.HeadObjectInput, _ ...request.Option) (*aws_s3.HeadObjectOutput, error) {
+	_, info, err := d.stat(aws.StringValue(input.Key))
+	if err != nil {
+		return nil, err
+	}
+	return &aws_s3.HeadObjectOutput{
+		ContentLength: aws.Int64(info.Size()),
+		ETag:          aws.String(etag(info)),
+		LastModified:  aws.Time(info.ModTime().UTC()),
+	}, nil
+}
+
+func (d *directory) GetObjectAttributes(input *aws_s3.GetObjectAttributesInput) (*aws_s3.GetObjectAttributesOutput, error) {
+	_, info, err := d.stat(aws.StringValue(input.Key))
+	if err != nil {
+		return nil, err
+	}
+	return &aws_s3.GetObjectAttributesOutput{
+		ETag:         aws.String(etag(info)),
+		LastModified: aws.Time(info.ModTime().UTC()),
+		ObjectSize:   aws.Int64(info.Size()),
+	}, nil
+}
+
+func (d *directory) GetObjectWithContext(_ context.Context, input *aws_s3.GetObjectInput, _ ...request.Option) (*aws_s3.GetObjectOutput, error) {
+	path, info, err := d.stat(aws.StringValue(input.Key))
+	if err != nil {
+		return nil, err
+	}
+	start, end := int64(0), info.Size()-1
+	var contentRange *string
+	if input.Range != nil {
+		var ok bool
+		start, end, ok = parseRange(*input.Range, info.Size())
+		if !ok {
+			return nil, awserr.NewRequestFailure(awserr.New("InvalidRange", fmt.Sprintf("range %s is not satisfiable", *input.Range), nil), http.StatusRequestedRangeNotSatisfiable, "")
+		}
+		contentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, info.Size()))
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open file %s: %w", path, err)
+	}
+	length := end - start + 1
+	return &aws_s3.GetObjectOutput{
+		Body: struct {
+			io.Reader
+			io.Closer
+		}{
+			Reader: io.NewSectionReader(file, start, length),
+			Closer: file,
+		},
+		ContentLength: aws.Int64(length),
+		ContentRange:  contentRange,
+		ETag:          aws.String(etag(info)),
+		LastModified:  aws.Time(info.ModTime().UTC()),
+	}, nil
+}
+
+// parseRange supports single ranges of forms `bytes=a-b`, `bytes=a-` and `bytes=-n`
+func parseRange(value string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(value, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	if from == "" {
+		suffix, err := strconv.ParseInt(to, 10, 64)
+		if err != nil || suffix <= 0 || size == 0 {
+			return 0, 0, false
+		}
+		return max(size-suffix, 0), size - 1, true
+	}
+	start, err := strconv.ParseInt(from, 10, 64)
+	if err != nil || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if to != "" {
+		end, err = strconv.ParseInt(to, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false
+		}
+		end = min(end, size-1)
+	}
+	return start, end, true
+}
+
+func (d *directory) DeleteObject(input *aws_s3.DeleteObjectInput) (*aws_s3.DeleteObjectOutput, error) {
+	path, err := d.path(aws.StringValue(input.Key))
+	if err != nil {
+		return nil, awserr.New("InvalidArgument", err.Error(), nil)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, xerrors.Errorf("unable to remove file %s: %w", path, err)
+	}
+	return &aws_s3.DeleteObjectOutput{}, nil
+}
+
+func (d *directory) Upload(input *s3manager.UploadInput, _ ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+	return d.UploadWithContext(context.Background(), input)
+}
+
+// UploadWithContext writes the body into a hidden file next to the target one and renames it,
+// so listings never return partially written files
+func (d *directory) UploadWithContext(ctx context.Context, input *s3manager.UploadInput, _ ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+	key := aws.StringValue(input.Key)
+	path, err := d.path(key)
+	if err != nil {
+		return nil, awserr.New("InvalidArgument", err.Error(), nil)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, xerrors.Errorf("unable to create directory for %s: %w", path, err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, xerrors.Errorf("unable to create temporary file for %s: %w", path, err)
+	}
+	defer os.Remove(file.Name())
+	if input.Body != nil {
+		if _, err := io.Copy(file, input.Body); err != nil {
+			_ = file.Close()
+			return nil, xerrors.Errorf("unable to write %s: %w", path, err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return nil, xerrors.Errorf("unable to close %s: %w", path, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("upload of %s is canceled: %w", key, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return nil, xerrors.Errorf("unable to rename temporary file into %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to stat written file %s: %w", path, err)
+	}
+	return &s3manager.UploadOutput{
+		Location: path,
+		ETag:     aws.String(etag(info)),
+	}, nil
+}
+
+func etag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,136 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectory(t *testing.T) {
+	root := t.TempDir()
+	dir, err := newDirectory(root)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = dir.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String("2024/01/01/table.csv"),
+		Body:   bytes.NewReader([]byte("a,b\n1,2\n")),
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "readme.txt"), []byte("hello"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".hidden"), []byte("hidden"), 0o644))
+
+	t.Run("list", func(t *testing.T) {
+		res, err := dir.ListObjects(&aws_s3.ListObjectsInput{Bucket: aws.String(bucket)})
+		require.NoError(t, err)
+		require.Len(t, res.Contents, 2)
+		require.Equal(t, "2024/01/01/table.csv", *res.Contents[0].Key)
+		require.Equal(t, int64(8), *res.Contents[0].Size)
+		require.NotNil(t, res.Contents[0].LastModified)
+		require.Equal(t, "readme.txt", *res.Contents[1].Key)
+
+		res, err = dir.ListObjects(&aws_s3.ListObjectsInput{Bucket: aws.String(bucket), Prefix: aws.String("2024/"), MaxKeys: aws.Int64(1)})
+		require.NoError(t, err)
+		require.Len(t, res.Contents, 1)
+
+		var keys []string
+		require.NoError(t, dir.ListObjectsPagesWithContext(ctx, &aws_s3.ListObjectsInput{Bucket: aws.String(bucket), MaxKeys: aws.Int64(1)}, func(page *aws_s3.ListObjectsOutput, _ bool) bool {
+			for _, object := range page.Contents {
+				keys = append(keys, *object.Key)
+			}
+			return true
+		}))
+		require.Equal(t, []string{"2024/01/01/table.csv", "readme.txt"}, keys)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		head, err := dir.HeadObjectWithContext(ctx, &aws_s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String("readme.txt")})
+		require.NoError(t, err)
+		require.Equal(t, int64(5), *head.ContentLength)
+
+		obj, err := dir.GetObjectWithContext(ctx, &aws_s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String("readme.txt"), Range: aws.String("bytes=1-3")})
+		require.NoError(t, err)
+		data, err := io.ReadAll(obj.Body)
+		require.NoError(t, err)
+		require.NoError(t, obj.Body.Close())
+		require.Equal(t, "ell", string(data))
+		require.Equal(t, "bytes 1-3/5", *obj.ContentRange)
+
+		attrs, err := dir.GetObjectAttributes(&aws_s3.GetObjectAttributesInput{
+			Bucket:           aws.String(bucket),
+			Key:              aws.String("readme.txt"),
+			ObjectAttributes: aws.StringSlice([]string{aws_s3.ObjectAttributesObjectSize}),
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(5), *attrs.ObjectSize)
+
+		_, err = dir.HeadObjectWithContext(ctx, &aws_s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String("missing")})
+		require.Error(t, err)
+		_, err = dir.GetObjectWithContext(ctx, &aws_s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String("../outside")})
+		require.Error(t, err)
+	})
+
+	t.Run("download", func(t *testing.T) {
+		body := bytes.Repeat([]byte("0123456789"), 600_000)
+		_, err := dir.Upload(&s3manager.UploadInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String("big/data.raw"),
+			Body:   bytes.NewReader(body),
+		})
+		require.NoError(t, err)
+
+		buf := aws.NewWriteAtBuffer(nil)
+		downloader := s3manager.NewDownloaderWithClient(dir)
+		downloader.PartSize = s3manager.MinUploadPartSize
+		n, err := downloader.Download(buf, &aws_s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String("big/data.raw")})
+		require.NoError(t, err)
+		require.Equal(t, int64(len(body)), n)
+		require.Equal(t, body, buf.Bytes())
+
+		// temporary files are renamed into the target ones
+		entries, err := os.ReadDir(filepath.Join(root, "big"))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := dir.DeleteObject(&aws_s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String("readme.txt")})
+		require.NoError(t, err)
+		_, err = dir.DeleteObject(&aws_s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String("readme.txt")})
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(root, "readme.txt"))
+		require.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestDirectoryPath(t *testing.T) {
+	for _, root := range []string{"/", "/var/data"} {
+		dir := &directory{S3API: nil, root: root}
+		path, err := dir.path("2024/table.csv")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(root, "2024", "table.csv"), path)
+
+		_, err = dir.path("")
+		require.Error(t, err)
+	}
+
+	// everything is within the root directory
+	path, err := (&directory{S3API: nil, root: "/"}).path("a/../../outside")
+	require.NoError(t, err)
+	require.Equal(t, "/outside", path)
+
+	dir := &directory{S3API: nil, root: "/var/data"}
+	_, err = dir.path("a/../../outside")
+	require.Error(t, err)
+	_, err = dir.path("../data2/table.csv")
+	require.Error(t, err)
+}
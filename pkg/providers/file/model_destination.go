@@ -0,0 +1,88 @@
+package file
+
+import (
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/middlewares/async/bufferer"
+	"github.com/transferia/transferia/pkg/providers/s3"
+)
+
+// FileDestination writes files into a directory by the S3 sink, so it supports the same formats and layouts
+type FileDestination struct {
+	Directory      string
+	OutputFormat   model.ParsingFormat
+	OutputEncoding s3.Encoding
+	BufferSize     model.BytesSize
+	BufferInterval time.Duration
+	// Layout is the time format of subdirectories files are written to, LayoutColumn picks the time column of rows
+	// instead of commit times, e.g. `2006/01/02` partitions files by days
+	Layout       string
+	LayoutTZ     string
+	LayoutColumn string
+	AnyAsString  bool
+}
+
+var _ model.Destination = (*FileDestination)(nil)
+
+func (d *FileDestination) WithDefaults() {
+	dst := d.ToS3Destination()
+	dst.WithDefaults()
+	d.Layout = dst.Layout
+	d.BufferSize = dst.BufferSize
+	d.BufferInterval = dst.BufferInterval
+}
+
+func (d *FileDestination) BuffererConfig() bufferer.BuffererConfig {
+	return d.ToS3Destination().BuffererConfig()
+}
+
+func (d *FileDestination) CleanupMode() model.CleanupType {
+	return model.DisabledCleanup
+}
+
+func (FileDestination) IsDestination() {}
+
+func (d *FileDestination) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (d *FileDestination) Validate() error {
+	if d.Directory == "" {
+		return xerrors.New("directory is required")
+	}
+	return nil
+}
+
+func (d *FileDestination) Compatible(src model.Source, transferType abstract.TransferType) error {
+	return d.ToS3Destination().Compatible(src, transferType)
+}
+
+// ToS3Destination converts the destination into the S3 one, files are written by the S3 sink over the directory
+func (d *FileDestination) ToS3Destination() *s3.S3Destination {
+	dst := &s3.S3Destination{
+		OutputFormat:     d.OutputFormat,
+		OutputEncoding:   d.OutputEncoding,
+		BufferSize:       d.BufferSize,
+		BufferInterval:   d.BufferInterval,
+		Endpoint:         "",
+		Region:           "",
+		AccessKey:        "",
+		S3ForcePathStyle: true,
+		Secret:           "",
+		ServiceAccountID: "",
+		Layout:           d.Layout,
+		LayoutTZ:         d.LayoutTZ,
+		LayoutColumn:     d.LayoutColumn,
+		Bucket:           bucket,
+		UseSSL:           false,
+		VerifySSL:        false,
+		PartSize:         0,
+		Concurrency:      0,
+		AnyAsString:      d.AnyAsString,
+	}
+	dst.WithDefaults()
+	return dst
+}
@@ -0,0 +1,104 @@
+package file
+
+import (
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/providers/s3"
+)
+
+const ProviderType = abstract.ProviderType("file")
+
+// bucket is a placeholder, directories serve S3 calls regardless of bucket names
+const bucket = "local"
+
+// FileSource reads files of a directory by the S3 readers, so it supports the same formats and settings
+type FileSource struct {
+	Directory string
+	// PathPattern is the glob of file paths relative to the directory, several globs are separated by `|`
+	PathPattern string
+
+	// files hold always single table, and TableID of such table defined by user
+	TableName      string
+	TableNamespace string
+
+	InputFormat    model.ParsingFormat
+	OutputSchema   []abstract.ColSchema
+	Format         s3.Format
+	HideSystemCols bool
+	UnparsedPolicy s3.UnparsedPolicy
+
+	ReadBatchSize int
+	InflightLimit int64
+	Concurrency   int64
+}
+
+var _ model.Source = (*FileSource)(nil)
+
+func (s *FileSource) WithDefaults() {
+	src := s.ToS3Source()
+	src.WithDefaults()
+	s.Format = src.Format
+	s.ReadBatchSize = src.ReadBatchSize
+	s.InflightLimit = src.InflightLimit
+	s.Concurrency = src.Concurrency
+}
+
+func (FileSource) IsSource() {}
+
+func (s *FileSource) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (s *FileSource) Validate() error {
+	if s.Directory == "" {
+		return xerrors.New("directory is required")
+	}
+	if s.TableName == "" {
+		return xerrors.New("table name is required")
+	}
+	return nil
+}
+
+func (s *FileSource) IsAppendOnly() bool {
+	return true
+}
+
+func (s *FileSource) TableID() abstract.TableID {
+	return abstract.TableID{Namespace: s.TableNamespace, Name: s.TableName}
+}
+
+// ToS3Source converts the source into the S3 one, files are read by the S3 readers over the directory
+func (s *FileSource) ToS3Source() *s3.S3Source {
+	return &s3.S3Source{
+		Bucket: bucket,
+		ConnectionConfig: s3.ConnectionConfig{
+			AccessKey:        "",
+			S3ForcePathStyle: true,
+			SecretKey:        "",
+			Endpoint:         "",
+			UseSSL:           false,
+			VerifySSL:        false,
+			Region:           "",
+			ServiceAccountID: "",
+		},
+		PathPrefix:     "",
+		HideSystemCols: s.HideSystemCols,
+		ReadBatchSize:  s.ReadBatchSize,
+		InflightLimit:  s.InflightLimit,
+		TableName:      s.TableName,
+		TableNamespace: s.TableNamespace,
+		InputFormat:    s.InputFormat,
+		OutputSchema:   s.OutputSchema,
+		AirbyteFormat:  "",
+		PathPattern:    s.PathPattern,
+		Concurrency:    s.Concurrency,
+		Format:         s.Format,
+		EventSource: s3.EventSource{
+			SQS:    nil,
+			SNS:    nil,
+			PubSub: nil,
+		},
+		UnparsedPolicy: s.UnparsedPolicy,
+	}
+}
@@ -0,0 +1,111 @@
+package file
+
+import (
+	"context"
+	"encoding/gob"
+
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/middlewares"
+	"github.com/transferia/transferia/pkg/providers"
+	s3_sink "github.com/transferia/transferia/pkg/providers/s3/sink"
+	"github.com/transferia/transferia/pkg/providers/s3/source"
+	"github.com/transferia/transferia/pkg/providers/s3/storage"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+func init() {
+	gob.RegisterName("*server.FileSource", new(FileSource))
+	gob.RegisterName("*server.FileDestination", new(FileDestination))
+	model.RegisterSource(ProviderType, func() model.Source {
+		return new(FileSource)
+	})
+	model.RegisterDestination(ProviderType, func() model.Destination {
+		return new(FileDestination)
+	})
+	abstract.RegisterProviderName(ProviderType, "Local files")
+	providers.Register(ProviderType, New)
+}
+
+// To verify providers contract implementation
+var (
+	_ providers.Sinker      = (*Provider)(nil)
+	_ providers.Snapshot    = (*Provider)(nil)
+	_ providers.Activator   = (*Provider)(nil)
+	_ providers.Replication = (*Provider)(nil)
+)
+
+type Provider struct {
+	logger   log.Logger
+	registry metrics.Registry
+	cp       coordinator.Coordinator
+	transfer *model.Transfer
+}
+
+func (p *Provider) Activate(ctx context.Context, task *model.TransferOperation, tables abstract.TableMap, callbacks providers.ActivateCallbacks) error {
+	if !p.transfer.IncrementOnly() {
+		if err := callbacks.Cleanup(tables); err != nil {
+			return xerrors.Errorf("Sinker cleanup failed: %w", err)
+		}
+		if err := callbacks.CheckIncludes(tables); err != nil {
+			return xerrors.Errorf("Failed in accordance with configuration: %w", err)
+		}
+		if err := callbacks.Upload(tables); err != nil {
+			return xerrors.Errorf("Snapshot loading failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *Provider) Type() abstract.ProviderType {
+	return ProviderType
+}
+
+func (p *Provider) Storage() (abstract.Storage, error) {
+	src, ok := p.transfer.Src.(*FileSource)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected source type: %T", p.transfer.Src)
+	}
+	dir, err := newDirectory(src.Directory)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open directory: %w", err)
+	}
+	return storage.NewWithClient(src.ToS3Source(), dir, p.logger, p.registry)
+}
+
+// Source polls the directory, files are replicated in order of their modification times
+func (p *Provider) Source() (abstract.Source, error) {
+	src, ok := p.transfer.Src.(*FileSource)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected source type: %T", p.transfer.Src)
+	}
+	dir, err := newDirectory(src.Directory)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open directory: %w", err)
+	}
+	return source.NewSourceWithClient(src.ToS3Source(), dir, p.transfer.ID, p.logger, p.registry, p.cp)
+}
+
+func (p *Provider) Sink(middlewares.Config) (abstract.Sinker, error) {
+	dst, ok := p.transfer.Dst.(*FileDestination)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected target type: %T", p.transfer.Dst)
+	}
+	dir, err := newDirectory(dst.Directory)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open directory: %w", err)
+	}
+	return s3_sink.NewSinkerWithClient(p.logger, dst.ToS3Destination(), dir, dir, p.registry, p.cp, p.transfer.ID), nil
+}
+
+func New(lgr log.Logger, registry metrics.Registry, cp coordinator.Coordinator, transfer *model.Transfer) providers.Provider {
+	return &Provider{
+		logger:   lgr,
+		registry: registry,
+		cp:       cp,
+		transfer: transfer,
+	}
+}
@@ -0,0 +1,134 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/middlewares"
+	"github.com/transferia/transferia/pkg/providers/s3/sink/testutil"
+)
+
+var testSchema = abstract.NewTableSchema([]abstract.ColSchema{
+	{ColumnName: "id", DataType: "int64", PrimaryKey: true},
+	{ColumnName: "name", DataType: "utf8"},
+})
+
+type mockAsyncSink struct {
+	mu    sync.Mutex
+	items []abstract.ChangeItem
+}
+
+func (m *mockAsyncSink) Close() error { return nil }
+
+func (m *mockAsyncSink) AsyncPush(items []abstract.ChangeItem) chan error {
+	res := make(chan error, 1)
+	m.mu.Lock()
+	m.items = append(m.items, items...)
+	m.mu.Unlock()
+	res <- nil
+	return res
+}
+
+func (m *mockAsyncSink) rows() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items)
+}
+
+func newTransfer(src model.Source, dst model.Destination) *model.Transfer {
+	return &model.Transfer{ID: "test", Type: abstract.TransferTypeSnapshotAndIncrement, Src: src, Dst: dst}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	dst := &FileDestination{Directory: dir, OutputFormat: model.ParsingFormatJSON, Layout: "2006/01/02"}
+	dst.WithDefaults()
+	require.NoError(t, dst.Validate())
+
+	provider := New(logger.Log, solomon.NewRegistry(solomon.NewRegistryOpts()), testutil.NewFakeClientWithTransferState(), newTransfer(nil, dst)).(*Provider)
+	sinker, err := provider.Sink(middlewares.Config{})
+	require.NoError(t, err)
+
+	commitTime := uint64(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).UnixNano())
+	var rows []abstract.ChangeItem
+	for i, name := range []string{"alice", "bob", "carol"} {
+		rows = append(rows, abstract.ChangeItem{
+			Kind:         abstract.InsertKind,
+			CommitTime:   commitTime,
+			Schema:       "public",
+			Table:        "users",
+			ColumnNames:  []string{"id", "name"},
+			ColumnValues: []interface{}{int64(i + 1), name},
+			TableSchema:  testSchema,
+		})
+	}
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{{Kind: abstract.InitTableLoad, CommitTime: commitTime, Schema: "public", Table: "users", TableSchema: testSchema}}))
+	require.NoError(t, sinker.Push(rows))
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{{Kind: abstract.DoneTableLoad, CommitTime: commitTime, Schema: "public", Table: "users", TableSchema: testSchema}}))
+	require.NoError(t, sinker.Close())
+
+	_, err = os.Stat(filepath.Join(dir, "2024", "05", "01", "public_users.json"))
+	require.NoError(t, err)
+
+	src := &FileSource{Directory: dir, PathPattern: "**/*.json", TableName: "users", InputFormat: model.ParsingFormatJSONLine, HideSystemCols: true}
+	src.WithDefaults()
+	require.NoError(t, src.Validate())
+	provider = New(logger.Log, solomon.NewRegistry(solomon.NewRegistryOpts()), testutil.NewFakeClientWithTransferState(), newTransfer(src, nil)).(*Provider)
+	storage, err := provider.Storage()
+	require.NoError(t, err)
+
+	tables, err := storage.TableList(nil)
+	require.NoError(t, err)
+	require.Contains(t, tables, *abstract.NewTableID("", "users"))
+
+	var loaded []abstract.ChangeItem
+	require.NoError(t, storage.LoadTable(context.Background(), abstract.TableDescription{Name: "users"}, func(items []abstract.ChangeItem) error {
+		for _, item := range items {
+			if item.IsRowEvent() {
+				loaded = append(loaded, item)
+			}
+		}
+		return nil
+	}))
+	require.Len(t, loaded, 3)
+	require.Equal(t, "alice", loaded[0].AsMap()["name"])
+}
+
+func TestPollingReplication(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "in"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "in", "first.csv"), []byte("1,alice\n2,bob\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "in", "second.csv"), []byte("3,carol\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "skipped.txt"), []byte("not a table\n"), 0o644))
+
+	src := &FileSource{
+		Directory:    dir,
+		PathPattern:  "in/*.csv",
+		TableName:    "users",
+		InputFormat:  model.ParsingFormatCSV,
+		OutputSchema: testSchema.Columns(),
+	}
+	src.WithDefaults()
+	src.Format.CSVSetting.AdvancedOptions.AutogenerateColumnNames = false
+	src.Format.CSVSetting.AdvancedOptions.ColumnNames = []string{"id", "name"}
+
+	provider := New(logger.Log, solomon.NewRegistry(solomon.NewRegistryOpts()), testutil.NewFakeClientWithTransferState(), newTransfer(src, nil)).(*Provider)
+	source, err := provider.Source()
+	require.NoError(t, err)
+	sink := new(mockAsyncSink)
+	go func() {
+		_ = source.Run(sink)
+	}()
+	defer source.Stop()
+
+	require.Eventually(t, func() bool { return sink.rows() == 3 }, 30*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/providers/kafka/writer"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// groupConsumers are the consumers of kafka sources running in this process by their group.
+//
+// When offsets are committed by exactly-once sink, the sink commits them within its transactions as the current
+// member & generation of the group of the source consumer, so commits made after the consumer lost its partitions
+// in a rebalance are rejected by the broker & the transaction is aborted
+var groupConsumers = struct {
+	mutex   sync.Mutex
+	clients map[string]*kgo.Client
+}{
+	mutex:   sync.Mutex{},
+	clients: map[string]*kgo.Client{},
+}
+
+// offsetsCommittedBySink - offsets can be committed within a transaction only when the consumer group lives on the cluster of the destination
+func offsetsCommittedBySink(src *KafkaSource, dst *KafkaDestination) bool {
+	return dst.ExactlyOnce && sameCluster(src.Connection, dst.Connection)
+}
+
+func registerGroupConsumer(group string, client *kgo.Client) {
+	groupConsumers.mutex.Lock()
+	defer groupConsumers.mutex.Unlock()
+	groupConsumers.clients[group] = client
+}
+
+func unregisterGroupConsumer(group string, client *kgo.Client) {
+	groupConsumers.mutex.Lock()
+	defer groupConsumers.mutex.Unlock()
+	if groupConsumers.clients[group] == client {
+		delete(groupConsumers.clients, group)
+	}
+}
+
+// groupMember returns the current member of the group consumed by the source of this process
+func groupMember(group string) (writer.GroupMember, error) {
+	groupConsumers.mutex.Lock()
+	client, ok := groupConsumers.clients[group]
+	groupConsumers.mutex.Unlock()
+	if !ok {
+		return writer.GroupMember{}, xerrors.Errorf("no kafka source consumes within group %q in this process", group)
+	}
+	memberID, generation := client.GroupMetadata()
+	if generation < 0 {
+		return writer.GroupMember{}, xerrors.Errorf("kafka source is not a member of group %q", group)
+	}
+	return writer.GroupMember{Group: group, MemberID: memberID, Generation: generation}, nil
+}
+
+// withSourcePosition keeps the position of the source message on the items made of it:
+// partition in 'PartID' & offset in 'LSN', so exactly-once sink knows offsets to commit for parsed items too
+func withSourcePosition(items []abstract.ChangeItem, partition abstract.Partition, offset int64) []abstract.ChangeItem {
+	for i := range items {
+		items[i].PartID = partition.String()
+		items[i].LSN = uint64(offset)
+	}
+	return items
+}
+
+// sourceOffsets returns the next offsets to consume for the source messages of items,
+// items without a source position (e.g. synchronize events) are skipped
+func sourceOffsets(items []abstract.ChangeItem) map[string]map[int32]int64 {
+	result := make(map[string]map[int32]int64)
+	for _, item := range items {
+		if item.PartID == "" {
+			continue
+		}
+		var partition abstract.Partition
+		if err := json.Unmarshal([]byte(item.PartID), &partition); err != nil || partition.Topic == "" {
+			continue
+		}
+		if _, ok := result[partition.Topic]; !ok {
+			result[partition.Topic] = make(map[int32]int64)
+		}
+		if next := int64(item.LSN) + 1; next > result[partition.Topic][int32(partition.Partition)] {
+			result[partition.Topic][int32(partition.Partition)] = next
+		}
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestSourceOffsets(t *testing.T) {
+	partition := abstract.Partition{Cluster: "", Partition: 1, Topic: "src_topic"}
+	var items []abstract.ChangeItem
+	items = append(items, withSourcePosition([]abstract.ChangeItem{*sinkTestTypicalChangeItem, *sinkTestTypicalChangeItem}, partition, 7)...)
+	items = append(items, withSourcePosition([]abstract.ChangeItem{*sinkTestTypicalChangeItem}, partition, 5)...)
+	items = append(items, abstract.MakeSynchronizeEvent())
+	require.Equal(t, map[string]map[int32]int64{"src_topic": {1: 8}}, sourceOffsets(items))
+}
+
+// TestExactlyOnceAtomicity checks that read_committed consumers see a pushed batch together with offsets of its source messages or nothing
+func TestExactlyOnceAtomicity(t *testing.T) {
+	src, err := SourceRecipe()
+	require.NoError(t, err)
+	brokers := src.Connection.Brokers
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	group, srcTopic, dstTopic := "exactly_once_"+suffix, "exactly_once_src_"+suffix, "exactly_once_dst_"+suffix
+
+	producer, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.AllowAutoTopicCreation())
+	require.NoError(t, err)
+	defer producer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, producer.ProduceSync(ctx, &kgo.Record{Topic: srcTopic, Value: []byte(fmt.Sprint(i))}).FirstErr())
+	}
+
+	// consumer of the group, the same as the kafka source of the transfer
+	consumer, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumerGroup(group),
+		kgo.ConsumeTopics(srcTopic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.DisableAutoCommit(),
+		kgo.RequireStableFetchOffsets(),
+	)
+	require.NoError(t, err)
+	defer consumer.Close()
+	var items []abstract.ChangeItem
+	for len(items) < 3 {
+		fetches := consumer.PollFetches(ctx)
+		require.NoError(t, fetches.Err())
+		fetches.EachRecord(func(r *kgo.Record) {
+			item := MakeKafkaRawMessage(r.Topic, r.Timestamp, r.Topic, int(r.Partition), r.Offset, r.Key, r.Value)
+			items = append(items, withSourcePosition([]abstract.ChangeItem{item}, abstract.Partition{Cluster: "", Partition: uint32(r.Partition), Topic: r.Topic}, r.Offset)...)
+		})
+	}
+	registerGroupConsumer(group, consumer)
+	defer unregisterGroupConsumer(group, consumer)
+
+	newSink := func(transactionalID, offsetsGroup string) abstract.Sinker {
+		dst := &KafkaDestination{
+			Connection:      src.Connection,
+			Auth:            src.Auth,
+			Topic:           dstTopic,
+			FormatSettings:  model.SerializationFormat{Name: model.SerializationFormatMirror},
+			ExactlyOnce:     true,
+			TransactionalID: transactionalID,
+			OffsetsGroup:    offsetsGroup,
+		}
+		dst.WithDefaults()
+		result, err := NewReplicationSink(dst, solomon.NewRegistry(nil), logger.Log)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, result.Close()) })
+		return result
+	}
+
+	t.Run("aborted batch is not visible", func(t *testing.T) {
+		// messages are written, but the offsets of a group without a consumer in this process are not, so the transaction is aborted
+		require.Error(t, newSink(group+"-1", "unknown_"+group).Push(items))
+		require.Empty(t, readCommitted(t, brokers, dstTopic))
+		require.Equal(t, int64(-1), committedOffset(t, consumer, group, srcTopic))
+	})
+
+	t.Run("committed batch is visible with offsets", func(t *testing.T) {
+		require.NoError(t, newSink(group+"-0", group).Push(items))
+		require.Equal(t, []string{"0", "1", "2"}, readCommitted(t, brokers, dstTopic))
+		require.Equal(t, int64(3), committedOffset(t, consumer, group, srcTopic))
+	})
+}
+
+func readCommitted(t *testing.T, brokers []string, topic string) []string {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	var result []string
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for ctx.Err() == nil {
+		client.PollFetches(ctx).EachRecord(func(r *kgo.Record) {
+			result = append(result, string(r.Value))
+		})
+	}
+	return result
+}
+
+func committedOffset(t *testing.T, client *kgo.Client, group, topic string) int64 {
+	req := kmsg.NewPtrOffsetFetchRequest()
+	req.Group = group
+	reqTopic := kmsg.NewOffsetFetchRequestTopic()
+	reqTopic.Topic = topic
+	reqTopic.Partitions = []int32{0}
+	req.Topics = append(req.Topics, reqTopic)
+	req.RequireStable = true
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := req.RequestWith(ctx, client)
+	require.NoError(t, err)
+	require.NoError(t, kerr.ErrorForCode(resp.ErrorCode))
+	require.Len(t, resp.Topics, 1)
+	require.Len(t, resp.Topics[0].Partitions, 1)
+	return resp.Topics[0].Partitions[0].Offset
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/transferia/transferia/pkg/abstract"
+	serializer "github.com/transferia/transferia/pkg/serializer/queue"
 	ytschema "go.ytsaurus.tech/yt/go/schema"
 )
 
@@ -14,6 +15,7 @@ const (
 	kafkaRawMessageWriteTime = "write_time"
 	kafkaRawMessageKey       = "key"
 	kafkaRawMessageData      = "data"
+	kafkaRawMessageHeaders   = "headers"
 )
 
 var (
@@ -24,8 +26,9 @@ var (
 		{ColumnName: kafkaRawMessageWriteTime, DataType: ytschema.TypeDatetime.String(), PrimaryKey: true, Required: true},
 		{ColumnName: kafkaRawMessageKey, DataType: ytschema.TypeBytes.String()},
 		{ColumnName: kafkaRawMessageData, DataType: ytschema.TypeBytes.String()},
+		{ColumnName: kafkaRawMessageHeaders, DataType: ytschema.TypeAny.String()},
 	})
-	kafkaRawDataColumns = []string{kafkaRawMessageTopic, kafkaRawMessagePartition, kafkaRawMessageOffset, kafkaRawMessageWriteTime, kafkaRawMessageKey, kafkaRawMessageData}
+	kafkaRawDataColumns = []string{kafkaRawMessageTopic, kafkaRawMessagePartition, kafkaRawMessageOffset, kafkaRawMessageWriteTime, kafkaRawMessageKey, kafkaRawMessageData, kafkaRawMessageHeaders}
 	kafkaRawDataColsIDX = abstract.ColIDX(kafkaRawDataSchema.Columns())
 )
 
@@ -37,6 +40,12 @@ func IsKafkaRawMessage(items []abstract.ChangeItem) bool {
 }
 
 func MakeKafkaRawMessage(table string, commitTime time.Time, topic string, shard int, offset int64, key, data []byte) abstract.ChangeItem {
+	return MakeKafkaRawMessageWithHeaders(table, commitTime, topic, shard, offset, key, data, nil)
+}
+
+// MakeKafkaRawMessageWithHeaders - the same as MakeKafkaRawMessage, but keeps headers of the original message,
+// so mirror transfers can reproduce it byte-for-byte
+func MakeKafkaRawMessageWithHeaders(table string, commitTime time.Time, topic string, shard int, offset int64, key, data []byte, headers []serializer.Header) abstract.ChangeItem {
 	return abstract.ChangeItem{
 		ID:          0,
 		Kind:        abstract.InsertKind,
@@ -58,6 +67,7 @@ func MakeKafkaRawMessage(table string, commitTime time.Time, topic string, shard
 			commitTime,
 			key,
 			data,
+			headers,
 		},
 		Size: abstract.RawEventSize(uint64(len(data))),
 	}
@@ -70,3 +80,13 @@ func GetKafkaRawMessageKey(r *abstract.ChangeItem) []byte {
 func GetKafkaRawMessageData(r *abstract.ChangeItem) []byte {
 	return r.ColumnValues[kafkaRawDataColsIDX[kafkaRawMessageData]].([]byte)
 }
+
+// GetKafkaRawMessageHeaders returns nil for items built without headers
+func GetKafkaRawMessageHeaders(r *abstract.ChangeItem) []serializer.Header {
+	idx := kafkaRawDataColsIDX[kafkaRawMessageHeaders]
+	if idx >= len(r.ColumnValues) {
+		return nil
+	}
+	headers, _ := r.ColumnValues[idx].([]serializer.Header)
+	return headers
+}
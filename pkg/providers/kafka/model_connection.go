@@ -9,6 +9,7 @@ import (
 	"github.com/segmentio/kafka-go/sasl/scram"
 	"github.com/transferia/transferia/library/go/core/xerrors"
 	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/util/set"
 	"github.com/transferia/transferia/pkg/util/validators"
 	franzsasl "github.com/twmb/franz-go/pkg/sasl"
 	franzscram "github.com/twmb/franz-go/pkg/sasl/scram"
@@ -61,6 +62,20 @@ type KafkaConnectionOptions struct {
 	SubNetworkID string
 }
 
+// sameCluster - managed clusters are compared by ID, on-premise ones by the set of brokers
+func sameCluster(a, b *KafkaConnectionOptions) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.ClusterID != "" || b.ClusterID != "" {
+		return a.ClusterID == b.ClusterID
+	}
+	if len(a.Brokers) == 0 {
+		return false
+	}
+	return set.New(a.Brokers...).Equals(set.New(b.Brokers...))
+}
+
 func (o *KafkaConnectionOptions) TLSConfig() (*tls.Config, error) {
 	if o.TLS == model.DisabledTLS {
 		return nil, nil
@@ -42,6 +42,17 @@ type KafkaDestination struct {
 
 	// Compression which compression mechanism use for writer, default - None
 	Compression Encoding
+
+	// Headers are set on every written message, in addition to the ones carried by mirrored messages
+	Headers []KafkaHeader
+
+	// ExactlyOnce makes replication write every pushed batch within a kafka transaction
+	ExactlyOnce bool
+	// TransactionalID & OffsetsGroup are filled by the provider when 'ExactlyOnce' is on:
+	//     TransactionalID - derived from transfer ID & job index, fences the writer of the previous run of the same job
+	//     OffsetsGroup - consumer group of the kafka source on the same cluster, its offsets are committed within the transaction
+	TransactionalID string `json:"-"`
+	OffsetsGroup    string `json:"-"`
 }
 
 var _ model.Destination = (*KafkaDestination)(nil)
@@ -50,6 +61,14 @@ type TopicConfigEntry struct {
 	ConfigName, ConfigValue string
 }
 
+// KafkaHeader - either a static Value, or a value of the Column of the written change item.
+// Items without the column (or with null in it) are written without the header
+type KafkaHeader struct {
+	Key    string
+	Value  string
+	Column string
+}
+
 func topicConfigEntryToSlices(t []TopicConfigEntry) [][2]string {
 	return slices.Map(t, func(tt TopicConfigEntry) [2]string {
 		return [2]string{tt.ConfigName, tt.ConfigValue}
@@ -118,9 +137,26 @@ func (d *KafkaDestination) Validate() error {
 	if d.TopicPrefix != "" && d.SaveTxOrder {
 		return xerrors.Errorf("option 'SaveTxOrder'=true is incompatible with 'TopicPrefix'. Use either full topic name or turn off 'SaveTxOrder'.")
 	}
+	for i, header := range d.Headers {
+		if header.Key == "" {
+			return xerrors.Errorf("header #%d has an empty key", i)
+		}
+		if header.Value != "" && header.Column != "" {
+			return xerrors.Errorf("header %q should have either a static value or a column, not both", header.Key)
+		}
+	}
 	return nil
 }
 
+func (d *KafkaDestination) hasColumnHeaders() bool {
+	for _, header := range d.Headers {
+		if header.Column != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *KafkaDestination) Compatible(src model.Source, transferType abstract.TransferType) error {
 	return sourceCompatible(src, transferType, d.FormatSettings.Name)
 }
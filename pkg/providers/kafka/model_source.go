@@ -30,6 +30,9 @@ type KafkaSource struct {
 	ParserConfig        map[string]interface{}
 	IsHomo              bool // enabled kafka mirror protocol which can work only with kafka target
 	SynchronizeIsNeeded bool // true, if we need to send synchronize events on releasing partitions
+	// OffsetsCommittedBySink is set by the provider when exactly-once kafka target on the same cluster
+	// commits offsets of consumed messages within its transactions, so the source doesn't commit them itself
+	OffsetsCommittedBySink bool `json:"-"`
 
 	OffsetPolicy          OffsetPolicy // specify from what topic part start message consumption
 	ParseQueueParallelism int
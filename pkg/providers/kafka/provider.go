@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/gob"
+	"fmt"
 
 	"github.com/transferia/transferia/library/go/core/metrics"
 	"github.com/transferia/transferia/library/go/core/xerrors"
@@ -122,6 +123,9 @@ func (p *Provider) Source() (abstract.Source, error) {
 	if !src.SynchronizeIsNeeded {
 		src.SynchronizeIsNeeded = p.transfer.DstType() == "lb" // sorry for that
 	}
+	if dst, ok := p.transfer.Dst.(*KafkaDestination); ok {
+		src.OffsetsCommittedBySink = offsetsCommittedBySink(src, dst)
+	}
 	if len(p.transfer.DataObjects.GetIncludeObjects()) > 0 && len(src.GroupTopics) == 0 { // infer topics from transfer
 		src.GroupTopics = p.transfer.DataObjects.GetIncludeObjects()
 	}
@@ -135,6 +139,13 @@ func (p *Provider) Sink(middlewares.Config) (abstract.Sinker, error) {
 	}
 	cfgCopy := *dst
 	cfgCopy.FormatSettings = InferFormatSettings(p.transfer.Src, cfgCopy.FormatSettings)
+	if cfgCopy.ExactlyOnce {
+		cfgCopy.TransactionalID = fmt.Sprintf("%s-%d", p.transfer.ID, p.transfer.CurrentJobIndex())
+		// kafka source consumes within group named by transfer ID
+		if src, ok := p.transfer.Src.(*KafkaSource); ok && offsetsCommittedBySink(src, &cfgCopy) {
+			cfgCopy.OffsetsGroup = p.transfer.ID
+		}
+	}
 	return NewReplicationSink(&cfgCopy, p.registry, p.logger)
 }
 
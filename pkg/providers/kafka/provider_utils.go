@@ -8,6 +8,7 @@ import (
 	debezium_prod_status "github.com/transferia/transferia/pkg/debezium/prodstatus"
 	"github.com/transferia/transferia/pkg/providers/airbyte"
 	clickhouse "github.com/transferia/transferia/pkg/providers/clickhouse/model"
+	"github.com/transferia/transferia/pkg/providers/mongo"
 	"github.com/transferia/transferia/pkg/providers/mysql"
 	"github.com/transferia/transferia/pkg/providers/postgres"
 )
@@ -49,6 +50,8 @@ func InferFormatSettings(src model.Source, formatSettings model.SerializationFor
 			result.Settings[debeziumparameters.SourceType] = "pg"
 		case *mysql.MysqlSource:
 			result.Settings[debeziumparameters.SourceType] = "mysql"
+		case *mongo.MongoSource:
+			result.Settings[debeziumparameters.SourceType] = "mongo"
 		}
 	}
 
@@ -0,0 +1,26 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	debeziumparameters "github.com/transferia/transferia/pkg/debezium/parameters"
+	"github.com/transferia/transferia/pkg/providers/mongo"
+	"github.com/transferia/transferia/pkg/providers/mysql"
+)
+
+func TestInferFormatSettingsDebeziumSourceType(t *testing.T) {
+	debeziumFormat := model.SerializationFormat{
+		Name:             model.SerializationFormatDebezium,
+		Settings:         map[string]string{},
+		SettingsKV:       nil,
+		BatchingSettings: nil,
+	}
+
+	result := InferFormatSettings(new(mongo.MongoSource), debeziumFormat)
+	require.Equal(t, debeziumparameters.SourceTypeMongo, result.Settings[debeziumparameters.SourceType])
+
+	result = InferFormatSettings(new(mysql.MysqlSource), debeziumFormat)
+	require.Equal(t, debeziumparameters.SourceTypeMysql, result.Settings[debeziumparameters.SourceType])
+}
@@ -27,6 +27,7 @@ type sink struct {
 	metrics    *stats.SinkerStats
 	serializer serializer.Serializer
 	writer     writer.AbstractWriter
+	txWriter   writer.AbstractTransactionalWriter // the same as 'writer', set only in exactly-once mode
 }
 
 func serializeKafkaMirror(input []abstract.ChangeItem) map[abstract.TablePartID][]serializer.SerializedMessage {
@@ -34,8 +35,9 @@ func serializeKafkaMirror(input []abstract.ChangeItem) map[abstract.TablePartID]
 	arr := make([]serializer.SerializedMessage, 0, len(input))
 	for _, changeItem := range input {
 		arr = append(arr, serializer.SerializedMessage{
-			Key:   GetKafkaRawMessageKey(&changeItem),
-			Value: GetKafkaRawMessageData(&changeItem),
+			Key:     GetKafkaRawMessageKey(&changeItem),
+			Value:   GetKafkaRawMessageData(&changeItem),
+			Headers: GetKafkaRawMessageHeaders(&changeItem),
 		})
 	}
 	if len(input) != 0 {
@@ -45,24 +47,103 @@ func serializeKafkaMirror(input []abstract.ChangeItem) map[abstract.TablePartID]
 	return tableToMessages
 }
 
+func (s *sink) serializeBatch(input []abstract.ChangeItem) (map[abstract.TablePartID][]serializer.SerializedMessage, error) {
+	if s.config.FormatSettings.Name == model.SerializationFormatLbMirror { // see comments to the function 'GroupAndSerializeLB'
+		// 'id' here - sourceID
+		tableToMessages, _, err := s.serializer.(*serializer.MirrorSerializer).GroupAndSerializeLB(input)
+		return tableToMessages, err
+	}
+	// 'id' here - fqtn()
+	if IsKafkaRawMessage(input) {
+		return serializeKafkaMirror(input), nil
+	}
+	return s.serializer.Serialize(input)
+}
+
+// serialize - headers taken from columns differ from item to item,
+// so in this case every item is serialized on its own & batching of several items into one message is off
+func (s *sink) serialize(input []abstract.ChangeItem) (map[abstract.TablePartID][]serializer.SerializedMessage, error) {
+	if !s.config.hasColumnHeaders() {
+		tableToMessages, err := s.serializeBatch(input)
+		if err != nil {
+			return nil, err
+		}
+		if len(s.config.Headers) != 0 {
+			headers := itemHeaders(s.config.Headers, nil)
+			for _, messages := range tableToMessages {
+				addHeaders(messages, headers)
+			}
+		}
+		return tableToMessages, nil
+	}
+
+	tableToMessages := make(map[abstract.TablePartID][]serializer.SerializedMessage)
+	for i := range input {
+		itemToMessages, err := s.serializeBatch(input[i : i+1])
+		if err != nil {
+			return nil, err
+		}
+		headers := itemHeaders(s.config.Headers, &input[i])
+		for id, messages := range itemToMessages {
+			addHeaders(messages, headers)
+			tableToMessages[id] = append(tableToMessages[id], messages...)
+		}
+	}
+	return tableToMessages, nil
+}
+
 func (s *sink) Push(input []abstract.ChangeItem) error {
-	start := time.Now()
+	if s.txWriter == nil {
+		return s.push(input)
+	}
+	return s.pushInTransaction(input)
+}
 
-	// serialize
+// pushInTransaction - the batch & offsets of the source messages it was made of are committed atomically,
+// so neither a restart nor a fenced zombie writer makes consumers with 'isolation.level=read_committed' see duplicates
+func (s *sink) pushInTransaction(input []abstract.ChangeItem) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
 
-	var tableToMessages map[abstract.TablePartID][]serializer.SerializedMessage
-	var err error
-	if s.config.FormatSettings.Name == model.SerializationFormatLbMirror { // see comments to the function 'GroupAndSerializeLB'
-		// 'id' here - sourceID
-		tableToMessages, _, err = s.serializer.(*serializer.MirrorSerializer).GroupAndSerializeLB(input)
-	} else {
-		// 'id' here - fqtn()
-		if IsKafkaRawMessage(input) {
-			tableToMessages = serializeKafkaMirror(input)
-		} else {
-			tableToMessages, err = s.serializer.Serialize(input)
+	if err := s.txWriter.BeginTransaction(); err != nil {
+		return xerrors.Errorf("unable to begin transaction: %w", err)
+	}
+	if err := s.commitInTransaction(ctx, input); err != nil {
+		if abortErr := s.txWriter.EndTransaction(ctx, false); abortErr != nil {
+			s.logger.Warn("unable to abort transaction", log.Error(abortErr))
+		}
+		return xerrors.Errorf("unable to push in transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *sink) commitInTransaction(ctx context.Context, input []abstract.ChangeItem) error {
+	if err := s.push(input); err != nil {
+		return xerrors.Errorf("unable to push: %w", err)
+	}
+	if s.config.OffsetsGroup != "" {
+		if offsets := sourceOffsets(input); len(offsets) != 0 {
+			member, err := groupMember(s.config.OffsetsGroup)
+			if err != nil {
+				return xerrors.Errorf("unable to get consumer group member: %w", err)
+			}
+			if err := s.txWriter.CommitOffsets(ctx, member, offsets); err != nil {
+				return xerrors.Errorf("unable to commit source offsets: %w", err)
+			}
 		}
 	}
+	if err := s.txWriter.EndTransaction(ctx, true); err != nil {
+		return xerrors.Errorf("unable to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *sink) push(input []abstract.ChangeItem) error {
+	start := time.Now()
+
+	// serialize
+
+	tableToMessages, err := s.serialize(input)
 	if err != nil {
 		return xerrors.Errorf("unable to serialize: %w", err)
 	}
@@ -156,7 +237,19 @@ func NewSinkImpl(cfg *KafkaDestination, registry metrics.Registry, lgr log.Logge
 		logger:     lgr,
 		metrics:    stats.NewSinkerStats(registry),
 		serializer: currSerializer,
-		writer:     writerFactory.BuildWriter(cfg.Connection.Brokers, cfg.Compression.AsKafka(), mechanism, tlsCfg, topicConfigEntryToSlices(cfg.TopicConfigEntries), cfg.BatchBytes, cfg.DialFunc),
+		writer:     nil,
+		txWriter:   nil,
+	}
+	if cfg.ExactlyOnce && cfg.TransactionalID != "" {
+		txWriter, err := writerFactory.BuildTransactionalWriter(cfg.Connection.Brokers, cfg.Compression.AsKafka(), mechanism, cfg.Auth.GetFranzAuthMechanism(), tlsCfg, topicConfigEntryToSlices(cfg.TopicConfigEntries), cfg.BatchBytes, cfg.DialFunc, cfg.TransactionalID)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to create transactional writer: %w", err)
+		}
+		lgr.Info("exactly-once mode is on", log.String("transactional_id", cfg.TransactionalID), log.String("offsets_group", cfg.OffsetsGroup))
+		result.writer = txWriter
+		result.txWriter = txWriter
+	} else {
+		result.writer = writerFactory.BuildWriter(cfg.Connection.Brokers, cfg.Compression.AsKafka(), mechanism, tlsCfg, topicConfigEntryToSlices(cfg.TopicConfigEntries), cfg.BatchBytes, cfg.DialFunc)
 	}
 
 	return &result, nil
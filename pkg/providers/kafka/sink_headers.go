@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/transferia/transferia/pkg/abstract"
+	serializer "github.com/transferia/transferia/pkg/serializer/queue"
+)
+
+// itemHeaders builds configured headers for the item, 'item' is nil when there are only static headers
+func itemHeaders(config []KafkaHeader, item *abstract.ChangeItem) []serializer.Header {
+	headers := make([]serializer.Header, 0, len(config))
+	for _, header := range config {
+		if header.Column == "" {
+			headers = append(headers, serializer.Header{Key: header.Key, Value: []byte(header.Value)})
+			continue
+		}
+		if item == nil {
+			continue
+		}
+		idx := item.ColumnNameIndex(header.Column)
+		if idx < 0 {
+			continue
+		}
+		if value, ok := headerValue(item.ColumnValues[idx]); ok {
+			headers = append(headers, serializer.Header{Key: header.Key, Value: value})
+		}
+	}
+	return headers
+}
+
+func headerValue(value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, false
+	case []byte:
+		return v, true
+	case string:
+		return []byte(v), true
+	case time.Time:
+		return []byte(v.Format(time.RFC3339Nano)), true
+	default:
+		result, err := json.Marshal(v)
+		if err != nil {
+			return nil, false
+		}
+		return result, true
+	}
+}
+
+// addHeaders appends headers to every message, already present (mirrored) headers are kept first
+func addHeaders(messages []serializer.SerializedMessage, headers []serializer.Header) {
+	if len(headers) == 0 {
+		return
+	}
+	for i := range messages {
+		merged := make([]serializer.Header, 0, len(messages[i].Headers)+len(headers))
+		merged = append(merged, messages[i].Headers...)
+		messages[i].Headers = append(merged, headers...)
+	}
+}
@@ -245,6 +245,75 @@ func TestMirrorKafka(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestMirrorKafkaHeaders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dst := &KafkaDestination{
+		Connection: &KafkaConnectionOptions{
+			TLS:     model.DefaultTLS,
+			Brokers: []string{"my_broker_0"},
+		},
+		Auth: &KafkaAuth{
+			Enabled:   true,
+			Mechanism: "SHA-512",
+			User:      "user1",
+			Password:  "qwert12345",
+		},
+		Topic: "foo_bar",
+		FormatSettings: model.SerializationFormat{
+			Name: model.SerializationFormatMirror,
+		},
+		Headers: []KafkaHeader{
+			{Key: "origin", Value: "transfer", Column: ""},
+			{Key: "source_topic", Value: "", Column: "topic"},
+			{Key: "absent", Value: "", Column: "no_such_column"},
+		},
+	}
+	dst.WithDefaults()
+	require.NoError(t, dst.Validate())
+
+	k := []byte(`my_key`)
+	v := []byte(`blablabla`)
+	mirrored := []serializer.Header{{Key: "trace_id", Value: []byte{0x0, 0x1}}}
+
+	currWriter := writer.NewMockAbstractWriter(ctrl)
+	currWriter.EXPECT().WriteMessages(gomock.Any(), gomock.Any(), "foo_bar", []serializer.SerializedMessage{{
+		Key:   k,
+		Value: v,
+		Headers: []serializer.Header{
+			{Key: "trace_id", Value: []byte{0x0, 0x1}},
+			{Key: "origin", Value: []byte("transfer")},
+			{Key: "source_topic", Value: []byte("src_topic")},
+		},
+	}})
+	client := writer.NewMockAbstractWriterFactory(ctrl)
+	client.EXPECT().BuildWriter([]string{"my_broker_0"}, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(currWriter)
+
+	testSink, err := NewSinkImpl(
+		dst,
+		solomon.NewRegistry(nil).WithTags(map[string]string{"ts": time.Now().String()}),
+		logger.Log,
+		client,
+		false,
+	)
+	require.NoError(t, err)
+
+	err = testSink.Push([]abstract.ChangeItem{MakeKafkaRawMessageWithHeaders("foo_bar", time.Time{}, "src_topic", 0, 0, k, v, mirrored)})
+	require.NoError(t, err)
+	require.Len(t, mirrored, 1)
+
+	dst.Headers = append(dst.Headers, KafkaHeader{Key: "both", Value: "a", Column: "topic"})
+	require.Error(t, dst.Validate())
+}
+
+func TestSameCluster(t *testing.T) {
+	require.True(t, sameCluster(&KafkaConnectionOptions{Brokers: []string{"b1", "b2"}}, &KafkaConnectionOptions{Brokers: []string{"b2", "b1"}}))
+	require.False(t, sameCluster(&KafkaConnectionOptions{Brokers: []string{"b1"}}, &KafkaConnectionOptions{Brokers: []string{"b1", "b2"}}))
+	require.True(t, sameCluster(&KafkaConnectionOptions{ClusterID: "c1"}, &KafkaConnectionOptions{ClusterID: "c1", Brokers: []string{"b1"}}))
+	require.False(t, sameCluster(&KafkaConnectionOptions{ClusterID: "c1"}, &KafkaConnectionOptions{Brokers: []string{"b1"}}))
+}
+
 func TestAddDTSystemTables(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
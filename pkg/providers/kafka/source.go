@@ -16,6 +16,7 @@ import (
 	"github.com/transferia/transferia/pkg/parsequeue"
 	"github.com/transferia/transferia/pkg/parsers"
 	"github.com/transferia/transferia/pkg/providers"
+	serializer "github.com/transferia/transferia/pkg/serializer/queue"
 	"github.com/transferia/transferia/pkg/stats"
 	"github.com/transferia/transferia/pkg/util"
 	"github.com/transferia/transferia/pkg/util/queues/sequencer"
@@ -42,6 +43,8 @@ type Source struct {
 	reader        reader
 	cancel        context.CancelFunc
 	ctx           context.Context
+	readCancel    context.CancelFunc
+	readCtx       context.Context // cancelled on Drain, while ctx is still alive to commit offsets
 	once          sync.Once
 	executor      *functions.Executor
 	errCh         chan error
@@ -52,6 +55,8 @@ type Source struct {
 
 	pmx               sync.Mutex
 	partitionReleased bool // becomes true, when consumer loses partitions
+
+	offsetsGroup string // consumer group, whose offsets are committed by exactly-once sink instead of the source
 }
 
 // inflightBytes - increased with every new message, but decreased only after AsyncPush returned something,
@@ -128,12 +133,17 @@ func (p *Source) run(parseQ *parsequeue.WaitableParseQueue[[]kgo.Record]) error
 		case err := <-p.errCh:
 			p.cancel() // after first error cancel ctx, so any other errors would be dropped, but not deadlocked
 			return err
+		case <-p.readCtx.Done():
+			return p.drain(parseQ, buffer)
 		default:
 		}
 
-		fetchCtx, cancel := context.WithTimeout(p.ctx, nextFetchDuration)
+		fetchCtx, cancel := context.WithTimeout(p.readCtx, nextFetchDuration)
 		m, err := p.reader.FetchMessage(fetchCtx)
 		cancel()
+		if err != nil && p.readCtx.Err() != nil {
+			return p.drain(parseQ, buffer)
+		}
 		if err != nil && err != errNoInput {
 			return xerrors.Errorf("unable to fetch message: %w", err)
 		}
@@ -185,9 +195,46 @@ func (p *Source) run(parseQ *parsequeue.WaitableParseQueue[[]kgo.Record]) error
 	}
 }
 
+// drain pushes already read messages and waits until all of them are acknowledged and committed
+func (p *Source) drain(parseQ *parsequeue.WaitableParseQueue[[]kgo.Record], buffer []kgo.Record) error {
+	p.logger.Info("Draining source", log.Int("buffered_messages", len(buffer)))
+	if len(buffer) > 0 {
+		if err := p.sequencer.StartProcessing(recordsToQueueMessages(buffer)); err != nil {
+			return xerrors.Errorf("sequencer found an error in StartProcessing, err: %w", err)
+		}
+		if err := parseQ.Add(buffer); err != nil {
+			return xerrors.Errorf("unable to add to pusher q: %w", err)
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		parseQ.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		p.logger.Info("Source drained, all read messages are committed")
+		return nil
+	case err := <-p.errCh:
+		p.cancel()
+		return xerrors.Errorf("unable to drain source: %w", err)
+	case <-p.ctx.Done():
+		return nil
+	}
+}
+
+// Drain stops reading new messages, Run returns once everything already read is committed
+func (p *Source) Drain() {
+	p.readCancel()
+}
+
 func (p *Source) Stop() {
 	p.once.Do(func() {
 		p.cancel()
+		if client, ok := p.reader.(*franzReader); ok && p.offsetsGroup != "" {
+			unregisterGroupConsumer(p.offsetsGroup, client.client)
+		}
 		if err := p.reader.Close(); err != nil {
 			p.logger.Warn("unable to close reader", log.Error(err))
 		}
@@ -242,9 +289,16 @@ func (p *Source) Fetch() ([]abstract.ChangeItem, error) {
 			if p.parser != nil {
 				// DO CONVERT
 				var parsed []abstract.ChangeItem
-				for _, row := range data {
-					ci, part := p.changeItemAsMessage(row)
-					parsed = append(parsed, p.parser.Do(ci, part)...)
+				if p.executor == nil {
+					for _, item := range buffer {
+						ci, part := recordAsMessage(item)
+						parsed = append(parsed, p.parser.Do(ci, part)...)
+					}
+				} else {
+					for _, row := range data {
+						ci, part := p.changeItemAsMessage(row)
+						parsed = append(parsed, p.parser.Do(ci, part)...)
+					}
 				}
 				res = parsed
 			}
@@ -274,6 +328,11 @@ func (p *Source) ack(data []kgo.Record, pushSt time.Time, err error) {
 		util.Send(p.ctx, p.errCh, xerrors.Errorf("sequencer found an error in Pushed, err: %w", err))
 		return
 	}
+	if p.offsetsGroup != "" {
+		// offsets are already committed by the sink within the transaction of the pushed batch
+		p.metrics.PushTime.RecordDuration(time.Since(pushSt))
+		return
+	}
 	if err := p.reader.CommitMessages(p.ctx, recordsFromQueueMessages(commitMessages)...); err != nil {
 		util.Send(p.ctx, p.errCh, err)
 		return
@@ -291,7 +350,8 @@ func (p *Source) parse(buffer []kgo.Record) []abstract.ChangeItem {
 	totalSize := 0
 	for _, msg := range buffer {
 		totalSize += len(msg.Value)
-		data = append(data, p.makeRawChangeItem(msg))
+		_, part := recordAsMessage(msg)
+		data = append(data, p.keepPosition([]abstract.ChangeItem{p.makeRawChangeItem(msg)}, part, msg.Offset)...)
 		msg.Value = nil
 	}
 	p.logger.Infof("begin transform for batches %v, total size: %v", len(data), format.SizeInt(totalSize))
@@ -312,9 +372,17 @@ func (p *Source) parse(buffer []kgo.Record) []abstract.ChangeItem {
 		// DO CONVERT
 		st := time.Now()
 		var converted []abstract.ChangeItem
-		for _, row := range data {
-			ci, part := p.changeItemAsMessage(row)
-			converted = append(converted, p.parser.Do(ci, part)...)
+		if p.executor == nil {
+			// without transformation records are parsed as is, so keys & headers reach the parser
+			for _, msg := range buffer {
+				ci, part := recordAsMessage(msg)
+				converted = append(converted, p.keepPosition(p.parser.Do(ci, part), part, int64(ci.Offset))...)
+			}
+		} else {
+			for _, row := range data {
+				ci, part := p.changeItemAsMessage(row)
+				converted = append(converted, p.keepPosition(p.parser.Do(ci, part), part, int64(ci.Offset))...)
+			}
 		}
 		p.logger.Infof("convert done in %v, %v rows -> %v rows", time.Since(st), len(data), len(converted))
 		data = converted
@@ -329,9 +397,17 @@ func (p *Source) parse(buffer []kgo.Record) []abstract.ChangeItem {
 	return data
 }
 
+// keepPosition stamps items made of the source message with its position, when offsets are committed by the sink
+func (p *Source) keepPosition(items []abstract.ChangeItem, partition abstract.Partition, offset int64) []abstract.ChangeItem {
+	if p.offsetsGroup == "" {
+		return items
+	}
+	return withSourcePosition(items, partition, offset)
+}
+
 func (p *Source) makeRawChangeItem(msg kgo.Record) abstract.ChangeItem {
 	if p.config.IsHomo {
-		return MakeKafkaRawMessage(
+		return MakeKafkaRawMessageWithHeaders(
 			msg.Topic,
 			msg.Timestamp,
 			msg.Topic,
@@ -339,6 +415,7 @@ func (p *Source) makeRawChangeItem(msg kgo.Record) abstract.ChangeItem {
 			msg.Offset,
 			msg.Key,
 			msg.Value,
+			recordHeaders(msg),
 		)
 	}
 
@@ -352,6 +429,41 @@ func (p *Source) makeRawChangeItem(msg kgo.Record) abstract.ChangeItem {
 	)
 }
 
+func recordHeaders(msg kgo.Record) []serializer.Header {
+	if len(msg.Headers) == 0 {
+		return nil
+	}
+	headers := make([]serializer.Header, 0, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers = append(headers, serializer.Header{Key: header.Key, Value: header.Value})
+	}
+	return headers
+}
+
+// recordAsMessage - kafka allows duplicated header keys, the last one wins
+func recordAsMessage(msg kgo.Record) (parsers.Message, abstract.Partition) {
+	var headers map[string]string
+	if len(msg.Headers) != 0 {
+		headers = make(map[string]string, len(msg.Headers))
+		for _, header := range msg.Headers {
+			headers[header.Key] = string(header.Value)
+		}
+	}
+	return parsers.Message{
+		Offset:     uint64(msg.Offset),
+		SeqNo:      uint64(msg.Offset),
+		Key:        msg.Key,
+		CreateTime: msg.Timestamp,
+		WriteTime:  msg.Timestamp,
+		Value:      msg.Value,
+		Headers:    headers,
+	}, abstract.Partition{
+		Cluster:   "", // v1 protocol does not contains such entity
+		Partition: uint32(msg.Partition),
+		Topic:     msg.Topic,
+	}
+}
+
 func (p *Source) changeItemAsMessage(ci abstract.ChangeItem) (parsers.Message, abstract.Partition) {
 	partition := uint32(ci.ColumnValues[1].(int))
 	seqNo := ci.ColumnValues[2].(uint64)
@@ -452,6 +564,7 @@ func ensureTopicExists(cl *kgo.Client, topics []string) error {
 
 func newSource(cfg *KafkaSource, logger log.Logger, registry metrics.Registry) (*Source, error) {
 	ctx, cancel := context.WithCancel(context.Background())
+	readCtx, readCancel := context.WithCancel(ctx)
 
 	source := &Source{
 		config:            cfg,
@@ -460,6 +573,8 @@ func newSource(cfg *KafkaSource, logger log.Logger, registry metrics.Registry) (
 		logger:            logger,
 		cancel:            cancel,
 		ctx:               ctx,
+		readCancel:        readCancel,
+		readCtx:           readCtx,
 		once:              sync.Once{},
 		executor:          nil,
 		errCh:             make(chan error, 1),
@@ -469,6 +584,7 @@ func newSource(cfg *KafkaSource, logger log.Logger, registry metrics.Registry) (
 		sequencer:         sequencer.NewSequencer(),
 		pmx:               sync.Mutex{},
 		partitionReleased: false,
+		offsetsGroup:      "",
 	}
 
 	if cfg.Transformer != nil {
@@ -542,6 +658,10 @@ func newSourceWithCallbacks(cfg *KafkaSource, logger log.Logger, registry metric
 		return nil, abstract.NewFatalError(xerrors.Errorf("unable to ensure topic exists: %w", err))
 	}
 
+	if group, ok := kfClient.OptValue(kgo.ConsumerGroup).(string); ok && group != "" && cfg.OffsetsCommittedBySink {
+		source.offsetsGroup = group
+		registerGroupConsumer(group, kfClient)
+	}
 	r := newFranzReader(kfClient)
 	source.reader = r
 
@@ -572,6 +692,10 @@ func NewSource(transferID string, cfg *KafkaSource, logger log.Logger, registry
 		kgo.RequestTimeoutOverhead(20 * time.Second),
 		kgo.DisableAutoCommit(),
 	}
+	if cfg.OffsetsCommittedBySink {
+		// after a rebalance offsets are fetched only once pending transactions of exactly-once sink are over
+		opts = append(opts, kgo.RequireStableFetchOffsets())
+	}
 
 	if mechanism != nil {
 		opts = append(opts, kgo.SASL(mechanism))
@@ -100,6 +100,34 @@ func TestThrottler(t *testing.T) {
 	wg.Wait()
 }
 
+func TestDrain(t *testing.T) {
+	reader := &mockKafkaReader{}
+	sinker := &mockSink{
+		pushF: func(items []abstract.ChangeItem) error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		},
+	}
+	kafkaSource := &KafkaSource{BufferSize: 100}
+	source, err := newSourceWithReader(kafkaSource, logger.Log, solomon.NewRegistry(solomon.NewRegistryOpts()), reader)
+	require.NoError(t, err)
+	var _ abstract.DrainableSource = source
+
+	runErrCh := make(chan error, 1)
+	go func() {
+		runErrCh <- source.Run(sinker)
+	}()
+	time.Sleep(time.Second)
+	source.Drain()
+	select {
+	case err := <-runErrCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "source is not drained in time")
+	}
+	require.Equal(t, reader.offset-1, reader.maxCommittedOffset) // every read message is committed
+}
+
 func TestConsumer(t *testing.T) {
 	parserConfigMap, err := parsers.ParserConfigStructToMap(&jsonparser.ParserConfigJSONCommon{
 		Fields:        []abstract.ColSchema{{ColumnName: "ts", DataType: "DateTime"}, {ColumnName: "msg", DataType: "string"}},
@@ -8,6 +8,7 @@ import (
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl"
 	serializer "github.com/transferia/transferia/pkg/serializer/queue"
+	franzsasl "github.com/twmb/franz-go/pkg/sasl"
 	"go.ytsaurus.tech/library/go/core/log"
 )
 
@@ -19,6 +20,24 @@ type AbstractWriter interface {
 	Close() error
 }
 
+// GroupMember is the consumer on whose behalf offsets are committed,
+// the broker rejects commits of a member which is not in the current generation of the group
+type GroupMember struct {
+	Group      string
+	MemberID   string
+	Generation int32
+}
+
+// AbstractTransactionalWriter - messages written between BeginTransaction & EndTransaction
+// become visible to 'read_committed' consumers all at once, together with committed offsets
+type AbstractTransactionalWriter interface {
+	AbstractWriter
+	BeginTransaction() error
+	CommitOffsets(ctx context.Context, member GroupMember, offsets map[string]map[int32]int64) error
+	EndTransaction(ctx context.Context, commit bool) error
+}
+
 type AbstractWriterFactory interface {
 	BuildWriter(brokers []string, compression kafka.Compression, saslMechanism sasl.Mechanism, tlsConfig *tls.Config, topicConfig [][2]string, batchBytes int64, dial func(ctx context.Context, network string, address string) (net.Conn, error)) AbstractWriter
+	BuildTransactionalWriter(brokers []string, compression kafka.Compression, saslMechanism sasl.Mechanism, franzSaslMechanism franzsasl.Mechanism, tlsConfig *tls.Config, topicConfig [][2]string, batchBytes int64, dial func(ctx context.Context, network string, address string) (net.Conn, error), transactionalID string) (AbstractTransactionalWriter, error)
 }
@@ -0,0 +1,59 @@
+package writer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/providers/kafka/client"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+// topicCreator creates topics on first write into them, it's shared by all writer implementations
+type topicCreator struct {
+	brokers       []string
+	saslMechanism sasl.Mechanism
+	tlsConfig     *tls.Config
+	topicConfig   [][2]string
+
+	mutex       sync.Mutex
+	knownTopics map[string]bool
+
+	dial func(ctx context.Context, network string, address string) (net.Conn, error)
+}
+
+func (c *topicCreator) ensureTopicExists(lgr log.Logger, topic string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	writerID := fmt.Sprintf("topic:%v", topic)
+	if c.knownTopics[writerID] {
+		return nil
+	}
+	kafkaClient, err := client.NewClient(c.brokers, c.saslMechanism, c.tlsConfig, c.dial)
+	if err != nil {
+		return xerrors.Errorf("unable to create kafka client, err: %w", err)
+	}
+	if err := kafkaClient.CreateTopicIfNotExist(lgr, topic, c.topicConfig); err != nil {
+		return xerrors.Errorf("unable to create topic, broker: %s, topic: %s, err: %w", c.brokers, topic, err)
+	}
+	c.knownTopics[writerID] = true
+	return nil
+}
+
+func newTopicCreator(brokers []string, saslMechanism sasl.Mechanism, tlsConfig *tls.Config, topicConfig [][2]string, dial func(ctx context.Context, network string, address string) (net.Conn, error)) *topicCreator {
+	return &topicCreator{
+		brokers:       brokers,
+		saslMechanism: saslMechanism,
+		tlsConfig:     tlsConfig,
+		topicConfig:   topicConfig,
+
+		mutex:       sync.Mutex{},
+		knownTopics: make(map[string]bool),
+
+		dial: dial,
+	}
+}
@@ -0,0 +1,219 @@
+package writer
+
+import (
+	"context"
+	"crypto/tls"
+	"hash/fnv"
+	"net"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	serializer "github.com/transferia/transferia/pkg/serializer/queue"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	franzsasl "github.com/twmb/franz-go/pkg/sasl"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const transactionTimeout = 10 * time.Minute // should be greater than the sink push timeout
+
+var _ AbstractTransactionalWriter = (*TransactionalWriter)(nil)
+
+// TransactionalWriter writes messages by franz-go producer with 'transactional.id' set.
+//
+// Initialization of the producer with the same 'transactional.id' fences the previous one:
+// its open transaction is aborted & all its further writes are rejected by brokers,
+// so a zombie worker left after a restart can't produce duplicates
+type TransactionalWriter struct {
+	topics          *topicCreator
+	transactionalID string
+
+	client *kgo.Client
+}
+
+func (w *TransactionalWriter) BeginTransaction() error {
+	if err := w.client.BeginTransaction(); err != nil {
+		return xerrors.Errorf("unable to begin transaction, transactional id: %s, err: %w", w.transactionalID, err)
+	}
+	return nil
+}
+
+func (w *TransactionalWriter) WriteMessages(ctx context.Context, lgr log.Logger, topicName string, currMessages []serializer.SerializedMessage) error {
+	err := w.topics.ensureTopicExists(lgr, topicName)
+	if err != nil {
+		return xerrors.Errorf("unable to ensureTopicExists, topicName: %s, err: %w", topicName, err)
+	}
+
+	records := make([]*kgo.Record, 0, len(currMessages))
+	for _, msg := range currMessages {
+		records = append(records, &kgo.Record{Key: msg.Key, Value: msg.Value, Topic: topicName, Headers: franzHeaders(msg.Headers)})
+	}
+	if err := w.client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+		return xerrors.Errorf("unable to write messages, topicName: %s, messages: %d : %w", topicName, len(currMessages), err)
+	}
+	return nil
+}
+
+// CommitOffsets adds offsets of the consumer group into the current transaction (the same as SendOffsetsToTransaction of java client),
+// 'offsets' are the next offsets to consume by topic & partition.
+// Offsets are committed as the given member of the group, so a member fenced by a rebalance can't commit them
+func (w *TransactionalWriter) CommitOffsets(ctx context.Context, member GroupMember, offsets map[string]map[int32]int64) error {
+	producerID, producerEpoch, err := w.client.ProducerID(ctx)
+	if err != nil {
+		return xerrors.Errorf("unable to get producer id: %w", err)
+	}
+
+	addReq := kmsg.NewPtrAddOffsetsToTxnRequest()
+	addReq.TransactionalID = w.transactionalID
+	addReq.ProducerID = producerID
+	addReq.ProducerEpoch = producerEpoch
+	addReq.Group = member.Group
+	addResp, err := addReq.RequestWith(ctx, w.client)
+	if err != nil {
+		return xerrors.Errorf("unable to add offsets to transaction: %w", err)
+	}
+	if err := kerr.ErrorForCode(addResp.ErrorCode); err != nil {
+		return xerrors.Errorf("unable to add offsets to transaction: %w", err)
+	}
+
+	commitReq := kmsg.NewPtrTxnOffsetCommitRequest()
+	commitReq.TransactionalID = w.transactionalID
+	commitReq.Group = member.Group
+	commitReq.ProducerID = producerID
+	commitReq.ProducerEpoch = producerEpoch
+	commitReq.Generation = member.Generation
+	commitReq.MemberID = member.MemberID
+	for topic, partitions := range offsets {
+		reqTopic := kmsg.NewTxnOffsetCommitRequestTopic()
+		reqTopic.Topic = topic
+		for partition, offset := range partitions {
+			reqPartition := kmsg.NewTxnOffsetCommitRequestTopicPartition()
+			reqPartition.Partition = partition
+			reqPartition.Offset = offset
+			reqPartition.LeaderEpoch = -1
+			reqTopic.Partitions = append(reqTopic.Partitions, reqPartition)
+		}
+		commitReq.Topics = append(commitReq.Topics, reqTopic)
+	}
+	commitResp, err := commitReq.RequestWith(ctx, w.client)
+	if err != nil {
+		return xerrors.Errorf("unable to commit offsets in transaction: %w", err)
+	}
+	for _, topic := range commitResp.Topics {
+		for _, partition := range topic.Partitions {
+			if err := kerr.ErrorForCode(partition.ErrorCode); err != nil {
+				return xerrors.Errorf("unable to commit offset in transaction, topic: %s, partition: %d, err: %w", topic.Topic, partition.Partition, err)
+			}
+		}
+	}
+	return nil
+}
+
+// EndTransaction commits or aborts the current transaction, records not yet written are dropped on abort
+func (w *TransactionalWriter) EndTransaction(ctx context.Context, commit bool) error {
+	if !commit {
+		if err := w.client.AbortBufferedRecords(ctx); err != nil {
+			return xerrors.Errorf("unable to abort buffered records: %w", err)
+		}
+	}
+	if err := w.client.EndTransaction(ctx, kgo.TransactionEndTry(commit)); err != nil {
+		return xerrors.Errorf("unable to end transaction, commit: %v, err: %w", commit, err)
+	}
+	return nil
+}
+
+func (w *TransactionalWriter) Close() error {
+	w.client.Close()
+	return nil
+}
+
+func franzHeaders(headers []serializer.Header) []kgo.RecordHeader {
+	if len(headers) == 0 {
+		return nil
+	}
+	result := make([]kgo.RecordHeader, 0, len(headers))
+	for _, header := range headers {
+		result = append(result, kgo.RecordHeader{Key: header.Key, Value: header.Value})
+	}
+	return result
+}
+
+func franzCompression(compression kafka.Compression) kgo.CompressionCodec {
+	switch compression {
+	case kafka.Gzip:
+		return kgo.GzipCompression()
+	case kafka.Snappy:
+		return kgo.SnappyCompression()
+	case kafka.Lz4:
+		return kgo.Lz4Compression()
+	case kafka.Zstd:
+		return kgo.ZstdCompression()
+	default:
+		return kgo.NoCompression()
+	}
+}
+
+// fnv32a - the hash of the kafka.Hash balancer, so keys land into the same partitions as with non-transactional writer
+func fnv32a(key []byte) uint32 {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write(key)
+	return hasher.Sum32()
+}
+
+func NewTransactionalWriter(
+	brokers []string,
+	compression kafka.Compression,
+	saslMechanism sasl.Mechanism,
+	franzSaslMechanism franzsasl.Mechanism,
+	tlsConfig *tls.Config,
+	topicConfig [][2]string,
+	batchBytes int64,
+	dial func(ctx context.Context, network string, address string) (net.Conn, error),
+	transactionalID string,
+) (*TransactionalWriter, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.TransactionalID(transactionalID),
+		kgo.TransactionTimeout(transactionTimeout),
+		kgo.RecordPartitioner(kgo.StickyKeyPartitioner(kgo.SaramaCompatHasher(fnv32a))),
+		kgo.ProducerBatchCompression(franzCompression(compression)),
+	}
+	if batchBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(int32(batchBytes)))
+	}
+	if franzSaslMechanism != nil {
+		opts = append(opts, kgo.SASL(franzSaslMechanism))
+	}
+	switch {
+	case dial != nil:
+		opts = append(opts, kgo.Dialer(func(ctx context.Context, network, host string) (net.Conn, error) {
+			conn, err := dial(ctx, network, host)
+			if err != nil || tlsConfig == nil {
+				return conn, err
+			}
+			cfg := tlsConfig.Clone()
+			if cfg.ServerName == "" {
+				if serverName, _, err := net.SplitHostPort(host); err == nil {
+					cfg.ServerName = serverName
+				}
+			}
+			return tls.Client(conn, cfg), nil
+		}))
+	case tlsConfig != nil:
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to create kafka client: %w", err)
+	}
+	return &TransactionalWriter{
+		topics:          newTopicCreator(brokers, saslMechanism, tlsConfig, topicConfig, dial),
+		transactionalID: transactionalID,
+
+		client: client,
+	}, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl"
+	franzsasl "github.com/twmb/franz-go/pkg/sasl"
 	"go.ytsaurus.tech/library/go/core/log"
 )
 
@@ -23,3 +24,7 @@ func NewWriterFactory(lgr log.Logger) *WriterFactory {
 func (c *WriterFactory) BuildWriter(brokers []string, compression kafka.Compression, saslMechanism sasl.Mechanism, tlsConfig *tls.Config, topicConfig [][2]string, batchBytes int64, dial func(ctx context.Context, network string, address string) (net.Conn, error)) AbstractWriter {
 	return NewWriter(brokers, compression, saslMechanism, tlsConfig, topicConfig, batchBytes, dial)
 }
+
+func (c *WriterFactory) BuildTransactionalWriter(brokers []string, compression kafka.Compression, saslMechanism sasl.Mechanism, franzSaslMechanism franzsasl.Mechanism, tlsConfig *tls.Config, topicConfig [][2]string, batchBytes int64, dial func(ctx context.Context, network string, address string) (net.Conn, error), transactionalID string) (AbstractTransactionalWriter, error) {
+	return NewTransactionalWriter(brokers, compression, saslMechanism, franzSaslMechanism, tlsConfig, topicConfig, batchBytes, dial, transactionalID)
+}
@@ -16,6 +16,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	kafka_go "github.com/segmentio/kafka-go"
 	sasl "github.com/segmentio/kafka-go/sasl"
+	sasl0 "github.com/twmb/franz-go/pkg/sasl"
 	net "net"
 	reflect "reflect"
 )
@@ -71,6 +72,99 @@ func (mr *MockAbstractWriterMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockAbstractWriter)(nil).Close))
 }
 
+// MockAbstractTransactionalWriter is a mock of AbstractTransactionalWriter interface
+type MockAbstractTransactionalWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockAbstractTransactionalWriterMockRecorder
+}
+
+// MockAbstractTransactionalWriterMockRecorder is the mock recorder for MockAbstractTransactionalWriter
+type MockAbstractTransactionalWriterMockRecorder struct {
+	mock *MockAbstractTransactionalWriter
+}
+
+// NewMockAbstractTransactionalWriter creates a new mock instance
+func NewMockAbstractTransactionalWriter(ctrl *gomock.Controller) *MockAbstractTransactionalWriter {
+	mock := &MockAbstractTransactionalWriter{ctrl: ctrl}
+	mock.recorder = &MockAbstractTransactionalWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAbstractTransactionalWriter) EXPECT() *MockAbstractTransactionalWriterMockRecorder {
+	return m.recorder
+}
+
+// WriteMessages mocks base method
+func (m *MockAbstractTransactionalWriter) WriteMessages(ctx context.Context, lgr log.Logger, topicName string, currMessages []queue.SerializedMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteMessages", ctx, lgr, topicName, currMessages)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteMessages indicates an expected call of WriteMessages
+func (mr *MockAbstractTransactionalWriterMockRecorder) WriteMessages(ctx, lgr, topicName, currMessages interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteMessages", reflect.TypeOf((*MockAbstractTransactionalWriter)(nil).WriteMessages), ctx, lgr, topicName, currMessages)
+}
+
+// Close mocks base method
+func (m *MockAbstractTransactionalWriter) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close
+func (mr *MockAbstractTransactionalWriterMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockAbstractTransactionalWriter)(nil).Close))
+}
+
+// BeginTransaction mocks base method
+func (m *MockAbstractTransactionalWriter) BeginTransaction() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTransaction")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BeginTransaction indicates an expected call of BeginTransaction
+func (mr *MockAbstractTransactionalWriterMockRecorder) BeginTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTransaction", reflect.TypeOf((*MockAbstractTransactionalWriter)(nil).BeginTransaction))
+}
+
+// CommitOffsets mocks base method
+func (m *MockAbstractTransactionalWriter) CommitOffsets(ctx context.Context, member GroupMember, offsets map[string]map[int32]int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitOffsets", ctx, member, offsets)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitOffsets indicates an expected call of CommitOffsets
+func (mr *MockAbstractTransactionalWriterMockRecorder) CommitOffsets(ctx, member, offsets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitOffsets", reflect.TypeOf((*MockAbstractTransactionalWriter)(nil).CommitOffsets), ctx, member, offsets)
+}
+
+// EndTransaction mocks base method
+func (m *MockAbstractTransactionalWriter) EndTransaction(ctx context.Context, commit bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndTransaction", ctx, commit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EndTransaction indicates an expected call of EndTransaction
+func (mr *MockAbstractTransactionalWriterMockRecorder) EndTransaction(ctx, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndTransaction", reflect.TypeOf((*MockAbstractTransactionalWriter)(nil).EndTransaction), ctx, commit)
+}
+
 // MockAbstractWriterFactory is a mock of AbstractWriterFactory interface
 type MockAbstractWriterFactory struct {
 	ctrl     *gomock.Controller
@@ -107,3 +201,18 @@ func (mr *MockAbstractWriterFactoryMockRecorder) BuildWriter(brokers, compressio
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildWriter", reflect.TypeOf((*MockAbstractWriterFactory)(nil).BuildWriter), brokers, compression, saslMechanism, tlsConfig, topicConfig, batchBytes, dial)
 }
+
+// BuildTransactionalWriter mocks base method
+func (m *MockAbstractWriterFactory) BuildTransactionalWriter(brokers []string, compression kafka_go.Compression, saslMechanism sasl.Mechanism, franzSaslMechanism sasl0.Mechanism, tlsConfig *tls.Config, topicConfig [][2]string, batchBytes int64, dial func(context.Context, string, string) (net.Conn, error), transactionalID string) (AbstractTransactionalWriter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildTransactionalWriter", brokers, compression, saslMechanism, franzSaslMechanism, tlsConfig, topicConfig, batchBytes, dial, transactionalID)
+	ret0, _ := ret[0].(AbstractTransactionalWriter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildTransactionalWriter indicates an expected call of BuildTransactionalWriter
+func (mr *MockAbstractWriterFactoryMockRecorder) BuildTransactionalWriter(brokers, compression, saslMechanism, franzSaslMechanism, tlsConfig, topicConfig, batchBytes, dial, transactionalID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildTransactionalWriter", reflect.TypeOf((*MockAbstractWriterFactory)(nil).BuildTransactionalWriter), brokers, compression, saslMechanism, franzSaslMechanism, tlsConfig, topicConfig, batchBytes, dial, transactionalID)
+}
@@ -0,0 +1,222 @@
+package mssql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const captureInstancePrefix = "transferia_"
+
+const captureInstancesQuery = `
+SELECT s.name, t.name, ct.capture_instance
+FROM cdc.change_tables ct
+JOIN sys.tables t ON t.object_id = ct.source_object_id
+JOIN sys.schemas s ON s.schema_id = t.schema_id
+ORDER BY ct.create_date`
+
+const capturedColumnsQuery = `
+SELECT cc.column_name
+FROM cdc.captured_columns cc
+JOIN cdc.change_tables ct ON ct.object_id = cc.object_id
+WHERE ct.capture_instance = @p1`
+
+// captureStartTimeout limits waiting for the capture job to establish start LSNs of new capture instances
+const captureStartTimeout = 5 * time.Minute
+
+// captureInstanceName is the name of capture instances created by the transfer, SQL Server limits it to 100 characters.
+// The transfer ID is a part of the name, so transfers of the same table never disable capture instances of each other
+func captureInstanceName(transferID string, tID abstract.TableID) string {
+	name := captureInstancePrefix + transferID + "_" + tID.Namespace + "_" + tID.Name
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return name
+}
+
+func cdcEnabled(ctx context.Context, db *sql.DB) (bool, error) {
+	var enabled bool
+	if err := db.QueryRowContext(ctx, `SELECT is_cdc_enabled FROM sys.databases WHERE name = DB_NAME()`).Scan(&enabled); err != nil {
+		return false, xerrors.Errorf("unable to check change data capture of database: %w", err)
+	}
+	return enabled, nil
+}
+
+// captureInstanceRank orders capture instances of a table: the one created by the transfer is preferred,
+// then the one created by users, then the ones created by other transfers
+func captureInstanceRank(transferID string, tID abstract.TableID, instance string) int {
+	switch {
+	case instance == captureInstanceName(transferID, tID):
+		return 2
+	case !strings.HasPrefix(instance, captureInstancePrefix):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// captureInstances maps tables onto capture instances read by the transfer. A table may have 2 capture instances,
+// they are picked by captureInstanceRank, the latest one wins among equal ones
+func captureInstances(ctx context.Context, db *sql.DB, transferID string) (map[abstract.TableID]string, error) {
+	result := make(map[abstract.TableID]string)
+	enabled, err := cdcEnabled(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return result, nil
+	}
+	rows, err := db.QueryContext(ctx, captureInstancesQuery)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to query capture instances: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tID abstract.TableID
+		var instance string
+		if err := rows.Scan(&tID.Namespace, &tID.Name, &instance); err != nil {
+			return nil, xerrors.Errorf("unable to scan capture instance: %w", err)
+		}
+		if current, ok := result[tID]; ok && captureInstanceRank(transferID, tID, current) > captureInstanceRank(transferID, tID, instance) {
+			continue
+		}
+		result[tID] = instance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read capture instances: %w", err)
+	}
+	return result, nil
+}
+
+func capturedColumns(ctx context.Context, db *sql.DB, instance string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, capturedColumnsQuery, instance)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to query captured columns: %w", err)
+	}
+	defer rows.Close()
+	result := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, xerrors.Errorf("unable to scan captured column: %w", err)
+		}
+		result[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read captured columns: %w", err)
+	}
+	return result, nil
+}
+
+// EnableCDC enables change data capture on the database and creates capture instances of the transfer
+// on tables captured neither by the transfer nor by users
+func EnableCDC(ctx context.Context, db *sql.DB, transferID string, tables []abstract.TableID, logger log.Logger) error {
+	enabled, err := cdcEnabled(ctx, db)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		logger.Info("enabling change data capture of database")
+		if _, err := db.ExecContext(ctx, `EXEC sys.sp_cdc_enable_db`); err != nil {
+			return xerrors.Errorf("unable to enable change data capture of database: %w", err)
+		}
+	}
+	instances, err := captureInstances(ctx, db, transferID)
+	if err != nil {
+		return xerrors.Errorf("unable to get capture instances: %w", err)
+	}
+	for _, tID := range tables {
+		if instance, ok := instances[tID]; ok && captureInstanceRank(transferID, tID, instance) > 0 {
+			logger.Info("change data capture is already enabled", log.String("table", tID.Fqtn()), log.String("capture_instance", instance))
+			continue
+		}
+		instance := captureInstanceName(transferID, tID)
+		logger.Info("enabling change data capture", log.String("table", tID.Fqtn()), log.String("capture_instance", instance))
+		if _, err := db.ExecContext(ctx,
+			`EXEC sys.sp_cdc_enable_table @source_schema = @p1, @source_name = @p2, @role_name = NULL, @capture_instance = @p3, @supports_net_changes = 0`,
+			tID.Namespace, tID.Name, instance,
+		); err != nil {
+			return xerrors.Errorf("unable to enable change data capture of table %s: %w", tID.Fqtn(), err)
+		}
+	}
+	return nil
+}
+
+// DisableCDC removes capture instances created by the transfer, ones created by users or other transfers are kept
+func DisableCDC(ctx context.Context, db *sql.DB, transferID string, include func(tID abstract.TableID) bool, logger log.Logger) error {
+	instances, err := captureInstances(ctx, db, transferID)
+	if err != nil {
+		return xerrors.Errorf("unable to get capture instances: %w", err)
+	}
+	for tID, instance := range instances {
+		if !include(tID) || instance != captureInstanceName(transferID, tID) {
+			continue
+		}
+		logger.Info("disabling change data capture", log.String("table", tID.Fqtn()), log.String("capture_instance", instance))
+		if _, err := db.ExecContext(ctx,
+			`EXEC sys.sp_cdc_disable_table @source_schema = @p1, @source_name = @p2, @capture_instance = @p3`,
+			tID.Namespace, tID.Name, instance,
+		); err != nil {
+			return xerrors.Errorf("unable to disable change data capture of table %s: %w", tID.Fqtn(), err)
+		}
+	}
+	return nil
+}
+
+// WaitCaptureStarted waits until capture instances of the tables get start LSNs. The capture job sets the start LSN
+// of a capture instance after it is created, changes before the start LSN are never captured
+func WaitCaptureStarted(ctx context.Context, db *sql.DB, transferID string, tables []abstract.TableID, logger log.Logger) error {
+	instances, err := captureInstances(ctx, db, transferID)
+	if err != nil {
+		return xerrors.Errorf("unable to get capture instances: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, captureStartTimeout)
+	defer cancel()
+	for _, tID := range tables {
+		instance, ok := instances[tID]
+		if !ok {
+			continue
+		}
+		for {
+			var startLSN []byte
+			if err := db.QueryRowContext(ctx, `SELECT start_lsn FROM cdc.change_tables WHERE capture_instance = @p1`, instance).Scan(&startLSN); err != nil {
+				return xerrors.Errorf("unable to get start LSN of capture instance %s: %w", instance, err)
+			}
+			if startLSN != nil {
+				break
+			}
+			logger.Info("waiting for the capture job to start capture instance", log.String("capture_instance", instance))
+			select {
+			case <-ctx.Done():
+				return xerrors.Errorf("capture instance %s has not started in %v, check that SQL Server Agent is running: %w", instance, captureStartTimeout, ctx.Err())
+			case <-time.After(time.Second):
+			}
+		}
+	}
+	return nil
+}
+
+// createdAfter checks whether the capture instance is created after the transaction of the LSN
+func createdAfter(ctx context.Context, db *sql.DB, instance string, lsn LSN) (bool, error) {
+	var result bool
+	if err := db.QueryRowContext(ctx,
+		`SELECT CASE WHEN create_date > sys.fn_cdc_map_lsn_to_time(@p2) THEN 1 ELSE 0 END FROM cdc.change_tables WHERE capture_instance = @p1`,
+		instance, []byte(lsn),
+	).Scan(&result); err != nil {
+		return false, xerrors.Errorf("unable to get creation time of capture instance %s: %w", instance, err)
+	}
+	return result, nil
+}
+
+func maxLSN(ctx context.Context, db *sql.DB) (LSN, error) {
+	var result []byte
+	if err := db.QueryRowContext(ctx, `SELECT sys.fn_cdc_get_max_lsn()`).Scan(&result); err != nil {
+		return nil, xerrors.Errorf("unable to get max LSN: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package mssql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+)
+
+func connectionConfig(src *MSSQLSource) (msdsn.Config, error) {
+	query := url.Values{}
+	query.Set("database", src.Database)
+	query.Set("app name", "transferia")
+	query.Set("encrypt", "disable")
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(src.User, string(src.Password)),
+		Host:     fmt.Sprintf("%s:%d", src.Host, src.Port),
+		RawQuery: query.Encode(),
+	}
+	cfg, err := msdsn.Parse(dsn.String())
+	if err != nil {
+		return msdsn.Config{}, xerrors.Errorf("unable to parse connection string: %w", err)
+	}
+	if src.TLSFile != "" {
+		cp := x509.NewCertPool()
+		if !cp.AppendCertsFromPEM([]byte(src.TLSFile)) {
+			return msdsn.Config{}, xerrors.New("credentials: failed to append certificates")
+		}
+		cfg.Encryption = msdsn.EncryptionRequired
+		cfg.TLSConfig = &tls.Config{
+			RootCAs:    cp,
+			ServerName: src.Host,
+		}
+	}
+	return cfg, nil
+}
+
+func openDB(src *MSSQLSource) (*sql.DB, error) {
+	cfg, err := connectionConfig(src)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to build connection config: %w", err)
+	}
+	db := sql.OpenDB(mssql.NewConnectorConfig(cfg))
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, xerrors.Errorf("unable to connect to %s:%d: %w", src.Host, src.Port, err)
+	}
+	return db, nil
+}
+
+func quote(identifier string) string {
+	return "[" + strings.ReplaceAll(identifier, "]", "]]") + "]"
+}
+
+func quoteTable(tID abstract.TableID) string {
+	return quote(tID.Namespace) + "." + quote(tID.Name)
+}
@@ -0,0 +1,82 @@
+package mssql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/util"
+)
+
+const lsnStateKey = "mssql_lsn"
+
+// LSN is the 10 bytes log sequence number used by change data capture,
+// LSNs are compared as big endian byte strings
+type LSN []byte
+
+func (l LSN) String() string {
+	return hex.EncodeToString(l)
+}
+
+func (l LSN) Less(other LSN) bool {
+	return bytes.Compare(l, other) < 0
+}
+
+// Uint64 packs the LSN into change items, the 2 leading bytes of the virtual log file number are dropped
+func (l LSN) Uint64() uint64 {
+	if len(l) != 10 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(l[2:])
+}
+
+func ParseLSN(s string) (LSN, error) {
+	result, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to decode LSN %s: %w", s, err)
+	}
+	if len(result) != 10 {
+		return nil, xerrors.Errorf("LSN %s should be 10 bytes", s)
+	}
+	return result, nil
+}
+
+type lsnState struct {
+	LSN string
+}
+
+// storeLSN saves the position all changes up to which are pushed
+func storeLSN(cp coordinator.Coordinator, transferID string, lsn LSN) error {
+	if err := cp.SetTransferState(transferID, map[string]*coordinator.TransferStateData{
+		lsnStateKey: {Generic: lsnState{LSN: lsn.String()}},
+	}); err != nil {
+		return xerrors.Errorf("unable to set transfer state: %w", err)
+	}
+	return nil
+}
+
+// loadLSN returns nil if the position is unknown, then changes are read from the beginning of change tables
+func loadLSN(cp coordinator.Coordinator, transferID string) (LSN, error) {
+	state, err := cp.GetTransferState(transferID)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get transfer state: %w", err)
+	}
+	value, ok := state[lsnStateKey]
+	if !ok || value.GetGeneric() == nil {
+		return nil, nil
+	}
+	var result lsnState
+	if err := util.MapFromJSON(value.Generic, &result); err != nil {
+		return nil, xerrors.Errorf("unable to unmarshal transfer state: %w", err)
+	}
+	if result.LSN == "" {
+		return nil, nil
+	}
+	return ParseLSN(result.LSN)
+}
+
+func removeLSN(cp coordinator.Coordinator, transferID string) error {
+	return cp.RemoveTransferState(transferID, []string{lsnStateKey})
+}
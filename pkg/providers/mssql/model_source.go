@@ -0,0 +1,93 @@
+package mssql
+
+import (
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+const ProviderType = abstract.ProviderType("mssql")
+
+type MSSQLSource struct {
+	Host     string
+	Port     int
+	User     string
+	Password model.SecretString
+	Database string
+	// TLSFile is the PEM encoded CA certificate, connections are encrypted if it is set
+	TLSFile string `model:"PemFileContent"`
+	// IncludeTables & ExcludeTables are `schema.table` or `schema.*` names, all user tables are transferred by default
+	IncludeTables []string
+	ExcludeTables []string
+	// DesiredShardRows - tables with more rows are split into primary key ranges of about this size, loaded in parallel
+	DesiredShardRows uint64
+	// PollInterval is the delay between reads of change tables when there are no new changes
+	PollInterval time.Duration
+	// MaxTransactionsPerPoll limits the number of transactions read from change tables at once
+	MaxTransactionsPerPoll int
+	// KeepCDC leaves change data capture enabled on tables when the transfer is deactivated
+	KeepCDC bool
+}
+
+var _ model.Source = (*MSSQLSource)(nil)
+
+func (s *MSSQLSource) WithDefaults() {
+	if s.Port == 0 {
+		s.Port = 1433
+	}
+	if s.DesiredShardRows == 0 {
+		s.DesiredShardRows = 1000000
+	}
+	if s.PollInterval == 0 {
+		s.PollInterval = time.Second
+	}
+	if s.MaxTransactionsPerPoll == 0 {
+		s.MaxTransactionsPerPoll = 1000
+	}
+}
+
+func (MSSQLSource) IsSource() {}
+
+func (s *MSSQLSource) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (s *MSSQLSource) Validate() error {
+	if s.Host == "" {
+		return xerrors.New("host is required")
+	}
+	if s.Database == "" {
+		return xerrors.New("database is required")
+	}
+	for _, name := range append(append([]string{}, s.IncludeTables...), s.ExcludeTables...) {
+		if _, _, ok := strings.Cut(name, "."); !ok {
+			return xerrors.Errorf("table %s should be in the form schema.table or schema.*", name)
+		}
+	}
+	return nil
+}
+
+func (s *MSSQLSource) Include(tID abstract.TableID) bool {
+	for _, name := range s.ExcludeTables {
+		if matchTable(name, tID) {
+			return false
+		}
+	}
+	if len(s.IncludeTables) == 0 {
+		return true
+	}
+	for _, name := range s.IncludeTables {
+		if matchTable(name, tID) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchTable(name string, tID abstract.TableID) bool {
+	schema, table, _ := strings.Cut(name, ".")
+	return schema == tID.Namespace && (table == "*" || table == tID.Name)
+}
@@ -0,0 +1,25 @@
+package mssqlrecipe
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/providers/mssql"
+)
+
+func RecipeSource() *mssql.MSSQLSource {
+	PrepareContainer(context.Background())
+	port, _ := strconv.Atoi(os.Getenv("RECIPE_MSSQL_PORT"))
+	src := new(mssql.MSSQLSource)
+	src.Host = os.Getenv("RECIPE_MSSQL_HOST")
+	src.Port = port
+	src.User = os.Getenv("RECIPE_MSSQL_USER")
+	src.Password = model.SecretString(os.Getenv("RECIPE_MSSQL_PASSWORD"))
+	src.Database = os.Getenv("RECIPE_MSSQL_DATABASE")
+	src.PollInterval = 100 * time.Millisecond
+	src.WithDefaults()
+	return src
+}
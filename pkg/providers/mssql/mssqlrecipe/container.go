@@ -0,0 +1,111 @@
+package mssqlrecipe
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+
+	_ "github.com/microsoft/go-mssqldb"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/tests/tcrecipes"
+)
+
+const (
+	defaultUser     = "sa"
+	defaultPassword = "P@ssw0rd1"
+	defaultVersion  = "mcr.microsoft.com/mssql/server:2022-latest"
+	SourceDB        = "source"
+)
+
+// MSSQLContainer represents the SQL Server container with SQL Server Agent running, which is required by change data capture
+type MSSQLContainer struct {
+	testcontainers.Container
+}
+
+func PrepareContainer(ctx context.Context) {
+	if _, ok := os.LookupEnv("RECIPE_MSSQL_HOST"); ok {
+		return // container already initialized
+	}
+	if tcrecipes.Enabled() {
+		_, err := Run(ctx, defaultVersion)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Run creates an instance of the SQL Server container with the source database
+func Run(ctx context.Context, img string, opts ...testcontainers.ContainerCustomizer) (*MSSQLContainer, error) {
+	req := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image: img,
+			Env: map[string]string{
+				"ACCEPT_EULA":         "Y",
+				"MSSQL_SA_PASSWORD":   defaultPassword,
+				"MSSQL_AGENT_ENABLED": "true",
+			},
+			ExposedPorts: []string{"1433/tcp"},
+			WaitingFor:   wait.ForLog("SQL Server is now ready for client connections"),
+		},
+		Started: true,
+	}
+	for _, opt := range opts {
+		if err := opt.Customize(&req); err != nil {
+			return nil, xerrors.Errorf("unable to customize container: %w", err)
+		}
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, req)
+	if err != nil {
+		return nil, xerrors.Errorf("generic container: %w", err)
+	}
+	port, err := container.MappedPort(ctx, "1433/tcp")
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get mssql port: %w", err)
+	}
+	host, err := container.Host(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get mssql host: %w", err)
+	}
+
+	for key, value := range map[string]string{
+		"RECIPE_MSSQL_HOST":     host,
+		"RECIPE_MSSQL_PORT":     port.Port(),
+		"RECIPE_MSSQL_USER":     defaultUser,
+		"RECIPE_MSSQL_PASSWORD": defaultPassword,
+	} {
+		if err := os.Setenv(key, value); err != nil {
+			return nil, xerrors.Errorf("unable to set %s env: %w", key, err)
+		}
+	}
+	if err := Exec("master", fmt.Sprintf("CREATE DATABASE %s", SourceDB)); err != nil {
+		return nil, xerrors.Errorf("unable to create database: %w", err)
+	}
+	if err := os.Setenv("RECIPE_MSSQL_DATABASE", SourceDB); err != nil {
+		return nil, xerrors.Errorf("unable to set RECIPE_MSSQL_DATABASE env: %w", err)
+	}
+	return &MSSQLContainer{Container: container}, nil
+}
+
+// Exec runs the batch in the database of the recipe
+func Exec(database string, query string) error {
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(os.Getenv("RECIPE_MSSQL_USER"), os.Getenv("RECIPE_MSSQL_PASSWORD")),
+		Host:     fmt.Sprintf("%s:%s", os.Getenv("RECIPE_MSSQL_HOST"), os.Getenv("RECIPE_MSSQL_PORT")),
+		RawQuery: url.Values{"database": {database}, "encrypt": {"disable"}}.Encode(),
+	}
+	db, err := sql.Open("sqlserver", dsn.String())
+	if err != nil {
+		return xerrors.Errorf("unable to open database: %w", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(query); err != nil {
+		return xerrors.Errorf("unable to exec: %s: %w", query, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package mssql
+
+import (
+	"context"
+	"encoding/gob"
+
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/providers"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+func init() {
+	gob.RegisterName("*server.MSSQLSource", new(MSSQLSource))
+	model.RegisterSource(ProviderType, func() model.Source {
+		return new(MSSQLSource)
+	})
+	abstract.RegisterProviderName(ProviderType, "Microsoft SQL Server")
+	providers.Register(ProviderType, New)
+}
+
+// To verify providers contract implementation
+var (
+	_ providers.Snapshot    = (*Provider)(nil)
+	_ providers.Replication = (*Provider)(nil)
+	_ providers.Activator   = (*Provider)(nil)
+	_ providers.Deactivator = (*Provider)(nil)
+	_ providers.Cleanuper   = (*Provider)(nil)
+)
+
+type Provider struct {
+	logger   log.Logger
+	registry metrics.Registry
+	cp       coordinator.Coordinator
+	transfer *model.Transfer
+}
+
+func (p *Provider) Type() abstract.ProviderType {
+	return ProviderType
+}
+
+func (p *Provider) Storage() (abstract.Storage, error) {
+	src, ok := p.transfer.Src.(*MSSQLSource)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected source type: %T", p.transfer.Src)
+	}
+	return NewStorage(src, p.logger)
+}
+
+func (p *Provider) Source() (abstract.Source, error) {
+	src, ok := p.transfer.Src.(*MSSQLSource)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected source type: %T", p.transfer.Src)
+	}
+	return NewSource(src, p.transfer.ID, p.cp, p.registry, p.logger)
+}
+
+func (p *Provider) Activate(ctx context.Context, task *model.TransferOperation, tables abstract.TableMap, callbacks providers.ActivateCallbacks) error {
+	src, ok := p.transfer.Src.(*MSSQLSource)
+	if !ok {
+		return xerrors.Errorf("unexpected source type: %T", p.transfer.Src)
+	}
+	if !p.transfer.SnapshotOnly() {
+		if err := p.startCapture(ctx, src, tables); err != nil {
+			return xerrors.Errorf("unable to start change data capture: %w", err)
+		}
+	}
+	if !p.transfer.IncrementOnly() {
+		if err := callbacks.Cleanup(tables); err != nil {
+			return xerrors.Errorf("Sinker cleanup failed: %w", err)
+		}
+		if err := callbacks.CheckIncludes(tables); err != nil {
+			return xerrors.Errorf("Failed in accordance with configuration: %w", err)
+		}
+		if err := callbacks.Upload(tables); err != nil {
+			return xerrors.Errorf("Snapshot loading failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// startCapture enables change data capture on tables and stores the current LSN before the snapshot,
+// so changes made during the snapshot are replicated after it
+func (p *Provider) startCapture(ctx context.Context, src *MSSQLSource, tables abstract.TableMap) error {
+	db, err := openDB(src)
+	if err != nil {
+		return xerrors.Errorf("unable to open database: %w", err)
+	}
+	defer db.Close()
+	tableIDs := make([]abstract.TableID, 0, len(tables))
+	for tID := range tables {
+		tableIDs = append(tableIDs, tID)
+	}
+	if err := EnableCDC(ctx, db, p.transfer.ID, tableIDs, p.logger); err != nil {
+		return xerrors.Errorf("unable to enable change data capture: %w", err)
+	}
+	// the position must not precede start LSNs of new capture instances, otherwise their changes after it are not available
+	if err := WaitCaptureStarted(ctx, db, p.transfer.ID, tableIDs, p.logger); err != nil {
+		return xerrors.Errorf("unable to wait for change data capture: %w", err)
+	}
+	lsn, err := maxLSN(ctx, db)
+	if err != nil {
+		return xerrors.Errorf("unable to get current LSN: %w", err)
+	}
+	if lsn == nil {
+		// nothing is captured yet, so changes are read from the beginning of change tables
+		return removeLSN(p.cp, p.transfer.ID)
+	}
+	p.logger.Info("replication will start after LSN", log.String("lsn", lsn.String()))
+	return storeLSN(p.cp, p.transfer.ID, lsn)
+}
+
+func (p *Provider) Deactivate(ctx context.Context, task *model.TransferOperation) error {
+	if p.transfer.SnapshotOnly() {
+		return nil
+	}
+	src, ok := p.transfer.Src.(*MSSQLSource)
+	if !ok {
+		return xerrors.Errorf("unexpected source type: %T", p.transfer.Src)
+	}
+	if err := removeLSN(p.cp, p.transfer.ID); err != nil {
+		return xerrors.Errorf("unable to remove LSN: %w", err)
+	}
+	if src.KeepCDC {
+		return nil
+	}
+	db, err := openDB(src)
+	if err != nil {
+		return xerrors.Errorf("unable to open database: %w", err)
+	}
+	defer db.Close()
+	if err := DisableCDC(ctx, db, p.transfer.ID, src.Include, p.logger); err != nil {
+		return xerrors.Errorf("unable to disable change data capture: %w", err)
+	}
+	return nil
+}
+
+func (p *Provider) Cleanup(ctx context.Context, task *model.TransferOperation) error {
+	return removeLSN(p.cp, p.transfer.ID)
+}
+
+func New(lgr log.Logger, registry metrics.Registry, cp coordinator.Coordinator, transfer *model.Transfer) providers.Provider {
+	return &Provider{
+		logger:   lgr,
+		registry: registry,
+		cp:       cp,
+		transfer: transfer,
+	}
+}
@@ -0,0 +1,328 @@
+package mssql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/stats"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+// windowQuery finds the end of the next window of at most @p2 transactions after @p1
+const windowQuery = `
+SELECT MAX(start_lsn)
+FROM (SELECT TOP (@p2) start_lsn FROM cdc.lsn_time_mapping WHERE start_lsn > @p1 ORDER BY start_lsn) AS w`
+
+const (
+	operationDelete       = 1
+	operationInsert       = 2
+	operationUpdateBefore = 3
+	operationUpdateAfter  = 4
+)
+
+var zeroLSN = LSN(make([]byte, 10))
+
+// capture is a table tracked by a capture instance
+type capture struct {
+	table    abstract.TableID
+	instance string
+	schema   *abstract.TableSchema
+}
+
+// change is a row of a change table, changes are ordered by commit LSNs of transactions, then by seqval within transactions
+type change struct {
+	startLSN LSN
+	seqval   LSN
+	item     abstract.ChangeItem
+}
+
+// Source polls change tables of capture instances. Changes of all tables are read by windows
+// of transactions, sorted by LSNs and pushed, then the end of the window is stored in the transfer state
+type Source struct {
+	config     *MSSQLSource
+	db         *sql.DB
+	storage    *Storage
+	cp         coordinator.Coordinator
+	transferID string
+	logger     log.Logger
+	metrics    *stats.SourceStats
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+}
+
+var _ abstract.Source = (*Source)(nil)
+
+func (s *Source) Run(sink abstract.AsyncSink) error {
+	defer s.storage.Close()
+	position, err := loadLSN(s.cp, s.transferID)
+	if err != nil {
+		return xerrors.Errorf("unable to load LSN: %w", err)
+	}
+	s.logger.Info("starting replication", log.String("lsn", position.String()))
+	for {
+		next, err := s.poll(position, sink)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return nil
+			}
+			return xerrors.Errorf("unable to replicate changes after LSN %s: %w", position.String(), err)
+		}
+		if next == nil {
+			select {
+			case <-s.ctx.Done():
+				return nil
+			case <-time.After(s.config.PollInterval):
+			}
+			continue
+		}
+		if err := storeLSN(s.cp, s.transferID, next); err != nil {
+			return xerrors.Errorf("unable to store LSN: %w", err)
+		}
+		position = next
+	}
+}
+
+// poll pushes changes of the next window and returns its end, or nil if there are no changes
+func (s *Source) poll(position LSN, sink abstract.AsyncSink) (LSN, error) {
+	from := position
+	if from == nil {
+		from = zeroLSN
+	}
+	var to []byte
+	if err := s.db.QueryRowContext(s.ctx, windowQuery, []byte(from), s.config.MaxTransactionsPerPoll).Scan(&to); err != nil {
+		return nil, xerrors.Errorf("unable to get the next window: %w", err)
+	}
+	if to == nil {
+		return nil, nil
+	}
+
+	captures, err := s.captures()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get capture instances: %w", err)
+	}
+	var changes []change
+	for _, c := range captures {
+		tableChanges, err := s.readChanges(c, position, to)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to read changes of table %s: %w", c.table.Fqtn(), err)
+		}
+		changes = append(changes, tableChanges...)
+	}
+	slices.SortStableFunc(changes, func(a, b change) int {
+		if result := bytes.Compare(a.startLSN, b.startLSN); result != 0 {
+			return result
+		}
+		return bytes.Compare(a.seqval, b.seqval)
+	})
+
+	batch := make([]abstract.ChangeItem, 0, min(len(changes), chunkSize))
+	for i, c := range changes {
+		c.item.Counter = i
+		batch = append(batch, c.item)
+		if len(batch) == chunkSize || i == len(changes)-1 {
+			pushStart := time.Now()
+			if err := <-sink.AsyncPush(batch); err != nil {
+				return nil, xerrors.Errorf("unable to push changes: %w", err)
+			}
+			s.metrics.PushTime.RecordDuration(time.Since(pushStart))
+			batch = make([]abstract.ChangeItem, 0, chunkSize)
+		}
+	}
+	return to, nil
+}
+
+// captures lists capture instances of included tables along with schemas of captured columns
+func (s *Source) captures() ([]capture, error) {
+	instances, err := captureInstances(s.ctx, s.db, s.transferID)
+	if err != nil {
+		return nil, err
+	}
+	var result []capture
+	for tID, instance := range instances {
+		if !s.config.Include(tID) {
+			continue
+		}
+		tableSchema, err := s.storage.TableSchema(s.ctx, tID)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get schema of table %s: %w", tID.Fqtn(), err)
+		}
+		captured, err := capturedColumns(s.ctx, s.db, instance)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get captured columns of %s: %w", instance, err)
+		}
+		var columns []abstract.ColSchema
+		for _, column := range tableSchema.Columns() {
+			if captured[column.ColumnName] {
+				columns = append(columns, column)
+			} else if column.IsKey() {
+				return nil, xerrors.Errorf("primary key column %s of table %s is not captured by %s", column.ColumnName, tID.Fqtn(), instance)
+			}
+		}
+		result = append(result, capture{
+			table:    tID,
+			instance: instance,
+			schema:   abstract.NewTableSchema(columns),
+		})
+	}
+	return result, nil
+}
+
+// readChanges reads changes of the capture instance after the position up to the LSN inclusive
+func (s *Source) readChanges(c capture, position LSN, to LSN) ([]change, error) {
+	var minLSN []byte
+	if err := s.db.QueryRowContext(s.ctx, `SELECT sys.fn_cdc_get_min_lsn(@p1)`, c.instance).Scan(&minLSN); err != nil {
+		return nil, xerrors.Errorf("unable to get min LSN: %w", err)
+	}
+	from := LSN(minLSN)
+	if !zeroLSN.Less(from) {
+		// the capture job has not established the start LSN of the capture instance yet, so nothing is captured
+		return nil, nil
+	}
+	if position != nil {
+		var next []byte
+		if err := s.db.QueryRowContext(s.ctx, `SELECT sys.fn_cdc_increment_lsn(@p1)`, []byte(position)).Scan(&next); err != nil {
+			return nil, xerrors.Errorf("unable to increment LSN: %w", err)
+		}
+		if !LSN(next).Less(from) {
+			from = next
+		} else {
+			// the capture instance created after the position (e.g. of a table added to the transfer) is read from its start,
+			// otherwise changes after the position are removed by the cleanup job
+			created, err := createdAfter(s.ctx, s.db, c.instance, position)
+			if err != nil {
+				return nil, err
+			}
+			if !created {
+				return nil, abstract.NewFatalError(xerrors.Errorf(
+					"changes of capture instance %s after LSN %s are not available, the min LSN is %s, the transfer should be reactivated",
+					c.instance, position.String(), from.String(),
+				))
+			}
+		}
+	}
+	if to.Less(from) {
+		return nil, nil
+	}
+
+	columns := c.schema.Columns()
+	columnNames := make([]string, len(columns))
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		columnNames[i] = column.ColumnName
+		quoted[i] = quote(column.ColumnName)
+	}
+	query := fmt.Sprintf(
+		"SELECT __$start_lsn, __$seqval, __$operation, sys.fn_cdc_map_lsn_to_time(__$start_lsn), %s FROM cdc.%s(@p1, @p2, N'all update old') ORDER BY __$start_lsn, __$seqval, __$operation",
+		strings.Join(quoted, ", "), quote("fn_cdc_get_all_changes_"+c.instance),
+	)
+	rows, err := s.db.QueryContext(s.ctx, query, []byte(from), []byte(to))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to query changes: %w", err)
+	}
+	defer rows.Close()
+
+	var result []change
+	var before []interface{}
+	for rows.Next() {
+		var startLSN, seqval []byte
+		var operation int64
+		var commitTime time.Time
+		header := []interface{}{&startLSN, &seqval, &operation, &commitTime}
+		values, size, err := scanValues(rows, header, columns)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to scan change: %w", err)
+		}
+		s.metrics.ChangeItems.Inc()
+		s.metrics.Size.Add(int64(size))
+
+		item := abstract.ChangeItem{
+			ID:           0,
+			LSN:          LSN(startLSN).Uint64(),
+			CommitTime:   uint64(commitTime.UnixNano()),
+			Counter:      0,
+			Kind:         abstract.InsertKind,
+			Schema:       c.table.Namespace,
+			Table:        c.table.Name,
+			PartID:       "",
+			ColumnNames:  columnNames,
+			ColumnValues: values,
+			TableSchema:  c.schema,
+			OldKeys:      abstract.EmptyOldKeys(),
+			TxID:         LSN(startLSN).String(),
+			Query:        "",
+			Size:         abstract.RawEventSize(uint64(size)),
+		}
+		switch operation {
+		case operationUpdateBefore:
+			before = values
+			continue
+		case operationInsert:
+		case operationUpdateAfter:
+			item.Kind = abstract.UpdateKind
+			if before != nil {
+				item.OldKeys = oldKeys(columns, before)
+			}
+			before = nil
+		case operationDelete:
+			item.Kind = abstract.DeleteKind
+			item.ColumnNames = nil
+			item.ColumnValues = nil
+			item.OldKeys = oldKeys(columns, values)
+		default:
+			return nil, xerrors.Errorf("unknown operation %d", operation)
+		}
+		result = append(result, change{startLSN: startLSN, seqval: seqval, item: item})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read changes: %w", err)
+	}
+	return result, nil
+}
+
+func oldKeys(columns []abstract.ColSchema, values []interface{}) abstract.OldKeysType {
+	var result abstract.OldKeysType
+	for i, column := range columns {
+		if column.IsKey() {
+			result.KeyNames = append(result.KeyNames, column.ColumnName)
+			result.KeyTypes = append(result.KeyTypes, column.DataType)
+			result.KeyValues = append(result.KeyValues, values[i])
+		}
+	}
+	return result
+}
+
+func (s *Source) Stop() {
+	s.stopOnce.Do(s.cancel)
+}
+
+func NewSource(cfg *MSSQLSource, transferID string, cp coordinator.Coordinator, registry metrics.Registry, lgr log.Logger) (*Source, error) {
+	storage, err := NewStorage(cfg, lgr)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to create storage: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Source{
+		config:     cfg,
+		db:         storage.db,
+		storage:    storage,
+		cp:         cp,
+		transferID: transferID,
+		logger:     lgr,
+		metrics:    stats.NewSourceStats(registry),
+		ctx:        ctx,
+		cancel:     cancel,
+		stopOnce:   sync.Once{},
+	}, nil
+}
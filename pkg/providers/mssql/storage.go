@@ -0,0 +1,381 @@
+package mssql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"go.ytsaurus.tech/library/go/core/log"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+const chunkSize = 10000
+
+const tablesQuery = `
+SELECT s.name, t.name
+FROM sys.tables t
+JOIN sys.schemas s ON s.schema_id = t.schema_id
+WHERE t.is_ms_shipped = 0 AND s.name <> 'cdc'
+ORDER BY s.name, t.name`
+
+// columnsQuery resolves alias types into their base types,
+// key_ordinal is the 1-based position of the column in the primary key or 0
+const columnsQuery = `
+SELECT c.name,
+	CASE WHEN t.is_user_defined = 1 AND t.is_assembly_type = 0 THEN TYPE_NAME(t.system_type_id) ELSE t.name END,
+	c.max_length, c.precision, c.scale, c.is_nullable, ISNULL(ic.key_ordinal, 0)
+FROM sys.columns c
+JOIN sys.types t ON t.user_type_id = c.user_type_id
+LEFT JOIN sys.indexes i ON i.object_id = c.object_id AND i.is_primary_key = 1
+LEFT JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id AND ic.column_id = c.column_id
+WHERE c.object_id = OBJECT_ID(@p1)
+ORDER BY c.column_id`
+
+// rowsCountQuery reads the number of rows of the heap or the clustered index from the catalog
+const rowsCountQuery = `
+SELECT ISNULL(SUM(p.rows), 0)
+FROM sys.partitions p
+WHERE p.object_id = OBJECT_ID(@p1) AND p.index_id IN (0, 1)`
+
+type Storage struct {
+	config *MSSQLSource
+	db     *sql.DB
+	logger log.Logger
+}
+
+var (
+	_ abstract.Storage         = (*Storage)(nil)
+	_ abstract.ShardingStorage = (*Storage)(nil)
+)
+
+func (s *Storage) Close() {
+	if err := s.db.Close(); err != nil {
+		s.logger.Warn("unable to close database", log.Error(err))
+	}
+}
+
+func (s *Storage) Ping() error {
+	return s.db.Ping()
+}
+
+func (s *Storage) TableList(includeTableFilter abstract.IncludeTableList) (abstract.TableMap, error) {
+	tableIDs, err := s.tables(context.Background())
+	if err != nil {
+		return nil, xerrors.Errorf("unable to list tables: %w", err)
+	}
+	result := make(abstract.TableMap)
+	for _, tableID := range tableIDs {
+		tableSchema, err := s.TableSchema(context.Background(), tableID)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get schema of table %s: %w", tableID.Fqtn(), err)
+		}
+		rowsCount, err := s.EstimateTableRowsCount(tableID)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to count rows of table %s: %w", tableID.Fqtn(), err)
+		}
+		result[tableID] = abstract.TableInfo{
+			EtaRow: rowsCount,
+			IsView: false,
+			Schema: tableSchema,
+		}
+	}
+	return model.FilteredMap(result, includeTableFilter), nil
+}
+
+// tables lists user tables matching the source filter
+func (s *Storage) tables(ctx context.Context) ([]abstract.TableID, error) {
+	rows, err := s.db.QueryContext(ctx, tablesQuery)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to query tables: %w", err)
+	}
+	defer rows.Close()
+	var result []abstract.TableID
+	for rows.Next() {
+		var tableID abstract.TableID
+		if err := rows.Scan(&tableID.Namespace, &tableID.Name); err != nil {
+			return nil, xerrors.Errorf("unable to scan table name: %w", err)
+		}
+		if s.config.Include(tableID) {
+			result = append(result, tableID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read tables: %w", err)
+	}
+	return result, nil
+}
+
+// TableSchema is built from the catalog, primary key columns go first in the order of the key
+func (s *Storage) TableSchema(ctx context.Context, table abstract.TableID) (*abstract.TableSchema, error) {
+	rows, err := s.db.QueryContext(ctx, columnsQuery, quoteTable(table))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	var keys, columns []abstract.ColSchema
+	keyPositions := make(map[string]int64)
+	for rows.Next() {
+		var name, typeName string
+		var maxLength, precision, scale, keyOrdinal int64
+		var nullable bool
+		if err := rows.Scan(&name, &typeName, &maxLength, &precision, &scale, &nullable, &keyOrdinal); err != nil {
+			return nil, xerrors.Errorf("unable to scan column: %w", err)
+		}
+		column := abstract.MakeOriginallyTypedColSchema(name, string(columnType(typeName)), "mssql:"+fullType(typeName, maxLength, precision, scale))
+		column.TableSchema = table.Namespace
+		column.TableName = table.Name
+		column.PrimaryKey = keyOrdinal > 0
+		column.Required = !nullable
+		if keyOrdinal > 0 {
+			keyPositions[name] = keyOrdinal
+			keys = append(keys, column)
+		} else {
+			columns = append(columns, column)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read columns: %w", err)
+	}
+	if len(keys)+len(columns) == 0 {
+		return nil, xerrors.Errorf("table %s not found", table.Fqtn())
+	}
+	slices.SortFunc(keys, func(a, b abstract.ColSchema) int {
+		return int(keyPositions[a.ColumnName] - keyPositions[b.ColumnName])
+	})
+	return abstract.NewTableSchema(append(keys, columns...)), nil
+}
+
+// fullType restores the declared type from the catalog, lengths of n-types are stored in bytes
+func fullType(typeName string, maxLength, precision, scale int64) string {
+	length := func(size int64) string {
+		if maxLength == -1 {
+			return "max"
+		}
+		return fmt.Sprint(size)
+	}
+	switch typeName {
+	case "char", "varchar", "binary", "varbinary":
+		return fmt.Sprintf("%s(%s)", typeName, length(maxLength))
+	case "nchar", "nvarchar":
+		return fmt.Sprintf("%s(%s)", typeName, length(maxLength/2))
+	case "decimal", "numeric":
+		return fmt.Sprintf("%s(%d,%d)", typeName, precision, scale)
+	case "datetime2", "datetimeoffset", "time":
+		return fmt.Sprintf("%s(%d)", typeName, scale)
+	default:
+		return typeName
+	}
+}
+
+func (s *Storage) LoadTable(ctx context.Context, table abstract.TableDescription, pusher abstract.Pusher) error {
+	st := time.Now()
+	tableSchema, err := s.TableSchema(ctx, table.ID())
+	if err != nil {
+		return xerrors.Errorf("unable to get schema: %w", err)
+	}
+	columns := tableSchema.Columns()
+	columnNames := make([]string, len(columns))
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		columnNames[i] = column.ColumnName
+		quoted[i] = quote(column.ColumnName)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoted, ", "), quoteTable(table.ID()))
+	if table.Filter != "" {
+		query += " WHERE " + string(table.Filter)
+	}
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return xerrors.Errorf("unable to select rows: %w", err)
+	}
+	defer rows.Close()
+
+	batch := make([]abstract.ChangeItem, 0, chunkSize)
+	for rows.Next() {
+		values, size, err := scanValues(rows, nil, columns)
+		if err != nil {
+			return xerrors.Errorf("unable to scan row: %w", err)
+		}
+		batch = append(batch, abstract.ChangeItem{
+			ID:           0,
+			LSN:          0,
+			CommitTime:   uint64(st.UnixNano()),
+			Counter:      0,
+			Kind:         abstract.InsertKind,
+			Schema:       table.Schema,
+			Table:        table.Name,
+			PartID:       table.PartID(),
+			ColumnNames:  columnNames,
+			ColumnValues: values,
+			TableSchema:  tableSchema,
+			OldKeys:      abstract.EmptyOldKeys(),
+			TxID:         "",
+			Query:        "",
+			Size:         abstract.RawEventSize(uint64(size)),
+		})
+		if len(batch) == chunkSize {
+			if err := pusher(batch); err != nil {
+				return xerrors.Errorf("unable to push rows: %w", err)
+			}
+			batch = make([]abstract.ChangeItem, 0, chunkSize)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return xerrors.Errorf("unable to read rows: %w", err)
+	}
+	if len(batch) > 0 {
+		if err := pusher(batch); err != nil {
+			return xerrors.Errorf("unable to push rows: %w", err)
+		}
+	}
+	return nil
+}
+
+// scanValues reads the row into the header and values of transfer types of columns going after the header
+func scanValues(rows *sql.Rows, header []interface{}, columns []abstract.ColSchema) ([]interface{}, int, error) {
+	raw := make([]interface{}, len(columns))
+	pointers := append(header, make([]interface{}, len(columns))...)
+	for i := range raw {
+		pointers[len(header)+i] = &raw[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, 0, err
+	}
+	values := make([]interface{}, len(columns))
+	size := 0
+	for i, column := range columns {
+		value, err := castValue(column, raw[i])
+		if err != nil {
+			return nil, 0, xerrors.Errorf("unable to cast value of column %s: %w", column.ColumnName, err)
+		}
+		values[i] = value
+		size += valueSize(value)
+	}
+	return values, size, nil
+}
+
+// ShardTable splits tables by ranges of the first primary key column,
+// bounds are every DesiredShardRows-th value of the column
+func (s *Storage) ShardTable(ctx context.Context, table abstract.TableDescription) ([]abstract.TableDescription, error) {
+	if table.Filter != "" || table.Offset != 0 {
+		return []abstract.TableDescription{table}, nil
+	}
+	rowsCount, err := s.EstimateTableRowsCount(table.ID())
+	if err != nil {
+		return nil, xerrors.Errorf("unable to count rows: %w", err)
+	}
+	if rowsCount <= s.config.DesiredShardRows {
+		return []abstract.TableDescription{table}, nil
+	}
+	tableSchema, err := s.TableSchema(ctx, table.ID())
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get schema: %w", err)
+	}
+	key := tableSchema.Columns()[0]
+	if !key.IsKey() || !shardableKey(key) {
+		s.logger.Info("table is not sharded, since it has no suitable primary key", log.String("table", table.Fqtn()))
+		return []abstract.TableDescription{table}, nil
+	}
+
+	query := fmt.Sprintf(
+		"SELECT k FROM (SELECT %[1]s AS k, ROW_NUMBER() OVER (ORDER BY %[1]s) AS rn FROM %[2]s) AS r WHERE rn %% @p1 = 0 ORDER BY k",
+		quote(key.ColumnName), quoteTable(table.ID()),
+	)
+	rows, err := s.db.QueryContext(ctx, query, int64(s.config.DesiredShardRows))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to select shard bounds: %w", err)
+	}
+	defer rows.Close()
+	var bounds []string
+	for rows.Next() {
+		var raw interface{}
+		if err := rows.Scan(&raw); err != nil {
+			return nil, xerrors.Errorf("unable to scan shard bound: %w", err)
+		}
+		value, err := castValue(key, raw)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to cast shard bound: %w", err)
+		}
+		bounds = append(bounds, literal(value))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read shard bounds: %w", err)
+	}
+	if len(bounds) == 0 {
+		return []abstract.TableDescription{table}, nil
+	}
+
+	column := quote(key.ColumnName)
+	filters := make([]string, 0, len(bounds)+1)
+	filters = append(filters, fmt.Sprintf("%s < %s", column, bounds[0]))
+	for i := 1; i < len(bounds); i++ {
+		filters = append(filters, fmt.Sprintf("%s >= %s AND %s < %s", column, bounds[i-1], column, bounds[i]))
+	}
+	filters = append(filters, fmt.Sprintf("%s >= %s", column, bounds[len(bounds)-1]))
+
+	result := make([]abstract.TableDescription, 0, len(filters))
+	for _, filter := range filters {
+		result = append(result, abstract.TableDescription{
+			Name:   table.Name,
+			Schema: table.Schema,
+			Filter: abstract.WhereStatement(filter),
+			EtaRow: s.config.DesiredShardRows,
+			Offset: 0,
+		})
+	}
+	return result, nil
+}
+
+// shardableKey reports whether bounds of the column can be written as literals
+func shardableKey(column abstract.ColSchema) bool {
+	switch schema.Type(column.DataType) {
+	case schema.TypeInt64, schema.TypeInt32, schema.TypeInt16, schema.TypeUint8, schema.TypeString:
+		return baseType(column) != "time"
+	default:
+		return false
+	}
+}
+
+func (s *Storage) ExactTableRowsCount(table abstract.TableID) (uint64, error) {
+	var count int64
+	if err := s.db.QueryRow(fmt.Sprintf("SELECT COUNT_BIG(*) FROM %s", quoteTable(table))).Scan(&count); err != nil {
+		return 0, xerrors.Errorf("unable to count rows: %w", err)
+	}
+	return uint64(count), nil
+}
+
+func (s *Storage) EstimateTableRowsCount(table abstract.TableID) (uint64, error) {
+	var count int64
+	if err := s.db.QueryRow(rowsCountQuery, quoteTable(table)).Scan(&count); err != nil {
+		return 0, xerrors.Errorf("unable to estimate rows count: %w", err)
+	}
+	return uint64(count), nil
+}
+
+func (s *Storage) TableExists(table abstract.TableID) (bool, error) {
+	var exists bool
+	if err := s.db.QueryRow(`SELECT CAST(CASE WHEN OBJECT_ID(@p1, 'U') IS NULL THEN 0 ELSE 1 END AS bit)`, quoteTable(table)).Scan(&exists); err != nil {
+		return false, xerrors.Errorf("unable to check table existence: %w", err)
+	}
+	return exists, nil
+}
+
+func NewStorage(cfg *MSSQLSource, lgr log.Logger) (*Storage, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open database: %w", err)
+	}
+	return &Storage{
+		config: cfg,
+		db:     db,
+		logger: lgr,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package mssql
+
+import (
+	"github.com/transferia/transferia/pkg/abstract/typesystem"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+func init() {
+	typesystem.SourceRules(ProviderType, map[schema.Type][]string{
+		schema.TypeInt64:     {"bigint"},
+		schema.TypeInt32:     {"int"},
+		schema.TypeInt16:     {"smallint"},
+		schema.TypeInt8:      {},
+		schema.TypeUint64:    {},
+		schema.TypeUint32:    {},
+		schema.TypeUint16:    {},
+		schema.TypeUint8:     {"tinyint"},
+		schema.TypeFloat32:   {"real"},
+		schema.TypeFloat64:   {"float", "decimal", "numeric", "money", "smallmoney"},
+		schema.TypeBytes:     {"binary", "varbinary", "image", "timestamp", "rowversion", typesystem.RestPlaceholder},
+		schema.TypeString:    {"char", "varchar", "text", "nchar", "nvarchar", "ntext", "xml", "uniqueidentifier", "time"},
+		schema.TypeBoolean:   {"bit"},
+		schema.TypeDate:      {"date"},
+		schema.TypeDatetime:  {},
+		schema.TypeTimestamp: {"datetime", "datetime2", "smalldatetime", "datetimeoffset"},
+		schema.TypeInterval:  {},
+		schema.TypeAny:       {},
+	})
+}
+
+// columnType maps SQL Server system type names onto transfer types,
+// user-defined types are resolved into their base types by the schema query
+func columnType(typeName string) schema.Type {
+	switch typeName {
+	case "bigint":
+		return schema.TypeInt64
+	case "int":
+		return schema.TypeInt32
+	case "smallint":
+		return schema.TypeInt16
+	case "tinyint":
+		return schema.TypeUint8
+	case "real":
+		return schema.TypeFloat32
+	case "float", "decimal", "numeric", "money", "smallmoney":
+		return schema.TypeFloat64
+	case "char", "varchar", "text", "nchar", "nvarchar", "ntext", "xml", "uniqueidentifier", "time":
+		return schema.TypeString
+	case "bit":
+		return schema.TypeBoolean
+	case "date":
+		return schema.TypeDate
+	case "datetime", "datetime2", "smalldatetime", "datetimeoffset":
+		return schema.TypeTimestamp
+	default:
+		return schema.TypeBytes
+	}
+}
@@ -0,0 +1,23 @@
+package mssql
+
+import (
+	_ "embed"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract/typesystem"
+)
+
+var (
+	//go:embed typesystem.md
+	canonDoc string
+)
+
+func TestTypeSystem(t *testing.T) {
+	rules := typesystem.RuleFor(ProviderType)
+	require.NotNil(t, rules.Source)
+	doc := typesystem.Doc(ProviderType, "Microsoft SQL Server")
+	fmt.Print(doc)
+	require.Equal(t, canonDoc, doc)
+}
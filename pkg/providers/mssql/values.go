@@ -0,0 +1,128 @@
+package mssql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// baseType is the SQL Server type name of the column without sizes
+func baseType(column abstract.ColSchema) string {
+	typeName := strings.TrimPrefix(column.OriginalType, "mssql:")
+	typeName, _, _ = strings.Cut(typeName, "(")
+	return typeName
+}
+
+// castValue converts values read by the driver into the column type
+func castValue(column abstract.ColSchema, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch schema.Type(column.DataType) {
+	case schema.TypeInt64:
+		if v, ok := value.(int64); ok {
+			return v, nil
+		}
+	case schema.TypeInt32:
+		if v, ok := value.(int64); ok {
+			return int32(v), nil
+		}
+	case schema.TypeInt16:
+		if v, ok := value.(int64); ok {
+			return int16(v), nil
+		}
+	case schema.TypeUint8:
+		if v, ok := value.(int64); ok {
+			return uint8(v), nil
+		}
+	case schema.TypeFloat32:
+		switch v := value.(type) {
+		case float32:
+			return v, nil
+		case float64:
+			return float32(v), nil
+		}
+	case schema.TypeFloat64:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case []byte:
+			// decimal & money values are read as strings to keep the precision
+			return json.Number(v), nil
+		case string:
+			return json.Number(v), nil
+		}
+	case schema.TypeBoolean:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case schema.TypeString:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case []byte:
+			if baseType(column) == "uniqueidentifier" {
+				return formatUUID(v)
+			}
+			return string(v), nil
+		case time.Time:
+			return v.Format("15:04:05.9999999"), nil
+		}
+	case schema.TypeDate, schema.TypeTimestamp:
+		if v, ok := value.(time.Time); ok {
+			return v, nil
+		}
+	case schema.TypeBytes:
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		}
+	default:
+		return value, nil
+	}
+	return nil, xerrors.Errorf("unable to convert %T into %s", value, column.DataType)
+}
+
+// formatUUID formats uniqueidentifier values, the first three groups are stored in little endian
+func formatUUID(b []byte) (string, error) {
+	if len(b) != 16 {
+		return "", xerrors.Errorf("unexpected uniqueidentifier length: %d", len(b))
+	}
+	return fmt.Sprintf("%X-%X-%X-%X-%X",
+		[]byte{b[3], b[2], b[1], b[0]},
+		[]byte{b[5], b[4]},
+		[]byte{b[7], b[6]},
+		b[8:10],
+		b[10:],
+	), nil
+}
+
+// literal formats values of shardable key columns for filters
+func literal(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "N'" + strings.ReplaceAll(v, "'", "''") + "'"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case json.Number:
+		return len(v)
+	default:
+		return 8
+	}
+}
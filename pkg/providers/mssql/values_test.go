@@ -0,0 +1,107 @@
+package mssql
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+func column(typeName string) abstract.ColSchema {
+	base, _, _ := strings.Cut(typeName, "(")
+	return abstract.MakeOriginallyTypedColSchema("c", string(columnType(base)), "mssql:"+typeName)
+}
+
+func TestCastValue(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+	for _, tc := range []struct {
+		typeName string
+		raw      interface{}
+		expected interface{}
+	}{
+		{"bigint", int64(1 << 40), int64(1 << 40)},
+		{"int", int64(-7), int32(-7)},
+		{"smallint", int64(300), int16(300)},
+		{"tinyint", int64(255), uint8(255)},
+		{"real", float32(1.5), float32(1.5)},
+		{"float", 2.5, 2.5},
+		{"decimal(10,2)", []byte("12345678.90"), json.Number("12345678.90")},
+		{"money", []byte("-1.0001"), json.Number("-1.0001")},
+		{"bit", true, true},
+		{"nvarchar(10)", "привет", "привет"},
+		{"uniqueidentifier", []byte{0x67, 0x45, 0x23, 0x01, 0xab, 0x89, 0xef, 0xcd, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}, "01234567-89AB-CDEF-0123-456789ABCDEF"},
+		{"time(7)", time.Date(1, 1, 1, 13, 14, 15, 1234500, time.UTC), "13:14:15.0012345"},
+		{"date", ts, ts},
+		{"datetime2(7)", ts, ts},
+		{"varbinary(max)", []byte{1, 2}, []byte{1, 2}},
+		{"geography", []byte{3}, []byte{3}},
+		{"int", nil, nil},
+	} {
+		t.Run(tc.typeName, func(t *testing.T) {
+			value, err := castValue(column(tc.typeName), tc.raw)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, value)
+		})
+	}
+
+	_, err := castValue(column("int"), "1")
+	require.Error(t, err)
+}
+
+func TestFullType(t *testing.T) {
+	require.Equal(t, "nvarchar(50)", fullType("nvarchar", 100, 0, 0))
+	require.Equal(t, "varchar(max)", fullType("varchar", -1, 0, 0))
+	require.Equal(t, "decimal(10,2)", fullType("decimal", 9, 10, 2))
+	require.Equal(t, "datetime2(3)", fullType("datetime2", 7, 23, 3))
+	require.Equal(t, "int", fullType("int", 4, 10, 0))
+	require.Equal(t, schema.TypeString, columnType("nvarchar"))
+}
+
+func TestShardBounds(t *testing.T) {
+	require.True(t, shardableKey(column("bigint")))
+	require.True(t, shardableKey(column("nvarchar(10)")))
+	require.False(t, shardableKey(column("time(7)")))
+	require.False(t, shardableKey(column("datetime2(7)")))
+	require.Equal(t, "42", literal(int32(42)))
+	require.Equal(t, "N'O''Brien'", literal("O'Brien"))
+}
+
+func TestLSN(t *testing.T) {
+	lsn, err := ParseLSN("0000002a000001e80003")
+	require.NoError(t, err)
+	require.Equal(t, "0000002a000001e80003", lsn.String())
+	require.Equal(t, uint64(0x2a000001e80003), lsn.Uint64())
+	next, err := ParseLSN("0000002a000001f00001")
+	require.NoError(t, err)
+	require.True(t, lsn.Less(next))
+	require.False(t, next.Less(lsn))
+
+	_, err = ParseLSN("0001")
+	require.Error(t, err)
+}
+
+func TestInclude(t *testing.T) {
+	src := &MSSQLSource{IncludeTables: []string{"dbo.*", "sales.orders"}, ExcludeTables: []string{"dbo.audit"}}
+	require.True(t, src.Include(*abstract.NewTableID("dbo", "users")))
+	require.True(t, src.Include(*abstract.NewTableID("sales", "orders")))
+	require.False(t, src.Include(*abstract.NewTableID("sales", "items")))
+	require.False(t, src.Include(*abstract.NewTableID("dbo", "audit")))
+	require.True(t, (&MSSQLSource{}).Include(*abstract.NewTableID("any", "table")))
+}
+
+func TestCaptureInstanceRank(t *testing.T) {
+	users := *abstract.NewTableID("dbo", "users")
+	require.Equal(t, "transferia_dtt_dbo_users", captureInstanceName("dtt", users))
+	require.Len(t, captureInstanceName("dtt", *abstract.NewTableID("dbo", strings.Repeat("t", 200))), 100)
+
+	own := captureInstanceRank("dtt", users, "transferia_dtt_dbo_users")
+	user := captureInstanceRank("dtt", users, "dbo_users")
+	other := captureInstanceRank("dtt", users, "transferia_other_dbo_users")
+	require.Greater(t, own, user)
+	require.Greater(t, user, other)
+	require.Equal(t, 0, other)
+}
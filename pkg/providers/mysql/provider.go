@@ -3,8 +3,10 @@ package mysql
 import (
 	"context"
 	"encoding/gob"
+	"strconv"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/go-mysql-org/go-mysql/mysql"
 	"github.com/transferia/transferia/library/go/core/metrics"
 	"github.com/transferia/transferia/library/go/core/metrics/solomon"
 	"github.com/transferia/transferia/library/go/core/xerrors"
@@ -69,10 +71,12 @@ var (
 	_ providers.Sinker      = (*Provider)(nil)
 	_ providers.Sampleable  = (*Provider)(nil)
 
-	_ providers.Activator   = (*Provider)(nil)
-	_ providers.Deactivator = (*Provider)(nil)
-	_ providers.Cleanuper   = (*Provider)(nil)
-	_ providers.Updater     = (*Provider)(nil)
+	_ providers.Activator    = (*Provider)(nil)
+	_ providers.Deactivator  = (*Provider)(nil)
+	_ providers.Cleanuper    = (*Provider)(nil)
+	_ providers.Updater      = (*Provider)(nil)
+	_ providers.DDLRenderer  = (*Provider)(nil)
+	_ providers.StateManager = (*Provider)(nil)
 )
 
 type Provider struct {
@@ -275,6 +279,89 @@ func (p *Provider) Update(ctx context.Context, addedTables []abstract.TableDescr
 	return LoadMysqlSchema(p.transfer, p.registry, false)
 }
 
+// RenderDDL returns DDL which sink issues for an absent table, unless tables are maintained by user.
+func (p *Provider) RenderDDL(table abstract.TableID, schema *abstract.TableSchema) ([]string, error) {
+	dst, ok := p.transfer.Dst.(*MysqlDestination)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected type: %T", p.transfer.Dst)
+	}
+	if dst.MaintainTables {
+		return nil, nil
+	}
+	if len(schema.Columns()) == 0 {
+		return nil, xerrors.Errorf("table %v has no columns", table.Fqtn())
+	}
+	tableID := abstract.TableID{Namespace: dst.Database, Name: table.Name}
+	if tableID.Namespace == "" {
+		tableID.Namespace = table.Namespace
+	}
+	return []string{prepareDDL(tableID, schema)}, nil
+}
+
+// ValidateState checks binlog position and gtid set stored by replication tracker.
+func (p *Provider) ValidateState(state map[string]*coordinator.TransferStateData) error {
+	if value, ok := state[binlogPosKey]; ok {
+		position := value.GetMysqlBinlogPosition()
+		if position == nil {
+			return xerrors.Errorf("state key %s does not contain binlog position", binlogPosKey)
+		}
+		if position.File == "" || position.Position < 0 {
+			return xerrors.Errorf("invalid binlog position %v:%v", position.File, position.Position)
+		}
+	}
+	if value, ok := state[gtidsetKey]; ok {
+		gtid := value.GetMysqlGtid()
+		if gtid == nil {
+			return xerrors.Errorf("state key %s does not contain gtid set", gtidsetKey)
+		}
+		if _, err := mysql.ParseGTIDSet(gtid.Flavor, gtid.Gtid); err != nil {
+			return xerrors.Errorf("unable to parse gtid set: %w", err)
+		}
+	}
+	return nil
+}
+
+// PositionState builds binlog position from `file` and `pos` parameters, or gtid set from `gtid` and optional `flavor` parameters.
+func (p *Provider) PositionState(position map[string]string) (map[string]*coordinator.TransferStateData, error) {
+	result := map[string]*coordinator.TransferStateData{}
+	for key := range position {
+		if key != "file" && key != "pos" && key != "gtid" && key != "flavor" {
+			return nil, xerrors.Errorf("unknown position parameter %s, expected file and pos or gtid and flavor", key)
+		}
+	}
+	if file, ok := position["file"]; ok {
+		pos, err := strconv.ParseUint(position["pos"], 10, 32)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to parse binlog position: %w", err)
+		}
+		result[binlogPosKey] = &coordinator.TransferStateData{
+			MysqlBinlogPosition: &coordinator.MysqlBinlogPositionState{
+				File:     file,
+				Position: int64(pos),
+			},
+		}
+	}
+	if gtid, ok := position["gtid"]; ok {
+		flavor := position["flavor"]
+		if flavor == "" {
+			flavor = MysqlFlavorTypeMysql
+		}
+		result[gtidsetKey] = &coordinator.TransferStateData{
+			MysqlGtid: &coordinator.MysqlGtidState{
+				Gtid:   gtid,
+				Flavor: flavor,
+			},
+		}
+	}
+	if len(result) == 0 {
+		return nil, xerrors.New("either file and pos or gtid must be specified")
+	}
+	if err := p.ValidateState(result); err != nil {
+		return nil, xerrors.Errorf("invalid position: %w", err)
+	}
+	return result, nil
+}
+
 func (p *Provider) Type() abstract.ProviderType {
 	return ProviderType
 }
@@ -726,13 +726,13 @@ func (s *sinker) pushQuires(tx *sql.Tx, queries []sinkQuery) error {
 	return nil
 }
 
-func (s *sinker) prepareDDL(tableID abstract.TableID, changeItem abstract.ChangeItem) string {
+func prepareDDL(tableID abstract.TableID, schema *abstract.TableSchema) string {
 	tModel := TemplateModel{
 		Cols:  []TemplateCol{},
 		Keys:  []TemplateCol{},
 		Table: fmt.Sprintf("`%v`.`%v`", tableID.Namespace, tableID.Name),
 	}
-	for _, col := range changeItem.TableSchema.Columns() {
+	for _, col := range schema.Columns() {
 		tModel.Cols = append(tModel.Cols, TemplateCol{
 			Name:  fmt.Sprintf("`%s`", col.ColumnName),
 			Typ:   TypeToMySQL(col),
@@ -799,7 +799,7 @@ func (s *sinker) checkTable(tableID abstract.TableID, changeItem abstract.Change
 }
 
 func (s *sinker) createTable(tableID abstract.TableID, changeItem abstract.ChangeItem) error {
-	q := s.prepareDDL(tableID, changeItem)
+	q := prepareDDL(tableID, changeItem.TableSchema)
 	if _, err := s.db.Exec(q); err != nil {
 		s.logger.Warnf("Unable to push DDL (%v):\n%v\n", err, util.Sample(q, maxSampleLen))
 		return abstract.NewFatalError(xerrors.Errorf("unable to execute ddl: %w", err))
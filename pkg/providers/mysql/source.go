@@ -391,6 +391,9 @@ type publisher struct {
 	stopCh          chan bool
 	stopped         bool
 	once            sync.Once
+	drainCh         chan struct{}
+	drainOnce       sync.Once
+	flusherDone     chan struct{}
 	canal           *Canal
 	handler         *binlogHandler
 	tracker         *Tracker
@@ -438,6 +441,9 @@ func (p *publisher) Run(sink abstract.AsyncSink) error {
 
 		p.logger.Infof("Init reader from gtidset: %v", gtid)
 		if err := p.canal.StartFromGTID(gtid); err != nil {
+			if !util.IsOpen(p.drainCh) {
+				return p.waitFlushed()
+			}
 			cErr := util.Unwrap(err)
 			var mErr *mysql.MyError
 			if xerrors.As(cErr, &mErr) {
@@ -464,6 +470,9 @@ func (p *publisher) Run(sink abstract.AsyncSink) error {
 			Name: name,
 			Pos:  pos,
 		}); err != nil {
+			if !util.IsOpen(p.drainCh) {
+				return p.waitFlushed()
+			}
 			cErr := util.Unwrap(err)
 			var mErr *mysql.MyError
 			if xerrors.As(cErr, &mErr) {
@@ -481,9 +490,29 @@ func (p *publisher) Run(sink abstract.AsyncSink) error {
 			return xerrors.Errorf("failed to run canal: %w", err)
 		}
 	}
+	if !util.IsOpen(p.drainCh) {
+		return p.waitFlushed()
+	}
+	if p.flusherErr != nil {
+		return xerrors.Errorf("flusher error: %w", p.flusherErr)
+	}
+	return nil
+}
+
+// Drain stops reading binlog, Run returns once everything already read is pushed and its position is stored
+func (p *publisher) Drain() {
+	p.drainOnce.Do(func() {
+		close(p.drainCh)
+		p.canal.Close()
+	})
+}
+
+func (p *publisher) waitFlushed() error {
+	<-p.flusherDone
 	if p.flusherErr != nil {
 		return xerrors.Errorf("flusher error: %w", p.flusherErr)
 	}
+	p.logger.Info("Binlog reader drained, all read changes are committed")
 	return nil
 }
 
@@ -500,6 +529,7 @@ func (p *publisher) Stop() {
 }
 
 func (p *publisher) flusher() {
+	defer close(p.flusherDone)
 	defer p.Stop()
 	lastPushedGTID := ""
 	ticker := time.NewTicker(time.Second)
@@ -550,6 +580,9 @@ func (p *publisher) flusher() {
 		h := p.handler
 		h.metrics.Master.Set(1)
 		if len(h.inflight) == 0 {
+			if !util.IsOpen(p.drainCh) {
+				return
+			}
 			time.Sleep(h.config.ReplicationFlushInterval)
 			continue
 		}
@@ -716,6 +749,9 @@ func NewSource(src *MysqlSource, transferID string, objects *model.DataObjects,
 		stopCh:          make(chan bool),
 		stopped:         false,
 		once:            sync.Once{},
+		drainCh:         make(chan struct{}),
+		drainOnce:       sync.Once{},
+		flusherDone:     make(chan struct{}),
 		canal:           canal,
 		handler:         handler,
 		tracker:         tr,
@@ -0,0 +1,68 @@
+package nats
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+type NatsConnection struct {
+	URLs     []string // nats://host:4222, tls:// scheme forces TLS
+	User     string
+	Password model.SecretString
+	Token    model.SecretString
+	TLSFile  string `model:"PemFileContent"`
+}
+
+func (c *NatsConnection) Validate() error {
+	if len(c.URLs) == 0 {
+		return xerrors.New("at least one server URL is required")
+	}
+	if c.User != "" && c.Token != "" {
+		return xerrors.New("only one of user credentials & token can be set")
+	}
+	return nil
+}
+
+func (c *NatsConnection) TLSConfig() (*tls.Config, error) {
+	if c.TLSFile == "" {
+		return nil, nil
+	}
+	cp := x509.NewCertPool()
+	if !cp.AppendCertsFromPEM([]byte(c.TLSFile)) {
+		return nil, xerrors.Errorf("credentials: failed to append certificates")
+	}
+	return &tls.Config{
+		RootCAs: cp,
+	}, nil
+}
+
+// Connect - 'name' is shown in the server monitoring, transfer ID is used for it
+func (c *NatsConnection) Connect(name string) (*nats.Conn, error) {
+	opts := []nats.Option{
+		nats.Name(name),
+		nats.MaxReconnects(-1),
+	}
+	if c.User != "" {
+		opts = append(opts, nats.UserInfo(c.User, string(c.Password)))
+	}
+	if c.Token != "" {
+		opts = append(opts, nats.Token(string(c.Token)))
+	}
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get TLS config: %w", err)
+	}
+	if tlsConfig != nil {
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+	conn, err := nats.Connect(strings.Join(c.URLs, ","), opts...)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to connect to %v: %w", c.URLs, err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,98 @@
+package nats
+
+import (
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	debeziumparameters "github.com/transferia/transferia/pkg/debezium/parameters"
+	"github.com/transferia/transferia/pkg/middlewares/async/bufferer"
+	queues "github.com/transferia/transferia/pkg/util/queues"
+)
+
+type NatsDestination struct {
+	Connection *NatsConnection
+
+	Subject       string // full subject, all tables are published into it
+	SubjectPrefix string // tables are published into '<SubjectPrefix>.<schema>.<table>'
+	// Stream is created when absent, it captures 'Subject' or all subjects under 'SubjectPrefix'
+	Stream string
+
+	AddSystemTables bool
+	SaveTxOrder     bool
+
+	// 'FormatSettings' works the same way as in kafka destination: 'Mirror' for queues, 'Debezium' for the rest by default
+	FormatSettings model.SerializationFormat
+}
+
+var _ model.Destination = (*NatsDestination)(nil)
+
+func (d *NatsDestination) WithDefaults() {
+	if d.Connection == nil {
+		d.Connection = &NatsConnection{
+			URLs:     nil,
+			User:     "",
+			Password: "",
+			Token:    "",
+			TLSFile:  "",
+		}
+	}
+	if d.FormatSettings.Name == "" {
+		d.FormatSettings.Name = model.SerializationFormatAuto
+	}
+	if d.FormatSettings.Settings == nil {
+		d.FormatSettings.Settings = make(map[string]string)
+	}
+	if d.FormatSettings.BatchingSettings == nil {
+		d.FormatSettings.BatchingSettings = &model.Batching{
+			Enabled:        false,
+			Interval:       0,
+			MaxChangeItems: 0,
+			MaxMessageSize: 0,
+		}
+	}
+}
+
+func (d *NatsDestination) CleanupMode() model.CleanupType {
+	return model.DisabledCleanup
+}
+
+func (NatsDestination) IsDestination() {}
+
+func (d *NatsDestination) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (d *NatsDestination) Validate() error {
+	if err := d.Connection.Validate(); err != nil {
+		return xerrors.Errorf("invalid connection: %w", err)
+	}
+	if _, err := queues.NewTopicDefinition(d.Subject, d.SubjectPrefix); err != nil {
+		return xerrors.Errorf("invalid subject settings: %w", err)
+	}
+	if d.SubjectPrefix != "" && d.SaveTxOrder {
+		return xerrors.Errorf("option 'SaveTxOrder'=true is incompatible with 'SubjectPrefix'. Use either full subject or turn off 'SaveTxOrder'.")
+	}
+	return nil
+}
+
+// streamSubjects are the subjects captured by the stream created by the sink
+func (d *NatsDestination) streamSubjects() []string {
+	if d.Subject != "" {
+		return []string{d.Subject}
+	}
+	return []string{d.SubjectPrefix + ".>"}
+}
+
+func (d *NatsDestination) Serializer() (model.SerializationFormat, bool) {
+	formatSettings := d.FormatSettings
+	formatSettings.Settings = debeziumparameters.EnrichedWithDefaults(formatSettings.Settings)
+	return formatSettings, d.SaveTxOrder
+}
+
+func (d *NatsDestination) BuffererConfig() bufferer.BuffererConfig {
+	return bufferer.BuffererConfig{
+		TriggingCount:    d.FormatSettings.BatchingSettings.MaxChangeItems,
+		TriggingSize:     uint64(d.FormatSettings.BatchingSettings.MaxMessageSize),
+		TriggingInterval: d.FormatSettings.BatchingSettings.Interval,
+	}
+}
@@ -0,0 +1,127 @@
+package nats
+
+import (
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/parsers"
+)
+
+const ProviderType = abstract.ProviderType("nats")
+
+type DeliverPolicy string
+
+const (
+	DeliverAll = DeliverPolicy("all")
+	DeliverNew = DeliverPolicy("new")
+)
+
+type NatsSource struct {
+	Connection *NatsConnection
+	Stream     string
+	// Consumer is the name of the durable consumer, transfer ID by default
+	Consumer       string
+	FilterSubjects []string
+	// SubjectsToTables - the first matched rule defines the table of a message, subject is the table name by default
+	SubjectsToTables []SubjectToTable
+	// DeliverPolicy is applied only when the durable consumer is created
+	DeliverPolicy DeliverPolicy
+
+	// AckWait - messages not acked in this time are redelivered, it should be greater than the time to push a batch
+	AckWait time.Duration
+	// MaxAckPending limits the number of read, but not yet pushed messages
+	MaxAckPending         int
+	BatchSize             int
+	ParseQueueParallelism int
+
+	ParserConfig map[string]interface{}
+}
+
+var _ model.Source = (*NatsSource)(nil)
+
+func (s *NatsSource) WithDefaults() {
+	if s.Connection == nil {
+		s.Connection = &NatsConnection{
+			URLs:     nil,
+			User:     "",
+			Password: "",
+			Token:    "",
+			TLSFile:  "",
+		}
+	}
+	if s.DeliverPolicy == "" {
+		s.DeliverPolicy = DeliverAll
+	}
+	if s.AckWait == 0 {
+		s.AckWait = 10 * time.Minute
+	}
+	if s.MaxAckPending == 0 {
+		s.MaxAckPending = 10000
+	}
+	if s.BatchSize == 0 {
+		s.BatchSize = 1000
+	}
+}
+
+func (NatsSource) IsSource() {}
+
+func (s *NatsSource) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (s *NatsSource) Validate() error {
+	if err := s.Connection.Validate(); err != nil {
+		return xerrors.Errorf("invalid connection: %w", err)
+	}
+	if s.Stream == "" {
+		return xerrors.New("stream is required")
+	}
+	if s.DeliverPolicy != DeliverAll && s.DeliverPolicy != DeliverNew {
+		return xerrors.Errorf("unknown deliver policy: %s", s.DeliverPolicy)
+	}
+	if s.ParserConfig != nil {
+		parserConfigStruct, err := parsers.ParserConfigMapToStruct(s.ParserConfig)
+		if err != nil {
+			return xerrors.Errorf("unable to create new parser config, err: %w", err)
+		}
+		return parserConfigStruct.Validate()
+	}
+	return nil
+}
+
+func (s *NatsSource) IsAppendOnly() bool {
+	if s.ParserConfig == nil {
+		return false
+	}
+	parserConfigStruct, _ := parsers.ParserConfigMapToStruct(s.ParserConfig)
+	if parserConfigStruct == nil {
+		return false
+	}
+	return parserConfigStruct.IsAppendOnly()
+}
+
+func (s *NatsSource) IsDefaultMirror() bool {
+	return s.ParserConfig == nil
+}
+
+func (s *NatsSource) Parser() map[string]interface{} {
+	return s.ParserConfig
+}
+
+// consumerName - consumer names can't contain '.', '*', '>' & whitespaces
+func (s *NatsSource) consumerName(transferID string) string {
+	name := s.Consumer
+	if name == "" {
+		name = transferID
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
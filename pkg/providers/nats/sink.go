@@ -0,0 +1,227 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	serializer "github.com/transferia/transferia/pkg/serializer/queue"
+	"github.com/transferia/transferia/pkg/stats"
+	queues "github.com/transferia/transferia/pkg/util/queues"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const (
+	pushTimeout = 5 * time.Minute
+
+	// maxPendingAcks is less than the default limit of async publishes, so publishing never stalls
+	maxPendingAcks = 1000
+)
+
+type sink struct {
+	config     *NatsDestination
+	logger     log.Logger
+	metrics    *stats.SinkerStats
+	serializer serializer.Serializer
+	conn       *nats.Conn
+	js         jetstream.JetStream
+	// withMsgIDs is true when every message is made of a single item, so message IDs can be derived from source positions of items
+	withMsgIDs bool
+}
+
+func (s *sink) Push(input []abstract.ChangeItem) error {
+	start := time.Now()
+
+	tableToMessages, tableToIDs, err := s.serialize(input)
+	if err != nil {
+		return xerrors.Errorf("unable to serialize: %w", err)
+	}
+	serializer.LogBatchingStat(s.logger, input, tableToMessages, start)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	futures := make([]jetstream.PubAckFuture, 0, maxPendingAcks)
+	for tablePartID, messages := range tableToMessages {
+		if tablePartID.IsSystemTable() && !s.config.AddSystemTables {
+			continue
+		}
+		subject := queues.GetTopicName(s.config.Subject, s.config.SubjectPrefix, tablePartID)
+		for i, msg := range messages {
+			var opts []jetstream.PublishOpt
+			if id := tableToIDs[tablePartID][i]; id != "" {
+				opts = append(opts, jetstream.WithMsgID(id))
+			}
+			future, err := s.js.PublishMsgAsync(makeNatsMessage(subject, msg), opts...)
+			if err != nil {
+				return xerrors.Errorf("unable to publish message into %s: %w", subject, err)
+			}
+			futures = append(futures, future)
+			if len(futures) == maxPendingAcks {
+				if err := waitAcks(ctx, futures); err != nil {
+					return xerrors.Errorf("unable to publish messages: %w", err)
+				}
+				futures = futures[:0]
+			}
+		}
+		s.metrics.Table(tablePartID.Fqtn(), "rows", len(messages))
+	}
+	if err := waitAcks(ctx, futures); err != nil {
+		return xerrors.Errorf("unable to publish messages: %w", err)
+	}
+	s.metrics.Elapsed.RecordDuration(time.Since(start))
+	return nil
+}
+
+// serialize returns messages by table and their IDs, the ID is empty for messages of items without a source position
+func (s *sink) serialize(input []abstract.ChangeItem) (map[abstract.TablePartID][]serializer.SerializedMessage, map[abstract.TablePartID][]string, error) {
+	tableToIDs := make(map[abstract.TablePartID][]string)
+	if !s.withMsgIDs {
+		tableToMessages, err := s.serializer.Serialize(input)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("unable to serialize batch: %w", err)
+		}
+		for tablePartID, messages := range tableToMessages {
+			tableToIDs[tablePartID] = make([]string, len(messages))
+		}
+		return tableToMessages, tableToIDs, nil
+	}
+
+	// items are serialized one by one to know the item of every message
+	tableToMessages := make(map[abstract.TablePartID][]serializer.SerializedMessage)
+	// parsers make several items of a single source message with the same position, so items are also numbered by position
+	positionItems := make(map[string]int)
+	for i := range input {
+		position := sourcePosition(&input[i])
+		ordinal := positionItems[position]
+		positionItems[position]++
+		itemMessages, err := s.serializer.Serialize(input[i : i+1])
+		if err != nil {
+			return nil, nil, xerrors.Errorf("unable to serialize item: %w", err)
+		}
+		for tablePartID, messages := range itemMessages {
+			for j, msg := range messages {
+				id := ""
+				if position != "" {
+					// debezium emits a delete with its tombstone, so a single item may make several messages
+					id = fmt.Sprintf("%s/%d/%d", position, ordinal, j)
+				}
+				tableToMessages[tablePartID] = append(tableToMessages[tablePartID], msg)
+				tableToIDs[tablePartID] = append(tableToIDs[tablePartID], id)
+			}
+		}
+	}
+	return tableToMessages, tableToIDs, nil
+}
+
+func (s *sink) Close() error {
+	s.conn.Close()
+	return nil
+}
+
+func waitAcks(ctx context.Context, futures []jetstream.PubAckFuture) error {
+	for _, future := range futures {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			return xerrors.Errorf("message into %s is not acked: %w", future.Msg().Subject, err)
+		case <-ctx.Done():
+			return xerrors.Errorf("messages are not acked in %v: %w", pushTimeout, ctx.Err())
+		}
+	}
+	return nil
+}
+
+func makeNatsMessage(subject string, msg serializer.SerializedMessage) *nats.Msg {
+	result := nats.NewMsg(subject)
+	result.Data = msg.Value
+	if len(msg.Key) != 0 {
+		result.Header.Set(KeyHeader, string(msg.Key))
+	}
+	for _, header := range msg.Headers {
+		result.Header.Add(header.Key, string(header.Value))
+	}
+	return result
+}
+
+// sourcePosition identifies the item by its position in the source: table, LSN (or commit time, if the source has no LSN)
+// and index of the item in its transaction, so the stream drops messages published again within its duplicates window
+// after the source resends items, e.g. after a restart. Items without a position get no message ID, so equal rows are never dropped.
+// The position is not unique for items parsed from a single queue message, so message IDs also include the ordinal of the item among
+// the items of the batch at the same position
+func sourcePosition(item *abstract.ChangeItem) string {
+	if !item.IsRowEvent() {
+		return ""
+	}
+	// mirrored queue messages are identified by their partition & offset columns
+	if len(item.ColumnNames) > 2 && item.ColumnNames[0] == abstract.RawMessageTopic && item.ColumnNames[1] == abstract.RawMessagePartition {
+		return fmt.Sprintf("%s/%v/%v", item.TableID().Fqtn(), item.ColumnValues[1], item.ColumnValues[2])
+	}
+	position := item.LSN
+	if position == 0 {
+		position = item.CommitTime
+	}
+	if position == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%d/%d", item.TableID().Fqtn(), item.PartID, position, item.Counter)
+}
+
+func ensureStream(ctx context.Context, js jetstream.JetStream, cfg *NatsDestination) error {
+	if cfg.Stream == "" {
+		return nil
+	}
+	_, err := js.Stream(ctx, cfg.Stream)
+	if err == nil {
+		return nil
+	}
+	if !xerrors.Is(err, jetstream.ErrStreamNotFound) {
+		return xerrors.Errorf("unable to get stream %s: %w", cfg.Stream, err)
+	}
+	if _, err := js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.Stream,
+		Subjects: cfg.streamSubjects(),
+	}); err != nil {
+		return xerrors.Errorf("unable to create stream %s: %w", cfg.Stream, err)
+	}
+	return nil
+}
+
+func NewSink(cfg *NatsDestination, registry metrics.Registry, lgr log.Logger, transferID string, isSnapshot bool) (abstract.Sinker, error) {
+	currSerializer, err := serializer.New(cfg.FormatSettings, cfg.SaveTxOrder, false, isSnapshot, lgr)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to create serializer: %w", err)
+	}
+
+	conn, err := cfg.Connection.Connect(transferID)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to connect: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, xerrors.Errorf("unable to init jetstream: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := ensureStream(ctx, js, cfg); err != nil {
+		conn.Close()
+		return nil, xerrors.Errorf("unable to ensure stream exists: %w", err)
+	}
+
+	return &sink{
+		config:     cfg,
+		logger:     lgr,
+		metrics:    stats.NewSinkerStats(registry),
+		serializer: currSerializer,
+		conn:       conn,
+		js:         js,
+		// snapshot rows have no source position, batched messages are made of several items
+		withMsgIDs: !isSnapshot && (cfg.FormatSettings.BatchingSettings == nil || !cfg.FormatSettings.BatchingSettings.Enabled),
+	}, nil
+}
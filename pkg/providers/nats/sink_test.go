@@ -0,0 +1,94 @@
+package nats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+func TestSink(t *testing.T) {
+	ns := runServer(t)
+	js := connect(t, ns)
+	ctx := context.Background()
+
+	cfg := &NatsDestination{
+		Connection: &NatsConnection{URLs: []string{ns.ClientURL()}},
+		Subject:    "mirror",
+		Stream:     "mirror",
+		FormatSettings: model.SerializationFormat{
+			Name: model.SerializationFormatMirror,
+		},
+	}
+	cfg.WithDefaults()
+	require.NoError(t, cfg.Validate())
+
+	sinker, err := NewSink(cfg, solomon.NewRegistry(solomon.NewRegistryOpts()), logger.Log, "dttnats", false)
+	require.NoError(t, err)
+	defer sinker.Close()
+
+	items := []abstract.ChangeItem{
+		abstract.MakeRawMessage("topic", time.Time{}, "topic", 0, 0, []byte("first")),
+		abstract.MakeRawMessage("topic", time.Time{}, "topic", 0, 1, []byte("second")),
+		// the same content at other positions is not a duplicate
+		abstract.MakeRawMessage("topic", time.Time{}, "topic", 0, 2, []byte("second")),
+		abstract.MakeRawMessage("topic", time.Time{}, "topic", 1, 1, []byte("second")),
+	}
+	require.NoError(t, sinker.Push(items))
+	// resent items are dropped by the stream as duplicates, even when batches are split differently
+	require.NoError(t, sinker.Push(items[1:3]))
+	require.NoError(t, sinker.Push(items[:1]))
+
+	stream, err := js.Stream(ctx, "mirror")
+	require.NoError(t, err)
+	info, err := stream.Info(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), info.State.Msgs)
+
+	// rows parsed from a single source message share its position, but are not duplicates
+	lines := []abstract.ChangeItem{
+		abstract.MakeRawMessage("topic", time.Time{}, "topic", 0, 3, []byte("line 1")),
+		abstract.MakeRawMessage("topic", time.Time{}, "topic", 0, 3, []byte("line 2")),
+	}
+	require.NoError(t, sinker.Push(lines))
+	require.NoError(t, sinker.Push(lines))
+	info, err = stream.Info(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), info.State.Msgs)
+
+	msg, err := stream.GetMsg(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "mirror", msg.Subject)
+	require.Equal(t, "first", string(msg.Data))
+}
+
+func TestSourcePosition(t *testing.T) {
+	item := abstract.ChangeItem{
+		ID:         1,
+		LSN:        100,
+		CommitTime: 200,
+		Counter:    3,
+		Kind:       abstract.InsertKind,
+		Schema:     "public",
+		Table:      "orders",
+	}
+	require.Equal(t, `"public"."orders"//100/3`, sourcePosition(&item))
+
+	item.LSN = 0
+	require.Equal(t, `"public"."orders"//200/3`, sourcePosition(&item))
+
+	item.CommitTime = 0
+	require.Empty(t, sourcePosition(&item))
+
+	item.Kind = abstract.DoneTableLoad
+	item.LSN = 100
+	require.Empty(t, sourcePosition(&item))
+
+	raw := abstract.MakeRawMessage("topic", time.Time{}, "topic", 2, 0, []byte("data"))
+	require.Equal(t, `"topic"/2/0`, sourcePosition(&raw))
+}
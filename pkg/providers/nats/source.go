@@ -0,0 +1,220 @@
+package nats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/parsequeue"
+	"github.com/transferia/transferia/pkg/parsers"
+	"github.com/transferia/transferia/pkg/stats"
+	"github.com/transferia/transferia/pkg/util"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const (
+	fetchMaxWait = time.Second
+
+	// KeyHeader carries the key of the serialized message, nats messages have no keys by themselves
+	KeyHeader = "Key"
+)
+
+// Source reads the stream by the durable pull consumer.
+// Messages are acked only after the batch they belong to is pushed, so unpushed messages are redelivered after restarts
+type Source struct {
+	config   *NatsSource
+	logger   log.Logger
+	metrics  *stats.SourceStats
+	conn     *nats.Conn
+	consumer jetstream.Consumer
+	parser   parsers.Parser
+	ctx      context.Context
+	cancel   context.CancelFunc
+	once     sync.Once
+	errCh    chan error
+}
+
+func (s *Source) Run(sink abstract.AsyncSink) error {
+	parseQ := parsequeue.New(s.logger, s.config.ParseQueueParallelism, sink, s.parse, s.ack)
+	defer parseQ.Close()
+	defer s.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case err := <-s.errCh:
+			s.cancel() // after first error cancel ctx, so any other errors would be dropped, but not deadlocked
+			return err
+		default:
+		}
+
+		batch, err := s.consumer.Fetch(s.config.BatchSize, jetstream.FetchMaxWait(fetchMaxWait))
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return nil
+			}
+			return xerrors.Errorf("unable to fetch messages: %w", err)
+		}
+		var msgs []jetstream.Msg
+		size := 0
+		for msg := range batch.Messages() {
+			msgs = append(msgs, msg)
+			size += len(msg.Data())
+		}
+		if err := batch.Error(); err != nil && !xerrors.Is(err, nats.ErrTimeout) && !xerrors.Is(err, jetstream.ErrNoMessages) {
+			if s.ctx.Err() != nil {
+				return nil
+			}
+			return xerrors.Errorf("unable to fetch messages: %w", err)
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		s.metrics.Size.Add(int64(size))
+		s.metrics.Count.Add(int64(len(msgs)))
+		if err := parseQ.Add(msgs); err != nil {
+			return xerrors.Errorf("unable to add to pusher q: %w", err)
+		}
+	}
+}
+
+func (s *Source) parse(msgs []jetstream.Msg) []abstract.ChangeItem {
+	st := time.Now()
+	var result []abstract.ChangeItem
+	for _, msg := range msgs {
+		writeTime, streamSeq := messagePosition(msg)
+		table := tableName(s.config.SubjectsToTables, msg.Subject())
+		if s.parser == nil {
+			result = append(result, abstract.MakeRawMessage(table, writeTime, msg.Subject(), 0, int64(streamSeq), msg.Data()))
+			continue
+		}
+		result = append(result, s.parser.Do(parsers.Message{
+			Offset:     streamSeq,
+			SeqNo:      streamSeq,
+			Key:        []byte(msg.Headers().Get(KeyHeader)),
+			CreateTime: writeTime,
+			WriteTime:  writeTime,
+			Value:      msg.Data(),
+			Headers:    messageHeaders(msg.Headers()),
+		}, abstract.Partition{
+			Cluster:   "",
+			Partition: 0,
+			Topic:     table,
+		})...)
+	}
+	if s.parser != nil {
+		s.logger.Infof("convert done in %v, %v messages -> %v rows", time.Since(st), len(msgs), len(result))
+		s.metrics.DecodeTime.RecordDuration(time.Since(st))
+	}
+	s.metrics.ChangeItems.Add(int64(len(result)))
+	return result
+}
+
+func (s *Source) ack(msgs []jetstream.Msg, pushSt time.Time, err error) {
+	if err != nil {
+		util.Send(s.ctx, s.errCh, err)
+		return
+	}
+	for _, msg := range msgs {
+		if err := msg.Ack(); err != nil {
+			util.Send(s.ctx, s.errCh, xerrors.Errorf("unable to ack message: %w", err))
+			return
+		}
+	}
+	s.metrics.PushTime.RecordDuration(time.Since(pushSt))
+}
+
+func (s *Source) Stop() {
+	s.once.Do(func() {
+		s.cancel()
+		s.conn.Close()
+	})
+}
+
+// messagePosition - metadata is absent only for messages not delivered by JetStream, what can't happen for the pull consumer
+func messagePosition(msg jetstream.Msg) (time.Time, uint64) {
+	metadata, err := msg.Metadata()
+	if err != nil {
+		return time.Now(), 0
+	}
+	return metadata.Timestamp, metadata.Sequence.Stream
+}
+
+// messageHeaders keeps the first value of every header
+func messageHeaders(header nats.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(header))
+	for key, values := range header {
+		if len(values) != 0 {
+			result[key] = values[0]
+		}
+	}
+	return result
+}
+
+func deliverPolicy(policy DeliverPolicy) jetstream.DeliverPolicy {
+	if policy == DeliverNew {
+		return jetstream.DeliverNewPolicy
+	}
+	return jetstream.DeliverAllPolicy
+}
+
+func NewSource(transferID string, cfg *NatsSource, logger log.Logger, registry metrics.Registry) (*Source, error) {
+	conn, err := cfg.Connection.Connect(transferID)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to connect: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, xerrors.Errorf("unable to init jetstream: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, jetstream.ConsumerConfig{
+		Durable:        cfg.consumerName(transferID),
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        cfg.AckWait,
+		MaxAckPending:  cfg.MaxAckPending,
+		FilterSubjects: cfg.FilterSubjects,
+		DeliverPolicy:  deliverPolicy(cfg.DeliverPolicy),
+	})
+	if err != nil {
+		cancel()
+		conn.Close()
+		return nil, xerrors.Errorf("unable to create consumer of stream %s: %w", cfg.Stream, err)
+	}
+
+	source := &Source{
+		config:   cfg,
+		logger:   logger,
+		metrics:  stats.NewSourceStats(registry),
+		conn:     conn,
+		consumer: consumer,
+		parser:   nil,
+		ctx:      ctx,
+		cancel:   cancel,
+		once:     sync.Once{},
+		errCh:    make(chan error),
+	}
+
+	if cfg.ParserConfig != nil {
+		parser, err := parsers.NewParserFromMap(cfg.ParserConfig, false, logger, source.metrics)
+		if err != nil {
+			cancel()
+			conn.Close()
+			return nil, xerrors.Errorf("unable to make parser, err: %w", err)
+		}
+		source.parser = parser
+	}
+
+	return source, nil
+}
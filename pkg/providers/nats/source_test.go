@@ -0,0 +1,153 @@
+package nats
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+)
+
+func runServer(t *testing.T) *server.Server {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+	go ns.Start()
+	require.True(t, ns.ReadyForConnections(10*time.Second))
+	t.Cleanup(ns.Shutdown)
+	return ns
+}
+
+func connect(t *testing.T, ns *server.Server) jetstream.JetStream {
+	conn, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+	return js
+}
+
+type mockSink struct {
+	pushF func([]abstract.ChangeItem) error
+}
+
+func (m mockSink) Close() error {
+	return nil
+}
+
+func (m mockSink) AsyncPush(input []abstract.ChangeItem) chan error {
+	result := make(chan error, 1)
+	result <- m.pushF(input)
+	return result
+}
+
+func TestSubjectMatches(t *testing.T) {
+	require.True(t, subjectMatches("orders", "orders"))
+	require.True(t, subjectMatches("orders.*", "orders.created"))
+	require.True(t, subjectMatches("orders.*.eu", "orders.created.eu"))
+	require.True(t, subjectMatches("orders.>", "orders.created.eu"))
+	require.True(t, subjectMatches(">", "orders"))
+	require.False(t, subjectMatches("orders", "orders.created"))
+	require.False(t, subjectMatches("orders.*", "orders"))
+	require.False(t, subjectMatches("orders.*", "orders.created.eu"))
+	require.False(t, subjectMatches("orders.>", "orders"))
+	require.False(t, subjectMatches("orders.*.eu", "orders.created.us"))
+
+	rules := []SubjectToTable{
+		{Subject: "orders.*.eu", Table: "eu_orders"},
+		{Subject: "orders.>", Table: "orders"},
+	}
+	require.Equal(t, "eu_orders", tableName(rules, "orders.created.eu"))
+	require.Equal(t, "orders", tableName(rules, "orders.created.us"))
+	require.Equal(t, "payments", tableName(rules, "payments"))
+}
+
+func TestSource(t *testing.T) {
+	ns := runServer(t)
+	js := connect(t, ns)
+	ctx := context.Background()
+	_, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "events", Subjects: []string{"events.>"}})
+	require.NoError(t, err)
+	for _, subject := range []string{"events.orders.created", "events.orders.paid", "events.users.created"} {
+		_, err := js.Publish(ctx, subject, []byte(subject))
+		require.NoError(t, err)
+	}
+
+	cfg := &NatsSource{
+		Connection: &NatsConnection{URLs: []string{ns.ClientURL()}},
+		Stream:     "events",
+		SubjectsToTables: []SubjectToTable{
+			{Subject: "events.orders.*", Table: "orders"},
+		},
+		AckWait: time.Second, // to redeliver messages of the failed push soon
+	}
+	cfg.WithDefaults()
+	require.NoError(t, cfg.Validate())
+
+	t.Run("failed push is not acked", func(t *testing.T) {
+		src, err := NewSource("dttnats", cfg, logger.Log, solomon.NewRegistry(solomon.NewRegistryOpts()))
+		require.NoError(t, err)
+		err = src.Run(mockSink{pushF: func([]abstract.ChangeItem) error {
+			return xerrors.New("push failed")
+		}})
+		require.Error(t, err)
+
+		consumer, err := js.Consumer(ctx, "events", "dttnats")
+		require.NoError(t, err)
+		info, err := consumer.Info(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), info.AckFloor.Stream)
+	})
+
+	t.Run("pushed messages are acked", func(t *testing.T) {
+		src, err := NewSource("dttnats", cfg, logger.Log, solomon.NewRegistry(solomon.NewRegistryOpts()))
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		tables := map[string]string{}
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, src.Run(mockSink{pushF: func(items []abstract.ChangeItem) error {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, item := range items {
+					data, err := abstract.GetRawMessageData(item)
+					if err != nil {
+						return err
+					}
+					tables[string(data)] = item.Table
+				}
+				return nil
+			}}))
+		}()
+		require.Eventually(t, func() bool {
+			consumer, err := js.Consumer(ctx, "events", "dttnats")
+			require.NoError(t, err)
+			info, err := consumer.Info(ctx)
+			require.NoError(t, err)
+			return info.AckFloor.Stream == 3
+		}, time.Minute, 100*time.Millisecond)
+		src.Stop()
+		wg.Wait()
+
+		require.Equal(t, map[string]string{
+			"events.orders.created": "orders",
+			"events.orders.paid":    "orders",
+			"events.users.created":  "events.users.created",
+		}, tables)
+	})
+}
@@ -0,0 +1,37 @@
+package nats
+
+import "strings"
+
+// SubjectToTable maps messages of subjects matched by the 'Subject' pattern into the 'Table'.
+// Patterns follow NATS wildcards: '*' matches one token, '>' matches all remaining tokens
+type SubjectToTable struct {
+	Subject string
+	Table   string
+}
+
+// tableName returns the table of the first matched rule, subject itself - if there are no such rules
+func tableName(rules []SubjectToTable, subject string) string {
+	for _, rule := range rules {
+		if subjectMatches(rule.Subject, subject) {
+			return rule.Table
+		}
+	}
+	return subject
+}
+
+func subjectMatches(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[i] {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
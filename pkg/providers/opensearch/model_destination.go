@@ -24,6 +24,11 @@ type OpenSearchDestination struct {
 	Cleanup          model.CleanupType
 
 	SanitizeDocKeys bool
+
+	IndexNameTemplate  string
+	IndexDateColumn    string
+	DocumentIDStrategy elastic.DocumentIDStrategy
+	IngestPipeline     string
 }
 
 var _ model.Destination = (*OpenSearchDestination)(nil)
@@ -48,6 +53,11 @@ func (d *OpenSearchDestination) ToElasticSearchDestination() (*elastic.ElasticSe
 		SecurityGroupIDs: d.SecurityGroupIDs,
 		Cleanup:          d.Cleanup,
 		SanitizeDocKeys:  d.SanitizeDocKeys,
+
+		IndexNameTemplate:  d.IndexNameTemplate,
+		IndexDateColumn:    d.IndexDateColumn,
+		DocumentIDStrategy: d.DocumentIDStrategy,
+		IngestPipeline:     d.IngestPipeline,
 	}, elastic.OpenSearch
 }
 
@@ -71,10 +81,14 @@ func (d *OpenSearchDestination) Validate() error {
 	if !d.SSLEnabled && len(d.TLSFile) > 0 {
 		return xerrors.Errorf("can't use CA certificate with disabled SSL")
 	}
-	return nil
+	elasticDst, _ := d.ToElasticSearchDestination()
+	return elasticDst.Validate()
 }
 
 func (d *OpenSearchDestination) WithDefaults() {
+	if d.DocumentIDStrategy == "" {
+		d.DocumentIDStrategy = elastic.DocumentIDPrimaryKey
+	}
 }
 
 func (d *OpenSearchDestination) IsDestination() {}
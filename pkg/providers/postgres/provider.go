@@ -3,9 +3,12 @@ package postgres
 import (
 	"context"
 	"encoding/gob"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pglogrepl"
 	"github.com/transferia/transferia/internal/logger"
 	"github.com/transferia/transferia/library/go/core/metrics"
 	"github.com/transferia/transferia/library/go/core/xerrors"
@@ -60,14 +63,17 @@ const ProviderType = abstract.ProviderType("pg")
 
 // To verify providers contract implementation
 var (
-	_ providers.Sampleable  = (*Provider)(nil)
-	_ providers.Snapshot    = (*Provider)(nil)
-	_ providers.Replication = (*Provider)(nil)
-	_ providers.Sinker      = (*Provider)(nil)
-	_ providers.Verifier    = (*Provider)(nil)
-	_ providers.Activator   = (*Provider)(nil)
-	_ providers.Deactivator = (*Provider)(nil)
-	_ providers.Cleanuper   = (*Provider)(nil)
+	_ providers.Sampleable   = (*Provider)(nil)
+	_ providers.Snapshot     = (*Provider)(nil)
+	_ providers.Replication  = (*Provider)(nil)
+	_ providers.Sinker       = (*Provider)(nil)
+	_ providers.Verifier     = (*Provider)(nil)
+	_ providers.Activator    = (*Provider)(nil)
+	_ providers.Deactivator  = (*Provider)(nil)
+	_ providers.Cleanuper    = (*Provider)(nil)
+	_ providers.PartCleaner  = (*Provider)(nil)
+	_ providers.DDLRenderer  = (*Provider)(nil)
+	_ providers.StateManager = (*Provider)(nil)
 )
 
 type Provider struct {
@@ -100,6 +106,165 @@ func (p *Provider) Cleanup(ctx context.Context, task *model.TransferOperation) e
 	return nil
 }
 
+// CleanupPart removes rows of a partially uploaded shard of homogeneous transfer from destination by its filter,
+// the shard is deleted from the table it is renamed into by transformations and alt names of destination.
+// Whole tables are cleaned up by the sink according to the cleanup policy of destination, so they are not supported here.
+func (p *Provider) CleanupPart(ctx context.Context, part *model.OperationTablePart) error {
+	dst, ok := p.transfer.Dst.(*PgDestination)
+	if !ok {
+		return xerrors.Errorf("unexpected type: %T", p.transfer.Dst)
+	}
+	if dst.CleanupMode() == model.DisabledCleanup {
+		return nil
+	}
+	if !part.Sharded() {
+		return providers.PartCleanupUnsupportedErr
+	}
+	if _, ok := p.transfer.Src.(*PgSource); !ok || part.Filter == "" || strings.HasPrefix(part.Filter, PartitionsFilterPrefix) {
+		return providers.PartCleanupUnsupportedErr
+	}
+	tableID, err := p.destinationTableID(*part.ToTableID())
+	if err != nil {
+		return xerrors.Errorf("unable to resolve destination table of %v: %w", part.TableFQTN(), err)
+	}
+	if tableID == nil {
+		return providers.PartCleanupUnsupportedErr
+	}
+	name := tableID.Fqtn()
+	if altName := dst.Tables[name]; altName != "" {
+		name = altName
+	}
+	query := fmt.Sprintf("delete from %v where %v", name, part.Filter)
+
+	pool, err := MakeConnPoolFromDst(dst, p.logger)
+	if err != nil {
+		return xerrors.Errorf("unable to connect to destination: %w", err)
+	}
+	defer pool.Close()
+
+	if _, err := pool.Exec(ctx, query); err != nil {
+		if IsPgError(err, ErrcRelationDoesNotExists) || IsPgError(err, ErrcSchemaDoesNotExists) {
+			p.logger.Infof("cleanup of table part %v skipped: table not exists", part.String())
+			return nil
+		}
+		return xerrors.Errorf("unable to cleanup table part %v: %w", part.String(), err)
+	}
+	return nil
+}
+
+// tableIDSink keeps the table of the last truncate event pushed into it
+type tableIDSink struct {
+	tableID *abstract.TableID
+}
+
+func (s *tableIDSink) Push(items []abstract.ChangeItem) error {
+	for _, item := range items {
+		if item.Kind == abstract.TruncateTableKind {
+			tableID := item.TableID()
+			s.tableID = &tableID
+		}
+	}
+	return nil
+}
+
+func (s *tableIDSink) Close() error {
+	return nil
+}
+
+// destinationTableID maps the source table onto the destination one by transformations of the transfer,
+// e.g. renames of partitions into parent tables. Returns nil if transformations drop events of the table
+func (p *Provider) destinationTableID(tableID abstract.TableID) (*abstract.TableID, error) {
+	transformation, err := middlewares.Transformation(p.transfer, p.logger, p.registry)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to make transformation: %w", err)
+	}
+	result := new(tableIDSink)
+	sink := transformation(result)
+	defer sink.Close()
+	if err := sink.Push([]abstract.ChangeItem{
+		{Kind: abstract.TruncateTableKind, Schema: tableID.Namespace, Table: tableID.Name, CommitTime: uint64(time.Now().UnixNano())},
+	}); err != nil {
+		return nil, xerrors.Errorf("unable to apply transformations: %w", err)
+	}
+	return result.tableID, nil
+}
+
+// RenderDDL returns queries which sink issues to create the table, tables are created only if sink maintains them.
+func (p *Provider) RenderDDL(table abstract.TableID, schema *abstract.TableSchema) ([]string, error) {
+	dst, ok := p.transfer.Dst.(*PgDestination)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected type: %T", p.transfer.Dst)
+	}
+	isHomo := p.transfer.SrcType() == ProviderType
+	if isHomo && !dst.MaintainTables {
+		return nil, nil
+	}
+
+	name := table.Fqtn()
+	if altName := dst.Tables[name]; altName != "" {
+		name = altName
+	}
+	var result []string
+	if csq := CreateSchemaQueryOptional(name); len(csq) > 0 {
+		result = append(result, csq)
+	}
+	ctq, err := CreateTableQuery(name, schema.Columns().Copy())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a SQL query to ensure table existence: %w", err)
+	}
+	return append(result, ctq), nil
+}
+
+// ValidateState checks LSN stored by replication slot tracker.
+func (p *Provider) ValidateState(state map[string]*coordinator.TransferStateData) error {
+	value, ok := state[pgLsn]
+	if !ok {
+		return nil
+	}
+	var lsnState LsnState
+	if err := util.MapFromJSON(value.GetGeneric(), &lsnState); err != nil {
+		return xerrors.Errorf("unable to parse state key %s: %w", pgLsn, err)
+	}
+	if lsnState.SlotID == "" {
+		return xerrors.Errorf("state key %s does not contain slot id", pgLsn)
+	}
+	if _, err := pglogrepl.ParseLSN(lsnState.CommittedLsn); err != nil {
+		return xerrors.Errorf("unable to parse lsn %s: %w", lsnState.CommittedLsn, err)
+	}
+	return nil
+}
+
+// PositionState builds LSN state from `lsn` and optional `slot_id` parameters. Stored LSN is used only
+// to recreate replication slot when it is lost, so the slot must be dropped to rewind replication.
+func (p *Provider) PositionState(position map[string]string) (map[string]*coordinator.TransferStateData, error) {
+	src, ok := p.transfer.Src.(*PgSource)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected type: %T", p.transfer.Src)
+	}
+	p.fillParams(src)
+	for key := range position {
+		if key != "lsn" && key != "slot_id" {
+			return nil, xerrors.Errorf("unknown position parameter %s, expected lsn and slot_id", key)
+		}
+	}
+	slotID := position["slot_id"]
+	if slotID == "" {
+		slotID = src.SlotID
+	}
+	result := map[string]*coordinator.TransferStateData{
+		pgLsn: {
+			Generic: &LsnState{
+				SlotID:       slotID,
+				CommittedLsn: position["lsn"],
+			},
+		},
+	}
+	if err := p.ValidateState(result); err != nil {
+		return nil, xerrors.Errorf("invalid position: %w", err)
+	}
+	return result, nil
+}
+
 func (p *Provider) Deactivate(ctx context.Context, task *model.TransferOperation) error {
 	src, ok := p.transfer.Src.(*PgSource)
 	if !ok {
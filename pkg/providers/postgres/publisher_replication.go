@@ -48,10 +48,16 @@ type replication struct {
 	cp              coordinator.Coordinator
 	sharedCtx       context.Context
 	sharedCtxCancel context.CancelFunc
+	receiveCtx      context.Context // cancelled on Drain to stop reading WAL
+	receiveCancel   context.CancelFunc
+	drainCh         chan struct{}
+	drainOnce       sync.Once
+	receiverDone    chan struct{}
+	parseWG         sync.WaitGroup
 	changeProcessor *changeProcessor
 	objects         *model.DataObjects
 	sequencer       *sequencer2.Sequencer
-	parseQ          *parsequeue.ParseQueue[[]abstract.ChangeItem]
+	parseQ          *parsequeue.WaitableParseQueue[[]abstract.ChangeItem]
 	objectsMap      map[abstract.TableID]bool //tables to include in transfer
 
 	skippedTables map[abstract.TableID]bool
@@ -68,7 +74,7 @@ const BufferLimit = 16 * humanize.MiByte
 func (p *replication) Run(sink abstract.AsyncSink) error {
 	var err error
 	//level of parallelism combined with hardcoded buffer size in receiver(16mb) prevent OOM in parsequeue
-	p.parseQ = parsequeue.New(p.logger, 10, sink, p.WithIncludeFilter, p.ack)
+	p.parseQ = parsequeue.NewWaitable(p.logger, 10, sink, p.WithIncludeFilter, p.ack)
 
 	if err = p.reloadSchema(); err != nil {
 		return xerrors.Errorf("failed to load schema: %w", err)
@@ -93,9 +99,42 @@ func (p *replication) Run(sink abstract.AsyncSink) error {
 		return err
 	case <-p.stopCh:
 		return nil
+	case <-p.drainCh:
+		return p.drain()
 	}
 }
 
+// Drain stops reading WAL, Run returns once everything already read is pushed and its LSN is committed
+func (p *replication) Drain() {
+	p.drainOnce.Do(func() {
+		close(p.drainCh)
+		p.receiveCancel()
+	})
+}
+
+func (p *replication) drain() error {
+	p.logger.Info("Draining replication")
+	<-p.receiverDone
+	p.parseWG.Wait()
+
+	drained := make(chan struct{})
+	go func() {
+		p.parseQ.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case err := <-p.error:
+		return xerrors.Errorf("unable to drain replication: %w", err)
+	case <-p.stopCh:
+		return nil
+	}
+
+	p.commitLsn()
+	p.logger.Info("Replication drained, all read changes are committed")
+	return nil
+}
+
 func (p *replication) Stop() {
 	p.once.Do(func() {
 		close(p.stopCh)
@@ -242,39 +281,40 @@ func (p *replication) standbyStatus() {
 	defer ticker.Stop()
 	defer p.wg.Done()
 
-	var lastLsn uint64
-
 	for range ticker.C {
 		select {
 		case <-p.stopCh:
 			return
 		default:
 		}
-		p.mutex.Lock()
-		copiedMaxLsn := p.maxLsn
-		p.mutex.Unlock()
+		p.commitLsn()
+	}
+}
 
-		if lastLsn != copiedMaxLsn {
-			lastLsn = copiedMaxLsn
-		}
-		ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
-		statusUpdate := pglogrepl.StandbyStatusUpdate{WALWritePosition: pglogrepl.LSN(copiedMaxLsn)}
-		if err := p.replConn.SendStandbyStatusUpdate(ctx, statusUpdate); err != nil {
-			logger.Log.Warn("Unable to send standby status", log.Error(err))
-		} else {
-			p.logger.Infof("Heartbeat send %v", copiedMaxLsn)
-		}
-		cancel()
-		if tracker, ok := p.slot.(*LsnTrackedSlot); ok && copiedMaxLsn > 0 {
-			if err := tracker.Move(pglogrepl.LSN(copiedMaxLsn).String()); err != nil {
-				logger.Log.Warn("Unable to move lsn", log.Error(err))
-			}
+// commitLsn reports the max pushed LSN to the server and moves the tracked slot
+func (p *replication) commitLsn() {
+	p.mutex.Lock()
+	copiedMaxLsn := p.maxLsn
+	p.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	statusUpdate := pglogrepl.StandbyStatusUpdate{WALWritePosition: pglogrepl.LSN(copiedMaxLsn)}
+	if err := p.replConn.SendStandbyStatusUpdate(ctx, statusUpdate); err != nil {
+		logger.Log.Warn("Unable to send standby status", log.Error(err))
+	} else {
+		p.logger.Infof("Heartbeat send %v", copiedMaxLsn)
+	}
+	cancel()
+	if tracker, ok := p.slot.(*LsnTrackedSlot); ok && copiedMaxLsn > 0 {
+		if err := tracker.Move(pglogrepl.LSN(copiedMaxLsn).String()); err != nil {
+			logger.Log.Warn("Unable to move lsn", log.Error(err))
 		}
 	}
 }
 
 func (p *replication) receiver(slotTroubleCh <-chan error) {
 	defer p.wg.Done()
+	defer close(p.receiverDone)
 	defer logger.Log.Info("Receiver stopped")
 	var lastLsn uint64
 	var cTime time.Time
@@ -297,9 +337,9 @@ func (p *replication) receiver(slotTroubleCh <-chan error) {
 		}
 		p.metrics.Master.Set(1)
 
-		backendMessage, err := p.replConn.ReceiveMessage(p.sharedCtx, p.slotMonitor)
+		backendMessage, err := p.replConn.ReceiveMessage(p.receiveCtx, p.slotMonitor)
 		if err != nil {
-			if xerrors.Is(err, context.Canceled) {
+			if xerrors.Is(err, context.Canceled) || p.receiveCtx.Err() != nil {
 				return
 			}
 			var pgErr *pgconn.PgError
@@ -345,7 +385,9 @@ func (p *replication) receiver(slotTroubleCh <-chan error) {
 			data = append(data, &xld)
 			if shouldFlush && parsed {
 				parsed = false
+				p.parseWG.Add(1)
 				go func(data []*pglogrepl.XLogData, messageCounter int) {
+					defer p.parseWG.Done()
 					defer func() {
 						parsed = true
 					}()
@@ -484,6 +526,7 @@ func (p *replication) parseWal2JsonChanges(cp *changeProcessor, xld *pglogrepl.X
 func NewReplicationPublisher(version PgVersion, replConn *mutexedPgConn, connPool *pgxpool.Pool, slot AbstractSlot, stats *stats.SourceStats, source *PgSource, transferID string, lgr log.Logger, cp coordinator.Coordinator, objects *model.DataObjects) (abstract.Source, error) {
 	mutex := &sync.Mutex{}
 	ctx, cancel := context.WithCancel(context.Background())
+	receiveCtx, receiveCancel := context.WithCancel(ctx)
 	return &replication{
 		logger:          lgr,
 		conn:            connPool,
@@ -509,6 +552,12 @@ func NewReplicationPublisher(version PgVersion, replConn *mutexedPgConn, connPoo
 		changeProcessor: nil,
 		sharedCtx:       ctx,
 		sharedCtxCancel: cancel,
+		receiveCtx:      receiveCtx,
+		receiveCancel:   receiveCancel,
+		drainCh:         make(chan struct{}),
+		drainOnce:       sync.Once{},
+		receiverDone:    make(chan struct{}),
+		parseWG:         sync.WaitGroup{},
 		objects:         objects,
 		sequencer:       sequencer2.NewSequencer(),
 		parseQ:          nil,
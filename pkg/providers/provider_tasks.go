@@ -3,7 +3,9 @@ package providers
 import (
 	"context"
 
+	"github.com/transferia/transferia/library/go/core/xerrors"
 	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
 	"github.com/transferia/transferia/pkg/abstract/model"
 	"github.com/transferia/transferia/pkg/cleanup"
 	"github.com/transferia/transferia/pkg/util"
@@ -78,3 +80,29 @@ type TMPCleaner interface {
 	Provider
 	TMPCleaner(ctx context.Context, task *model.TransferOperation) (Cleaner, error)
 }
+
+var PartCleanupUnsupportedErr = xerrors.New("table part cleanup is not supported")
+
+// PartCleaner enable custom cleanup of a single table part on destination, used when a resumed snapshot reloads
+// a part which was partially uploaded by a previous attempt. Returns PartCleanupUnsupportedErr if the given part
+// can not be cleaned up by the provider.
+type PartCleaner interface {
+	Provider
+	CleanupPart(ctx context.Context, part *model.OperationTablePart) error
+}
+
+// DDLRenderer renders DDL statements which destination sink would issue to create a table with the given schema,
+// without connecting to destination. Returns empty list if sink does not create tables by itself.
+type DDLRenderer interface {
+	Provider
+	RenderDDL(table abstract.TableID, schema *abstract.TableSchema) ([]string, error)
+}
+
+// StateManager enable provider-aware validation and manual changes of transfer state, used to recover transfers by hand.
+type StateManager interface {
+	Provider
+	// ValidateState checks state keys owned by provider, other keys are ignored.
+	ValidateState(state map[string]*coordinator.TransferStateData) error
+	// PositionState builds state which makes replication continue from the given position, position parameters are provider specific.
+	PositionState(position map[string]string) (map[string]*coordinator.TransferStateData, error)
+}
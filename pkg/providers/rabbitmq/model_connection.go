@@ -0,0 +1,84 @@
+package rabbitmq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/util"
+)
+
+type RabbitMQConnection struct {
+	Hosts    []string // host:port, hosts are tried in order until the first successful connection
+	VHost    string
+	User     string
+	Password model.SecretString
+	TLS      bool
+	TLSFile  string `model:"PemFileContent"`
+}
+
+func (c *RabbitMQConnection) Validate() error {
+	if len(c.Hosts) == 0 {
+		return xerrors.New("at least one host is required")
+	}
+	return nil
+}
+
+func (c *RabbitMQConnection) TLSConfig() (*tls.Config, error) {
+	if !c.TLS {
+		return nil, nil
+	}
+	if c.TLSFile == "" {
+		return new(tls.Config), nil
+	}
+	cp := x509.NewCertPool()
+	if !cp.AppendCertsFromPEM([]byte(c.TLSFile)) {
+		return nil, xerrors.Errorf("credentials: failed to append certificates")
+	}
+	return &tls.Config{
+		RootCAs: cp,
+	}, nil
+}
+
+func (c *RabbitMQConnection) uri(host string) string {
+	scheme := "amqp"
+	if c.TLS {
+		scheme = "amqps"
+	}
+	result := url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   "/" + url.PathEscape(c.VHost),
+	}
+	if c.User != "" {
+		result.User = url.UserPassword(c.User, string(c.Password))
+	}
+	return result.String()
+}
+
+// Connect dials hosts in order & returns the first established connection
+func (c *RabbitMQConnection) Connect(name string) (*amqp.Connection, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get TLS config: %w", err)
+	}
+	properties := amqp.NewConnectionProperties()
+	properties.SetClientConnectionName(name)
+
+	var errs util.Errors
+	for _, host := range c.Hosts {
+		conn, err := amqp.DialConfig(c.uri(host), amqp.Config{
+			Vhost:           c.VHost,
+			TLSClientConfig: tlsConfig,
+			Properties:      properties,
+		})
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, xerrors.Errorf("unable to connect to %s: %w", host, err))
+	}
+	return nil, xerrors.Errorf("unable to connect to any host: %w", errs)
+}
@@ -0,0 +1,94 @@
+package rabbitmq
+
+import (
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	debeziumparameters "github.com/transferia/transferia/pkg/debezium/parameters"
+	"github.com/transferia/transferia/pkg/middlewares/async/bufferer"
+)
+
+type RabbitMQDestination struct {
+	Connection *RabbitMQConnection
+
+	// Exchange to publish into, the default exchange routes messages into the queue named by the routing key
+	Exchange string
+	// ExchangeType - if set, the durable exchange of this type (direct, topic, fanout, headers) is declared on start
+	ExchangeType string
+	// RoutingKey is the template of message routing keys, see RoutingKeyTemplate for the syntax
+	RoutingKey string
+
+	AddSystemTables bool
+
+	// 'FormatSettings' works the same way as in kafka destination: 'Mirror' for queues, 'Debezium' for the rest by default
+	FormatSettings model.SerializationFormat
+}
+
+var _ model.Destination = (*RabbitMQDestination)(nil)
+
+func (d *RabbitMQDestination) WithDefaults() {
+	if d.Connection == nil {
+		d.Connection = &RabbitMQConnection{
+			Hosts:    nil,
+			VHost:    "",
+			User:     "",
+			Password: "",
+			TLS:      false,
+			TLSFile:  "",
+		}
+	}
+	if d.RoutingKey == "" {
+		d.RoutingKey = "{table}"
+	}
+	if d.FormatSettings.Name == "" {
+		d.FormatSettings.Name = model.SerializationFormatAuto
+	}
+	if d.FormatSettings.Settings == nil {
+		d.FormatSettings.Settings = make(map[string]string)
+	}
+	if d.FormatSettings.BatchingSettings == nil {
+		d.FormatSettings.BatchingSettings = &model.Batching{
+			Enabled:        false,
+			Interval:       0,
+			MaxChangeItems: 0,
+			MaxMessageSize: 0,
+		}
+	}
+}
+
+func (d *RabbitMQDestination) CleanupMode() model.CleanupType {
+	return model.DisabledCleanup
+}
+
+func (RabbitMQDestination) IsDestination() {}
+
+func (d *RabbitMQDestination) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (d *RabbitMQDestination) Validate() error {
+	if err := d.Connection.Validate(); err != nil {
+		return xerrors.Errorf("invalid connection: %w", err)
+	}
+	if d.Exchange == "" && d.ExchangeType != "" {
+		return xerrors.New("the default exchange can't be declared, set the exchange name")
+	}
+	if _, err := NewRoutingKeyTemplate(d.RoutingKey); err != nil {
+		return xerrors.Errorf("invalid routing key: %w", err)
+	}
+	return nil
+}
+
+func (d *RabbitMQDestination) Serializer() (model.SerializationFormat, bool) {
+	formatSettings := d.FormatSettings
+	formatSettings.Settings = debeziumparameters.EnrichedWithDefaults(formatSettings.Settings)
+	return formatSettings, false
+}
+
+func (d *RabbitMQDestination) BuffererConfig() bufferer.BuffererConfig {
+	return bufferer.BuffererConfig{
+		TriggingCount:    d.FormatSettings.BatchingSettings.MaxChangeItems,
+		TriggingSize:     uint64(d.FormatSettings.BatchingSettings.MaxMessageSize),
+		TriggingInterval: d.FormatSettings.BatchingSettings.Interval,
+	}
+}
@@ -0,0 +1,97 @@
+package rabbitmq
+
+import (
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/parsers"
+)
+
+const ProviderType = abstract.ProviderType("rabbitmq")
+
+type RabbitMQSource struct {
+	Connection *RabbitMQConnection
+	// Queues are consumed into tables of the same names
+	Queues []string
+
+	// Prefetch limits the number of read, but not yet pushed messages, so a slow target holds the source back
+	Prefetch int
+	// BatchSize & BatchInterval define when read messages are pushed: whichever comes first.
+	// BatchSize should not exceed Prefetch, since no more than Prefetch messages can be read before the push
+	BatchSize             int
+	BatchInterval         time.Duration
+	ParseQueueParallelism int
+
+	ParserConfig map[string]interface{}
+}
+
+var _ model.Source = (*RabbitMQSource)(nil)
+
+func (s *RabbitMQSource) WithDefaults() {
+	if s.Connection == nil {
+		s.Connection = &RabbitMQConnection{
+			Hosts:    nil,
+			VHost:    "",
+			User:     "",
+			Password: "",
+			TLS:      false,
+			TLSFile:  "",
+		}
+	}
+	if s.Prefetch == 0 {
+		s.Prefetch = 1000
+	}
+	if s.BatchSize == 0 {
+		s.BatchSize = s.Prefetch
+	}
+	if s.BatchInterval == 0 {
+		s.BatchInterval = time.Second
+	}
+}
+
+func (RabbitMQSource) IsSource() {}
+
+func (s *RabbitMQSource) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (s *RabbitMQSource) Validate() error {
+	if err := s.Connection.Validate(); err != nil {
+		return xerrors.Errorf("invalid connection: %w", err)
+	}
+	if len(s.Queues) == 0 {
+		return xerrors.New("at least one queue is required")
+	}
+	if s.BatchSize > s.Prefetch {
+		return xerrors.Errorf("batch size %d exceeds prefetch %d", s.BatchSize, s.Prefetch)
+	}
+	if s.ParserConfig != nil {
+		parserConfigStruct, err := parsers.ParserConfigMapToStruct(s.ParserConfig)
+		if err != nil {
+			return xerrors.Errorf("unable to create new parser config, err: %w", err)
+		}
+		return parserConfigStruct.Validate()
+	}
+	return nil
+}
+
+func (s *RabbitMQSource) IsAppendOnly() bool {
+	if s.ParserConfig == nil {
+		return false
+	}
+	parserConfigStruct, _ := parsers.ParserConfigMapToStruct(s.ParserConfig)
+	if parserConfigStruct == nil {
+		return false
+	}
+	return parserConfigStruct.IsAppendOnly()
+}
+
+func (s *RabbitMQSource) IsDefaultMirror() bool {
+	return s.ParserConfig == nil
+}
+
+func (s *RabbitMQSource) Parser() map[string]interface{} {
+	return s.ParserConfig
+}
@@ -0,0 +1,92 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/gob"
+
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/middlewares"
+	"github.com/transferia/transferia/pkg/providers"
+	"github.com/transferia/transferia/pkg/providers/kafka"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+func init() {
+	gob.RegisterName("*server.RabbitMQSource", new(RabbitMQSource))
+	gob.RegisterName("*server.RabbitMQDestination", new(RabbitMQDestination))
+	model.RegisterSource(ProviderType, func() model.Source {
+		return new(RabbitMQSource)
+	})
+	model.RegisterDestination(ProviderType, func() model.Destination {
+		return new(RabbitMQDestination)
+	})
+	abstract.RegisterProviderName(ProviderType, "RabbitMQ")
+	providers.Register(ProviderType, New)
+}
+
+// To verify providers contract implementation
+var (
+	_ providers.Replication    = (*Provider)(nil)
+	_ providers.Sinker         = (*Provider)(nil)
+	_ providers.SnapshotSinker = (*Provider)(nil)
+	_ providers.Activator      = (*Provider)(nil)
+)
+
+type Provider struct {
+	logger   log.Logger
+	registry metrics.Registry
+	cp       coordinator.Coordinator
+	transfer *model.Transfer
+}
+
+func (p *Provider) Type() abstract.ProviderType {
+	return ProviderType
+}
+
+func (p *Provider) Source() (abstract.Source, error) {
+	src, ok := p.transfer.Src.(*RabbitMQSource)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected source type: %T", p.transfer.Src)
+	}
+	return NewSource(p.transfer.ID, src, p.logger, p.registry)
+}
+
+func (p *Provider) Sink(middlewares.Config) (abstract.Sinker, error) {
+	dst, ok := p.transfer.Dst.(*RabbitMQDestination)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected target type: %T", p.transfer.Dst)
+	}
+	cfgCopy := *dst
+	cfgCopy.FormatSettings = kafka.InferFormatSettings(p.transfer.Src, cfgCopy.FormatSettings)
+	return NewSink(&cfgCopy, p.registry, p.logger, p.transfer.ID, false)
+}
+
+func (p *Provider) SnapshotSink(middlewares.Config) (abstract.Sinker, error) {
+	dst, ok := p.transfer.Dst.(*RabbitMQDestination)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected target type: %T", p.transfer.Dst)
+	}
+	cfgCopy := *dst
+	cfgCopy.FormatSettings = kafka.InferFormatSettings(p.transfer.Src, cfgCopy.FormatSettings)
+	return NewSink(&cfgCopy, p.registry, p.logger, p.transfer.ID, true)
+}
+
+func (p *Provider) Activate(_ context.Context, _ *model.TransferOperation, _ abstract.TableMap, _ providers.ActivateCallbacks) error {
+	if p.transfer.SrcType() == ProviderType && !p.transfer.IncrementOnly() {
+		return xerrors.New("Only allowed mode for RabbitMQ source is replication")
+	}
+	return nil
+}
+
+func New(lgr log.Logger, registry metrics.Registry, cp coordinator.Coordinator, transfer *model.Transfer) providers.Provider {
+	return &Provider{
+		logger:   lgr,
+		registry: registry,
+		cp:       cp,
+		transfer: transfer,
+	}
+}
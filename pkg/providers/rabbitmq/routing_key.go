@@ -0,0 +1,105 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+)
+
+const (
+	routingKeyTable     = "table"
+	routingKeyNamespace = "namespace"
+	routingKeyPK        = "pk"
+)
+
+// routingKeyValueEscaper escapes '.' which separates segments of routing keys & '%' which starts escape sequences
+var routingKeyValueEscaper = strings.NewReplacer("%", "%25", ".", "%2E")
+
+// RoutingKeyTemplate renders routing keys like `events.{namespace}.{table}.{pk}`.
+//
+// Supported placeholders are {table}, {namespace} and {pk}.
+// {pk} is the primary key values of the change item joined by '.', so topic exchanges can bind to single keys.
+// Values are escaped, so '.' within a value does not split it into several segments: '%' becomes "%25" and '.' becomes "%2E".
+type RoutingKeyTemplate struct {
+	parts []string // literals & placeholders in braces
+}
+
+func NewRoutingKeyTemplate(template string) (*RoutingKeyTemplate, error) {
+	var parts []string
+	rest := template
+	for len(rest) > 0 {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			parts = append(parts, rest)
+			break
+		}
+		if start > 0 {
+			parts = append(parts, rest[:start])
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, xerrors.Errorf("unclosed placeholder in routing key template %q", template)
+		}
+		placeholder := rest[start+1 : start+end]
+		switch placeholder {
+		case routingKeyTable, routingKeyNamespace, routingKeyPK:
+			parts = append(parts, rest[start:start+end+1])
+		default:
+			return nil, xerrors.Errorf("unknown placeholder {%s} in routing key template %q", placeholder, template)
+		}
+		rest = rest[start+end+1:]
+	}
+	return &RoutingKeyTemplate{parts: parts}, nil
+}
+
+// HasPK reports whether the routing key differs from row to row of the same table.
+func (t *RoutingKeyTemplate) HasPK() bool {
+	for _, part := range t.parts {
+		if part == "{"+routingKeyPK+"}" {
+			return true
+		}
+	}
+	return false
+}
+
+// Render builds the routing key of the table, changeItem is required only if the template has {pk}.
+func (t *RoutingKeyTemplate) Render(tableID abstract.TableID, changeItem *abstract.ChangeItem) string {
+	var out strings.Builder
+	for _, part := range t.parts {
+		switch part {
+		case "{" + routingKeyTable + "}":
+			out.WriteString(tableID.Name)
+		case "{" + routingKeyNamespace + "}":
+			out.WriteString(tableID.Namespace)
+		case "{" + routingKeyPK + "}":
+			if changeItem != nil {
+				values := pkValues(changeItem)
+				for i := range values {
+					values[i] = routingKeyValueEscaper.Replace(values[i])
+				}
+				out.WriteString(strings.Join(values, "."))
+			}
+		default:
+			out.WriteString(part)
+		}
+	}
+	return out.String()
+}
+
+// pkValues - deleted rows have no column values, their keys are in OldKeys.
+// OldKeys are filtered by the table schema, since some sources put all columns there
+func pkValues(changeItem *abstract.ChangeItem) []string {
+	if changeItem.Kind != abstract.DeleteKind {
+		return changeItem.KeyVals()
+	}
+	keyColumns := changeItem.MakeMapKeys()
+	result := make([]string, 0, len(keyColumns))
+	for i, name := range changeItem.OldKeys.KeyNames {
+		if keyColumns[name] && i < len(changeItem.OldKeys.KeyValues) {
+			result = append(result, fmt.Sprintf("%v", changeItem.OldKeys.KeyValues[i]))
+		}
+	}
+	return result
+}
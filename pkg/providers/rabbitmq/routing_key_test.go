@@ -0,0 +1,61 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract"
+)
+
+func TestRoutingKeyTemplate(t *testing.T) {
+	schema := abstract.NewTableSchema([]abstract.ColSchema{
+		{ColumnName: "region", DataType: "string", PrimaryKey: true},
+		{ColumnName: "id", DataType: "int64", PrimaryKey: true},
+		{ColumnName: "value", DataType: "string"},
+	})
+	insert := abstract.ChangeItem{
+		Kind:         abstract.InsertKind,
+		Schema:       "public",
+		Table:        "orders",
+		ColumnNames:  []string{"region", "id", "value"},
+		ColumnValues: []interface{}{"eu", 42, "v"},
+		TableSchema:  schema,
+	}
+	deleted := abstract.ChangeItem{
+		Kind:        abstract.DeleteKind,
+		Schema:      "public",
+		Table:       "orders",
+		TableSchema: schema,
+		OldKeys: abstract.OldKeysType{
+			KeyNames:  []string{"region", "id", "value"},
+			KeyTypes:  []string{"string", "int64", "string"},
+			KeyValues: []interface{}{"us", 7, "v"},
+		},
+	}
+
+	template, err := NewRoutingKeyTemplate("events.{namespace}.{table}.{pk}")
+	require.NoError(t, err)
+	require.True(t, template.HasPK())
+	require.Equal(t, "events.public.orders.eu.42", template.Render(insert.TableID(), &insert))
+	require.Equal(t, "events.public.orders.us.7", template.Render(deleted.TableID(), &deleted))
+
+	dotted := insert
+	dotted.ColumnValues = []interface{}{"eu.west", 1.5, "v"}
+	require.Equal(t, "events.public.orders.eu%2Ewest.1%2E5", template.Render(dotted.TableID(), &dotted))
+	dotted.ColumnValues = []interface{}{"100%", 1, "v"}
+	require.Equal(t, "events.public.orders.100%25.1", template.Render(dotted.TableID(), &dotted))
+
+	template, err = NewRoutingKeyTemplate("{table}")
+	require.NoError(t, err)
+	require.False(t, template.HasPK())
+	require.Equal(t, "orders", template.Render(insert.TableID(), nil))
+
+	template, err = NewRoutingKeyTemplate("")
+	require.NoError(t, err)
+	require.Equal(t, "", template.Render(insert.TableID(), &insert))
+
+	_, err = NewRoutingKeyTemplate("events.{table")
+	require.Error(t, err)
+	_, err = NewRoutingKeyTemplate("events.{id}")
+	require.Error(t, err)
+}
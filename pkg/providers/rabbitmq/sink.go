@@ -0,0 +1,221 @@
+package rabbitmq
+
+import (
+	"context"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	serializer "github.com/transferia/transferia/pkg/serializer/queue"
+	"github.com/transferia/transferia/pkg/stats"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const pushTimeout = 5 * time.Minute
+
+type routedMessage struct {
+	tableID    abstract.TablePartID
+	routingKey string
+	message    serializer.SerializedMessage
+}
+
+type sink struct {
+	config     *RabbitMQDestination
+	routingKey *RoutingKeyTemplate
+	logger     log.Logger
+	metrics    *stats.SinkerStats
+	serializer serializer.Serializer
+	transferID string
+	conn       *amqp.Connection
+	channel    *amqp.Channel
+	closeCh    chan *amqp.Error
+}
+
+// serialize - routing keys with {pk} differ from row to row,
+// so in this case every item is serialized on its own & batching of several items into one message is off
+func (s *sink) serialize(input []abstract.ChangeItem) ([]routedMessage, error) {
+	var result []routedMessage
+	if !s.routingKey.HasPK() {
+		tableToMessages, err := s.serializer.Serialize(input)
+		if err != nil {
+			return nil, err
+		}
+		for tablePartID, messages := range tableToMessages {
+			if tablePartID.IsSystemTable() && !s.config.AddSystemTables {
+				continue
+			}
+			routingKey := s.routingKey.Render(tablePartID.TableID, nil)
+			for _, message := range messages {
+				result = append(result, routedMessage{tableID: tablePartID, routingKey: routingKey, message: message})
+			}
+		}
+		return result, nil
+	}
+
+	for i := range input {
+		if input[i].IsSystemTable() && !s.config.AddSystemTables {
+			continue
+		}
+		itemToMessages, err := s.serializer.Serialize(input[i : i+1])
+		if err != nil {
+			return nil, err
+		}
+		routingKey := s.routingKey.Render(input[i].TableID(), &input[i])
+		for tablePartID, messages := range itemToMessages {
+			for _, message := range messages {
+				result = append(result, routedMessage{tableID: tablePartID, routingKey: routingKey, message: message})
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *sink) Push(input []abstract.ChangeItem) error {
+	start := time.Now()
+
+	messages, err := s.serialize(input)
+	if err != nil {
+		return xerrors.Errorf("unable to serialize: %w", err)
+	}
+
+	if err := s.ensureChannel(); err != nil {
+		return xerrors.Errorf("unable to reconnect: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	confirms := make([]*amqp.DeferredConfirmation, 0, len(messages))
+	tableRows := make(map[abstract.TablePartID]int)
+	for _, msg := range messages {
+		confirm, err := s.channel.PublishWithDeferredConfirmWithContext(ctx, s.config.Exchange, msg.routingKey, false, false, makePublishing(msg.message))
+		if err != nil {
+			return xerrors.Errorf("unable to publish message with routing key %s: %w", msg.routingKey, err)
+		}
+		confirms = append(confirms, confirm)
+		tableRows[msg.tableID]++
+	}
+	for _, confirm := range confirms {
+		acked, err := confirm.WaitContext(ctx)
+		if err != nil {
+			return xerrors.Errorf("unable to wait for publisher confirm: %w", err)
+		}
+		if !acked {
+			return xerrors.Errorf("message %d is nacked by the broker", confirm.DeliveryTag)
+		}
+	}
+	for tableID, rows := range tableRows {
+		s.metrics.Table(tableID.Fqtn(), "rows", rows)
+	}
+	s.metrics.Elapsed.RecordDuration(time.Since(start))
+	return nil
+}
+
+// ensureChannel re-dials the broker once the channel or the connection is closed, e.g. by a broker restart
+// or a channel exception, so the push is retried on a new channel instead of failing forever on the closed one
+func (s *sink) ensureChannel() error {
+	select {
+	case amqpErr, ok := <-s.closeCh:
+		if ok && amqpErr != nil {
+			s.logger.Warn("channel is closed, reconnecting", log.Error(amqpErr))
+		} else {
+			s.logger.Warn("channel is closed, reconnecting")
+		}
+	default:
+		if !s.channel.IsClosed() {
+			return nil
+		}
+		s.logger.Warn("channel is closed, reconnecting")
+	}
+	if err := s.conn.Close(); err != nil && !xerrors.Is(err, amqp.ErrClosed) {
+		s.logger.Warn("unable to close connection", log.Error(err))
+	}
+	conn, channel, err := openChannel(s.config, s.transferID)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.channel = channel
+	s.closeCh = channel.NotifyClose(make(chan *amqp.Error, 1))
+	return nil
+}
+
+func (s *sink) Close() error {
+	if err := s.conn.Close(); err != nil && !xerrors.Is(err, amqp.ErrClosed) {
+		return xerrors.Errorf("unable to close connection: %w", err)
+	}
+	return nil
+}
+
+func makePublishing(msg serializer.SerializedMessage) amqp.Publishing {
+	var headers amqp.Table
+	if len(msg.Key) != 0 || len(msg.Headers) != 0 {
+		headers = make(amqp.Table, len(msg.Headers)+1)
+		for _, header := range msg.Headers {
+			headers[header.Key] = string(header.Value)
+		}
+		if len(msg.Key) != 0 {
+			headers[KeyHeader] = string(msg.Key)
+		}
+	}
+	return amqp.Publishing{
+		Headers:      headers,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         msg.Value,
+	}
+}
+
+func NewSink(cfg *RabbitMQDestination, registry metrics.Registry, lgr log.Logger, transferID string, isSnapshot bool) (abstract.Sinker, error) {
+	routingKey, err := NewRoutingKeyTemplate(cfg.RoutingKey)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid routing key: %w", err)
+	}
+	currSerializer, err := serializer.New(cfg.FormatSettings, false, false, isSnapshot, lgr)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to create serializer: %w", err)
+	}
+
+	conn, channel, err := openChannel(cfg, transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sink{
+		config:     cfg,
+		routingKey: routingKey,
+		logger:     lgr,
+		metrics:    stats.NewSinkerStats(registry),
+		serializer: currSerializer,
+		transferID: transferID,
+		conn:       conn,
+		channel:    channel,
+		closeCh:    channel.NotifyClose(make(chan *amqp.Error, 1)),
+	}, nil
+}
+
+// openChannel connects to the broker & opens the channel with publisher confirms, declaring the exchange if asked
+func openChannel(cfg *RabbitMQDestination, transferID string) (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := cfg.Connection.Connect(transferID)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("unable to connect: %w", err)
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, xerrors.Errorf("unable to open channel: %w", err)
+	}
+	if err := channel.Confirm(false); err != nil {
+		_ = conn.Close()
+		return nil, nil, xerrors.Errorf("unable to turn on publisher confirms: %w", err)
+	}
+	if cfg.ExchangeType != "" {
+		if err := channel.ExchangeDeclare(cfg.Exchange, cfg.ExchangeType, true, false, false, false, nil); err != nil {
+			_ = conn.Close()
+			return nil, nil, xerrors.Errorf("unable to declare exchange %s: %w", cfg.Exchange, err)
+		}
+	}
+	return conn, channel, nil
+}
@@ -0,0 +1,29 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+	serializer "github.com/transferia/transferia/pkg/serializer/queue"
+)
+
+func TestPublishingKeyAndHeaders(t *testing.T) {
+	publishing := makePublishing(serializer.SerializedMessage{
+		Key:     []byte(`{"id":1}`),
+		Value:   []byte("value"),
+		Headers: []serializer.Header{{Key: "source", Value: []byte("orders")}},
+	})
+	require.Equal(t, "value", string(publishing.Body))
+	require.Equal(t, uint8(amqp.Persistent), publishing.DeliveryMode)
+	require.Equal(t, `{"id":1}`, string(messageKey(publishing.Headers)))
+	require.Equal(t, map[string]string{KeyHeader: `{"id":1}`, "source": "orders"}, messageHeaders(publishing.Headers))
+
+	publishing = makePublishing(serializer.SerializedMessage{Key: nil, Value: []byte("value"), Headers: nil})
+	require.Nil(t, publishing.Headers)
+	require.Nil(t, messageKey(publishing.Headers))
+
+	headers := amqp.Table{"retries": int32(3), "trace": []byte("abc")}
+	require.Nil(t, messageKey(headers))
+	require.Equal(t, map[string]string{"retries": "3", "trace": "abc"}, messageHeaders(headers))
+}
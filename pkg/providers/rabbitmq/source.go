@@ -0,0 +1,246 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/parsequeue"
+	"github.com/transferia/transferia/pkg/parsers"
+	"github.com/transferia/transferia/pkg/stats"
+	"github.com/transferia/transferia/pkg/util"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+// KeyHeader carries the key of the serialized message, AMQP messages have no keys by themselves
+const KeyHeader = "key"
+
+type delivery struct {
+	queue string
+	amqp.Delivery
+}
+
+// Source consumes queues with manual acks.
+// Messages are acked only after the batch they belong to is pushed, so unpushed messages are requeued by the broker
+// once the connection is closed. Prefetch bounds the number of unacked messages, what holds reading back on slow pushes
+type Source struct {
+	config     *RabbitMQSource
+	logger     log.Logger
+	metrics    *stats.SourceStats
+	conn       *amqp.Connection
+	channel    *amqp.Channel
+	deliveries chan delivery
+	closeCh    chan *amqp.Error
+	parser     parsers.Parser
+	ctx        context.Context
+	cancel     context.CancelFunc
+	once       sync.Once
+	errCh      chan error
+}
+
+func (s *Source) Run(sink abstract.AsyncSink) error {
+	parseQ := parsequeue.New(s.logger, s.config.ParseQueueParallelism, sink, s.parse, s.ack)
+	defer parseQ.Close()
+	defer s.Stop()
+
+	ticker := time.NewTicker(s.config.BatchInterval)
+	defer ticker.Stop()
+
+	var batch []delivery
+	size := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		s.metrics.Size.Add(int64(size))
+		s.metrics.Count.Add(int64(len(batch)))
+		if err := parseQ.Add(batch); err != nil {
+			return xerrors.Errorf("unable to add to pusher q: %w", err)
+		}
+		batch = nil
+		size = 0
+		return nil
+	}
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case err := <-s.errCh:
+			s.cancel() // after first error cancel ctx, so any other errors would be dropped, but not deadlocked
+			return err
+		case amqpErr, ok := <-s.closeCh:
+			if s.ctx.Err() != nil {
+				return nil
+			}
+			if !ok {
+				return xerrors.New("channel is closed")
+			}
+			return xerrors.Errorf("channel is closed: %w", amqpErr)
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case msg := <-s.deliveries:
+			batch = append(batch, msg)
+			size += len(msg.Body)
+			if len(batch) >= s.config.BatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (s *Source) parse(batch []delivery) []abstract.ChangeItem {
+	st := time.Now()
+	var result []abstract.ChangeItem
+	for _, msg := range batch {
+		writeTime := msg.Timestamp
+		if writeTime.IsZero() {
+			writeTime = st
+		}
+		if s.parser == nil {
+			result = append(result, abstract.MakeRawMessage(msg.queue, writeTime, msg.queue, 0, int64(msg.DeliveryTag), msg.Body))
+			continue
+		}
+		result = append(result, s.parser.Do(parsers.Message{
+			Offset:     msg.DeliveryTag,
+			SeqNo:      msg.DeliveryTag,
+			Key:        messageKey(msg.Headers),
+			CreateTime: writeTime,
+			WriteTime:  writeTime,
+			Value:      msg.Body,
+			Headers:    messageHeaders(msg.Headers),
+		}, abstract.Partition{
+			Cluster:   "",
+			Partition: 0,
+			Topic:     msg.queue,
+		})...)
+	}
+	if s.parser != nil {
+		s.logger.Infof("convert done in %v, %v messages -> %v rows", time.Since(st), len(batch), len(result))
+		s.metrics.DecodeTime.RecordDuration(time.Since(st))
+	}
+	s.metrics.ChangeItems.Add(int64(len(result)))
+	return result
+}
+
+// ack - messages of different queues are read concurrently, so delivery tags of a batch are not contiguous
+// and every message is acked on its own instead of acking all up to the last one
+func (s *Source) ack(batch []delivery, pushSt time.Time, err error) {
+	if err != nil {
+		util.Send(s.ctx, s.errCh, err)
+		return
+	}
+	for _, msg := range batch {
+		if err := msg.Ack(false); err != nil {
+			util.Send(s.ctx, s.errCh, xerrors.Errorf("unable to ack message: %w", err))
+			return
+		}
+	}
+	s.metrics.PushTime.RecordDuration(time.Since(pushSt))
+}
+
+func (s *Source) Stop() {
+	s.once.Do(func() {
+		s.cancel()
+		if err := s.conn.Close(); err != nil && !xerrors.Is(err, amqp.ErrClosed) {
+			s.logger.Warn("unable to close connection", log.Error(err))
+		}
+	})
+}
+
+func (s *Source) consume(queue string, deliveries <-chan amqp.Delivery) {
+	for msg := range deliveries {
+		if !util.Send(s.ctx, s.deliveries, delivery{queue: queue, Delivery: msg}) {
+			return
+		}
+	}
+}
+
+func messageKey(headers amqp.Table) []byte {
+	switch key := headers[KeyHeader].(type) {
+	case string:
+		return []byte(key)
+	case []byte:
+		return key
+	}
+	return nil
+}
+
+func messageHeaders(headers amqp.Table) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(headers))
+	for key, value := range headers {
+		switch v := value.(type) {
+		case string:
+			result[key] = v
+		case []byte:
+			result[key] = string(v)
+		default:
+			result[key] = fmt.Sprintf("%v", v)
+		}
+	}
+	return result
+}
+
+func NewSource(transferID string, cfg *RabbitMQSource, logger log.Logger, registry metrics.Registry) (*Source, error) {
+	conn, err := cfg.Connection.Connect(transferID)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to connect: %w", err)
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("unable to open channel: %w", err)
+	}
+	if err := channel.Qos(cfg.Prefetch, 0, false); err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("unable to set prefetch: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	source := &Source{
+		config:     cfg,
+		logger:     logger,
+		metrics:    stats.NewSourceStats(registry),
+		conn:       conn,
+		channel:    channel,
+		deliveries: make(chan delivery),
+		closeCh:    channel.NotifyClose(make(chan *amqp.Error, 1)),
+		parser:     nil,
+		ctx:        ctx,
+		cancel:     cancel,
+		once:       sync.Once{},
+		errCh:      make(chan error),
+	}
+
+	if cfg.ParserConfig != nil {
+		parser, err := parsers.NewParserFromMap(cfg.ParserConfig, false, logger, source.metrics)
+		if err != nil {
+			source.Stop()
+			return nil, xerrors.Errorf("unable to make parser, err: %w", err)
+		}
+		source.parser = parser
+	}
+
+	for _, queue := range cfg.Queues {
+		deliveries, err := channel.Consume(queue, fmt.Sprintf("%s-%s", transferID, queue), false, false, false, false, nil)
+		if err != nil {
+			source.Stop()
+			return nil, xerrors.Errorf("unable to consume queue %s: %w", queue, err)
+		}
+		go source.consume(queue, deliveries)
+	}
+
+	return source, nil
+}
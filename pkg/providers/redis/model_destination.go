@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+const ProviderType = abstract.ProviderType("redis")
+
+type Layout string
+
+const (
+	// LayoutHash stores every row as the hash '<table>:<pk>' with a field per column
+	LayoutHash = Layout("hash")
+	// LayoutJSON stores every row as the JSON string '<table>:<pk>'
+	LayoutJSON = Layout("json")
+	// LayoutSortedSet stores primary keys of all rows in the sorted set '<table>' ordered by the score column
+	LayoutSortedSet = Layout("sorted_set")
+)
+
+type RedisTableSettings struct {
+	// Layout overrides the destination layout for the table
+	Layout Layout
+	// Key replaces the table name in keys, for example to keep tables of different schemas apart
+	Key string
+	// ScoreColumn is the numeric column ordering the sorted set, required for the sorted set layout
+	ScoreColumn string
+	// TTL of row keys, or of the whole sorted set for the sorted set layout. Keys never expire if it is zero
+	TTL time.Duration
+}
+
+type RedisDestination struct {
+	Hosts    []string // host:port, several hosts are treated as the cluster
+	DB       int
+	User     string
+	Password model.SecretString
+	TLS      bool
+	TLSFile  string `model:"PemFileContent"`
+
+	Layout Layout
+	TTL    time.Duration
+	// Tables overrides settings of tables by their names, both 'table' & 'schema.table' forms are accepted
+	Tables map[string]RedisTableSettings
+
+	Cleanup model.CleanupType
+}
+
+var _ model.Destination = (*RedisDestination)(nil)
+
+func (d *RedisDestination) WithDefaults() {
+	if d.Layout == "" {
+		d.Layout = LayoutHash
+	}
+	if d.Cleanup == "" {
+		d.Cleanup = model.Drop
+	}
+}
+
+func (d *RedisDestination) CleanupMode() model.CleanupType {
+	return d.Cleanup
+}
+
+func (RedisDestination) IsDestination() {}
+
+func (d *RedisDestination) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (d *RedisDestination) Validate() error {
+	if len(d.Hosts) == 0 {
+		return xerrors.New("at least one host is required")
+	}
+	if len(d.Hosts) > 1 && d.DB != 0 {
+		return xerrors.New("cluster supports only the database 0")
+	}
+	if d.Layout == LayoutSortedSet {
+		return xerrors.New("sorted set layout requires a score column, set it in per table settings")
+	}
+	if err := validateLayout(d.Layout); err != nil {
+		return xerrors.Errorf("invalid layout: %w", err)
+	}
+	for table, settings := range d.Tables {
+		if settings.Layout == "" {
+			continue
+		}
+		if err := validateLayout(settings.Layout); err != nil {
+			return xerrors.Errorf("invalid layout of table %s: %w", table, err)
+		}
+		if settings.Layout == LayoutSortedSet && settings.ScoreColumn == "" {
+			return xerrors.Errorf("sorted set layout of table %s requires a score column", table)
+		}
+	}
+	return nil
+}
+
+func validateLayout(layout Layout) error {
+	switch layout {
+	case LayoutHash, LayoutJSON, LayoutSortedSet:
+		return nil
+	default:
+		return xerrors.Errorf("unknown layout: %s", layout)
+	}
+}
+
+func (d *RedisDestination) TLSConfig() (*tls.Config, error) {
+	if !d.TLS {
+		return nil, nil
+	}
+	if d.TLSFile == "" {
+		return new(tls.Config), nil
+	}
+	cp := x509.NewCertPool()
+	if !cp.AppendCertsFromPEM([]byte(d.TLSFile)) {
+		return nil, xerrors.Errorf("credentials: failed to append certificates")
+	}
+	return &tls.Config{
+		RootCAs: cp,
+	}, nil
+}
+
+// tableSettings merges per table settings with the destination ones
+func (d *RedisDestination) tableSettings(tableID abstract.TableID) RedisTableSettings {
+	settings, ok := d.Tables[tableID.Namespace+"."+tableID.Name]
+	if !ok {
+		settings = d.Tables[tableID.Name]
+	}
+	if settings.Layout == "" {
+		settings.Layout = d.Layout
+	}
+	if settings.Key == "" {
+		settings.Key = tableID.Name
+	}
+	if settings.TTL == 0 {
+		settings.TTL = d.TTL
+	}
+	return settings
+}
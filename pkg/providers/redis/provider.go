@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"encoding/gob"
+
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/middlewares"
+	"github.com/transferia/transferia/pkg/providers"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+func init() {
+	gob.RegisterName("*server.RedisDestination", new(RedisDestination))
+	model.RegisterDestination(ProviderType, func() model.Destination {
+		return new(RedisDestination)
+	})
+	abstract.RegisterProviderName(ProviderType, "Redis")
+	providers.Register(ProviderType, New)
+}
+
+// To verify providers contract implementation
+var (
+	_ providers.Sinker = (*Provider)(nil)
+)
+
+type Provider struct {
+	logger   log.Logger
+	registry metrics.Registry
+	cp       coordinator.Coordinator
+	transfer *model.Transfer
+}
+
+func (p *Provider) Type() abstract.ProviderType {
+	return ProviderType
+}
+
+func (p *Provider) Sink(middlewares.Config) (abstract.Sinker, error) {
+	dst, ok := p.transfer.Dst.(*RedisDestination)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected target type: %T", p.transfer.Dst)
+	}
+	return NewSink(dst, p.registry, p.logger)
+}
+
+func New(lgr log.Logger, registry metrics.Registry, cp coordinator.Coordinator, transfer *model.Transfer) providers.Provider {
+	return &Provider{
+		logger:   lgr,
+		registry: registry,
+		cp:       cp,
+		transfer: transfer,
+	}
+}
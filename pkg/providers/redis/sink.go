@@ -0,0 +1,321 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/stats"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const (
+	pushTimeout = 5 * time.Minute
+	scanCount   = 1000
+)
+
+// sink writes all rows of a push by one pipeline, cleanup items flush the pipeline collected so far
+type sink struct {
+	config  *RedisDestination
+	client  redis.UniversalClient
+	logger  log.Logger
+	metrics *stats.SinkerStats
+}
+
+func (s *sink) Push(input []abstract.ChangeItem) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	pipe := s.client.Pipeline()
+	tableRows := make(map[abstract.TableID]int)
+	for i := range input {
+		item := &input[i]
+		switch {
+		case item.IsRowEvent():
+			if item.IsSystemTable() {
+				continue
+			}
+			if err := s.writeRow(ctx, pipe, item); err != nil {
+				return xerrors.Errorf("unable to write row of table %s: %w", item.TableID().Fqtn(), err)
+			}
+			tableRows[item.TableID()]++
+		case item.Kind == abstract.DropTableKind || item.Kind == abstract.TruncateTableKind:
+			if err := execPipeline(ctx, pipe); err != nil {
+				return xerrors.Errorf("unable to write rows: %w", err)
+			}
+			if err := s.cleanup(ctx, item); err != nil {
+				return xerrors.Errorf("unable to cleanup table %s: %w", item.TableID().Fqtn(), err)
+			}
+		}
+	}
+	if err := execPipeline(ctx, pipe); err != nil {
+		return xerrors.Errorf("unable to write rows: %w", err)
+	}
+	for tableID, rows := range tableRows {
+		s.metrics.Table(tableID.Fqtn(), "rows", rows)
+	}
+	s.metrics.Elapsed.RecordDuration(time.Since(start))
+	return nil
+}
+
+func (s *sink) Close() error {
+	return s.client.Close()
+}
+
+func (s *sink) writeRow(ctx context.Context, pipe redis.Pipeliner, item *abstract.ChangeItem) error {
+	settings := s.config.tableSettings(item.TableID())
+	pk, err := rowPK(item, item.Kind == abstract.DeleteKind)
+	if err != nil {
+		return xerrors.Errorf("unable to get primary key: %w", err)
+	}
+	if item.Kind == abstract.UpdateKind && len(item.OldKeys.KeyNames) != 0 && item.KeysChanged() {
+		oldPK, err := rowPK(item, true)
+		if err != nil {
+			return xerrors.Errorf("unable to get old primary key: %w", err)
+		}
+		deleteRow(ctx, pipe, settings, oldPK)
+	}
+	if item.Kind == abstract.DeleteKind {
+		deleteRow(ctx, pipe, settings, pk)
+		return nil
+	}
+
+	switch settings.Layout {
+	case LayoutHash:
+		var fields []interface{}
+		var nullFields []string
+		for i, column := range item.ColumnNames {
+			value, ok := fieldValue(item.ColumnValues[i])
+			if !ok {
+				nullFields = append(nullFields, column)
+				continue
+			}
+			fields = append(fields, column, value)
+		}
+		key := rowKey(settings, pk)
+		if len(nullFields) != 0 {
+			pipe.HDel(ctx, key, nullFields...)
+		}
+		if len(fields) != 0 {
+			pipe.HSet(ctx, key, fields...)
+		}
+		if settings.TTL > 0 {
+			pipe.Expire(ctx, key, settings.TTL)
+		}
+	case LayoutJSON:
+		value, err := json.Marshal(item.AsMap())
+		if err != nil {
+			return xerrors.Errorf("unable to marshal row: %w", err)
+		}
+		pipe.Set(ctx, rowKey(settings, pk), value, settings.TTL)
+	case LayoutSortedSet:
+		idx := item.ColumnNameIndex(settings.ScoreColumn)
+		if idx < 0 {
+			return xerrors.Errorf("score column %s not found", settings.ScoreColumn)
+		}
+		score, err := scoreValue(item.ColumnValues[idx])
+		if err != nil {
+			return xerrors.Errorf("unable to get score from column %s: %w", settings.ScoreColumn, err)
+		}
+		pipe.ZAdd(ctx, settings.Key, redis.Z{Score: score, Member: strings.Join(pk, ":")})
+		if settings.TTL > 0 {
+			pipe.Expire(ctx, settings.Key, settings.TTL)
+		}
+	default:
+		return xerrors.Errorf("unknown layout: %s", settings.Layout)
+	}
+	return nil
+}
+
+func deleteRow(ctx context.Context, pipe redis.Pipeliner, settings RedisTableSettings, pk []string) {
+	if settings.Layout == LayoutSortedSet {
+		pipe.ZRem(ctx, settings.Key, strings.Join(pk, ":"))
+		return
+	}
+	pipe.Del(ctx, rowKey(settings, pk))
+}
+
+// cleanup removes all keys of the table, both drop & truncate mean the same for a key-value storage
+func (s *sink) cleanup(ctx context.Context, item *abstract.ChangeItem) error {
+	if (item.Kind == abstract.DropTableKind && s.config.Cleanup != model.Drop) ||
+		(item.Kind == abstract.TruncateTableKind && s.config.Cleanup != model.Truncate) {
+		s.logger.Infof("Skipped cleanup of table %s due cleanup policy", item.TableID().Fqtn())
+		return nil
+	}
+	settings := s.config.tableSettings(item.TableID())
+	if settings.Layout == LayoutSortedSet {
+		if err := s.client.Del(ctx, settings.Key).Err(); err != nil {
+			return xerrors.Errorf("unable to delete sorted set %s: %w", settings.Key, err)
+		}
+		return nil
+	}
+	pattern := escapePattern(settings.Key) + ":*"
+	if cluster, ok := s.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return deleteByPattern(ctx, client, pattern)
+		})
+	}
+	return deleteByPattern(ctx, s.client, pattern)
+}
+
+// deleteByPattern unlinks keys one by one, since keys of one node may belong to different cluster slots
+func deleteByPattern(ctx context.Context, client redis.Cmdable, pattern string) error {
+	iter := client.Scan(ctx, 0, pattern, scanCount).Iterator()
+	pipe := client.Pipeline()
+	for iter.Next(ctx) {
+		pipe.Unlink(ctx, iter.Val())
+		if pipe.Len() >= scanCount {
+			if err := execPipeline(ctx, pipe); err != nil {
+				return xerrors.Errorf("unable to delete keys: %w", err)
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return xerrors.Errorf("unable to scan keys by %s: %w", pattern, err)
+	}
+	if err := execPipeline(ctx, pipe); err != nil {
+		return xerrors.Errorf("unable to delete keys: %w", err)
+	}
+	return nil
+}
+
+func execPipeline(ctx context.Context, pipe redis.Pipeliner) error {
+	if pipe.Len() == 0 {
+		return nil
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func rowKey(settings RedisTableSettings, pk []string) string {
+	return settings.Key + ":" + strings.Join(pk, ":")
+}
+
+// rowPK returns primary key values in the table schema order. Deleted rows have no column values,
+// their keys are in OldKeys, as well as old keys of updated rows
+func rowPK(item *abstract.ChangeItem, old bool) ([]string, error) {
+	names, values := item.ColumnNames, item.ColumnValues
+	if old {
+		names, values = item.OldKeys.KeyNames, item.OldKeys.KeyValues
+	}
+	var result []string
+	for _, column := range item.TableSchema.Columns() {
+		if !column.IsKey() {
+			continue
+		}
+		idx := slices.Index(names, column.ColumnName)
+		if idx < 0 || idx >= len(values) {
+			return nil, xerrors.Errorf("primary key column %s is missing", column.ColumnName)
+		}
+		result = append(result, fmt.Sprintf("%v", values[idx]))
+	}
+	if len(result) == 0 {
+		return nil, xerrors.New("table has no primary key, rows can't be addressed by keys")
+	}
+	return result, nil
+}
+
+func fieldValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, false
+	case []byte:
+		return v, true
+	case string:
+		return v, true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool, json.Number:
+		return fmt.Sprintf("%v", v), true
+	default:
+		result, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v), true
+		}
+		return result, true
+	}
+}
+
+func scoreValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case time.Time:
+		return float64(v.UnixNano()) / float64(time.Second), nil
+	default:
+		return 0, xerrors.Errorf("unsupported score type %T", value)
+	}
+}
+
+func escapePattern(key string) string {
+	var result strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(`*?[]\`, r) {
+			result.WriteByte('\\')
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+func NewSink(cfg *RedisDestination, registry metrics.Registry, lgr log.Logger) (abstract.Sinker, error) {
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get TLS config: %w", err)
+	}
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:     cfg.Hosts,
+		DB:        cfg.DB,
+		Username:  cfg.User,
+		Password:  string(cfg.Password),
+		TLSConfig: tlsConfig,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, xerrors.Errorf("unable to ping redis: %w", err)
+	}
+	return &sink{
+		config:  cfg,
+		client:  client,
+		logger:  lgr,
+		metrics: stats.NewSinkerStats(registry),
+	}, nil
+}
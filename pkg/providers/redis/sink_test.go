@@ -0,0 +1,157 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+var testSchema = abstract.NewTableSchema([]abstract.ColSchema{
+	{ColumnName: "id", DataType: "int64", PrimaryKey: true},
+	{ColumnName: "name", DataType: "string"},
+	{ColumnName: "rank", DataType: "double"},
+})
+
+func row(kind abstract.Kind, table string, id int64, name interface{}, rank float64) abstract.ChangeItem {
+	item := abstract.ChangeItem{
+		Kind:         kind,
+		Schema:       "public",
+		Table:        table,
+		ColumnNames:  []string{"id", "name", "rank"},
+		ColumnValues: []interface{}{id, name, rank},
+		TableSchema:  testSchema,
+	}
+	if kind == abstract.DeleteKind {
+		item.ColumnNames = nil
+		item.ColumnValues = nil
+		item.OldKeys = abstract.OldKeysType{
+			KeyNames:  []string{"id"},
+			KeyTypes:  []string{"int64"},
+			KeyValues: []interface{}{id},
+		}
+	}
+	return item
+}
+
+func newTestSink(t *testing.T, cfg *RedisDestination) (*miniredis.Miniredis, abstract.Sinker) {
+	server := miniredis.RunT(t)
+	cfg.Hosts = []string{server.Addr()}
+	cfg.WithDefaults()
+	require.NoError(t, cfg.Validate())
+	sinker, err := NewSink(cfg, solomon.NewRegistry(solomon.NewRegistryOpts()), logger.Log)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, sinker.Close()) })
+	return server, sinker
+}
+
+func TestHashLayout(t *testing.T) {
+	server, sinker := newTestSink(t, &RedisDestination{
+		TTL: time.Hour,
+		Tables: map[string]RedisTableSettings{
+			"public.short": {TTL: time.Minute},
+		},
+	})
+
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		row(abstract.InsertKind, "users", 1, "alice", 1.5),
+		row(abstract.InsertKind, "users", 2, "bob", 2),
+		row(abstract.InsertKind, "short", 1, "tmp", 0),
+	}))
+	require.Equal(t, "alice", server.HGet("users:1", "name"))
+	require.Equal(t, "1.5", server.HGet("users:1", "rank"))
+	require.Equal(t, time.Hour, server.TTL("users:1"))
+	require.Equal(t, time.Minute, server.TTL("short:1"))
+
+	update := row(abstract.UpdateKind, "users", 1, nil, 3)
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		update,
+		row(abstract.DeleteKind, "users", 2, nil, 0),
+	}))
+	fields, err := server.HKeys("users:1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "rank"}, fields)
+	require.Equal(t, "3", server.HGet("users:1", "rank"))
+	require.False(t, server.Exists("users:2"))
+
+	moved := row(abstract.UpdateKind, "users", 10, "alice", 3)
+	moved.OldKeys = abstract.OldKeysType{KeyNames: []string{"id"}, KeyTypes: []string{"int64"}, KeyValues: []interface{}{int64(1)}}
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{moved}))
+	require.False(t, server.Exists("users:1"))
+	require.Equal(t, "alice", server.HGet("users:10", "name"))
+
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		{Kind: abstract.DropTableKind, Schema: "public", Table: "users"},
+	}))
+	require.Equal(t, []string{"short:1"}, server.Keys())
+}
+
+func TestJSONLayout(t *testing.T) {
+	server, sinker := newTestSink(t, &RedisDestination{Layout: LayoutJSON})
+
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		row(abstract.InsertKind, "users", 1, "alice", 1.5),
+	}))
+	value, err := server.Get("users:1")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"name":"alice","rank":1.5}`, value)
+	require.Equal(t, time.Duration(0), server.TTL("users:1"))
+
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		row(abstract.DeleteKind, "users", 1, nil, 0),
+	}))
+	require.False(t, server.Exists("users:1"))
+}
+
+func TestSortedSetLayout(t *testing.T) {
+	server, sinker := newTestSink(t, &RedisDestination{
+		Tables: map[string]RedisTableSettings{
+			"leaderboard": {Layout: LayoutSortedSet, Key: "top", ScoreColumn: "rank"},
+		},
+		Cleanup: model.Truncate,
+	})
+
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		row(abstract.InsertKind, "leaderboard", 1, "alice", 10),
+		row(abstract.InsertKind, "leaderboard", 2, "bob", 5),
+		row(abstract.InsertKind, "leaderboard", 3, "carol", 7),
+		row(abstract.UpdateKind, "leaderboard", 2, "bob", 20),
+		row(abstract.DeleteKind, "leaderboard", 3, nil, 0),
+	}))
+	members, err := server.ZMembers("top")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2"}, members)
+	score, err := server.ZScore("top", "2")
+	require.NoError(t, err)
+	require.Equal(t, 20.0, score)
+
+	// drop is skipped by the truncate cleanup policy
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		{Kind: abstract.DropTableKind, Schema: "public", Table: "leaderboard"},
+	}))
+	require.True(t, server.Exists("top"))
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		{Kind: abstract.TruncateTableKind, Schema: "public", Table: "leaderboard"},
+	}))
+	require.False(t, server.Exists("top"))
+}
+
+func TestValidate(t *testing.T) {
+	cfg := &RedisDestination{Hosts: []string{"localhost:6379"}, Layout: LayoutSortedSet}
+	require.Error(t, cfg.Validate())
+
+	cfg = &RedisDestination{
+		Hosts:  []string{"localhost:6379"},
+		Layout: LayoutHash,
+		Tables: map[string]RedisTableSettings{"leaderboard": {Layout: LayoutSortedSet}},
+	}
+	require.Error(t, cfg.Validate())
+
+	cfg = &RedisDestination{Hosts: []string{"redis-1:6379", "redis-2:6379"}, DB: 1, Layout: LayoutHash}
+	require.Error(t, cfg.Validate())
+}
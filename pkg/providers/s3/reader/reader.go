@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/transferia/transferia/library/go/core/xerrors"
@@ -161,30 +160,30 @@ func appendSystemColsTableSchema(cols []abstract.ColSchema) *abstract.TableSchem
 func New(
 	src *s3.S3Source,
 	lgr log.Logger,
-	sess *session.Session,
+	client s3iface.S3API,
 	metrics *stats.SourceStats,
 ) (Reader, error) {
 	switch src.InputFormat {
 	case model.ParsingFormatPARQUET:
-		reader, err := NewParquet(src, lgr, sess, metrics)
+		reader, err := NewParquet(src, lgr, client, metrics)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to initialize new parquet reader: %w", err)
 		}
 		return reader, nil
 	case model.ParsingFormatJSON:
-		reader, err := NewJSONParserReader(src, lgr, sess, metrics)
+		reader, err := NewJSONParserReader(src, lgr, client, metrics)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to initialize new json reader: %w", err)
 		}
 		return reader, nil
 	case model.ParsingFormatJSONLine:
-		reader, err := NewJSONLineReader(src, lgr, sess, metrics)
+		reader, err := NewJSONLineReader(src, lgr, client, metrics)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to initialize new jsonline reader: %w", err)
 		}
 		return reader, nil
 	case model.ParsingFormatCSV:
-		reader, err := NewCSVReader(src, lgr, sess, metrics)
+		reader, err := NewCSVReader(src, lgr, client, metrics)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to initialize new csv reader: %w", err)
 		}
@@ -218,7 +217,7 @@ func New(
 		reader, err := NewGenericParserReader(
 			src,
 			lgr,
-			sess,
+			client,
 			metrics,
 			parser,
 		)
@@ -227,7 +226,7 @@ func New(
 		}
 		return reader, nil
 	case model.ParsingFormatLine:
-		reader, err := NewLineReader(src, lgr, sess, metrics)
+		reader, err := NewLineReader(src, lgr, client, metrics)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to initialize new line reader: %w", err)
 		}
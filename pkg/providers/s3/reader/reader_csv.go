@@ -12,7 +12,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -587,7 +586,7 @@ func (r *CSVReader) newCSVReaderFromReader(reader io.Reader) *csv.Reader {
 	return csvReader
 }
 
-func NewCSVReader(src *s3.S3Source, lgr log.Logger, sess *session.Session, metrics *stats.SourceStats) (*CSVReader, error) {
+func NewCSVReader(src *s3.S3Source, lgr log.Logger, client s3iface.S3API, metrics *stats.SourceStats) (*CSVReader, error) {
 	if src == nil || src.Format.CSVSetting == nil {
 		return nil, xerrors.New("uninitialized settings for csv reader")
 	}
@@ -618,8 +617,8 @@ func NewCSVReader(src *s3.S3Source, lgr log.Logger, sess *session.Session, metri
 			Name:      src.TableName,
 		},
 		bucket:                  src.Bucket,
-		client:                  aws_s3.New(sess),
-		downloader:              s3manager.NewDownloader(sess),
+		client:                  client,
+		downloader:              s3manager.NewDownloaderWithClient(client),
 		logger:                  lgr,
 		tableSchema:             abstract.NewTableSchema(src.OutputSchema),
 		fastCols:                abstract.NewTableSchema(src.OutputSchema).FastColumns(),
@@ -10,7 +10,6 @@ import (
 	"sync/atomic"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -259,15 +258,15 @@ func (r *GenericParserReader) resolveSchema(ctx context.Context, key string) (*a
 	return r.tableSchema, nil
 }
 
-func NewGenericParserReader(src *s3.S3Source, lgr log.Logger, sess *session.Session, metrics *stats.SourceStats, parser parsers.Parser) (*GenericParserReader, error) {
+func NewGenericParserReader(src *s3.S3Source, lgr log.Logger, client s3iface.S3API, metrics *stats.SourceStats, parser parsers.Parser) (*GenericParserReader, error) {
 	reader := &GenericParserReader{
 		table: abstract.TableID{
 			Namespace: src.TableNamespace,
 			Name:      src.TableName,
 		},
 		bucket:      src.Bucket,
-		client:      aws_s3.New(sess),
-		downloader:  s3manager.NewDownloader(sess),
+		client:      client,
+		downloader:  s3manager.NewDownloaderWithClient(client),
 		logger:      lgr,
 		tableSchema: abstract.NewTableSchema(src.OutputSchema),
 		pathPrefix:  src.PathPrefix,
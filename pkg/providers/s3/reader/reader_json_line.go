@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -479,7 +478,7 @@ func readSingleJSONObject(reader *bufio.Reader) (string, error) {
 	return string(extractedLine), nil
 }
 
-func NewJSONLineReader(src *s3.S3Source, lgr log.Logger, sess *session.Session, metrics *stats.SourceStats) (*JSONLineReader, error) {
+func NewJSONLineReader(src *s3.S3Source, lgr log.Logger, client s3iface.S3API, metrics *stats.SourceStats) (*JSONLineReader, error) {
 	if src == nil || src.Format.JSONLSetting == nil {
 		return nil, xerrors.New("uninitialized settings for jsonline reader")
 	}
@@ -495,8 +494,8 @@ func NewJSONLineReader(src *s3.S3Source, lgr log.Logger, sess *session.Session,
 		newlinesInValue:         jsonlSettings.NewlinesInValue,
 		unexpectedFieldBehavior: jsonlSettings.UnexpectedFieldBehavior,
 		blockSize:               jsonlSettings.BlockSize,
-		client:                  aws_s3.New(sess),
-		downloader:              s3manager.NewDownloader(sess),
+		client:                  client,
+		downloader:              s3manager.NewDownloaderWithClient(client),
 		logger:                  lgr,
 		table: abstract.TableID{
 			Namespace: src.TableNamespace,
@@ -9,7 +9,6 @@ import (
 	"math"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -304,7 +303,7 @@ func (r *JSONParserReader) resolveSchema(ctx context.Context, key string) (*abst
 	return abstract.NewTableSchema(cols), nil
 }
 
-func NewJSONParserReader(src *s3.S3Source, lgr log.Logger, sess *session.Session, metrics *stats.SourceStats) (*JSONParserReader, error) {
+func NewJSONParserReader(src *s3.S3Source, lgr log.Logger, client s3iface.S3API, metrics *stats.SourceStats) (*JSONParserReader, error) {
 	if src == nil || src.Format.JSONLSetting == nil {
 		return nil, xerrors.New("uninitialized settings for jsonline reader")
 	}
@@ -320,8 +319,8 @@ func NewJSONParserReader(src *s3.S3Source, lgr log.Logger, sess *session.Session
 		newlinesInValue:         jsonlSettings.NewlinesInValue,
 		unexpectedFieldBehavior: jsonlSettings.UnexpectedFieldBehavior,
 		blockSize:               jsonlSettings.BlockSize,
-		client:                  aws_s3.New(sess),
-		downloader:              s3manager.NewDownloader(sess),
+		client:                  client,
+		downloader:              s3manager.NewDownloaderWithClient(client),
 		logger:                  lgr,
 		table: abstract.TableID{
 			Namespace: src.TableNamespace,
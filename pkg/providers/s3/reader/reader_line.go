@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -277,15 +276,15 @@ func (r *LineReader) ResolveSchema(ctx context.Context) (*abstract.TableSchema,
 	return abstract.NewTableSchema([]abstract.ColSchema{abstract.NewColSchema("row", schema.TypeBytes, false)}), nil
 }
 
-func NewLineReader(src *s3.S3Source, lgr log.Logger, sess *session.Session, metrics *stats.SourceStats) (*LineReader, error) {
+func NewLineReader(src *s3.S3Source, lgr log.Logger, client s3iface.S3API, metrics *stats.SourceStats) (*LineReader, error) {
 	reader := &LineReader{
 		table: abstract.TableID{
 			Namespace: src.TableNamespace,
 			Name:      src.TableName,
 		},
 		bucket:         src.Bucket,
-		client:         aws_s3.New(sess),
-		downloader:     s3manager.NewDownloader(sess),
+		client:         client,
+		downloader:     s3manager.NewDownloaderWithClient(client),
 		logger:         lgr,
 		metrics:        metrics,
 		tableSchema:    abstract.NewTableSchema(src.OutputSchema),
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/parquet-go/parquet-go"
@@ -340,7 +339,7 @@ func (r *ReaderParquet) ParsePassthrough(chunk chunk_pusher.Chunk) []abstract.Ch
 	return chunk.Items
 }
 
-func NewParquet(src *s3.S3Source, lgr log.Logger, sess *session.Session, metrics *stats.SourceStats) (*ReaderParquet, error) {
+func NewParquet(src *s3.S3Source, lgr log.Logger, client s3iface.S3API, metrics *stats.SourceStats) (*ReaderParquet, error) {
 	if src == nil {
 		return nil, xerrors.New("uninitialized settings for parquet reader")
 	}
@@ -350,7 +349,7 @@ func NewParquet(src *s3.S3Source, lgr log.Logger, sess *session.Session, metrics
 		batchSize:      src.ReadBatchSize,
 		pathPrefix:     src.PathPrefix,
 		pathPattern:    src.PathPattern,
-		client:         aws_s3.New(sess),
+		client:         client,
 		logger:         lgr,
 		table: abstract.TableID{
 			Namespace: src.TableNamespace,
@@ -9,7 +9,9 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
 	"github.com/transferia/transferia/library/go/core/metrics"
 	"github.com/transferia/transferia/library/go/core/xerrors"
 	"github.com/transferia/transferia/pkg/abstract"
@@ -25,11 +27,11 @@ import (
 )
 
 type sinker struct {
-	client              *s3.S3
+	client              s3iface.S3API
 	cfg                 *s3_provider.S3Destination
 	snapshots           map[string]map[string]*snapshotHolder
 	logger              log.Logger
-	uploader            *s3manager.Uploader
+	uploader            s3manageriface.UploaderAPI
 	metrics             *stats.SinkerStats
 	replicationUploader *replicationUploader
 	mu                  sync.Mutex
@@ -355,18 +357,21 @@ func NewSinker(lgr log.Logger, cfg *s3_provider.S3Destination, mtrcs metrics.Reg
 		return nil, xerrors.Errorf("unable to create session to s3 bucket: %w", err)
 	}
 
+	uploader := s3manager.NewUploader(sess)
+	uploader.PartSize = cfg.PartSize
+	return NewSinkerWithClient(lgr, cfg, s3.New(sess), uploader, mtrcs, cp, transferID), nil
+}
+
+// NewSinkerWithClient creates the sinker over the given client & uploader instead of aws session made of the destination connection
+func NewSinkerWithClient(lgr log.Logger, cfg *s3_provider.S3Destination, client s3iface.S3API, uploader s3manageriface.UploaderAPI, mtrcs metrics.Registry, cp coordinator.Coordinator, transferID string) *sinker {
 	buffer := &replicationUploader{
 		cfg:      cfg,
 		logger:   log.With(lgr, log.Any("sub_component", "uploader")),
-		uploader: s3manager.NewUploader(sess),
+		uploader: uploader,
 	}
 
-	s3Client := s3.New(sess)
-	uploader := s3manager.NewUploader(sess)
-	uploader.PartSize = cfg.PartSize
-
 	return &sinker{
-		client:              s3Client,
+		client:              client,
 		cfg:                 cfg,
 		logger:              lgr,
 		metrics:             stats.NewSinkerStats(mtrcs),
@@ -374,5 +379,5 @@ func NewSinker(lgr log.Logger, cfg *s3_provider.S3Destination, mtrcs metrics.Reg
 		mu:                  sync.Mutex{},
 		snapshots:           map[string]map[string]*snapshotHolder{},
 		replicationUploader: buffer,
-	}, nil
+	}
 }
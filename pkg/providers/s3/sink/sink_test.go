@@ -12,6 +12,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/stretchr/testify/require"
 	"github.com/transferia/transferia/internal/logger"
 	"github.com/transferia/transferia/library/go/core/metrics/solomon"
@@ -25,7 +26,7 @@ import (
 	"go.ytsaurus.tech/yt/go/schema"
 )
 
-func canonFile(t *testing.T, client *s3.S3, bucket, file string) {
+func canonFile(t *testing.T, client s3iface.S3API, bucket, file string) {
 	obj, err := client.GetObject(&s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(file),
@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
 	"github.com/transferia/transferia/library/go/core/xerrors"
 	"github.com/transferia/transferia/pkg/abstract"
 	"github.com/transferia/transferia/pkg/format"
@@ -25,7 +26,7 @@ var (
 type replicationUploader struct {
 	cfg      *s3_provider.S3Destination
 	logger   log.Logger
-	uploader *s3manager.Uploader
+	uploader s3manageriface.UploaderAPI
 }
 
 func (u *replicationUploader) Upload(name string, lsns []uint64, data []byte) error {
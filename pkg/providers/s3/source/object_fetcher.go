@@ -38,6 +38,9 @@ func NewObjectFetcher(ctx context.Context, client s3iface.S3API, logger log.Logg
 	}
 
 	if sourceConfig.EventSource.SQS != nil && sourceConfig.EventSource.SQS.QueueName != "" {
+		if sess == nil {
+			return nil, xerrors.New("sqs event source requires aws session")
+		}
 		source, err := NewSQSSource(ctx, logger, reader, sess, sourceConfig)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to initialize new sqs source: %w", err)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/session"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/cenkalti/backoff/v4"
@@ -142,18 +143,25 @@ func NewSource(src *s3.S3Source, transferID string, logger log.Logger, registry
 	if err != nil {
 		return nil, xerrors.Errorf("failed to create aws session: %w", err)
 	}
+	return newSource(src, aws_s3.New(sess), sess, transferID, logger, registry, cp)
+}
+
+// NewSourceWithClient creates the source over the given client, objects are always polled,
+// since event sources need an aws session
+func NewSourceWithClient(src *s3.S3Source, client s3iface.S3API, transferID string, logger log.Logger, registry metrics.Registry, cp coordinator.Coordinator) (abstract.Source, error) {
+	return newSource(src, client, nil, transferID, logger, registry, cp)
+}
 
+func newSource(src *s3.S3Source, client s3iface.S3API, sess *session.Session, transferID string, logger log.Logger, registry metrics.Registry, cp coordinator.Coordinator) (abstract.Source, error) {
 	metrics := stats.NewSourceStats(registry)
 
-	reader, err := reader.New(src, logger, sess, metrics)
+	reader, err := reader.New(src, logger, client, metrics)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to create reader: %w", err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	client := aws_s3.New(sess)
-
 	fetcher, err := NewObjectFetcher(ctx, client, logger, cp, transferID, reader, sess, src)
 	if err != nil {
 		cancel()
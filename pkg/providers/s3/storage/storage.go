@@ -125,8 +125,12 @@ func New(src *s3.S3Source, lgr log.Logger, registry metrics.Registry) (*Storage,
 	if err != nil {
 		return nil, xerrors.Errorf("failed to create aws session: %w", err)
 	}
+	return NewWithClient(src, aws_s3.New(sess), lgr, registry)
+}
 
-	r, err := reader.New(src, lgr, sess, stats.NewSourceStats(registry))
+// NewWithClient creates the storage over the given client instead of aws session made of the source connection
+func NewWithClient(src *s3.S3Source, client s3iface.S3API, lgr log.Logger, registry metrics.Registry) (*Storage, error) {
+	r, err := reader.New(src, lgr, client, stats.NewSourceStats(registry))
 	if err != nil {
 		return nil, xerrors.Errorf("unable to create reader: %w", err)
 	}
@@ -136,7 +140,7 @@ func New(src *s3.S3Source, lgr log.Logger, registry metrics.Registry) (*Storage,
 	}
 	return &Storage{
 		cfg:         src,
-		client:      aws_s3.New(sess),
+		client:      client,
 		logger:      lgr,
 		tableSchema: tableSchema,
 		reader:      r,
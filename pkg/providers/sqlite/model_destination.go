@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+type SQLiteDestination struct {
+	// Path to the database file, it is created if absent
+	Path    string
+	Cleanup model.CleanupType
+}
+
+var _ model.Destination = (*SQLiteDestination)(nil)
+
+func (d *SQLiteDestination) WithDefaults() {
+	if d.Cleanup == "" {
+		d.Cleanup = model.Drop
+	}
+}
+
+func (d *SQLiteDestination) CleanupMode() model.CleanupType {
+	return d.Cleanup
+}
+
+func (SQLiteDestination) IsDestination() {}
+
+func (d *SQLiteDestination) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (d *SQLiteDestination) Validate() error {
+	if d.Path == "" {
+		return xerrors.New("path to the database file is required")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"slices"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+const ProviderType = abstract.ProviderType("sqlite")
+
+type SQLiteSource struct {
+	Path string
+	// IncludeTables & ExcludeTables filter tables by names, all tables are transferred by default
+	IncludeTables []string
+	ExcludeTables []string
+	// DesiredShardRows - tables with more rows are split into rowid ranges of about this size, loaded in parallel
+	DesiredShardRows uint64
+}
+
+var _ model.Source = (*SQLiteSource)(nil)
+
+func (s *SQLiteSource) WithDefaults() {
+	if s.DesiredShardRows == 0 {
+		s.DesiredShardRows = 1000000
+	}
+}
+
+func (SQLiteSource) IsSource() {}
+
+func (s *SQLiteSource) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (s *SQLiteSource) Validate() error {
+	if s.Path == "" {
+		return xerrors.New("path to the database file is required")
+	}
+	return nil
+}
+
+// Include - SQLite has no schemas, so tables are matched only by names
+func (s *SQLiteSource) Include(tID abstract.TableID) bool {
+	if slices.Contains(s.ExcludeTables, tID.Name) {
+		return false
+	}
+	return len(s.IncludeTables) == 0 || slices.Contains(s.IncludeTables, tID.Name)
+}
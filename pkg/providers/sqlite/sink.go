@@ -0,0 +1,290 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/abstract/typesystem"
+	"github.com/transferia/transferia/pkg/stats"
+	"go.ytsaurus.tech/library/go/core/log"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+const pushTimeout = 10 * time.Minute
+
+// sink writes every push in one transaction. SQLite has no schemas, so tables are named by table names only
+type sink struct {
+	config  *SQLiteDestination
+	db      *sql.DB
+	logger  log.Logger
+	metrics *stats.SinkerStats
+	created map[string]bool
+}
+
+type pushTx struct {
+	tx         *sql.Tx
+	statements map[string]*sql.Stmt
+	created    map[string]bool
+	dropped    map[string]bool
+}
+
+func (s *sink) Push(input []abstract.ChangeItem) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xerrors.Errorf("unable to begin transaction: %w", err)
+	}
+	ptx := &pushTx{
+		tx:         tx,
+		statements: make(map[string]*sql.Stmt),
+		created:    make(map[string]bool),
+		dropped:    make(map[string]bool),
+	}
+	tableRows := make(map[abstract.TableID]int)
+	for i := range input {
+		item := &input[i]
+		if item.IsSystemTable() {
+			continue
+		}
+		if err := s.apply(ctx, ptx, item); err != nil {
+			_ = tx.Rollback()
+			return xerrors.Errorf("unable to apply %s to table %s: %w", item.Kind, item.Table, err)
+		}
+		if item.IsRowEvent() {
+			tableRows[item.TableID()]++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("unable to commit transaction: %w", err)
+	}
+
+	for table := range ptx.dropped {
+		delete(s.created, table)
+	}
+	for table := range ptx.created {
+		s.created[table] = true
+	}
+	for tableID, rows := range tableRows {
+		s.metrics.Table(tableID.Fqtn(), "rows", rows)
+	}
+	s.metrics.Elapsed.RecordDuration(time.Since(start))
+	return nil
+}
+
+func (s *sink) Close() error {
+	return s.db.Close()
+}
+
+func (s *sink) apply(ctx context.Context, ptx *pushTx, item *abstract.ChangeItem) error {
+	switch item.Kind {
+	case abstract.InitTableLoad, abstract.InitShardedTableLoad:
+		return s.createTable(ctx, ptx, item)
+	case abstract.DropTableKind:
+		if s.config.Cleanup != model.Drop {
+			s.logger.Infof("Skipped dropping table %s due cleanup policy", item.Table)
+			return nil
+		}
+		if _, err := ptx.tx.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", quote(item.Table))); err != nil {
+			return xerrors.Errorf("unable to drop table: %w", err)
+		}
+		delete(ptx.created, item.Table)
+		ptx.dropped[item.Table] = true
+		return nil
+	case abstract.TruncateTableKind:
+		if s.config.Cleanup != model.Truncate {
+			s.logger.Infof("Skipped truncating table %s due cleanup policy", item.Table)
+			return nil
+		}
+		exists, err := s.tableExists(ctx, ptx, item.Table)
+		if err != nil {
+			return xerrors.Errorf("unable to check table existence: %w", err)
+		}
+		if !exists {
+			return nil
+		}
+		if _, err := ptx.tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", quote(item.Table))); err != nil {
+			return xerrors.Errorf("unable to truncate table: %w", err)
+		}
+		return nil
+	case abstract.InsertKind, abstract.UpdateKind:
+		if err := s.createTable(ctx, ptx, item); err != nil {
+			return err
+		}
+		if item.Kind == abstract.UpdateKind && len(item.OldKeys.KeyNames) != 0 && item.KeysChanged() {
+			if err := s.deleteRow(ctx, ptx, item); err != nil {
+				return xerrors.Errorf("unable to delete row by old keys: %w", err)
+			}
+		}
+		return s.upsertRow(ctx, ptx, item)
+	case abstract.DeleteKind:
+		if err := s.createTable(ctx, ptx, item); err != nil {
+			return err
+		}
+		return s.deleteRow(ctx, ptx, item)
+	default:
+		return nil
+	}
+}
+
+func (s *sink) tableExists(ctx context.Context, ptx *pushTx, table string) (bool, error) {
+	if s.created[table] && !ptx.dropped[table] || ptx.created[table] {
+		return true, nil
+	}
+	var count int
+	if err := ptx.tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *sink) createTable(ctx context.Context, ptx *pushTx, item *abstract.ChangeItem) error {
+	if s.created[item.Table] && !ptx.dropped[item.Table] || ptx.created[item.Table] {
+		return nil
+	}
+	if _, err := ptx.tx.ExecContext(ctx, createTableQuery(item.Table, item.TableSchema.Columns())); err != nil {
+		return xerrors.Errorf("unable to create table: %w", err)
+	}
+	ptx.created[item.Table] = true
+	return nil
+}
+
+func createTableQuery(table string, columns []abstract.ColSchema) string {
+	var definitions, keys []string
+	for _, column := range columns {
+		definition := quote(column.ColumnName) + " " + targetType(column)
+		if column.Required && !column.IsKey() {
+			definition += " NOT NULL"
+		}
+		definitions = append(definitions, definition)
+		if column.IsKey() {
+			keys = append(keys, quote(column.ColumnName))
+		}
+	}
+	if len(keys) > 0 {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(keys, ", ")))
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quote(table), strings.Join(definitions, ", "))
+}
+
+// targetType keeps the original type of columns transferred from SQLite
+func targetType(column abstract.ColSchema) string {
+	if strings.HasPrefix(column.OriginalType, "sqlite:") {
+		return strings.TrimPrefix(column.OriginalType, "sqlite:")
+	}
+	if result, ok := typesystem.RuleFor(ProviderType).Target[schema.Type(column.DataType)]; ok {
+		return result
+	}
+	return "BLOB"
+}
+
+func (s *sink) upsertRow(ctx context.Context, ptx *pushTx, item *abstract.ChangeItem) error {
+	keys := item.MakeMapKeys()
+	quoted := make([]string, len(item.ColumnNames))
+	placeholders := make([]string, len(item.ColumnNames))
+	var keyColumns, updates []string
+	for i, name := range item.ColumnNames {
+		quoted[i] = quote(name)
+		placeholders[i] = "?"
+		if keys[name] {
+			keyColumns = append(keyColumns, quote(name))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", quote(name), quote(name)))
+		}
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quote(item.Table), strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+	if len(keyColumns) > 0 {
+		if len(updates) > 0 {
+			query += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keyColumns, ", "), strings.Join(updates, ", "))
+		} else {
+			query += fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(keyColumns, ", "))
+		}
+	}
+
+	values := make([]interface{}, len(item.ColumnValues))
+	for i, value := range item.ColumnValues {
+		converted, err := writeValue(value)
+		if err != nil {
+			return xerrors.Errorf("unable to convert value of column %s: %w", item.ColumnNames[i], err)
+		}
+		values[i] = converted
+	}
+	return ptx.exec(ctx, query, values)
+}
+
+// deleteRow deletes by old keys, which are filtered by the table schema, since some sources put all columns there
+func (s *sink) deleteRow(ctx context.Context, ptx *pushTx, item *abstract.ChangeItem) error {
+	names, values := item.OldKeys.KeyNames, item.OldKeys.KeyValues
+	if len(names) == 0 {
+		names, values = item.ColumnNames, item.ColumnValues
+	}
+	var conditions []string
+	var args []interface{}
+	for _, column := range item.TableSchema.Columns() {
+		if !column.IsKey() {
+			continue
+		}
+		idx := slices.Index(names, column.ColumnName)
+		if idx < 0 || idx >= len(values) {
+			return xerrors.Errorf("primary key column %s is missing", column.ColumnName)
+		}
+		value, err := writeValue(values[idx])
+		if err != nil {
+			return xerrors.Errorf("unable to convert value of column %s: %w", column.ColumnName, err)
+		}
+		conditions = append(conditions, quote(column.ColumnName)+" = ?")
+		args = append(args, value)
+	}
+	if len(conditions) == 0 {
+		return xerrors.New("table has no primary key, rows can't be deleted")
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", quote(item.Table), strings.Join(conditions, " AND "))
+	return ptx.exec(ctx, query, args)
+}
+
+// exec prepares every distinct query once per transaction
+func (t *pushTx) exec(ctx context.Context, query string, args []interface{}) error {
+	statement, ok := t.statements[query]
+	if !ok {
+		var err error
+		statement, err = t.tx.PrepareContext(ctx, query)
+		if err != nil {
+			return xerrors.Errorf("unable to prepare query: %w", err)
+		}
+		t.statements[query] = statement
+	}
+	if _, err := statement.ExecContext(ctx, args...); err != nil {
+		return xerrors.Errorf("unable to execute query: %w", err)
+	}
+	return nil
+}
+
+func NewSink(cfg *SQLiteDestination, registry metrics.Registry, lgr log.Logger) (abstract.Sinker, error) {
+	db, err := sql.Open(driverName, fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", cfg.Path))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open database: %w", err)
+	}
+	// SQLite allows only one writer at a time
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, xerrors.Errorf("unable to open database %s: %w", cfg.Path, err)
+	}
+	return &sink{
+		config:  cfg,
+		db:      db,
+		logger:  lgr,
+		metrics: stats.NewSinkerStats(registry),
+		created: make(map[string]bool),
+	}, nil
+}
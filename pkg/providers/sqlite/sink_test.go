@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+var testSchema = abstract.NewTableSchema([]abstract.ColSchema{
+	{ColumnName: "id", DataType: "int64", PrimaryKey: true},
+	{ColumnName: "name", DataType: "utf8"},
+	{ColumnName: "rank", DataType: "double"},
+})
+
+func row(kind abstract.Kind, id int64, name interface{}, rank float64) abstract.ChangeItem {
+	item := abstract.ChangeItem{
+		Kind:         kind,
+		Schema:       "public",
+		Table:        "users",
+		ColumnNames:  []string{"id", "name", "rank"},
+		ColumnValues: []interface{}{id, name, rank},
+		TableSchema:  testSchema,
+	}
+	if kind == abstract.DeleteKind {
+		item.ColumnNames = nil
+		item.ColumnValues = nil
+		item.OldKeys = abstract.OldKeysType{
+			KeyNames:  []string{"id"},
+			KeyTypes:  []string{"int64"},
+			KeyValues: []interface{}{id},
+		}
+	}
+	return item
+}
+
+func newTestSink(t *testing.T, cfg *SQLiteDestination) abstract.Sinker {
+	cfg.WithDefaults()
+	require.NoError(t, cfg.Validate())
+	sinker, err := NewSink(cfg, solomon.NewRegistry(solomon.NewRegistryOpts()), logger.Log)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, sinker.Close()) })
+	return sinker
+}
+
+func readUsers(t *testing.T, path string) []abstract.ChangeItem {
+	storage := newTestStorage(t, &SQLiteSource{Path: path})
+	return loadRows(t, storage, abstract.TableDescription{Name: "users"})
+}
+
+func TestSinkUpsertAndDelete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "target.db")
+	sinker := newTestSink(t, &SQLiteDestination{Path: path})
+
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		{Kind: abstract.InitTableLoad, Schema: "public", Table: "users", TableSchema: testSchema},
+		row(abstract.InsertKind, 1, "alice", 1.5),
+		row(abstract.InsertKind, 2, "bob", 2),
+		row(abstract.InsertKind, 3, "carol", 3),
+	}))
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		row(abstract.UpdateKind, 1, "alice", 10),
+		row(abstract.InsertKind, 2, nil, 20),
+		row(abstract.DeleteKind, 3, nil, 0),
+	}))
+	// primary key change removes the row with the old key
+	moved := row(abstract.UpdateKind, 4, "bob", 20)
+	moved.OldKeys = abstract.OldKeysType{
+		KeyNames:  []string{"id", "name"},
+		KeyTypes:  []string{"int64", "utf8"},
+		KeyValues: []interface{}{int64(2), nil},
+	}
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{moved}))
+
+	rows := readUsers(t, path)
+	require.Len(t, rows, 2)
+	require.Equal(t, []interface{}{int64(1), "alice", float64(10)}, rows[0].ColumnValues)
+	require.Equal(t, []interface{}{int64(4), "bob", float64(20)}, rows[1].ColumnValues)
+	require.True(t, rows[0].TableSchema.Columns()[0].IsKey())
+	require.Equal(t, 1, rows[0].TableSchema.Columns().KeysNum())
+}
+
+func TestSinkRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "target.db")
+	sinker := newTestSink(t, &SQLiteDestination{Path: path})
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{row(abstract.InsertKind, 1, "alice", 1)}))
+
+	broken := row(abstract.InsertKind, 3, "carol", 3)
+	broken.ColumnNames = []string{"id", "name", "missing"}
+	require.Error(t, sinker.Push([]abstract.ChangeItem{row(abstract.InsertKind, 2, "bob", 2), broken}))
+
+	rows := readUsers(t, path)
+	require.Len(t, rows, 1)
+	require.Equal(t, []interface{}{int64(1), "alice", float64(1)}, rows[0].ColumnValues)
+}
+
+func TestSinkCleanup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "target.db")
+	sinker := newTestSink(t, &SQLiteDestination{Path: path, Cleanup: model.Truncate})
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{row(abstract.InsertKind, 1, "alice", 1)}))
+
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		{Kind: abstract.DropTableKind, Schema: "public", Table: "users"},
+	}))
+	require.Len(t, readUsers(t, path), 1)
+
+	require.NoError(t, sinker.Push([]abstract.ChangeItem{
+		{Kind: abstract.TruncateTableKind, Schema: "public", Table: "users"},
+		{Kind: abstract.TruncateTableKind, Schema: "public", Table: "absent"},
+	}))
+	require.Len(t, readUsers(t, path), 0)
+}
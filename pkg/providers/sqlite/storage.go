@@ -0,0 +1,273 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"go.ytsaurus.tech/library/go/core/log"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	driverName = "sqlite"
+	chunkSize  = 10000
+)
+
+type Storage struct {
+	config *SQLiteSource
+	db     *sql.DB
+	logger log.Logger
+}
+
+var (
+	_ abstract.Storage         = (*Storage)(nil)
+	_ abstract.ShardingStorage = (*Storage)(nil)
+)
+
+func (s *Storage) Close() {
+	if err := s.db.Close(); err != nil {
+		s.logger.Warn("unable to close database", log.Error(err))
+	}
+}
+
+func (s *Storage) Ping() error {
+	return s.db.Ping()
+}
+
+func (s *Storage) TableList(includeTableFilter abstract.IncludeTableList) (abstract.TableMap, error) {
+	rows, err := s.db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to list tables: %w", err)
+	}
+	var tableIDs []abstract.TableID
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return nil, xerrors.Errorf("unable to scan table name: %w", err)
+		}
+		tableID := *abstract.NewTableID("", name)
+		if s.config.Include(tableID) {
+			tableIDs = append(tableIDs, tableID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read tables: %w", err)
+	}
+	_ = rows.Close()
+
+	result := make(abstract.TableMap)
+	for _, tableID := range tableIDs {
+		tableSchema, err := s.TableSchema(context.Background(), tableID)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get schema of table %s: %w", tableID.Name, err)
+		}
+		rowsCount, err := s.ExactTableRowsCount(tableID)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to count rows of table %s: %w", tableID.Name, err)
+		}
+		result[tableID] = abstract.TableInfo{
+			EtaRow: rowsCount,
+			IsView: false,
+			Schema: tableSchema,
+		}
+	}
+	return model.FilteredMap(result, includeTableFilter), nil
+}
+
+// TableSchema is built from `PRAGMA table_info`, primary key columns go first in the order of the key
+func (s *Storage) TableSchema(ctx context.Context, table abstract.TableID) (*abstract.TableSchema, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", quote(table.Name)))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get table info: %w", err)
+	}
+	defer rows.Close()
+
+	var keys, columns []abstract.ColSchema
+	keyPositions := make(map[string]int)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, declared string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &declared, &notNull, &defaultValue, &pk); err != nil {
+			return nil, xerrors.Errorf("unable to scan column info: %w", err)
+		}
+		column := abstract.MakeOriginallyTypedColSchema(name, string(columnType(declared)), "sqlite:"+declared)
+		column.TableName = table.Name
+		column.PrimaryKey = pk > 0
+		column.Required = notNull == 1 || pk > 0
+		if pk > 0 {
+			// pk is the 1-based position of the column in the primary key
+			keyPositions[name] = pk
+			keys = append(keys, column)
+		} else {
+			columns = append(columns, column)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read columns: %w", err)
+	}
+	slices.SortFunc(keys, func(a, b abstract.ColSchema) int {
+		return keyPositions[a.ColumnName] - keyPositions[b.ColumnName]
+	})
+	if len(keys)+len(columns) == 0 {
+		return nil, xerrors.Errorf("table %s not found", table.Name)
+	}
+	return abstract.NewTableSchema(append(keys, columns...)), nil
+}
+
+func (s *Storage) LoadTable(ctx context.Context, table abstract.TableDescription, pusher abstract.Pusher) error {
+	st := time.Now()
+	tableSchema, err := s.TableSchema(ctx, table.ID())
+	if err != nil {
+		return xerrors.Errorf("unable to get schema: %w", err)
+	}
+	columns := tableSchema.Columns()
+	columnNames := make([]string, len(columns))
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		columnNames[i] = column.ColumnName
+		quoted[i] = quote(column.ColumnName)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoted, ", "), quote(table.Name))
+	if table.Filter != "" {
+		query += " WHERE " + string(table.Filter)
+	}
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return xerrors.Errorf("unable to select rows: %w", err)
+	}
+	defer rows.Close()
+
+	batch := make([]abstract.ChangeItem, 0, chunkSize)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return xerrors.Errorf("unable to scan row: %w", err)
+		}
+		size := 0
+		for i := range values {
+			value, err := castValue(columns[i], values[i])
+			if err != nil {
+				return xerrors.Errorf("unable to cast value of column %s: %w", columns[i].ColumnName, err)
+			}
+			values[i] = value
+			size += valueSize(value)
+		}
+		batch = append(batch, abstract.ChangeItem{
+			ID:           0,
+			LSN:          0,
+			CommitTime:   uint64(st.UnixNano()),
+			Counter:      0,
+			Kind:         abstract.InsertKind,
+			Schema:       table.Schema,
+			Table:        table.Name,
+			PartID:       table.PartID(),
+			ColumnNames:  columnNames,
+			ColumnValues: values,
+			TableSchema:  tableSchema,
+			OldKeys:      abstract.EmptyOldKeys(),
+			TxID:         "",
+			Query:        "",
+			Size:         abstract.RawEventSize(uint64(size)),
+		})
+		if len(batch) == chunkSize {
+			if err := pusher(batch); err != nil {
+				return xerrors.Errorf("unable to push rows: %w", err)
+			}
+			batch = make([]abstract.ChangeItem, 0, chunkSize)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return xerrors.Errorf("unable to read rows: %w", err)
+	}
+	if len(batch) > 0 {
+		if err := pusher(batch); err != nil {
+			return xerrors.Errorf("unable to push rows: %w", err)
+		}
+	}
+	return nil
+}
+
+// ShardTable splits tables by rowid ranges. Tables WITHOUT ROWID are loaded as a whole
+func (s *Storage) ShardTable(ctx context.Context, table abstract.TableDescription) ([]abstract.TableDescription, error) {
+	if table.Filter != "" || table.Offset != 0 {
+		return []abstract.TableDescription{table}, nil
+	}
+	var minRowID, maxRowID sql.NullInt64
+	query := fmt.Sprintf("SELECT MIN(rowid), MAX(rowid) FROM %s", quote(table.Name))
+	if err := s.db.QueryRowContext(ctx, query).Scan(&minRowID, &maxRowID); err != nil {
+		s.logger.Info("table is not sharded, since it has no rowid", log.String("table", table.Name), log.Error(err))
+		return []abstract.TableDescription{table}, nil
+	}
+	if !minRowID.Valid || uint64(maxRowID.Int64-minRowID.Int64) < s.config.DesiredShardRows {
+		return []abstract.TableDescription{table}, nil
+	}
+	step := int64(s.config.DesiredShardRows)
+	var result []abstract.TableDescription
+	for from := minRowID.Int64; from <= maxRowID.Int64; from += step {
+		to := from + step - 1
+		if to > maxRowID.Int64 || to < from {
+			to = maxRowID.Int64
+		}
+		result = append(result, abstract.TableDescription{
+			Name:   table.Name,
+			Schema: table.Schema,
+			Filter: abstract.WhereStatement(fmt.Sprintf("rowid >= %d AND rowid <= %d", from, to)),
+			EtaRow: uint64(to - from + 1),
+			Offset: 0,
+		})
+		if to == maxRowID.Int64 {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (s *Storage) ExactTableRowsCount(table abstract.TableID) (uint64, error) {
+	var count uint64
+	if err := s.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", quote(table.Name))).Scan(&count); err != nil {
+		return 0, xerrors.Errorf("unable to count rows: %w", err)
+	}
+	return count, nil
+}
+
+func (s *Storage) EstimateTableRowsCount(table abstract.TableID) (uint64, error) {
+	return s.ExactTableRowsCount(table)
+}
+
+func (s *Storage) TableExists(table abstract.TableID) (bool, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table.Name).Scan(&count); err != nil {
+		return false, xerrors.Errorf("unable to check table existence: %w", err)
+	}
+	return count > 0, nil
+}
+
+func NewStorage(cfg *SQLiteSource, lgr log.Logger) (*Storage, error) {
+	db, err := sql.Open(driverName, fmt.Sprintf("file:%s?mode=ro&_pragma=busy_timeout(5000)", cfg.Path))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, xerrors.Errorf("unable to open database %s: %w", cfg.Path, err)
+	}
+	return &Storage{
+		config: cfg,
+		db:     db,
+		logger: lgr,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/pkg/abstract"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+func prepareDatabase(t *testing.T, queries ...string) string {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open(driverName, path)
+	require.NoError(t, err)
+	defer db.Close()
+	for _, query := range queries {
+		_, err := db.Exec(query)
+		require.NoError(t, err)
+	}
+	return path
+}
+
+func newTestStorage(t *testing.T, cfg *SQLiteSource) *Storage {
+	cfg.WithDefaults()
+	require.NoError(t, cfg.Validate())
+	storage, err := NewStorage(cfg, logger.Log)
+	require.NoError(t, err)
+	t.Cleanup(storage.Close)
+	return storage
+}
+
+func loadRows(t *testing.T, storage *Storage, table abstract.TableDescription) []abstract.ChangeItem {
+	var result []abstract.ChangeItem
+	require.NoError(t, storage.LoadTable(context.Background(), table, func(items []abstract.ChangeItem) error {
+		result = append(result, items...)
+		return nil
+	}))
+	return result
+}
+
+func TestTableSchema(t *testing.T) {
+	path := prepareDatabase(t,
+		`CREATE TABLE orders (note TEXT, amount NUMERIC(10, 2), id INTEGER NOT NULL, region VARCHAR(16) NOT NULL, created DATETIME, meta JSON, payload, PRIMARY KEY (region, id))`,
+		`CREATE TABLE skipped (id INTEGER PRIMARY KEY)`,
+	)
+	storage := newTestStorage(t, &SQLiteSource{Path: path, ExcludeTables: []string{"skipped"}})
+
+	tables, err := storage.TableList(nil)
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	info, ok := tables[*abstract.NewTableID("", "orders")]
+	require.True(t, ok)
+
+	columns := info.Schema.Columns()
+	require.Equal(t, []string{"region", "id", "note", "amount", "created", "meta", "payload"}, columns.ColumnNames())
+	require.Equal(t, 2, columns.KeysNum())
+	expectedTypes := []schema.Type{schema.TypeString, schema.TypeInt64, schema.TypeString, schema.TypeFloat64, schema.TypeTimestamp, schema.TypeAny, schema.TypeBytes}
+	for i, column := range columns {
+		require.Equal(t, string(expectedTypes[i]), column.DataType, column.ColumnName)
+	}
+	require.Equal(t, "sqlite:NUMERIC(10, 2)", columns[3].OriginalType)
+}
+
+func TestLoadTable(t *testing.T) {
+	path := prepareDatabase(t,
+		`CREATE TABLE events (id INTEGER PRIMARY KEY, name TEXT, flag BOOLEAN, created TIMESTAMP, meta JSON)`,
+		`INSERT INTO events VALUES (1, 'first', 1, '2024-05-01 10:00:00', '{"a": 1}'), (2, NULL, 0, '2024-05-02T10:00:00Z', NULL)`,
+	)
+	storage := newTestStorage(t, &SQLiteSource{Path: path})
+
+	rows := loadRows(t, storage, abstract.TableDescription{Name: "events"})
+	require.Len(t, rows, 2)
+	require.Equal(t, []string{"id", "name", "flag", "created", "meta"}, rows[0].ColumnNames)
+	require.Equal(t, []interface{}{
+		int64(1), "first", true, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), map[string]interface{}{"a": float64(1)},
+	}, rows[0].ColumnValues)
+	require.Equal(t, []interface{}{
+		int64(2), nil, false, time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC), nil,
+	}, rows[1].ColumnValues)
+}
+
+func TestShardTable(t *testing.T) {
+	path := prepareDatabase(t,
+		`CREATE TABLE numbers (value INTEGER)`,
+		`WITH RECURSIVE seq(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM seq WHERE x < 25) INSERT INTO numbers SELECT x FROM seq`,
+		`CREATE TABLE keyed (id TEXT PRIMARY KEY, value INTEGER) WITHOUT ROWID`,
+		`INSERT INTO keyed VALUES ('a', 1), ('b', 2)`,
+	)
+	storage := newTestStorage(t, &SQLiteSource{Path: path, DesiredShardRows: 10})
+
+	shards, err := storage.ShardTable(context.Background(), abstract.TableDescription{Name: "numbers"})
+	require.NoError(t, err)
+	require.Len(t, shards, 3)
+	require.Equal(t, abstract.WhereStatement("rowid >= 21 AND rowid <= 25"), shards[2].Filter)
+	loaded := 0
+	for _, shard := range shards {
+		loaded += len(loadRows(t, storage, shard))
+	}
+	require.Equal(t, 25, loaded)
+
+	shards, err = storage.ShardTable(context.Background(), abstract.TableDescription{Name: "keyed"})
+	require.NoError(t, err)
+	require.Len(t, shards, 1)
+	require.Len(t, loadRows(t, storage, shards[0]), 2)
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/transferia/transferia/pkg/abstract/typesystem"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+func init() {
+	typesystem.SourceRules(ProviderType, map[schema.Type][]string{
+		schema.TypeInt64:     {"INTEGER", "INT", "BIGINT", "SMALLINT", "TINYINT", "MEDIUMINT"},
+		schema.TypeInt32:     {},
+		schema.TypeInt16:     {},
+		schema.TypeInt8:      {},
+		schema.TypeUint64:    {},
+		schema.TypeUint32:    {},
+		schema.TypeUint16:    {},
+		schema.TypeUint8:     {},
+		schema.TypeFloat32:   {},
+		schema.TypeFloat64:   {"REAL", "DOUBLE", "FLOAT", "NUMERIC", "DECIMAL"},
+		schema.TypeBytes:     {"BLOB", typesystem.RestPlaceholder},
+		schema.TypeString:    {"TEXT", "VARCHAR", "CHAR", "CLOB"},
+		schema.TypeBoolean:   {"BOOLEAN"},
+		schema.TypeDate:      {"DATE"},
+		schema.TypeDatetime:  {},
+		schema.TypeTimestamp: {"DATETIME", "TIMESTAMP"},
+		schema.TypeAny:       {"JSON"},
+	})
+	typesystem.TargetRule(ProviderType, map[schema.Type]string{
+		schema.TypeInt64:     "INTEGER",
+		schema.TypeInt32:     "INTEGER",
+		schema.TypeInt16:     "INTEGER",
+		schema.TypeInt8:      "INTEGER",
+		schema.TypeUint64:    "INTEGER",
+		schema.TypeUint32:    "INTEGER",
+		schema.TypeUint16:    "INTEGER",
+		schema.TypeUint8:     "INTEGER",
+		schema.TypeFloat32:   "REAL",
+		schema.TypeFloat64:   "REAL",
+		schema.TypeBytes:     "BLOB",
+		schema.TypeString:    "TEXT",
+		schema.TypeBoolean:   "BOOLEAN",
+		schema.TypeAny:       "JSON",
+		schema.TypeDate:      "DATE",
+		schema.TypeDatetime:  "TIMESTAMP",
+		schema.TypeTimestamp: "TIMESTAMP",
+		schema.TypeInterval:  "INTEGER",
+	})
+}
+
+var typeSize = regexp.MustCompile(`\s*\(.*\)$`)
+
+// columnType maps declared types onto transfer types: known names go first,
+// the rest follow the SQLite type affinity rules, see https://www.sqlite.org/datatype3.html
+func columnType(declared string) schema.Type {
+	clearTyp := strings.ToUpper(typeSize.ReplaceAllString(strings.TrimSpace(declared), ""))
+	switch clearTyp {
+	case "BOOLEAN", "BOOL":
+		return schema.TypeBoolean
+	case "DATE":
+		return schema.TypeDate
+	case "DATETIME", "TIMESTAMP":
+		return schema.TypeTimestamp
+	case "JSON":
+		return schema.TypeAny
+	case "NUMERIC", "DECIMAL":
+		return schema.TypeFloat64
+	}
+	switch {
+	case strings.Contains(clearTyp, "INT"):
+		return schema.TypeInt64
+	case strings.Contains(clearTyp, "CHAR"), strings.Contains(clearTyp, "CLOB"), strings.Contains(clearTyp, "TEXT"):
+		return schema.TypeString
+	case strings.Contains(clearTyp, "REAL"), strings.Contains(clearTyp, "FLOA"), strings.Contains(clearTyp, "DOUB"):
+		return schema.TypeFloat64
+	default:
+		return schema.TypeBytes
+	}
+}
@@ -0,0 +1,23 @@
+package sqlite
+
+import (
+	_ "embed"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract/typesystem"
+)
+
+var (
+	//go:embed typesystem.md
+	canonDoc string
+)
+
+func TestTypeSystem(t *testing.T) {
+	rules := typesystem.RuleFor(ProviderType)
+	require.NotNil(t, rules.Source)
+	doc := typesystem.Doc(ProviderType, "SQLite")
+	fmt.Print(doc)
+	require.Equal(t, canonDoc, doc)
+}
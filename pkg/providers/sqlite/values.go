@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// timeLayouts are the formats of SQLite date & time functions, in addition to RFC3339
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// castValue converts values read by the driver into the column type,
+// since SQLite columns may store values of any type regardless of the declared one
+func castValue(column abstract.ColSchema, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch schema.Type(column.DataType) {
+	case schema.TypeInt64:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return int64(v), nil
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		case []byte:
+			return strconv.ParseInt(string(v), 10, 64)
+		}
+	case schema.TypeFloat64:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case string:
+			return strconv.ParseFloat(v, 64)
+		case []byte:
+			return strconv.ParseFloat(string(v), 64)
+		}
+	case schema.TypeString:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case []byte:
+			return string(v), nil
+		case time.Time:
+			return v.Format(time.RFC3339Nano), nil
+		default:
+			return fmt.Sprintf("%v", v), nil
+		}
+	case schema.TypeBytes:
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		default:
+			return []byte(fmt.Sprintf("%v", v)), nil
+		}
+	case schema.TypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case schema.TypeDate, schema.TypeTimestamp:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			return parseTime(v)
+		case []byte:
+			return parseTime(string(v))
+		case int64:
+			return time.Unix(v, 0).UTC(), nil
+		}
+	case schema.TypeAny:
+		var raw []byte
+		switch v := value.(type) {
+		case string:
+			raw = []byte(v)
+		case []byte:
+			raw = v
+		default:
+			return v, nil
+		}
+		var result interface{}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return string(raw), nil
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+	return nil, xerrors.Errorf("unable to convert %T into %s", value, column.DataType)
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if result, err := time.Parse(layout, value); err == nil {
+			return result, nil
+		}
+	}
+	return time.Time{}, xerrors.Errorf("unknown time format: %s", value)
+}
+
+// writeValue converts values into ones accepted by the driver
+func writeValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, int64, float64, bool, string, []byte, time.Time:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		return v.String(), nil
+	case time.Duration:
+		return int64(v), nil
+	default:
+		result, err := json.Marshal(v)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to marshal %T: %w", v, err)
+		}
+		return string(result), nil
+	}
+}
+
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	default:
+		return 8
+	}
+}
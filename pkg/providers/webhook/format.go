@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/serializer"
+)
+
+var contentTypes = map[BodyFormat]string{
+	BodyFormatJSONArray: "application/json",
+	BodyFormatNDJSON:    "application/x-ndjson",
+	BodyFormatCSV:       "text/csv",
+	BodyFormatParquet:   "application/vnd.apache.parquet",
+	BodyFormatRaw:       "application/octet-stream",
+}
+
+type event struct {
+	Kind       abstract.Kind          `json:"kind"`
+	Namespace  string                 `json:"namespace"`
+	Table      string                 `json:"table"`
+	CommitTime uint64                 `json:"commit_time"`
+	LSN        uint64                 `json:"lsn"`
+	Data       map[string]interface{} `json:"data"`
+	OldKeys    map[string]interface{} `json:"old_keys,omitempty"`
+}
+
+// rowData is the row for inserts & updates, and old keys for deletes, which carry no values
+func rowData(item *abstract.ChangeItem) map[string]interface{} {
+	if item.Kind == abstract.DeleteKind {
+		return oldKeys(item)
+	}
+	return item.AsMap()
+}
+
+func oldKeys(item *abstract.ChangeItem) map[string]interface{} {
+	if len(item.OldKeys.KeyNames) == 0 {
+		return nil
+	}
+	result := make(map[string]interface{}, len(item.OldKeys.KeyNames))
+	for i, name := range item.OldKeys.KeyNames {
+		if i < len(item.OldKeys.KeyValues) {
+			result[name] = item.OldKeys.KeyValues[i]
+		}
+	}
+	return result
+}
+
+func marshalJSON(item *abstract.ChangeItem, envelope bool) ([]byte, error) {
+	if !envelope {
+		return json.Marshal(rowData(item))
+	}
+	data := item.AsMap()
+	if item.Kind == abstract.DeleteKind {
+		data = nil
+	}
+	return json.Marshal(event{
+		Kind:       item.Kind,
+		Namespace:  item.Schema,
+		Table:      item.Table,
+		CommitTime: item.CommitTime,
+		LSN:        item.LSN,
+		Data:       data,
+		OldKeys:    oldKeys(item),
+	})
+}
+
+// serialize builds the body of rows, all of them belong to the same table
+func serialize(cfg *WebhookDestination, items []*abstract.ChangeItem) ([]byte, error) {
+	switch cfg.Format {
+	case BodyFormatJSONArray, BodyFormatNDJSON:
+		var buf bytes.Buffer
+		if cfg.Format == BodyFormatJSONArray {
+			buf.WriteByte('[')
+		}
+		for i, item := range items {
+			data, err := marshalJSON(item, cfg.Envelope)
+			if err != nil {
+				return nil, xerrors.Errorf("unable to marshal row: %w", err)
+			}
+			if i > 0 && cfg.Format == BodyFormatJSONArray {
+				buf.WriteByte(',')
+			}
+			buf.Write(data)
+			if cfg.Format == BodyFormatNDJSON {
+				buf.WriteByte('\n')
+			}
+		}
+		if cfg.Format == BodyFormatJSONArray {
+			buf.WriteByte(']')
+		}
+		return buf.Bytes(), nil
+	case BodyFormatCSV:
+		return serializer.NewCsvBatchSerializer(nil).Serialize(items)
+	case BodyFormatParquet:
+		return serializer.NewParquetBatchSerializer().Serialize(items)
+	case BodyFormatRaw:
+		return serializer.NewRawBatchSerializer(&serializer.RawBatchSerializerConfig{
+			SerializerConfig: &serializer.RawSerializerConfig{
+				AddClosingNewLine: true,
+			},
+			BatchConfig: nil,
+		}).Serialize(items)
+	default:
+		return nil, xerrors.Errorf("unsupported format: %s", cfg.Format)
+	}
+}
@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"net/url"
+	"text/template"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/middlewares/async/bufferer"
+)
+
+const ProviderType = abstract.ProviderType("webhook")
+
+type BodyFormat string
+
+const (
+	// BodyFormatJSONArray - single JSON array of rows
+	BodyFormatJSONArray = BodyFormat("JSON_ARRAY")
+	// BodyFormatNDJSON - rows as JSON objects separated by new lines
+	BodyFormatNDJSON  = BodyFormat("NDJSON")
+	BodyFormatCSV     = BodyFormat("CSV")
+	BodyFormatParquet = BodyFormat("PARQUET")
+	BodyFormatRaw     = BodyFormat("RAW")
+)
+
+type HMACAlgorithm string
+
+const (
+	HMACSHA256 = HMACAlgorithm("sha256")
+	HMACSHA512 = HMACAlgorithm("sha512")
+)
+
+type WebhookDestination struct {
+	URL    string
+	Method string
+	Format BodyFormat
+	// Envelope wraps JSON rows into events with kinds, table names & old keys, which is useful for replication
+	Envelope bool
+	// Headers values are text templates executed over the batch: `{{.Namespace}}`, `{{.Table}}`, `{{.Rows}}` and `{{.BatchID}}`.
+	// BatchID is the hash of the body, so it is the same for retries & may be used for deduplication
+	Headers map[string]string
+
+	// HMACSecret enables signing, the signature of the body is sent in HMACHeader as `<algorithm>=<hex digest>`
+	HMACSecret    model.SecretString
+	HMACHeader    string
+	HMACAlgorithm HMACAlgorithm
+
+	// BatchSize is the maximum number of rows in a request, rows are buffered up to BatchSize or BatchInterval
+	BatchSize     int
+	BatchInterval time.Duration
+	Timeout       time.Duration
+	// RetryTimeout limits retries of 5xx, 429 & network errors, the other 4xx responses fail the transfer
+	RetryTimeout time.Duration
+
+	TLSFile string `model:"PemFileContent"`
+}
+
+var _ model.Destination = (*WebhookDestination)(nil)
+
+func (d *WebhookDestination) WithDefaults() {
+	if d.Method == "" {
+		d.Method = "POST"
+	}
+	if d.Format == "" {
+		d.Format = BodyFormatJSONArray
+	}
+	if d.HMACHeader == "" {
+		d.HMACHeader = "X-Signature"
+	}
+	if d.HMACAlgorithm == "" {
+		d.HMACAlgorithm = HMACSHA256
+	}
+	if d.BatchSize == 0 {
+		d.BatchSize = 1000
+	}
+	if d.BatchInterval == 0 {
+		d.BatchInterval = time.Second
+	}
+	if d.Timeout == 0 {
+		d.Timeout = 30 * time.Second
+	}
+	if d.RetryTimeout == 0 {
+		d.RetryTimeout = 10 * time.Minute
+	}
+}
+
+func (d *WebhookDestination) BuffererConfig() bufferer.BuffererConfig {
+	return bufferer.BuffererConfig{
+		TriggingCount:    d.BatchSize,
+		TriggingSize:     0,
+		TriggingInterval: d.BatchInterval,
+	}
+}
+
+func (d *WebhookDestination) CleanupMode() model.CleanupType {
+	return model.DisabledCleanup
+}
+
+func (WebhookDestination) IsDestination() {}
+
+func (d *WebhookDestination) GetProviderType() abstract.ProviderType {
+	return ProviderType
+}
+
+func (d *WebhookDestination) Validate() error {
+	parsed, err := url.Parse(d.URL)
+	if err != nil {
+		return xerrors.Errorf("invalid URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return xerrors.Errorf("URL scheme should be http or https, got: %q", parsed.Scheme)
+	}
+	switch d.Format {
+	case BodyFormatJSONArray, BodyFormatNDJSON, BodyFormatCSV, BodyFormatParquet, BodyFormatRaw:
+	default:
+		return xerrors.Errorf("unsupported format: %s", d.Format)
+	}
+	if d.Envelope && d.Format != BodyFormatJSONArray && d.Format != BodyFormatNDJSON {
+		return xerrors.Errorf("envelope is supported only by JSON formats")
+	}
+	switch d.HMACAlgorithm {
+	case HMACSHA256, HMACSHA512:
+	default:
+		return xerrors.Errorf("unsupported HMAC algorithm: %s", d.HMACAlgorithm)
+	}
+	for name, value := range d.Headers {
+		if _, err := template.New(name).Parse(value); err != nil {
+			return xerrors.Errorf("invalid template of header %s: %w", name, err)
+		}
+	}
+	return nil
+}
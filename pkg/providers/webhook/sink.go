@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/transferia/transferia/library/go/core/metrics"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/stats"
+	"github.com/transferia/transferia/pkg/util"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+type sink struct {
+	cfg     *WebhookDestination
+	client  *http.Client
+	headers map[string]*template.Template
+	logger  log.Logger
+	metrics *stats.SinkerStats
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// batch is the data available to header templates
+type batch struct {
+	Namespace string
+	Table     string
+	Rows      int
+	BatchID   string
+}
+
+func (s *sink) Push(input []abstract.ChangeItem) error {
+	// consecutive rows of the same table are sent together, so the order of rows is kept
+	var chunk []*abstract.ChangeItem
+	for i := range input {
+		item := &input[i]
+		if !item.IsRowEvent() {
+			continue
+		}
+		if len(chunk) > 0 && (chunk[0].TableID() != item.TableID() || len(chunk) == s.cfg.BatchSize) {
+			if err := s.send(chunk); err != nil {
+				return err
+			}
+			chunk = nil
+		}
+		chunk = append(chunk, item)
+	}
+	if len(chunk) > 0 {
+		return s.send(chunk)
+	}
+	return nil
+}
+
+func (s *sink) send(items []*abstract.ChangeItem) error {
+	start := time.Now()
+	body, err := serialize(s.cfg, items)
+	if err != nil {
+		return xerrors.Errorf("unable to serialize %d rows of table %s: %w", len(items), items[0].TableID().Fqtn(), err)
+	}
+	digest := sha256.Sum256(body)
+	headers, err := s.renderHeaders(batch{
+		Namespace: items[0].Schema,
+		Table:     items[0].Table,
+		Rows:      len(items),
+		BatchID:   hex.EncodeToString(digest[:]),
+	})
+	if err != nil {
+		return xerrors.Errorf("unable to render headers: %w", err)
+	}
+	if s.cfg.HMACSecret != "" {
+		headers.Set(s.cfg.HMACHeader, s.sign(body))
+	}
+
+	retries := backoff.NewExponentialBackOff()
+	retries.MaxElapsedTime = s.cfg.RetryTimeout
+	retries.Reset()
+	for {
+		retryAfter, err := s.do(body, headers)
+		if err == nil {
+			break
+		}
+		if abstract.IsFatal(err) {
+			return xerrors.Errorf("unable to send %d rows of table %s: %w", len(items), items[0].TableID().Fqtn(), err)
+		}
+		wait := retries.NextBackOff()
+		if wait == backoff.Stop {
+			return xerrors.Errorf("unable to send %d rows of table %s, retries exceeded: %w", len(items), items[0].TableID().Fqtn(), err)
+		}
+		wait = max(wait, retryAfter)
+		s.logger.Warn("unable to send batch, will retry", log.Duration("wait", wait), log.Error(err))
+		select {
+		case <-time.After(wait):
+		case <-s.ctx.Done():
+			return xerrors.Errorf("sink is closed: %w", err)
+		}
+	}
+	s.metrics.Table(items[0].TableID().Fqtn(), "rows", len(items))
+	s.metrics.Elapsed.RecordDuration(time.Since(start))
+	return nil
+}
+
+// do sends the request once. It returns fatal errors for 4xx responses except 429,
+// the other errors are retriable, and the delay requested by Retry-After, if any
+func (s *sink) do(body []byte, headers http.Header) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, s.cfg.Method, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, abstract.NewFatalError(xerrors.Errorf("unable to make request: %w", err))
+	}
+	req.Header = headers.Clone()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, xerrors.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = xerrors.Errorf("%s: %s", resp.Status, util.Sample(string(respBody), 1024))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, abstract.NewFatalError(xerrors.Errorf("rows are rejected: %w", err))
+	}
+	return retryAfter(resp.Header.Get("Retry-After")), err
+}
+
+// retryAfter parses both forms of the header: delay in seconds and HTTP date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+func (s *sink) renderHeaders(data batch) (http.Header, error) {
+	headers := http.Header{}
+	headers.Set("Content-Type", contentTypes[s.cfg.Format])
+	for name, tmpl := range s.headers {
+		var value strings.Builder
+		if err := tmpl.Execute(&value, data); err != nil {
+			return nil, xerrors.Errorf("unable to execute template of header %s: %w", name, err)
+		}
+		headers.Set(name, value.String())
+	}
+	return headers, nil
+}
+
+func (s *sink) sign(body []byte) string {
+	var hashFunc func() hash.Hash
+	switch s.cfg.HMACAlgorithm {
+	case HMACSHA512:
+		hashFunc = sha512.New
+	default:
+		hashFunc = sha256.New
+	}
+	mac := hmac.New(hashFunc, []byte(s.cfg.HMACSecret))
+	_, _ = mac.Write(body)
+	return string(s.cfg.HMACAlgorithm) + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *sink) Close() error {
+	s.cancel()
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func NewSink(cfg *WebhookDestination, registry metrics.Registry, lgr log.Logger) (abstract.Sinker, error) {
+	headers := make(map[string]*template.Template, len(cfg.Headers))
+	for name, value := range cfg.Headers {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid template of header %s: %w", name, err)
+		}
+		headers[name] = tmpl
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TLSFile != "" {
+		cp := x509.NewCertPool()
+		if !cp.AppendCertsFromPEM([]byte(cfg.TLSFile)) {
+			return nil, xerrors.New("credentials: failed to append certificates")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: cp}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &sink{
+		cfg:     cfg,
+		client:  &http.Client{Transport: transport},
+		headers: headers,
+		logger:  lgr,
+		metrics: stats.NewSinkerStats(registry),
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+)
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+type server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []request
+}
+
+func newServer(t *testing.T, handler func(n int, w http.ResponseWriter)) *server {
+	s := new(server)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		s.mu.Lock()
+		s.requests = append(s.requests, request{header: r.Header, body: body})
+		n := len(s.requests)
+		s.mu.Unlock()
+		handler(n, w)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newSink(t *testing.T, cfg *WebhookDestination) abstract.Sinker {
+	cfg.WithDefaults()
+	require.NoError(t, cfg.Validate())
+	sink, err := NewSink(cfg, solomon.NewRegistry(nil), logger.Log)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, sink.Close()) })
+	return sink
+}
+
+func rows(table string, kind abstract.Kind, ids ...int64) []abstract.ChangeItem {
+	schema := abstract.NewTableSchema([]abstract.ColSchema{
+		{ColumnName: "id", DataType: "int64", PrimaryKey: true},
+		{ColumnName: "name", DataType: "utf8"},
+	})
+	var result []abstract.ChangeItem
+	for _, id := range ids {
+		item := abstract.ChangeItem{
+			Kind:         kind,
+			Schema:       "public",
+			Table:        table,
+			ColumnNames:  []string{"id", "name"},
+			ColumnValues: []interface{}{id, "name"},
+			TableSchema:  schema,
+		}
+		if kind == abstract.DeleteKind {
+			item.ColumnNames = nil
+			item.ColumnValues = nil
+			item.OldKeys = abstract.OldKeysType{KeyNames: []string{"id"}, KeyTypes: []string{"int64"}, KeyValues: []interface{}{id}}
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+func ok(int, http.ResponseWriter) {}
+
+func TestFormats(t *testing.T) {
+	t.Run("json array", func(t *testing.T) {
+		srv := newServer(t, ok)
+		sink := newSink(t, &WebhookDestination{URL: srv.URL, BatchSize: 2})
+		require.NoError(t, sink.Push(append(rows("a", abstract.InsertKind, 1, 2, 3), rows("b", abstract.DeleteKind, 4)...)))
+
+		require.Len(t, srv.requests, 3)
+		require.JSONEq(t, `[{"id":1,"name":"name"},{"id":2,"name":"name"}]`, string(srv.requests[0].body))
+		require.JSONEq(t, `[{"id":3,"name":"name"}]`, string(srv.requests[1].body))
+		require.JSONEq(t, `[{"id":4}]`, string(srv.requests[2].body))
+		require.Equal(t, "application/json", srv.requests[0].header.Get("Content-Type"))
+	})
+
+	t.Run("ndjson envelope", func(t *testing.T) {
+		srv := newServer(t, ok)
+		sink := newSink(t, &WebhookDestination{URL: srv.URL, Format: BodyFormatNDJSON, Envelope: true})
+		require.NoError(t, sink.Push(append(rows("a", abstract.InsertKind, 1), rows("a", abstract.DeleteKind, 1)...)))
+
+		require.Len(t, srv.requests, 1)
+		lines := strings.Split(strings.TrimSpace(string(srv.requests[0].body)), "\n")
+		require.Len(t, lines, 2)
+		var deleted event
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &deleted))
+		require.Equal(t, abstract.DeleteKind, deleted.Kind)
+		require.Equal(t, "a", deleted.Table)
+		require.Nil(t, deleted.Data)
+		require.Equal(t, map[string]interface{}{"id": float64(1)}, deleted.OldKeys)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		srv := newServer(t, ok)
+		sink := newSink(t, &WebhookDestination{URL: srv.URL, Format: BodyFormatCSV})
+		require.NoError(t, sink.Push(rows("a", abstract.InsertKind, 1, 2)))
+
+		require.Len(t, srv.requests, 1)
+		require.Equal(t, "1,name\n2,name\n", string(srv.requests[0].body))
+	})
+}
+
+func TestHeadersAndSignature(t *testing.T) {
+	srv := newServer(t, ok)
+	sink := newSink(t, &WebhookDestination{
+		URL: srv.URL,
+		Headers: map[string]string{
+			"Authorization": "Bearer token",
+			"X-Table":       "{{.Namespace}}.{{.Table}}",
+			"X-Rows":        "{{.Rows}}",
+			"X-Batch":       "{{.BatchID}}",
+		},
+		HMACSecret: "secret",
+	})
+	require.NoError(t, sink.Push(rows("a", abstract.InsertKind, 1, 2)))
+
+	require.Len(t, srv.requests, 1)
+	req := srv.requests[0]
+	require.Equal(t, "Bearer token", req.header.Get("Authorization"))
+	require.Equal(t, "public.a", req.header.Get("X-Table"))
+	require.Equal(t, "2", req.header.Get("X-Rows"))
+	digest := sha256.Sum256(req.body)
+	require.Equal(t, hex.EncodeToString(digest[:]), req.header.Get("X-Batch"))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, _ = mac.Write(req.body)
+	require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.header.Get("X-Signature"))
+}
+
+func TestRetries(t *testing.T) {
+	t.Run("too many requests", func(t *testing.T) {
+		srv := newServer(t, func(n int, w http.ResponseWriter) {
+			if n == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+		})
+		sink := newSink(t, &WebhookDestination{URL: srv.URL})
+		start := time.Now()
+		require.NoError(t, sink.Push(rows("a", abstract.InsertKind, 1)))
+		require.GreaterOrEqual(t, time.Since(start), time.Second)
+		require.Len(t, srv.requests, 2)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		srv := newServer(t, func(n int, w http.ResponseWriter) {
+			if n < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+		sink := newSink(t, &WebhookDestination{URL: srv.URL})
+		require.NoError(t, sink.Push(rows("a", abstract.InsertKind, 1)))
+		require.Len(t, srv.requests, 3)
+	})
+
+	t.Run("retries exceeded", func(t *testing.T) {
+		srv := newServer(t, func(n int, w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+		sink := newSink(t, &WebhookDestination{URL: srv.URL, RetryTimeout: time.Second})
+		err := sink.Push(rows("a", abstract.InsertKind, 1))
+		require.Error(t, err)
+		require.False(t, abstract.IsFatal(err))
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		srv := newServer(t, func(n int, w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("invalid row"))
+		})
+		sink := newSink(t, &WebhookDestination{URL: srv.URL})
+		err := sink.Push(rows("a", abstract.InsertKind, 1))
+		require.Error(t, err)
+		require.True(t, abstract.IsFatal(err))
+		require.Contains(t, err.Error(), "invalid row")
+		require.Len(t, srv.requests, 1)
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	require.Equal(t, 5*time.Second, retryAfter("5"))
+	require.Equal(t, time.Duration(0), retryAfter(""))
+	require.Equal(t, time.Duration(0), retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)))
+	require.InDelta(t, float64(time.Minute), float64(retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))), float64(2*time.Second))
+}
@@ -3,6 +3,7 @@ package ydb
 import (
 	"context"
 	"encoding/gob"
+	"path"
 
 	"github.com/transferia/transferia/library/go/core/metrics"
 	"github.com/transferia/transferia/library/go/core/xerrors"
@@ -39,6 +40,7 @@ var (
 	_ providers.Activator   = (*Provider)(nil)
 	_ providers.Deactivator = (*Provider)(nil)
 	_ providers.Cleanuper   = (*Provider)(nil)
+	_ providers.DDLRenderer = (*Provider)(nil)
 )
 
 type Provider struct {
@@ -159,6 +161,21 @@ func (p *Provider) Sink(middlewares.Config) (abstract.Sinker, error) {
 	return NewSinker(p.logger, dst, p.registry)
 }
 
+// RenderDDL returns query which sink issues to create an absent table, path of rotated table is annotated with current time.
+func (p *Provider) RenderDDL(table abstract.TableID, schema *abstract.TableSchema) ([]string, error) {
+	dst, ok := p.transfer.Dst.(*YdbDestination)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected target type: %T", p.transfer.Dst)
+	}
+	s := &sinker{config: dst, logger: p.logger}
+	tablePath := s.makeTablePath(abstract.ChangeItem{Schema: table.Namespace, Table: table.Name})
+	query, err := s.createTableQuery(path.Join(dst.Database, string(tablePath)), schema.Columns())
+	if err != nil {
+		return nil, xerrors.Errorf("unable to prepare create table query: %w", err)
+	}
+	return []string{query}, nil
+}
+
 func New(lgr log.Logger, registry metrics.Registry, cp coordinator.Coordinator, transfer *model.Transfer) providers.Provider {
 	return &Provider{
 		logger:   lgr,
@@ -261,6 +261,22 @@ func (s *sinker) Close() error {
 	return nil
 }
 
+func (s *sinker) makeTablePath(item abstract.ChangeItem) ydbPath {
+	tableName := Fqtn(item.TableID())
+
+	if altName, ok := s.config.AltNames[item.Fqtn()]; ok {
+		tableName = altName
+	} else if altName, ok = s.config.AltNames[tableName]; ok {
+		// for backward compatibility need to check both name and old Fqtn
+		tableName = altName
+	}
+	tablePath := ydbPath(s.config.Rotation.AnnotateWithTimeFromColumn(tableName, item))
+	if s.config.Path != "" {
+		tablePath = ydbPath(path.Join(s.config.Path, string(tablePath)))
+	}
+	return tablePath
+}
+
 func (s *sinker) isClosed() bool {
 	select {
 	case <-s.closeCh:
@@ -270,6 +286,60 @@ func (s *sinker) isClosed() bool {
 	}
 }
 
+func (s *sinker) createTableQuery(fullPath string, schema []abstract.ColSchema) (string, error) {
+	columns := make([]ColumnTemplate, 0)
+	keys := make([]string, 0)
+	for _, col := range schema {
+		if col.ColumnName == "_shard_key" {
+			continue
+		}
+
+		ydbType := s.ydbType(col.DataType, col.OriginalType)
+		if ydbType == types.TypeUnknown {
+			return "", abstract.NewFatalError(xerrors.Errorf("YDB create table type %v not supported", col.DataType))
+		}
+
+		isPrimaryKey, err := s.isPrimaryKey(ydbType, col)
+		if err != nil {
+			return "", abstract.NewFatalError(xerrors.Errorf("Unable to create primary key: %w", err))
+		}
+		s.logger.Infof("col: %v type: %v isPrimary: %v)", col.ColumnName, ydbType, isPrimaryKey)
+
+		columns = append(columns, ColumnTemplate{
+			col.ColumnName,
+			ydbType.Yql(),
+			isPrimaryKey && s.config.IsTableColumnOriented,
+		})
+
+		if isPrimaryKey {
+			keys = append(keys, col.ColumnName)
+		}
+	}
+
+	if s.config.ShardCount > 0 {
+		columns = append(columns, ColumnTemplate{"_shard_key", types.TypeUint64.Yql(), s.config.IsTableColumnOriented})
+
+		keys = append([]string{"_shard_key"}, keys...)
+
+		s.logger.Infof("Keys %v", keys)
+	}
+
+	currTable := CreateTableTemplate{
+		Path:                  fullPath,
+		Columns:               columns,
+		Keys:                  keys,
+		ShardCount:            s.config.ShardCount,
+		IsTableColumnOriented: s.config.IsTableColumnOriented,
+		DefaultCompression:    s.config.DefaultCompression,
+	}
+
+	var query strings.Builder
+	if err := createTableQueryTemplate.Execute(&query, currTable); err != nil {
+		return "", xerrors.Errorf("unable to execute create table template: %w", err)
+	}
+	return query.String(), nil
+}
+
 func (s *sinker) checkTable(tablePath ydbPath, schema []abstract.ColSchema) error {
 	if s.cache[tablePath] {
 		return nil
@@ -306,60 +376,14 @@ func (s *sinker) checkTable(tablePath ydbPath, schema []abstract.ColSchema) erro
 			}
 		}
 		if err := s.db.Table().Do(ctx, func(ctx context.Context, session table.Session) error {
-			columns := make([]ColumnTemplate, 0)
-			keys := make([]string, 0)
-			for _, col := range schema {
-				if col.ColumnName == "_shard_key" {
-					continue
-				}
-
-				ydbType := s.ydbType(col.DataType, col.OriginalType)
-				if ydbType == types.TypeUnknown {
-					return abstract.NewFatalError(xerrors.Errorf("YDB create table type %v not supported", col.DataType))
-				}
-
-				isPrimaryKey, err := s.isPrimaryKey(ydbType, col)
-				if err != nil {
-					return abstract.NewFatalError(xerrors.Errorf("Unable to create primary key: %w", err))
-				}
-				s.logger.Infof("col: %v type: %v isPrimary: %v)", col.ColumnName, ydbType, isPrimaryKey)
-
-				columns = append(columns, ColumnTemplate{
-					col.ColumnName,
-					ydbType.Yql(),
-					isPrimaryKey && s.config.IsTableColumnOriented,
-				})
-
-				if isPrimaryKey {
-					keys = append(keys, col.ColumnName)
-				}
-			}
-
-			if s.config.ShardCount > 0 {
-				columns = append(columns, ColumnTemplate{"_shard_key", types.TypeUint64.Yql(), s.config.IsTableColumnOriented})
-
-				keys = append([]string{"_shard_key"}, keys...)
-
-				s.logger.Infof("Keys %v", keys)
-			}
-
-			currTable := CreateTableTemplate{
-				Path:                  s.getFullPath(tablePath),
-				Columns:               columns,
-				Keys:                  keys,
-				ShardCount:            s.config.ShardCount,
-				IsTableColumnOriented: s.config.IsTableColumnOriented,
-				DefaultCompression:    s.config.DefaultCompression,
-			}
-
-			var query strings.Builder
-			if err := createTableQueryTemplate.Execute(&query, currTable); err != nil {
-				return xerrors.Errorf("unable to execute create table template: %w", err)
+			query, err := s.createTableQuery(s.getFullPath(tablePath), schema)
+			if err != nil {
+				return xerrors.Errorf("unable to prepare create table query: %w", err)
 			}
 
-			s.logger.Info("Try to create table", log.String("table", s.getFullPath(tablePath)), log.String("query", query.String()))
+			s.logger.Info("Try to create table", log.String("table", s.getFullPath(tablePath)), log.String("query", query))
 
-			return session.ExecuteSchemeQuery(ctx, query.String())
+			return session.ExecuteSchemeQuery(ctx, query)
 		}); err != nil {
 			return xerrors.Errorf("unable to create table: %s: %w", s.getFullPath(tablePath), err)
 		}
@@ -589,18 +613,7 @@ func (s *sinker) Push(input []abstract.ChangeItem) error {
 				return xerrors.Errorf("unable to drop table %s: %w", s.getFullPath(ydbPath(Fqtn(item.TableID()))), err)
 			}
 		case abstract.InsertKind, abstract.UpdateKind, abstract.DeleteKind:
-			tableName := Fqtn(item.TableID())
-
-			if altName, ok := s.config.AltNames[item.Fqtn()]; ok {
-				tableName = altName
-			} else if altName, ok = s.config.AltNames[tableName]; ok {
-				// for backward compatibility need to check both name and old Fqtn
-				tableName = altName
-			}
-			tablePath := ydbPath(s.config.Rotation.AnnotateWithTimeFromColumn(tableName, item))
-			if s.config.Path != "" {
-				tablePath = ydbPath(path.Join(s.config.Path, string(tablePath)))
-			}
+			tablePath := s.makeTablePath(item)
 			batches[tablePath] = append(batches[tablePath], item)
 		default:
 			s.logger.Infof("kind: %v not supported", item.Kind)
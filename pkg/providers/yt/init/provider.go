@@ -2,6 +2,7 @@ package init
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/transferia/transferia/library/go/core/metrics"
 	"github.com/transferia/transferia/library/go/core/xerrors"
@@ -24,6 +25,7 @@ import (
 	"github.com/transferia/transferia/pkg/targets"
 	"go.ytsaurus.tech/library/go/core/log"
 	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yson"
 )
 
 func init() {
@@ -42,6 +44,8 @@ var (
 	_ providers.Cleanuper  = (*Provider)(nil)
 	_ providers.TMPCleaner = (*Provider)(nil)
 	_ providers.Verifier   = (*Provider)(nil)
+
+	_ providers.DDLRenderer = (*Provider)(nil)
 )
 
 type Provider struct {
@@ -145,6 +149,31 @@ func (p *Provider) Sink(middlewares.Config) (abstract.Sinker, error) {
 	return s, nil
 }
 
+// RenderDDL returns yt command which creates the table as sink does, tables of staging and copy destinations are not rendered.
+func (p *Provider) RenderDDL(table abstract.TableID, schema *abstract.TableSchema) ([]string, error) {
+	if p.provider != yt_provider.ProviderType {
+		return nil, nil
+	}
+	dst, ok := p.transfer.Dst.(yt_provider.YtDestinationModel)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected target type: %T", p.transfer.Dst)
+	}
+	tablePath, spec, err := ytsink.TableSpec(table, schema.Columns(), dst)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to build table spec: %w", err)
+	}
+	attrs := map[string]interface{}{}
+	for k, v := range spec.Attributes {
+		attrs[k] = v
+	}
+	attrs["schema"] = spec.Schema
+	data, err := yson.MarshalFormat(attrs, yson.FormatText)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal table attributes: %w", err)
+	}
+	return []string{fmt.Sprintf("yt create table %v --attributes '%s'", tablePath, data)}, nil
+}
+
 func getJobIndex(transfer *model.Transfer) int {
 	if shardingTaskRuntime, ok := transfer.Runtime.(abstract.ShardingTaskRuntime); ok {
 		return shardingTaskRuntime.CurrentJobIndex()
@@ -119,34 +119,8 @@ func (t *OrderedTable) Init() error {
 		}
 	}
 
-	s := make([]schema.Column, len(t.schema))
-	for i, col := range t.schema {
-		s[i] = schema.Column{
-			Name: col.ColumnName,
-			Type: fixDatetime(&col),
-		}
-	}
-
-	systemAttrs := map[string]interface{}{
-		"primary_medium":     t.config.PrimaryMedium(),
-		"tablet_cell_bundle": t.config.CellBundle(),
-		"optimize_for":       t.config.OptimizeFor(),
-	}
-
-	if t.config.TTL() > 0 {
-		systemAttrs["min_data_versions"] = 0
-		systemAttrs["max_data_versions"] = 1
-		systemAttrs["max_data_ttl"] = t.config.TTL()
-	}
-
 	ddlCommand := map[ypath.Path]migrate.Table{}
-	ddlCommand[t.path] = migrate.Table{
-		Schema: schema.Schema{
-			UniqueKeys: false,
-			Columns:    s,
-		},
-		Attributes: t.config.MergeAttributes(systemAttrs),
-	}
+	ddlCommand[t.path] = orderedTableSpec(t.schema, t.config)
 
 	return backoff.Retry(func() error {
 		if !exist {
@@ -167,6 +141,36 @@ func (t *OrderedTable) Init() error {
 	}, backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 10))
 }
 
+func orderedTableSpec(cols []abstract.ColSchema, cfg yt2.YtDestinationModel) migrate.Table {
+	s := make([]schema.Column, len(cols))
+	for i, col := range cols {
+		s[i] = schema.Column{
+			Name: col.ColumnName,
+			Type: fixDatetime(&col),
+		}
+	}
+
+	systemAttrs := map[string]interface{}{
+		"primary_medium":     cfg.PrimaryMedium(),
+		"tablet_cell_bundle": cfg.CellBundle(),
+		"optimize_for":       cfg.OptimizeFor(),
+	}
+
+	if cfg.TTL() > 0 {
+		systemAttrs["min_data_versions"] = 0
+		systemAttrs["max_data_versions"] = 1
+		systemAttrs["max_data_ttl"] = cfg.TTL()
+	}
+
+	return migrate.Table{
+		Schema: schema.Schema{
+			UniqueKeys: false,
+			Columns:    s,
+		},
+		Attributes: cfg.MergeAttributes(systemAttrs),
+	}
+}
+
 func getTabletIndexByPartition(partition abstract.Partition) (uint32, error) {
 	var dcNum uint32
 	switch partition.Cluster {
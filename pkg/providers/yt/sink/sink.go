@@ -310,6 +310,10 @@ func (s *sinker) checkPrimaryKeyChanges(input []abstract.ChangeItem) error {
 }
 
 func (s *sinker) makeTableName(tableID abstract.TableID) string {
+	return makeTableName(tableID, s.config.AltNames())
+}
+
+func makeTableName(tableID abstract.TableID, altNames map[string]string) string {
 	var name string
 	if tableID.Namespace == "public" || tableID.Namespace == "" {
 		name = tableID.Name
@@ -317,7 +321,7 @@ func (s *sinker) makeTableName(tableID abstract.TableID) string {
 		name = fmt.Sprintf("%v_%v", tableID.Namespace, tableID.Name)
 	}
 
-	if altName, ok := s.config.AltNames()[name]; ok {
+	if altName, ok := altNames[name]; ok {
 		name = altName
 	}
 
@@ -0,0 +1,38 @@
+package sink
+
+import (
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	yt2 "github.com/transferia/transferia/pkg/providers/yt"
+	"go.ytsaurus.tech/yt/go/migrate"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/ypath"
+)
+
+// TableSpec returns path and spec of a table which sink creates for the given source table, without connecting to YT.
+// Rotated table name is annotated with the current time.
+func TableSpec(tableID abstract.TableID, cols []abstract.ColSchema, cfg yt2.YtDestinationModel) (ypath.Path, migrate.Table, error) {
+	name := cfg.Rotation().AnnotateWithTimeFromColumn(makeTableName(tableID, cfg.AltNames()), abstract.ChangeItem{})
+	tablePath := yt2.SafeChild(ypath.Path(cfg.Path()), name)
+
+	if cfg.Static() {
+		return tablePath, migrate.Table{
+			Schema: schema.Schema{
+				Columns: abstract.ToYtSchema(cols, true),
+			},
+			Attributes: nil,
+		}, nil
+	}
+
+	if !cfg.DisableDatetimeHack() {
+		cols = hackTimestamps(cols)
+	}
+	if cfg.Ordered() {
+		return tablePath, orderedTableSpec(cols, cfg), nil
+	}
+	spec, err := NewSchema(cols, cfg, tablePath).Table()
+	if err != nil {
+		return "", migrate.Table{}, xerrors.Errorf("unable to build table schema: %w", err)
+	}
+	return tablePath, spec, nil
+}
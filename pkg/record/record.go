@@ -0,0 +1,205 @@
+// Package record stores batches of change items pushed into sink, so change stream can be replayed later.
+//
+// Recording is a gzip compressed sequence of JSON lines, one line per batch. Values are stored with their types,
+// so replayed items are the same as recorded ones, including table schema, kinds, LSNs and control events.
+package record
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+)
+
+// Batch is change items pushed into sink at once
+type Batch struct {
+	Time  time.Time                  `json:"ts"`
+	Items []abstract.TypedChangeItem `json:"items"`
+}
+
+func (b *Batch) ChangeItems() []abstract.ChangeItem {
+	items := make([]abstract.ChangeItem, len(b.Items))
+	for i := range b.Items {
+		items[i] = abstract.ChangeItem(b.Items[i])
+	}
+	return items
+}
+
+var (
+	writersMu sync.Mutex
+	writers   = map[string]*Writer{}
+	// opened are recording files opened by this process, a file left by another process is rotated on first open
+	opened = map[string]bool{}
+)
+
+// Writer appends batches to recording file. It is shared by all sinks of the process writing to the same file.
+type Writer struct {
+	path string
+	refs int
+
+	mu   sync.Mutex
+	file *os.File
+	gz   *gzip.Writer
+}
+
+// Open returns writer of recording file, writer must be closed. Each process starts a new recording:
+// existing file is renamed on first open, while files closed by this process are appended.
+func Open(path string) (*Writer, error) {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	if w, ok := writers[path]; ok {
+		w.refs++
+		return w, nil
+	}
+	if !opened[path] {
+		if err := rotate(path); err != nil {
+			return nil, xerrors.Errorf("unable to rotate recording file: %w", err)
+		}
+		opened[path] = true
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open recording file: %w", err)
+	}
+	w := &Writer{
+		path: path,
+		refs: 1,
+		mu:   sync.Mutex{},
+		file: file,
+		gz:   gzip.NewWriter(file),
+	}
+	writers[path] = w
+	return w, nil
+}
+
+// rotate renames recording file left by another process. Gzip stream of a killed process is not terminated,
+// so batches appended after it would not be readable. Rotated file is named by its modification time,
+// e.g. `transfer.20240101T000000.rec`.
+func rotate(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("unable to stat recording file: %w", err)
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext) + "." + info.ModTime().UTC().Format("20060102T150405")
+	rotated := base + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotated); os.IsNotExist(err) {
+			break
+		}
+		rotated = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	if err := os.Rename(path, rotated); err != nil {
+		return xerrors.Errorf("unable to rename recording file: %w", err)
+	}
+	return nil
+}
+
+// Write records batch of items. Batch is flushed to file, so recording is readable even if process is killed.
+func (w *Writer) Write(ts time.Time, items []abstract.ChangeItem) error {
+	batch := Batch{Time: ts, Items: make([]abstract.TypedChangeItem, len(items))}
+	for i := range items {
+		batch.Items[i] = abstract.TypedChangeItem(items[i])
+	}
+	data, err := json.Marshal(&batch)
+	if err != nil {
+		return xerrors.Errorf("unable to marshal batch: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.gz.Write(append(data, '\n')); err != nil {
+		return xerrors.Errorf("unable to write batch: %w", err)
+	}
+	if err := w.gz.Flush(); err != nil {
+		return xerrors.Errorf("unable to flush batch: %w", err)
+	}
+	return nil
+}
+
+func (w *Writer) Close() error {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	w.refs--
+	if w.refs > 0 {
+		return nil
+	}
+	delete(writers, w.path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.gz.Close(); err != nil {
+		_ = w.file.Close()
+		return xerrors.Errorf("unable to close gzip stream: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return xerrors.Errorf("unable to close recording file: %w", err)
+	}
+	return nil
+}
+
+// Reader reads batches from recording file
+type Reader struct {
+	file    *os.File
+	scanner *bufio.Scanner
+}
+
+func NewReader(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open recording file: %w", err)
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, xerrors.Errorf("unable to read gzip header: %w", err)
+	}
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024*1024)
+	return &Reader{
+		file:    file,
+		scanner: scanner,
+	}, nil
+}
+
+// Next returns next batch or io.EOF if there are no more batches.
+// Batch truncated by killed writer is treated as end of recording.
+func (r *Reader) Next() (*Batch, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil && !xerrors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, xerrors.Errorf("unable to read batch: %w", err)
+		}
+		return nil, io.EOF
+	}
+	line := r.scanner.Bytes()
+	if !json.Valid(line) {
+		if r.scanner.Scan() {
+			return nil, xerrors.New("recording is corrupted: invalid batch is followed by other batches")
+		}
+		return nil, io.EOF
+	}
+	var batch Batch
+	if err := json.Unmarshal(line, &batch); err != nil {
+		return nil, xerrors.Errorf("unable to unmarshal batch: %w", err)
+	}
+	return &batch, nil
+}
+
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,141 @@
+package record
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract"
+)
+
+func TestWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transfer.rec")
+	schema := abstract.NewTableSchema([]abstract.ColSchema{
+		{ColumnName: "id", DataType: "int32", PrimaryKey: true},
+		{ColumnName: "name", DataType: "utf8"},
+	})
+	inserts := []abstract.ChangeItem{{
+		ID:           1,
+		LSN:          100,
+		CommitTime:   1700000000000000000,
+		Kind:         abstract.InsertKind,
+		Schema:       "public",
+		Table:        "users",
+		ColumnNames:  []string{"id", "name"},
+		ColumnValues: []any{int32(1), "alice"},
+		TableSchema:  schema,
+		OldKeys:      abstract.OldKeysType{KeyNames: nil, KeyTypes: nil, KeyValues: nil},
+	}}
+	control := []abstract.ChangeItem{{
+		ID:          2,
+		LSN:         101,
+		Kind:        abstract.DoneShardedTableLoad,
+		Schema:      "public",
+		Table:       "users",
+		TableSchema: schema,
+	}}
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	w1, err := Open(path)
+	require.NoError(t, err)
+	w2, err := Open(path)
+	require.NoError(t, err)
+	require.Same(t, w1, w2)
+	require.NoError(t, w1.Write(ts, inserts))
+	require.NoError(t, w1.Close())
+	require.NoError(t, w2.Write(ts.Add(time.Second), control))
+	require.NoError(t, w2.Close())
+
+	// recording is appended by next process
+	w3, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, w3.Write(ts.Add(2*time.Second), inserts))
+	require.NoError(t, w3.Close())
+
+	reader, err := NewReader(path)
+	require.NoError(t, err)
+	defer reader.Close()
+	for i, expected := range [][]abstract.ChangeItem{inserts, control, inserts} {
+		batch, err := reader.Next()
+		require.NoError(t, err)
+		require.True(t, ts.Add(time.Duration(i)*time.Second).Equal(batch.Time))
+		items := batch.ChangeItems()
+		require.Len(t, items, 1)
+		require.Equal(t, expected[0].Kind, items[0].Kind)
+		require.Equal(t, expected[0].LSN, items[0].LSN)
+		require.Equal(t, expected[0].ColumnValues, items[0].ColumnValues)
+		require.Equal(t, schema.Columns(), items[0].TableSchema.Columns())
+	}
+	_, err = reader.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transfer.rec")
+	w, err := Open(path)
+	require.NoError(t, err)
+	var sizes []int64
+	for i := 0; i < 2; i++ {
+		require.NoError(t, w.Write(time.Now(), []abstract.ChangeItem{{Kind: abstract.InsertKind, Table: "users", ColumnNames: []string{"id"}, ColumnValues: []any{int64(i)}}}))
+		stat, err := w.file.Stat()
+		require.NoError(t, err)
+		sizes = append(sizes, stat.Size())
+	}
+	// writer is killed in the middle of the last batch
+	require.NoError(t, os.Truncate(path, (sizes[0]+sizes[1])/2))
+
+	reader, err := NewReader(path)
+	require.NoError(t, err)
+	defer reader.Close()
+	batch, err := reader.Next()
+	require.NoError(t, err)
+	require.Equal(t, []any{int64(0)}, batch.ChangeItems()[0].ColumnValues)
+	_, err = reader.Next()
+	require.ErrorIs(t, err, io.EOF)
+	require.NoError(t, w.Close())
+}
+
+func TestRotateAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "transfer.rec")
+	item := func(id int64) []abstract.ChangeItem {
+		return []abstract.ChangeItem{{Kind: abstract.InsertKind, Table: "users", ColumnNames: []string{"id"}, ColumnValues: []any{id}}}
+	}
+
+	w, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(time.Now(), item(1)))
+	// process is killed, so gzip stream is flushed but not terminated
+	require.NoError(t, w.file.Close())
+	writersMu.Lock()
+	delete(writers, path)
+	delete(opened, path)
+	writersMu.Unlock()
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	w, err = Open(path)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(time.Now(), item(2)))
+	require.NoError(t, w.Close())
+
+	readAll := func(path string) [][]any {
+		reader, err := NewReader(path)
+		require.NoError(t, err)
+		defer reader.Close()
+		var values [][]any
+		for {
+			batch, err := reader.Next()
+			if err == io.EOF {
+				return values
+			}
+			require.NoError(t, err)
+			values = append(values, batch.ChangeItems()[0].ColumnValues)
+		}
+	}
+	require.Equal(t, [][]any{{int64(1)}}, readAll(filepath.Join(dir, "transfer.20240101T000000.rec")))
+	require.Equal(t, [][]any{{int64(2)}}, readAll(path))
+}
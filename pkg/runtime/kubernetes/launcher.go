@@ -0,0 +1,465 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/worker/tasks"
+	"go.ytsaurus.tech/library/go/core/log"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	managedByLabel   = "app.kubernetes.io/managed-by"
+	operationLabel   = "transferia.io/operation"
+	workerIndexLabel = "transferia.io/worker-index"
+
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+var (
+	_ tasks.SecondaryWorkersLauncher = (*Runtime)(nil)
+
+	jobFlags        = []string{"--coordinator-job-index", "--coordinator-job-count", "--coordinator-process-count"}
+	nonDNSSymbols   = regexp.MustCompile("[^a-z0-9-]+")
+	teardownTimeout = time.Minute
+)
+
+// LaunchSecondaryWorkers creates a pod for each secondary worker of operation and keeps them running until stop is called
+func (r *Runtime) LaunchSecondaryWorkers(ctx context.Context, cp coordinator.Coordinator, transfer *model.Transfer, operationID string) (func(), error) {
+	if _, ok := cp.(*coordinator.CoordinatorInMemory); ok {
+		return nil, xerrors.New("memory coordinator is not shared with secondary workers, use persistent coordinator, e.g. s3")
+	}
+	client, err := r.kubeClient()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to init kubernetes client: %w", err)
+	}
+	namespace := r.namespace()
+	template, err := r.podTemplate(ctx, client, namespace)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to build pod template: %w", err)
+	}
+	workers, err := cp.GetOperationWorkers(operationID)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get operation workers: %w", err)
+	}
+
+	l := &launcher{
+		runtime:     r,
+		client:      client,
+		cp:          cp,
+		namespace:   namespace,
+		template:    template,
+		namePrefix:  podNamePrefix(transfer.ID, operationID),
+		operationID: operationID,
+		pods:        map[int]*workerPod{},
+		created:     nil,
+		logger:      log.With(logger.Log, log.String("operation_id", operationID), log.String("namespace", namespace)),
+	}
+	if err := l.removeStale(ctx); err != nil {
+		return nil, xerrors.Errorf("unable to remove pods of previous run: %w", err)
+	}
+	for _, worker := range workers {
+		if worker.Completed {
+			continue
+		}
+		if err := l.start(ctx, worker.WorkerIndex, 0); err != nil {
+			l.teardown()
+			return nil, xerrors.Errorf("unable to start secondary worker %v: %w", worker.WorkerIndex, err)
+		}
+	}
+	l.logger.Infof("started %v secondary worker pods", len(l.pods))
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.watch(watchCtx)
+	}()
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			cancel()
+			<-done
+			l.teardown()
+		})
+	}, nil
+}
+
+type workerPod struct {
+	name      string
+	restarts  int
+	createdAt time.Time
+}
+
+type launcher struct {
+	runtime     *Runtime
+	client      kubernetes.Interface
+	cp          coordinator.Coordinator
+	namespace   string
+	template    corev1.PodSpec
+	namePrefix  string
+	operationID string
+	// pods are current pods of secondary workers, which are not finished yet
+	pods map[int]*workerPod
+	// created are all pods ever created, failed pods are kept for investigation until teardown
+	created []string
+	logger  log.Logger
+}
+
+// removeStale deletes pods of the operation left by a previous run of *main* worker, e.g. when it is resumed after a crash,
+// and waits until they are gone, since new pods get the same names
+func (l *launcher) removeStale(ctx context.Context) error {
+	selector := fmt.Sprintf("%s=transferia,%s=%s", managedByLabel, operationLabel, l.namePrefix)
+	pods, err := l.client.CoreV1().Pods(l.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return xerrors.Errorf("unable to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil
+	}
+	for _, pod := range pods.Items {
+		l.logger.Info("deleting secondary worker pod of previous run", log.String("pod", pod.Name))
+		if err := l.client.CoreV1().Pods(l.namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return xerrors.Errorf("unable to delete pod %s: %w", pod.Name, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, teardownTimeout)
+	defer cancel()
+	for {
+		pods, err := l.client.CoreV1().Pods(l.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return xerrors.Errorf("unable to list pods: %w", err)
+		}
+		if len(pods.Items) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return xerrors.Errorf("%v pods are not deleted in time: %w", len(pods.Items), ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (l *launcher) start(ctx context.Context, workerIndex int, restarts int) error {
+	name := fmt.Sprintf("%s-%d-%d", l.namePrefix, workerIndex, restarts)
+	spec := l.template.DeepCopy()
+	spec.Containers[0].Args = l.runtime.workerArgs(workerIndex)
+	labels := map[string]string{}
+	for k, v := range l.runtime.Labels {
+		labels[k] = v
+	}
+	labels[managedByLabel] = "transferia"
+	labels[operationLabel] = l.namePrefix
+	labels[workerIndexLabel] = strconv.Itoa(workerIndex)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: l.namespace,
+			Labels:    labels,
+		},
+		Spec: *spec,
+	}
+	if _, err := l.client.CoreV1().Pods(l.namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return xerrors.Errorf("unable to create pod %s: %w", name, err)
+	}
+	l.pods[workerIndex] = &workerPod{
+		name:      name,
+		restarts:  restarts,
+		createdAt: time.Now(),
+	}
+	l.created = append(l.created, name)
+	return nil
+}
+
+func (l *launcher) watch(ctx context.Context) {
+	ticker := time.NewTicker(l.runtime.CheckInterval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := l.check(ctx); err != nil {
+			l.logger.Warn("unable to check secondary workers", log.Error(err))
+		}
+	}
+}
+
+func (l *launcher) check(ctx context.Context) error {
+	completed, err := l.completedWorkers()
+	if err != nil {
+		return xerrors.Errorf("unable to get operation workers: %w", err)
+	}
+	health := map[int]time.Time{}
+	if tracker, ok := l.cp.(coordinator.OperationHealthTracker); ok {
+		if health, err = tracker.GetOperationHealth(ctx, l.operationID); err != nil {
+			return xerrors.Errorf("unable to get operation health: %w", err)
+		}
+	}
+	now := time.Now()
+	for workerIndex, pod := range l.pods {
+		if completed[workerIndex] {
+			delete(l.pods, workerIndex)
+			continue
+		}
+		reason, err := l.failure(ctx, pod, health[workerIndex], now)
+		if err != nil {
+			return xerrors.Errorf("unable to check pod %s: %w", pod.name, err)
+		}
+		if reason == "" {
+			continue
+		}
+		// the worker may finish right before its pod is checked
+		if completed, err = l.completedWorkers(); err != nil {
+			return xerrors.Errorf("unable to get operation workers: %w", err)
+		}
+		if completed[workerIndex] {
+			delete(l.pods, workerIndex)
+			continue
+		}
+		if err := l.replace(ctx, workerIndex, pod, reason); err != nil {
+			return xerrors.Errorf("unable to replace pod %s: %w", pod.name, err)
+		}
+	}
+	return nil
+}
+
+func (l *launcher) completedWorkers() (map[int]bool, error) {
+	workers, err := l.cp.GetOperationWorkers(l.operationID)
+	if err != nil {
+		return nil, err
+	}
+	res := map[int]bool{}
+	for _, worker := range workers {
+		res[worker.WorkerIndex] = worker.Completed
+	}
+	return res, nil
+}
+
+// failure returns why pod of unfinished worker must be replaced, empty if pod is fine
+func (l *launcher) failure(ctx context.Context, pod *workerPod, lastHealth time.Time, now time.Time) (string, error) {
+	res, err := l.client.CoreV1().Pods(l.namespace).Get(ctx, pod.name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "pod is not found", nil
+		}
+		return "", err
+	}
+	switch res.Status.Phase {
+	case corev1.PodFailed:
+		return fmt.Sprintf("pod failed: %s %s", res.Status.Reason, res.Status.Message), nil
+	case corev1.PodSucceeded:
+		return "pod exited without finishing the operation", nil
+	}
+	lastSeen := pod.createdAt
+	if lastHealth.After(lastSeen) {
+		lastSeen = lastHealth
+	}
+	if silence := now.Sub(lastSeen); silence > l.runtime.HealthTimeout.Duration {
+		return fmt.Sprintf("no operation health for %v", silence.Truncate(time.Second)), nil
+	}
+	return "", nil
+}
+
+func (l *launcher) replace(ctx context.Context, workerIndex int, pod *workerPod, reason string) error {
+	l.logger.Warn("secondary worker pod is broken", log.Int("worker_index", workerIndex), log.String("pod", pod.name), log.String("reason", reason))
+	if err := l.delete(ctx, pod.name); err != nil {
+		return xerrors.Errorf("unable to delete pod: %w", err)
+	}
+	if pod.restarts >= l.runtime.MaxRestarts {
+		delete(l.pods, workerIndex)
+		workerErr := xerrors.Errorf("secondary worker pod is replaced %v times, last one is broken: %s", pod.restarts, reason)
+		if err := l.cp.FinishOperation(l.operationID, workerIndex, workerErr); err != nil {
+			return xerrors.Errorf("unable to finish failed worker %v: %w", workerIndex, err)
+		}
+		return nil
+	}
+	if err := l.start(ctx, workerIndex, pod.restarts+1); err != nil {
+		return xerrors.Errorf("unable to start new pod: %w", err)
+	}
+	l.logger.Info("secondary worker pod is replaced", log.Int("worker_index", workerIndex), log.String("pod", l.pods[workerIndex].name))
+	return nil
+}
+
+// delete removes running pod, pods which are already failed are kept until teardown
+func (l *launcher) delete(ctx context.Context, name string) error {
+	pod, err := l.client.CoreV1().Pods(l.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if pod.Status.Phase == corev1.PodFailed {
+		return nil
+	}
+	if err := l.client.CoreV1().Pods(l.namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *launcher) teardown() {
+	ctx, cancel := context.WithTimeout(context.Background(), teardownTimeout)
+	defer cancel()
+	for _, name := range l.created {
+		if err := l.client.CoreV1().Pods(l.namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			l.logger.Warn("unable to delete secondary worker pod", log.String("pod", name), log.Error(err))
+		}
+	}
+	l.logger.Infof("deleted %v secondary worker pods", len(l.created))
+}
+
+func (r *Runtime) kubeClient() (kubernetes.Interface, error) {
+	if r.client != nil {
+		return r.client, nil
+	}
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		// outside of cluster, e.g. against kind cluster, use kubeconfig
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			clientcmd.NewDefaultClientConfigLoadingRules(),
+			&clientcmd.ConfigOverrides{},
+		).ClientConfig()
+		if err != nil {
+			return nil, xerrors.Errorf("unable to load neither in-cluster config nor kubeconfig: %w", err)
+		}
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to create kubernetes client: %w", err)
+	}
+	r.client = client
+	return client, nil
+}
+
+func (r *Runtime) namespace() string {
+	if r.Namespace != "" {
+		return r.Namespace
+	}
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return metav1.NamespaceDefault
+}
+
+// podTemplate is pod spec of secondary workers, without args
+func (r *Runtime) podTemplate(ctx context.Context, client kubernetes.Interface, namespace string) (corev1.PodSpec, error) {
+	var spec corev1.PodSpec
+	if r.Image != "" {
+		spec = corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "worker",
+				Image: r.Image,
+			}},
+		}
+	} else {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return spec, xerrors.Errorf("unable to get hostname: %w", err)
+		}
+		self, err := client.CoreV1().Pods(namespace).Get(ctx, hostname, metav1.GetOptions{})
+		if err != nil {
+			return spec, xerrors.Errorf("image is not set and unable to get pod %s/%s of main worker to copy: %w", namespace, hostname, err)
+		}
+		spec = *self.Spec.DeepCopy()
+		// only the worker container is copied, sidecars would keep finished pods running
+		spec.Containers = spec.Containers[:1]
+		spec.EphemeralContainers = nil
+		spec.NodeName = ""
+		spec.Hostname = ""
+	}
+	container := &spec.Containers[0]
+	container.Command = r.Command
+	if len(container.Command) == 0 {
+		container.Command = os.Args[:1]
+	}
+	container.Env = append(container.Env, r.Env...)
+	if len(r.Resources.Limits) > 0 || len(r.Resources.Requests) > 0 {
+		container.Resources = r.Resources
+	}
+	if r.ServiceAccount != "" {
+		spec.ServiceAccountName = r.ServiceAccount
+	}
+	if len(r.NodeSelector) > 0 {
+		spec.NodeSelector = r.NodeSelector
+	}
+	spec.RestartPolicy = corev1.RestartPolicyNever
+	return spec, nil
+}
+
+// workerArgs are args of secondary worker with given index, all other args are the same as of *main* worker
+func (r *Runtime) workerArgs(workerIndex int) []string {
+	args := r.Args
+	if len(args) == 0 {
+		args = stripJobFlags(os.Args[1:])
+	}
+	res := append([]string{}, args...)
+	return append(res,
+		fmt.Sprintf("--coordinator-job-index=%d", workerIndex),
+		fmt.Sprintf("--coordinator-job-count=%d", r.JobCount),
+		fmt.Sprintf("--coordinator-process-count=%d", r.ProcessCount),
+	)
+}
+
+func stripJobFlags(args []string) []string {
+	var res []string
+	for i := 0; i < len(args); i++ {
+		flag := strings.SplitN(args[i], "=", 2)
+		if !isJobFlag(flag[0]) {
+			res = append(res, args[i])
+			continue
+		}
+		if len(flag) == 1 {
+			// value is the next arg
+			i++
+		}
+	}
+	return res
+}
+
+func isJobFlag(arg string) bool {
+	for _, flag := range jobFlags {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// podNamePrefix is DNS-compatible prefix of pod names of operation, unique for each operation
+func podNamePrefix(transferID, operationID string) string {
+	name := nonDNSSymbols.ReplaceAllString(strings.ToLower(transferID), "-")
+	if len(name) > 30 {
+		name = name[:30]
+	}
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = "transfer"
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(operationID))
+	return fmt.Sprintf("%s-%08x", name, h.Sum32())
+}
@@ -0,0 +1,269 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeCoordinator struct {
+	*coordinator.CoordinatorNoOp
+	mu      sync.Mutex
+	workers map[int]*model.OperationWorker
+	health  map[int]time.Time
+}
+
+func newFakeCoordinator(operationID string, workersCount int) *fakeCoordinator {
+	cp := &fakeCoordinator{
+		CoordinatorNoOp: coordinator.NewFakeClient(),
+		mu:              sync.Mutex{},
+		workers:         map[int]*model.OperationWorker{},
+		health:          map[int]time.Time{},
+	}
+	for i := 1; i <= workersCount; i++ {
+		cp.workers[i] = &model.OperationWorker{OperationID: operationID, WorkerIndex: i, Completed: false, Err: "", Progress: nil}
+	}
+	return cp
+}
+
+func (f *fakeCoordinator) GetOperationWorkers(operationID string) ([]*model.OperationWorker, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res []*model.OperationWorker
+	for _, worker := range f.workers {
+		copied := *worker
+		res = append(res, &copied)
+	}
+	return res, nil
+}
+
+func (f *fakeCoordinator) FinishOperation(operationID string, workerIndex int, taskErr error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.workers[workerIndex].Completed = true
+	if taskErr != nil {
+		f.workers[workerIndex].Err = taskErr.Error()
+	}
+	return nil
+}
+
+func (f *fakeCoordinator) OperationHealth(ctx context.Context, operationID string, workerIndex int, workerTime time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.health[workerIndex] = workerTime
+	return nil
+}
+
+func (f *fakeCoordinator) GetOperationHealth(ctx context.Context, operationID string) (map[int]time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	res := map[int]time.Time{}
+	for k, v := range f.health {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func (f *fakeCoordinator) worker(workerIndex int) model.OperationWorker {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.workers[workerIndex]
+}
+
+func newTestRuntime(client *fake.Clientset) *Runtime {
+	rt := &Runtime{
+		Namespace:     "transfers",
+		Image:         "transferia:test",
+		Args:          []string{"activate", "--transfer", "/etc/transfer.yaml", "--coordinator", "s3"},
+		JobCount:      2,
+		ProcessCount:  4,
+		HealthTimeout: metav1.Duration{Duration: time.Hour},
+		MaxRestarts:   1,
+		CheckInterval: metav1.Duration{Duration: 10 * time.Millisecond},
+	}
+	rt.WithDefaults()
+	return rt.WithClient(client)
+}
+
+func listPods(t *testing.T, client *fake.Clientset) map[string]corev1.Pod {
+	pods, err := client.CoreV1().Pods("transfers").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	res := map[string]corev1.Pod{}
+	for _, pod := range pods.Items {
+		res[pod.Name] = pod
+	}
+	return res
+}
+
+func setPhase(t *testing.T, client *fake.Clientset, name string, phase corev1.PodPhase) {
+	pod, err := client.CoreV1().Pods("transfers").Get(context.Background(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	pod.Status.Phase = phase
+	_, err = client.CoreV1().Pods("transfers").UpdateStatus(context.Background(), pod, metav1.UpdateOptions{})
+	require.NoError(t, err)
+}
+
+func TestLaunchSecondaryWorkers(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	rt := newTestRuntime(client)
+	cp := newFakeCoordinator("dtt/activation", 2)
+	transfer := &model.Transfer{ID: "dtt"}
+	prefix := podNamePrefix("dtt", "dtt/activation")
+
+	stop, err := rt.LaunchSecondaryWorkers(context.Background(), cp, transfer, "dtt/activation")
+	require.NoError(t, err)
+	defer stop()
+
+	pods := listPods(t, client)
+	var names []string
+	for name := range pods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{prefix + "-1-0", prefix + "-2-0"}, names)
+	pod := pods[prefix+"-2-0"]
+	require.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+	require.Equal(t, "transferia:test", pod.Spec.Containers[0].Image)
+	require.Equal(t, []string{
+		"activate", "--transfer", "/etc/transfer.yaml", "--coordinator", "s3",
+		"--coordinator-job-index=2", "--coordinator-job-count=2", "--coordinator-process-count=4",
+	}, pod.Spec.Containers[0].Args)
+	require.Equal(t, "2", pod.Labels[workerIndexLabel])
+
+	t.Run("finished worker is not replaced", func(t *testing.T) {
+		require.NoError(t, cp.FinishOperation("dtt/activation", 2, nil))
+		setPhase(t, client, prefix+"-2-0", corev1.PodSucceeded)
+		time.Sleep(100 * time.Millisecond)
+		require.NotContains(t, listPods(t, client), prefix+"-2-1")
+	})
+
+	t.Run("failed pod is replaced", func(t *testing.T) {
+		setPhase(t, client, prefix+"-1-0", corev1.PodFailed)
+		require.Eventually(t, func() bool {
+			_, ok := listPods(t, client)[prefix+"-1-1"]
+			return ok
+		}, 5*time.Second, 10*time.Millisecond)
+		// failed pod is kept for investigation
+		require.Contains(t, listPods(t, client), prefix+"-1-0")
+	})
+
+	t.Run("worker fails after max restarts", func(t *testing.T) {
+		setPhase(t, client, prefix+"-1-1", corev1.PodFailed)
+		require.Eventually(t, func() bool {
+			return cp.worker(1).Completed
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Contains(t, cp.worker(1).Err, "replaced 1 times")
+		require.NotContains(t, listPods(t, client), prefix+"-1-2")
+	})
+
+	stop()
+	require.Empty(t, listPods(t, client))
+}
+
+func TestReplaceSilentWorker(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	rt := newTestRuntime(client)
+	rt.HealthTimeout = metav1.Duration{Duration: 200 * time.Millisecond}
+	cp := newFakeCoordinator("dtt/upload", 2)
+	prefix := podNamePrefix("dtt", "dtt/upload")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// only the second worker signals it is alive
+	go func() {
+		for ctx.Err() == nil {
+			_ = cp.OperationHealth(ctx, "dtt/upload", 2, time.Now())
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	stop, err := rt.LaunchSecondaryWorkers(ctx, cp, &model.Transfer{ID: "dtt"}, "dtt/upload")
+	require.NoError(t, err)
+	defer stop()
+
+	require.Eventually(t, func() bool {
+		_, ok := listPods(t, client)[prefix+"-1-1"]
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+	pods := listPods(t, client)
+	// silent pod is running, so it is deleted once replaced
+	require.NotContains(t, pods, prefix+"-1-0")
+	require.Contains(t, pods, prefix+"-2-0")
+	require.NotContains(t, pods, prefix+"-2-1")
+}
+
+func TestRemoveStalePods(t *testing.T) {
+	prefix := podNamePrefix("dtt", "dtt/upload")
+	stale := func(name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "transfers", Labels: labels}}
+	}
+	client := fake.NewSimpleClientset(
+		stale(prefix+"-1-0", map[string]string{managedByLabel: "transferia", operationLabel: prefix}),
+		stale(prefix+"-1-1", map[string]string{managedByLabel: "transferia", operationLabel: prefix}),
+		stale("other-1-0", map[string]string{managedByLabel: "transferia", operationLabel: "other"}),
+	)
+	rt := newTestRuntime(client)
+	cp := newFakeCoordinator("dtt/upload", 1)
+
+	stop, err := rt.LaunchSecondaryWorkers(context.Background(), cp, &model.Transfer{ID: "dtt"}, "dtt/upload")
+	require.NoError(t, err)
+	defer stop()
+
+	pods := listPods(t, client)
+	require.Contains(t, pods, prefix+"-1-0")
+	require.NotContains(t, pods, prefix+"-1-1")
+	// pods of other operations are kept
+	require.Contains(t, pods, "other-1-0")
+}
+
+func TestMemoryCoordinatorIsNotSupported(t *testing.T) {
+	rt := newTestRuntime(fake.NewSimpleClientset())
+	_, err := rt.LaunchSecondaryWorkers(context.Background(), coordinator.NewStatefulFakeClient(), &model.Transfer{ID: "dtt"}, "dtt/upload")
+	require.Error(t, err)
+}
+
+func TestStripJobFlags(t *testing.T) {
+	require.Equal(t,
+		[]string{"activate", "--coordinator", "s3", "--transfer", "t.yaml"},
+		stripJobFlags([]string{
+			"activate", "--coordinator", "s3", "--coordinator-job-index", "0",
+			"--coordinator-job-count=3", "--transfer", "t.yaml", "--coordinator-process-count=2",
+		}),
+	)
+}
+
+func TestParseRuntime(t *testing.T) {
+	rt, err := abstract.NewRuntime(RuntimeType, `
+job_count: 3
+health_timeout: 90s
+env:
+  - name: AWS_REGION
+    value: eu-central-1
+resources:
+  limits:
+    memory: 2Gi
+`)
+	require.NoError(t, err)
+	rt.WithDefaults()
+	require.NoError(t, rt.Validate())
+	krt := rt.(*Runtime)
+	require.Equal(t, 3, krt.WorkersNum())
+	require.Equal(t, 1, krt.ThreadsNumPerWorker())
+	require.True(t, krt.IsMain())
+	require.Equal(t, 90*time.Second, krt.HealthTimeout.Duration)
+	require.Equal(t, "eu-central-1", krt.Env[0].Value)
+	require.Equal(t, "2Gi", krt.Resources.Limits.Memory().String())
+
+	_, err = abstract.NewRuntime(RuntimeType, `job_count: [1]`)
+	require.Error(t, err)
+}
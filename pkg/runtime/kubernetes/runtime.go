@@ -0,0 +1,118 @@
+// Package kubernetes is sharded snapshot runtime which runs secondary workers as kubernetes pods.
+//
+// *Main* worker creates a pod for each secondary worker of operation with its job index,
+// watches pods and their OperationHealth, replaces failed pods and deletes all of them once the operation is over.
+package kubernetes
+
+import (
+	"encoding/gob"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	sig_yaml "sigs.k8s.io/yaml"
+)
+
+const RuntimeType = abstract.RuntimeType("kubernetes")
+
+func init() {
+	gob.RegisterName("*kubernetes.Runtime", new(Runtime))
+	abstract.RegisterRuntime(RuntimeType, func(spec string) (abstract.Runtime, error) {
+		res := new(Runtime)
+		if err := sig_yaml.Unmarshal([]byte(spec), res); err != nil {
+			return nil, xerrors.Errorf("kubernetes: %w", err)
+		}
+		return res, nil
+	})
+}
+
+type Runtime struct {
+	// Namespace of secondary worker pods, namespace of *main* worker pod by default
+	Namespace string `json:"namespace,omitempty"`
+	// Image of secondary worker pods, if empty the pod of *main* worker is copied,
+	// so secondary workers get the same image, volumes, env and service account
+	Image          string                      `json:"image,omitempty"`
+	ServiceAccount string                      `json:"service_account,omitempty"`
+	NodeSelector   map[string]string           `json:"node_selector,omitempty"`
+	Labels         map[string]string           `json:"labels,omitempty"`
+	Env            []corev1.EnvVar             `json:"env,omitempty"`
+	Resources      corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Command and Args of secondary workers, command and args of *main* worker process by default,
+	// job index, count and process count flags are appended to args
+	Command []string `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+
+	JobCount     int `json:"job_count"`
+	ProcessCount int `json:"process_count"`
+	CurrentJob   int `json:"current_job,omitempty"`
+
+	// HealthTimeout is how long secondary worker may stay without OperationHealth before its pod is replaced
+	HealthTimeout metav1.Duration `json:"health_timeout,omitempty"`
+	// MaxRestarts is how many times pod of secondary worker is replaced before the worker is considered failed, 3 by default
+	MaxRestarts   int             `json:"max_restarts,omitempty"`
+	CheckInterval metav1.Duration `json:"check_interval,omitempty"`
+
+	client kubernetes.Interface
+}
+
+var (
+	_ abstract.Runtime             = (*Runtime)(nil)
+	_ abstract.ShardingTaskRuntime = (*Runtime)(nil)
+)
+
+// WithClient overrides kubernetes client, which is created from in-cluster config or kubeconfig otherwise
+func (r *Runtime) WithClient(client kubernetes.Interface) *Runtime {
+	r.client = client
+	return r
+}
+
+func (*Runtime) Type() abstract.RuntimeType {
+	return RuntimeType
+}
+
+func (r *Runtime) NeedRestart(runtime abstract.Runtime) bool {
+	return false
+}
+
+func (r *Runtime) WithDefaults() {
+	if r.ProcessCount == 0 {
+		r.ProcessCount = 1
+	}
+	if r.HealthTimeout.Duration == 0 {
+		r.HealthTimeout = metav1.Duration{Duration: 5 * time.Minute}
+	}
+	if r.MaxRestarts == 0 {
+		r.MaxRestarts = 3
+	}
+	if r.CheckInterval.Duration == 0 {
+		r.CheckInterval = metav1.Duration{Duration: 10 * time.Second}
+	}
+}
+
+func (r *Runtime) Validate() error {
+	if r.JobCount < 1 {
+		return xerrors.Errorf("job_count must be positive, got: %v", r.JobCount)
+	}
+	if r.ProcessCount < 1 {
+		return xerrors.Errorf("process_count must be positive, got: %v", r.ProcessCount)
+	}
+	if r.MaxRestarts < 0 {
+		return xerrors.Errorf("max_restarts must not be negative, got: %v", r.MaxRestarts)
+	}
+	if r.HealthTimeout.Duration < 0 || r.CheckInterval.Duration < 0 {
+		return xerrors.New("health_timeout and check_interval must not be negative")
+	}
+	return nil
+}
+
+func (r *Runtime) SetVersion(runtimeSpecificVersion string, versionProperties *string) error {
+	return nil
+}
+
+func (r *Runtime) WorkersNum() int          { return r.JobCount }
+func (r *Runtime) ThreadsNumPerWorker() int { return r.ProcessCount }
+func (r *Runtime) CurrentJobIndex() int     { return r.CurrentJob }
+func (r *Runtime) IsMain() bool             { return r.CurrentJob == 0 }
@@ -32,6 +32,7 @@ type LocalWorker struct {
 	replicationSource   base.EventSource
 	wg                  sync.WaitGroup
 	stopCh              chan struct{}
+	runDone             chan struct{}
 	mutex               sync.Mutex
 	initialized         bool
 	cp                  coordinator.Coordinator
@@ -111,6 +112,37 @@ func (w *LocalWorker) Stop() error {
 	return nil
 }
 
+// Shutdown stops the worker gracefully: a drainable source stops reading and commits positions of everything
+// already pushed, then the worker is stopped and its sink is flushed. If ctx expires before the drain completes,
+// the worker is stopped forcibly.
+func (w *LocalWorker) Shutdown(ctx context.Context) error {
+	w.mutex.Lock()
+	drainable, ok := w.legacySource.(abstract.DrainableSource)
+	w.mutex.Unlock()
+
+	if ok {
+		w.logger.Info("LocalWorker is draining")
+		drainable.Drain()
+		select {
+		case <-w.runDone:
+			w.logger.Info("LocalWorker is drained")
+		case <-ctx.Done():
+			w.logger.Warn("LocalWorker drain is not completed in time, force stop")
+		}
+	}
+
+	stopErrCh := make(chan error, 1)
+	go func() {
+		stopErrCh <- w.Stop()
+	}()
+	select {
+	case err := <-stopErrCh:
+		return err
+	case <-ctx.Done():
+		return xerrors.Errorf("unable to stop LocalWorker in time: %w", ctx.Err())
+	}
+}
+
 func (w *LocalWorker) Runtime() abstract.Runtime {
 	return new(abstract.LocalRuntime)
 }
@@ -175,6 +207,7 @@ func (w *LocalWorker) initialize() (err error) {
 }
 
 func (w *LocalWorker) Run() error {
+	defer close(w.runDone)
 	if err := w.initialize(); err != nil {
 		return xerrors.Errorf("failed to initialize LocalWorker: %w", err)
 	}
@@ -198,6 +231,7 @@ func NewLocalWorker(cp coordinator.Coordinator, transfer *model.Transfer, regist
 		registry:            registry,
 		logger:              lgr,
 		stopCh:              make(chan struct{}),
+		runDone:             make(chan struct{}),
 		cp:                  cp,
 		sink:                nil,
 		legacySource:        nil,
@@ -0,0 +1,30 @@
+package secret
+
+import "strings"
+
+// authErrorMarkers are lowercased parts of authentication errors of supported databases and brokers
+var authErrorMarkers = []string{
+	"password authentication failed", // PostgreSQL
+	"access denied for user",         // MySQL
+	"authentication failed",          // ClickHouse, MongoDB and others
+	"sasl authentication",            // Kafka
+	"login failed for user",          // SQL Server
+	"unauthorized",
+	"unauthenticated",
+	"invalid credentials",
+	"invalid password",
+}
+
+// IsAuthError reports whether err looks like an authentication failure, e.g. caused by rotated secret.
+func IsAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	text := strings.ToLower(err.Error())
+	for _, marker := range authErrorMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
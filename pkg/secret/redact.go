@@ -0,0 +1,82 @@
+package secret
+
+import (
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	Redacted = "<secret>"
+
+	// secrets shorter than that are not redacted, otherwise too much of unrelated text is hidden
+	minRedactedLen = 4
+)
+
+var (
+	redactedMu sync.RWMutex
+	redacted   = map[string]bool{}
+)
+
+// AddRedacted makes secret to be replaced by Redact.
+func AddRedacted(secret string) {
+	if len(secret) < minRedactedLen {
+		return
+	}
+	redactedMu.Lock()
+	defer redactedMu.Unlock()
+	redacted[secret] = true
+}
+
+// Redact replaces all known secrets in text.
+func Redact(text string) string {
+	redactedMu.RLock()
+	defer redactedMu.RUnlock()
+	for secret := range redacted {
+		text = strings.ReplaceAll(text, secret, Redacted)
+	}
+	return text
+}
+
+// NewRedactingCore wraps logger core, so known secrets are redacted from messages, string and error fields.
+func NewRedactingCore(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core}
+}
+
+type redactingCore struct {
+	zapcore.Core
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = Redact(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	result := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		switch field.Type {
+		case zapcore.StringType:
+			field.String = Redact(field.String)
+		case zapcore.ErrorType:
+			if err, ok := field.Interface.(error); ok && err != nil {
+				field = zap.String(field.Key, Redact(err.Error()))
+			}
+		}
+		result[i] = field
+	}
+	return result
+}
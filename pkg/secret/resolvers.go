@@ -0,0 +1,52 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+)
+
+func init() {
+	Register("file", ResolverFunc(resolveFile))
+	Register("env", ResolverFunc(resolveEnv))
+	Register("exec", ResolverFunc(resolveExec))
+}
+
+// resolveFile reads secret from file, `file:///run/secrets/pg` refers to /run/secrets/pg
+func resolveFile(_ context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", xerrors.Errorf("unable to read file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveEnv reads secret from env-var, `env://PG_PASSWORD` refers to PG_PASSWORD
+func resolveEnv(_ context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", xerrors.Errorf("env-var %s is not set", ref)
+	}
+	return value, nil
+}
+
+// resolveExec takes secret from stdout of a helper command, `exec://helper get pg-prod` runs `helper get pg-prod`.
+// Arguments are split by spaces, no shell is involved.
+func resolveExec(ctx context.Context, ref string) (string, error) {
+	args := strings.Fields(ref)
+	if len(args) == 0 {
+		return "", xerrors.New("command is empty")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", xerrors.Errorf("command %s failed: %s: %w", args[0], strings.TrimSpace(stderr.String()), err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
@@ -0,0 +1,88 @@
+// Package secret resolves secret references used instead of plain secrets in endpoint params,
+// e.g. `file:///run/secrets/pg`, `env://PG_PASSWORD` or `exec://helper get pg-prod`.
+package secret
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+)
+
+const (
+	schemeSeparator = "://"
+
+	resolveTimeout = time.Minute
+)
+
+// Resolver resolves references of its scheme, ref is a part of reference after `scheme://`.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]Resolver{}
+)
+
+// Register adds resolver of references with the given scheme, vault-like backends are plugged in this way.
+// This should be placed inside `init() func`.
+func Register(scheme string, resolver Resolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	resolvers[scheme] = resolver
+}
+
+func KnownSchemes() []string {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	var keys []string
+	for k := range resolvers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsReference reports whether value is a reference of a registered scheme.
+func IsReference(value string) bool {
+	_, _, ok := parseReference(value)
+	return ok
+}
+
+// Resolve returns secret of the reference, values which are not references are returned as is.
+// Resolved secret is redacted from logs.
+func Resolve(ctx context.Context, value string) (string, error) {
+	resolver, ref, ok := parseReference(value)
+	if !ok {
+		return value, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	result, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return "", xerrors.Errorf("unable to resolve secret %s: %w", value, err)
+	}
+	AddRedacted(result)
+	return result, nil
+}
+
+func parseReference(value string) (Resolver, string, bool) {
+	scheme, ref, ok := strings.Cut(value, schemeSeparator)
+	if !ok {
+		return nil, "", false
+	}
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	resolver, ok := resolvers[scheme]
+	return resolver, ref, ok
+}
@@ -0,0 +1,80 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestResolve(t *testing.T) {
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "pg")
+	require.NoError(t, os.WriteFile(file, []byte("from-file\n"), 0o600))
+	value, err := Resolve(ctx, "file://"+file)
+	require.NoError(t, err)
+	require.Equal(t, "from-file", value)
+
+	t.Setenv("SECRET_TEST_PASSWORD", "from-env")
+	value, err = Resolve(ctx, "env://SECRET_TEST_PASSWORD")
+	require.NoError(t, err)
+	require.Equal(t, "from-env", value)
+	_, err = Resolve(ctx, "env://SECRET_TEST_NOT_SET")
+	require.Error(t, err)
+
+	value, err = Resolve(ctx, "exec://echo from-exec")
+	require.NoError(t, err)
+	require.Equal(t, "from-exec", value)
+	_, err = Resolve(ctx, "exec://false")
+	require.Error(t, err)
+
+	Register("test-vault", ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		return "vault:" + ref, nil
+	}))
+	require.Contains(t, KnownSchemes(), "test-vault")
+	value, err = Resolve(ctx, "test-vault://secret/pg")
+	require.NoError(t, err)
+	require.Equal(t, "vault:secret/pg", value)
+
+	for _, plain := range []string{"plain password", "unknown://scheme", ""} {
+		require.False(t, IsReference(plain))
+		value, err = Resolve(ctx, plain)
+		require.NoError(t, err)
+		require.Equal(t, plain, value)
+	}
+}
+
+func TestRedactingCore(t *testing.T) {
+	Register("test-redact", ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		return "s3cr3t-" + ref, nil
+	}))
+	value, err := Resolve(context.Background(), "test-redact://pg")
+	require.NoError(t, err)
+	require.Equal(t, "user:<secret>@host", Redact("user:"+value+"@host"))
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	lgr := zap.New(NewRedactingCore(core)).With(zap.String("dsn", "password="+value))
+	lgr.Info("connect with "+value, zap.Error(xerrors.Errorf("auth failed for %s", value)))
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	require.Equal(t, "connect with <secret>", entry.Message)
+	require.Equal(t, map[string]any{
+		"dsn":   "password=<secret>",
+		"error": "auth failed for <secret>",
+	}, entry.ContextMap())
+}
+
+func TestIsAuthError(t *testing.T) {
+	require.True(t, IsAuthError(xerrors.New(`failed to connect to host=localhost user=user: FATAL: password authentication failed for user "user" (SQLSTATE 28P01)`)))
+	require.True(t, IsAuthError(xerrors.Errorf("unable to connect: %w", xerrors.New("Error 1045 (28000): Access denied for user 'user'@'localhost'"))))
+	require.False(t, IsAuthError(xerrors.New("connection refused")))
+	require.False(t, IsAuthError(nil))
+}
@@ -5,11 +5,18 @@ import (
 	"github.com/transferia/transferia/pkg/debezium/packer"
 )
 
-type SerializedMessage struct {
-	Key   []byte
+// Header - queue message header, values are kept as raw bytes, so they can be mirrored byte-for-byte
+type Header struct {
+	Key   string
 	Value []byte
 }
 
+type SerializedMessage struct {
+	Key     []byte
+	Value   []byte
+	Headers []Header
+}
+
 // Serializer - takes array of changeItems, returns queue messages, grouped by some groupID (string)
 // all messages of one group should go in the same partition (that's why TopicName field in SerializedMessagesGroup struct)
 //
@@ -17,7 +17,9 @@ import (
 	"github.com/transferia/transferia/pkg/middlewares/async"
 	"github.com/transferia/transferia/pkg/middlewares/async/bufferer"
 	"github.com/transferia/transferia/pkg/middlewares/memthrottle"
+	"github.com/transferia/transferia/pkg/middlewares/ratelimit"
 	"github.com/transferia/transferia/pkg/providers"
+	"github.com/transferia/transferia/pkg/record"
 	"github.com/transferia/transferia/pkg/stats"
 	"go.ytsaurus.tech/library/go/core/log"
 )
@@ -46,7 +48,18 @@ func MakeAsyncSink(transfer *model.Transfer, lgr log.Logger, mtrcs metrics.Regis
 		pipelineAsync = wrapSinkIntoAsyncPipeline(sink, transfer, lgr, mtrcs, middleware, config)
 	}
 
+	if !config.NoData {
+		pipelineAsync = ratelimit.RateLimiter(cp, transfer.ID, lgr)(pipelineAsync)
+	}
 	pipelineAsync = async.Measurer(lgr)(pipelineAsync)
+	if transfer.Record.Enabled() {
+		writer, err := record.Open(transfer.Record.Path)
+		if err != nil {
+			_ = pipelineAsync.Close()
+			return nil, xerrors.Errorf("unable to open recording: %w", err)
+		}
+		pipelineAsync = async.Recorder(writer, lgr)(pipelineAsync)
+	}
 	return pipelineAsync, nil
 }
 
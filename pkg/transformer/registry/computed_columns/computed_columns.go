@@ -0,0 +1,465 @@
+package computedcolumns
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	parser "github.com/transferia/transferia/library/go/yandex/cloud/filter"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/transformer"
+	"github.com/transferia/transferia/pkg/transformer/registry/filter"
+	"github.com/transferia/transferia/pkg/transformer/sqlexpr"
+	"github.com/transferia/transferia/pkg/util/set"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const Type = abstract.TransformerType("computed_columns")
+
+func init() {
+	transformer.Register[Config](Type, func(cfg Config, lgr log.Logger, _ abstract.TransformationRuntimeOpts) (abstract.Transformer, error) {
+		return New(cfg, lgr)
+	})
+}
+
+var (
+	//go:embed README.md
+	readme []byte
+	_      model.Describable = (*Config)(nil)
+)
+
+type Config struct {
+	Tables filter.Tables `json:"tables" yaml:"tables"`
+	// Columns are `name = expression` assignments, evaluated in order.
+	Columns []string `json:"columns" yaml:"columns"`
+	// Keys are computed columns added to the primary key.
+	Keys []string `json:"keys" yaml:"keys"`
+}
+
+func (c Config) Describe() model.Doc {
+	return model.Doc{
+		Usage: string(readme),
+		Example: `
+tables:
+	include_tables:
+	- '"public"."orders"'
+columns:
+	- full_name = concat(first_name, ' ', last_name)
+	- day = toDate(created_at)
+	- is_big = amount > 1000
+	- amount_with_tax = multiply(amount, 1.2)
+`,
+	}
+}
+
+type computedColumn struct {
+	name       string
+	node       sqlexpr.Node
+	primaryKey bool
+}
+
+// program is the computed columns bound to a single source table schema
+type program struct {
+	expressions []*sqlexpr.Expression
+	// targets are indexes of the computed columns in the result schema
+	targets []int
+	schema  *abstract.TableSchema
+	// keyExpressions compute the key columns from the old keys
+	keyExpressions []*sqlexpr.Expression
+	keyNames       []string
+}
+
+type ComputedColumnsTransformer struct {
+	tables  filter.Filter
+	columns []computedColumn
+	logger  log.Logger
+
+	cacheMu sync.RWMutex
+	cache   map[string]*program
+}
+
+func (t *ComputedColumnsTransformer) Type() abstract.TransformerType {
+	return Type
+}
+
+func (t *ComputedColumnsTransformer) Apply(input []abstract.ChangeItem) abstract.TransformerResult {
+	transformed := make([]abstract.ChangeItem, 0, len(input))
+	errors := make([]abstract.TransformerError, 0)
+	for _, item := range input {
+		if !item.IsRowEvent() {
+			transformed = append(transformed, item)
+			continue
+		}
+		result, err := t.transformItem(item)
+		if err != nil {
+			errors = append(errors, abstract.TransformerError{
+				Input: item,
+				Error: err,
+			})
+			continue
+		}
+		transformed = append(transformed, result)
+	}
+	return abstract.TransformerResult{
+		Transformed: transformed,
+		Errors:      errors,
+	}
+}
+
+func (t *ComputedColumnsTransformer) transformItem(item abstract.ChangeItem) (abstract.ChangeItem, error) {
+	prog, err := t.program(item.TableSchema)
+	if err != nil {
+		return item, xerrors.Errorf("unable to bind computed columns to table %s: %w", item.TableID().Fqtn(), err)
+	}
+
+	if len(item.OldKeys.KeyNames) > 0 && len(prog.keyExpressions) > 0 {
+		oldKeys, err := prog.computeOldKeys(item.OldKeys)
+		if err != nil {
+			return item, xerrors.Errorf("unable to compute old keys: %w", err)
+		}
+		item.OldKeys = oldKeys
+	}
+	// deletes usually carry the old keys only
+	if item.Kind != abstract.DeleteKind || len(item.ColumnNames) > 0 {
+		names, values, err := prog.computeValues(item.ColumnNames, item.ColumnValues)
+		if err != nil {
+			return item, err
+		}
+		item.ColumnNames = names
+		item.ColumnValues = values
+	}
+	item.SetTableSchema(prog.schema)
+	return item, nil
+}
+
+func (p *program) computeValues(names []string, values []interface{}) ([]string, []interface{}, error) {
+	resultNames := append(make([]string, 0, len(names)+len(p.expressions)), names...)
+	resultValues := append(make([]interface{}, 0, len(values)+len(p.expressions)), values...)
+	for i, expr := range p.expressions {
+		name := p.schema.Columns()[p.targets[i]].ColumnName
+		// previously computed columns are visible to the next ones
+		value, err := expr.Eval(sqlexpr.NewRowFromValues(resultNames, resultValues))
+		if err != nil {
+			return nil, nil, xerrors.Errorf("unable to compute column %q: %w", name, err)
+		}
+		if idx := indexOf(resultNames, name); idx >= 0 {
+			resultValues[idx] = value
+		} else {
+			resultNames = append(resultNames, name)
+			resultValues = append(resultValues, value)
+		}
+	}
+	return resultNames, resultValues, nil
+}
+
+func (p *program) computeOldKeys(oldKeys abstract.OldKeysType) (abstract.OldKeysType, error) {
+	result := abstract.OldKeysType{
+		KeyNames:  append(make([]string, 0, len(oldKeys.KeyNames)+len(p.keyNames)), oldKeys.KeyNames...),
+		KeyTypes:  nil,
+		KeyValues: append(make([]interface{}, 0, len(oldKeys.KeyValues)+len(p.keyNames)), oldKeys.KeyValues...),
+	}
+	if len(oldKeys.KeyTypes) == len(oldKeys.KeyNames) {
+		result.KeyTypes = append(make([]string, 0, len(oldKeys.KeyTypes)+len(p.keyNames)), oldKeys.KeyTypes...)
+	}
+	for i, expr := range p.keyExpressions {
+		value, err := expr.Eval(sqlexpr.NewRowFromValues(result.KeyNames, result.KeyValues))
+		if err != nil {
+			return abstract.OldKeysType{}, xerrors.Errorf("unable to compute key column %q: %w", p.keyNames[i], err)
+		}
+		result.KeyNames = append(result.KeyNames, p.keyNames[i])
+		result.KeyValues = append(result.KeyValues, value)
+		if result.KeyTypes != nil {
+			result.KeyTypes = append(result.KeyTypes, string(expr.Type()))
+		}
+	}
+	return result, nil
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *ComputedColumnsTransformer) program(tableSchema *abstract.TableSchema) (*program, error) {
+	hash, err := tableSchema.Hash()
+	if err != nil {
+		t.logger.Warnf("getting schema hash failed, thus cannot use cache for schema: %v", err)
+		return t.bind(tableSchema.Columns())
+	}
+
+	t.cacheMu.RLock()
+	prog, ok := t.cache[hash]
+	t.cacheMu.RUnlock()
+	if ok {
+		return prog, nil
+	}
+
+	prog, err = t.bind(tableSchema.Columns())
+	if err != nil {
+		return nil, err
+	}
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+	t.cache[hash] = prog
+	return prog, nil
+}
+
+func (t *ComputedColumnsTransformer) bind(original abstract.TableColumns) (*program, error) {
+	prog := &program{
+		expressions:    make([]*sqlexpr.Expression, 0, len(t.columns)),
+		targets:        make([]int, 0, len(t.columns)),
+		schema:         nil,
+		keyExpressions: nil,
+		keyNames:       nil,
+	}
+	columns := original.Copy()
+	keyColumns := make(abstract.TableColumns, 0)
+	for _, col := range original {
+		if col.PrimaryKey {
+			keyColumns = append(keyColumns, col)
+		}
+	}
+
+	for _, computed := range t.columns {
+		expr, err := sqlexpr.Bind(computed.node, columns)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to bind column %q: %w", computed.name, err)
+		}
+		col := abstract.NewColSchema(computed.name, expr.Type(), computed.primaryKey)
+		if source, ok := expr.Column(); ok {
+			// a plain copy of a column keeps its type details
+			col = source
+			col.ColumnName = computed.name
+			col.PrimaryKey = computed.primaryKey
+		}
+		target := indexOf(columns.ColumnNames(), computed.name)
+		if target >= 0 {
+			if columns[target].PrimaryKey {
+				return nil, xerrors.Errorf("key column %q cannot be overwritten", computed.name)
+			}
+			if computed.primaryKey {
+				return nil, xerrors.Errorf("existing column %q cannot be added to the key", computed.name)
+			}
+			columns[target] = col
+		} else {
+			target = len(columns)
+			columns = append(columns, col)
+		}
+		prog.expressions = append(prog.expressions, expr)
+		prog.targets = append(prog.targets, target)
+
+		if computed.primaryKey {
+			// key columns are computed for old keys too, so they may depend on other key columns only
+			keyExpr, err := sqlexpr.Bind(computed.node, keyColumns)
+			if err != nil {
+				return nil, xerrors.Errorf("key column %q must depend on key columns only: %w", computed.name, err)
+			}
+			prog.keyExpressions = append(prog.keyExpressions, keyExpr)
+			prog.keyNames = append(prog.keyNames, computed.name)
+			keyColumns = append(keyColumns, col)
+		}
+	}
+	prog.schema = abstract.NewTableSchema(columns)
+	return prog, nil
+}
+
+func (t *ComputedColumnsTransformer) Suitable(table abstract.TableID, schema *abstract.TableSchema) bool {
+	if !filter.MatchAnyTableNameVariant(t.tables, table) {
+		return false
+	}
+	if _, err := t.program(schema); err != nil {
+		t.logger.Warn("computed columns are not applicable to the table", log.String("table", table.Fqtn()), log.Error(err))
+		return false
+	}
+	return true
+}
+
+func (t *ComputedColumnsTransformer) ResultSchema(original *abstract.TableSchema) (*abstract.TableSchema, error) {
+	prog, err := t.program(original)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to bind computed columns: %w", err)
+	}
+	return prog.schema, nil
+}
+
+func (t *ComputedColumnsTransformer) Description() string {
+	columns := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		columns[i] = fmt.Sprintf("%s = %s", col.name, col.node.String())
+	}
+	description := fmt.Sprintf("Computed columns: %s", strings.Join(columns, ", "))
+	if len(description) > 200 {
+		description = description[:200] + "..."
+	}
+	return description
+}
+
+func toNode(expr parser.Expression) (sqlexpr.Node, error) {
+	left, err := operandToNode(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	if !expr.IsComparison() {
+		return left, nil
+	}
+
+	switch expr.Operator {
+	case parser.In, parser.NotIn:
+		list, err := listToNodes(*expr.Right.Value)
+		if err != nil {
+			return nil, err
+		}
+		return sqlexpr.In(left, list, expr.Operator == parser.NotIn), nil
+	}
+	right, err := operandToNode(*expr.Right)
+	if err != nil {
+		return nil, err
+	}
+	switch expr.Operator {
+	case parser.Match, parser.NotMatch:
+		// the same as in filter_rows: `~` checks for a substring
+		zero, _ := sqlexpr.Literal(int64(0))
+		contains, err := sqlexpr.Compare(">", sqlexpr.Call("position", left, right), zero)
+		if err != nil {
+			return nil, err
+		}
+		if expr.Operator == parser.NotMatch {
+			return sqlexpr.Not(contains), nil
+		}
+		return contains, nil
+	case parser.Equals, parser.NotEquals:
+		if expr.Right.Value != nil && expr.Right.Value.IsNull() {
+			// `= NULL` is an IS NULL check in the filter syntax
+			return sqlexpr.IsNull(left, expr.Operator == parser.NotEquals), nil
+		}
+	}
+	return sqlexpr.Compare(expr.Operator.String(), left, right)
+}
+
+func operandToNode(operand parser.Operand) (sqlexpr.Node, error) {
+	switch {
+	case operand.Value != nil:
+		return valueToNode(*operand.Value)
+	case operand.Call != nil:
+		args := make([]sqlexpr.Node, len(operand.Call.Arguments))
+		for i, arg := range operand.Call.Arguments {
+			node, err := toNode(arg)
+			if err != nil {
+				return nil, xerrors.Errorf("argument %d of %s: %w", i+1, operand.Call.Function, err)
+			}
+			args[i] = node
+		}
+		return sqlexpr.Call(operand.Call.Function, args...), nil
+	default:
+		return sqlexpr.ColumnRef(operand.Attribute), nil
+	}
+}
+
+func valueToNode(value parser.Value) (sqlexpr.Node, error) {
+	switch {
+	case value.IsNull():
+		return sqlexpr.Literal(nil)
+	case value.IsBool():
+		return sqlexpr.Literal(value.AsBool())
+	case value.IsString():
+		return sqlexpr.Literal(value.AsString())
+	case value.IsInt():
+		return sqlexpr.Literal(value.AsInt())
+	case value.IsFloat():
+		return sqlexpr.Literal(value.AsFloat())
+	case value.IsTime():
+		return sqlexpr.Literal(value.AsTime())
+	default:
+		return nil, xerrors.Errorf("unsupported value of type %s", value.Type())
+	}
+}
+
+func listToNodes(value parser.Value) ([]sqlexpr.Node, error) {
+	var values []interface{}
+	switch {
+	case value.IsBoolList():
+		for _, v := range value.AsBoolList() {
+			values = append(values, v)
+		}
+	case value.IsStringList():
+		for _, v := range value.AsStringList() {
+			values = append(values, v)
+		}
+	case value.IsIntList():
+		for _, v := range value.AsIntList() {
+			values = append(values, v)
+		}
+	case value.IsFloatList():
+		for _, v := range value.AsFloatList() {
+			values = append(values, v)
+		}
+	case value.IsTimeList():
+		for _, v := range value.AsTimeList() {
+			values = append(values, v)
+		}
+	default:
+		return nil, xerrors.Errorf("unsupported list of type %s", value.Type())
+	}
+	nodes := make([]sqlexpr.Node, len(values))
+	for i, v := range values {
+		node, err := sqlexpr.Literal(v)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+func New(cfg Config, lgr log.Logger) (*ComputedColumnsTransformer, error) {
+	tables, err := filter.NewFilter(cfg.Tables.IncludeTables, cfg.Tables.ExcludeTables)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to init table filter: %w", err)
+	}
+	if len(cfg.Columns) == 0 {
+		return nil, xerrors.New("at least one computed column must be set")
+	}
+
+	keys := set.New(cfg.Keys...)
+	names := set.New[string]()
+	columns := make([]computedColumn, 0, len(cfg.Columns))
+	for _, column := range cfg.Columns {
+		assignment, err := parser.ParseAssignment(column)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to parse computed column %q: %w", column, err)
+		}
+		if names.Contains(assignment.Name) {
+			return nil, xerrors.Errorf("column %q is computed more than once", assignment.Name)
+		}
+		names.Add(assignment.Name)
+		node, err := toNode(assignment.Expression)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to build expression of column %q: %w", assignment.Name, err)
+		}
+		columns = append(columns, computedColumn{
+			name:       assignment.Name,
+			node:       node,
+			primaryKey: keys.Contains(assignment.Name),
+		})
+	}
+	for _, key := range cfg.Keys {
+		if !names.Contains(key) {
+			return nil, xerrors.Errorf("key %q is not a computed column", key)
+		}
+	}
+
+	return &ComputedColumnsTransformer{
+		tables:  tables,
+		columns: columns,
+		logger:  lgr,
+		cacheMu: sync.RWMutex{},
+		cache:   make(map[string]*program),
+	}, nil
+}
@@ -0,0 +1,115 @@
+package computedcolumns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/transformer/registry/filter"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+var testSchema = abstract.NewTableSchema([]abstract.ColSchema{
+	abstract.NewColSchema("id", schema.TypeInt64, true),
+	abstract.NewColSchema("first", schema.TypeString, false),
+	abstract.NewColSchema("last", schema.TypeString, false),
+	abstract.NewColSchema("amount", schema.TypeFloat64, false),
+	abstract.NewColSchema("created_at", schema.TypeTimestamp, false),
+})
+
+func testItem(kind abstract.Kind, id int64, amount float64) abstract.ChangeItem {
+	return abstract.ChangeItem{
+		Kind:         kind,
+		Schema:       "public",
+		Table:        "orders",
+		ColumnNames:  []string{"id", "first", "last", "amount", "created_at"},
+		ColumnValues: []interface{}{id, "John", "Smith", amount, time.Date(2024, 2, 29, 13, 45, 10, 0, time.UTC)},
+		TableSchema:  testSchema,
+		OldKeys: abstract.OldKeysType{
+			KeyNames:  []string{"id"},
+			KeyTypes:  []string{"bigint"},
+			KeyValues: []interface{}{id},
+		},
+	}
+}
+
+func TestComputedColumns(t *testing.T) {
+	tr, err := New(Config{
+		Tables: filter.Tables{IncludeTables: []string{"public.orders"}},
+		Columns: []string{
+			"full_name = concat(first, ' ', last)",
+			"day = toDate(created_at)",
+			"is_big = amount > 1000",
+			"shard = modulo(id, 4)",
+			"label = if(is_big, upper(full_name), NULL)",
+			"amount = multiply(amount, 2)",
+			"is_john = first IN ('John', 'Jane')",
+		},
+		Keys: []string{"shard"},
+	}, logger.Log)
+	require.NoError(t, err)
+	require.True(t, tr.Suitable(abstract.TableID{Namespace: "public", Name: "orders"}, testSchema))
+
+	resultSchema, err := tr.ResultSchema(testSchema)
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "first", "last", "amount", "created_at", "full_name", "day", "is_big", "shard", "label", "is_john"}, resultSchema.Columns().ColumnNames())
+	types := make([]string, 0)
+	for _, col := range resultSchema.Columns()[5:] {
+		types = append(types, col.DataType)
+	}
+	require.Equal(t, []string{"utf8", "date", "boolean", "int64", "utf8", "boolean"}, types)
+	require.True(t, resultSchema.Columns()[8].PrimaryKey)
+	require.Equal(t, string(schema.TypeFloat64), resultSchema.Columns()[3].DataType)
+
+	result := tr.Apply([]abstract.ChangeItem{
+		testItem(abstract.InsertKind, 1, 1500),
+		testItem(abstract.UpdateKind, 6, 10),
+		{Kind: abstract.DeleteKind, Schema: "public", Table: "orders", TableSchema: testSchema, OldKeys: abstract.OldKeysType{KeyNames: []string{"id"}, KeyTypes: nil, KeyValues: []interface{}{int64(7)}}},
+	})
+	require.Empty(t, result.Errors)
+	require.Len(t, result.Transformed, 3)
+
+	require.Equal(t, []interface{}{
+		int64(1), "John", "Smith", 3000.0, time.Date(2024, 2, 29, 13, 45, 10, 0, time.UTC),
+		"John Smith", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), true, int64(1), "JOHN SMITH", true,
+	}, result.Transformed[0].ColumnValues)
+	require.Equal(t, resultSchema.Columns(), result.Transformed[0].TableSchema.Columns())
+
+	updated := result.Transformed[1]
+	require.Equal(t, []interface{}{int64(2), nil}, []interface{}{updated.ColumnValues[8], updated.ColumnValues[9]})
+	require.Equal(t, []string{"id", "shard"}, updated.OldKeys.KeyNames)
+	require.Equal(t, []string{"bigint", "int64"}, updated.OldKeys.KeyTypes)
+	require.Equal(t, []interface{}{int64(6), int64(2)}, updated.OldKeys.KeyValues)
+
+	deleted := result.Transformed[2]
+	require.Empty(t, deleted.ColumnNames)
+	require.Equal(t, []interface{}{int64(7), int64(3)}, deleted.OldKeys.KeyValues)
+}
+
+func TestComputedColumnsErrors(t *testing.T) {
+	for _, columns := range [][]string{
+		{"full_name concat(first, last)"},
+		{"x = concat(first,"},
+		{"x = amount IN 5"},
+		{"x = (1, 2)"},
+		{"x = 1", "x = 2"},
+	} {
+		_, err := New(Config{Tables: filter.Tables{}, Columns: columns, Keys: nil}, logger.Log)
+		require.Error(t, err, columns)
+	}
+
+	_, err := New(Config{Tables: filter.Tables{}, Columns: []string{"x = 1"}, Keys: []string{"y"}}, logger.Log)
+	require.Error(t, err)
+
+	for _, cfg := range []Config{
+		{Tables: filter.Tables{}, Columns: []string{"x = unknown_function(id)"}, Keys: nil},
+		{Tables: filter.Tables{}, Columns: []string{"id = plus(id, 1)"}, Keys: nil},
+		{Tables: filter.Tables{}, Columns: []string{"x = concat(first, id)"}, Keys: []string{"x"}},
+	} {
+		tr, err := New(cfg, logger.Log)
+		require.NoError(t, err)
+		require.False(t, tr.Suitable(abstract.TableID{Namespace: "public", Name: "orders"}, testSchema), cfg.Columns)
+	}
+}
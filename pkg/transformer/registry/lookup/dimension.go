@@ -0,0 +1,340 @@
+package lookup
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+// lazyBatchSize limits the number of keys queried by a single filter in the lazy mode
+const lazyBatchSize = 500
+
+// dimension gives access to the rows of the dimension table by join key
+type dimension struct {
+	table   abstract.TableID
+	storage abstract.Storage
+	keys    []JoinKey
+	columns []Column
+	mode    LoadMode
+	ttl     time.Duration
+	logger  log.Logger
+
+	mutex sync.Mutex
+	// rows and loadedAt are used in the full mode
+	rows     map[string][]interface{}
+	loadedAt time.Time
+	// cache is used in the lazy mode
+	cache *lruCache
+}
+
+func newDimension(cfg Config, table abstract.TableID, storage abstract.Storage, lgr log.Logger) *dimension {
+	return &dimension{
+		table:    table,
+		storage:  storage,
+		keys:     cfg.Keys,
+		columns:  cfg.Columns,
+		mode:     cfg.Mode,
+		ttl:      cfg.CacheTTL,
+		logger:   lgr,
+		mutex:    sync.Mutex{},
+		rows:     nil,
+		loadedAt: time.Time{},
+		cache:    newLRUCache(cfg.CacheSize, cfg.CacheTTL),
+	}
+}
+
+// lookup returns the appended values of the rows found by the given keys, empty keys are skipped
+func (d *dimension) lookup(keys []string, values [][]interface{}) (map[string][]interface{}, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.mode == LoadFull {
+		return d.lookupFull(keys)
+	}
+	return d.lookupLazy(keys, values)
+}
+
+func (d *dimension) lookupFull(keys []string) (map[string][]interface{}, error) {
+	if d.rows == nil || (d.ttl > 0 && time.Since(d.loadedAt) > d.ttl) {
+		rows := make(map[string][]interface{})
+		if err := d.load(abstract.NoFilter, rows); err != nil {
+			return nil, xerrors.Errorf("unable to load dimension table %s: %w", d.table.Fqtn(), err)
+		}
+		d.logger.Info("dimension table loaded", log.String("table", d.table.Fqtn()), log.Int("rows", len(rows)))
+		d.rows = rows
+		d.loadedAt = time.Now()
+	}
+	result := make(map[string][]interface{})
+	for _, key := range keys {
+		if row, ok := d.rows[key]; ok && key != "" {
+			result[key] = row
+		}
+	}
+	return result, nil
+}
+
+func (d *dimension) lookupLazy(keys []string, values [][]interface{}) (map[string][]interface{}, error) {
+	result := make(map[string][]interface{})
+	missed := make(map[string][]interface{})
+	for i, key := range keys {
+		if key == "" {
+			continue
+		}
+		if row, found, ok := d.cache.get(key); ok {
+			if found {
+				result[key] = row
+			}
+			continue
+		}
+		missed[key] = values[i]
+	}
+	if len(missed) == 0 {
+		return result, nil
+	}
+
+	missedKeys := make([]string, 0, len(missed))
+	for key := range missed {
+		missedKeys = append(missedKeys, key)
+	}
+	for start := 0; start < len(missedKeys); start += lazyBatchSize {
+		batch := missedKeys[start:min(start+lazyBatchSize, len(missedKeys))]
+		batchValues := make([][]interface{}, len(batch))
+		for i, key := range batch {
+			batchValues[i] = missed[key]
+		}
+		filter, err := d.keysFilter(batchValues)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to build filter: %w", err)
+		}
+		rows := make(map[string][]interface{})
+		if err := d.load(filter, rows); err != nil {
+			return nil, xerrors.Errorf("unable to query dimension table %s: %w", d.table.Fqtn(), err)
+		}
+		for _, key := range batch {
+			row, found := rows[key]
+			// misses are cached too, so absent keys are not queried on every batch
+			d.cache.put(key, row, found)
+			if found {
+				result[key] = row
+			}
+		}
+	}
+	return result, nil
+}
+
+func (d *dimension) load(filter abstract.WhereStatement, rows map[string][]interface{}) error {
+	table := abstract.TableDescription{
+		Name:   d.table.Name,
+		Schema: d.table.Namespace,
+		Filter: filter,
+		EtaRow: 0,
+		Offset: 0,
+	}
+	return d.storage.LoadTable(context.Background(), table, func(items []abstract.ChangeItem) error {
+		for _, item := range items {
+			if !item.IsRowEvent() {
+				continue
+			}
+			indices := item.ColumnNameIndices()
+			keyValues := make([]interface{}, len(d.keys))
+			for i, key := range d.keys {
+				idx, ok := indices[key.DimensionColumn]
+				if !ok {
+					return xerrors.Errorf("dimension row has no join key column %q", key.DimensionColumn)
+				}
+				keyValues[i] = item.ColumnValues[idx]
+			}
+			row := make([]interface{}, len(d.columns))
+			for i, col := range d.columns {
+				if idx, ok := indices[col.Name]; ok {
+					row[i] = item.ColumnValues[idx]
+				}
+			}
+			rows[joinKey(keyValues)] = row
+		}
+		return nil
+	})
+}
+
+// keysFilter builds a filter selecting the rows with the given join keys
+func (d *dimension) keysFilter(keys [][]interface{}) (abstract.WhereStatement, error) {
+	if len(d.keys) == 1 {
+		literals := make([]string, len(keys))
+		for i, key := range keys {
+			literal, err := sqlLiteral(key[0])
+			if err != nil {
+				return abstract.NoFilter, err
+			}
+			literals[i] = literal
+		}
+		return abstract.WhereStatement(fmt.Sprintf("%s IN (%s)", quoteIdentifier(d.keys[0].DimensionColumn), strings.Join(literals, ", "))), nil
+	}
+	conditions := make([]string, len(keys))
+	for i, key := range keys {
+		parts := make([]string, len(d.keys))
+		for j, joinKey := range d.keys {
+			literal, err := sqlLiteral(key[j])
+			if err != nil {
+				return abstract.NoFilter, err
+			}
+			parts[j] = fmt.Sprintf("%s = %s", quoteIdentifier(joinKey.DimensionColumn), literal)
+		}
+		conditions[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+	return abstract.WhereStatement(strings.Join(conditions, " OR ")), nil
+}
+
+func sqlLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return fmt.Sprint(v), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case string:
+		return quoteString(v), nil
+	case []byte:
+		return quoteString(string(v)), nil
+	case time.Time:
+		return quoteString(v.Format("2006-01-02 15:04:05.999999999")), nil
+	default:
+		return "", xerrors.Errorf("join key value of type %T is not supported in the lazy mode", value)
+	}
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// joinKey makes a map key from the join key values. Every value is encoded with its kind, so a string never matches a number,
+// while values of different integer or floating point types match each other.
+func joinKey(values []interface{}) string {
+	var key strings.Builder
+	for _, value := range values {
+		kind, text := joinKeyPart(value)
+		_, _ = fmt.Fprintf(&key, "%c%d:%s", kind, len(text), text)
+	}
+	return key.String()
+}
+
+func joinKeyPart(value interface{}) (byte, string) {
+	switch v := value.(type) {
+	case int:
+		return 'i', strconv.FormatInt(int64(v), 10)
+	case int8:
+		return 'i', strconv.FormatInt(int64(v), 10)
+	case int16:
+		return 'i', strconv.FormatInt(int64(v), 10)
+	case int32:
+		return 'i', strconv.FormatInt(int64(v), 10)
+	case int64:
+		return 'i', strconv.FormatInt(v, 10)
+	case uint:
+		return 'i', strconv.FormatUint(uint64(v), 10)
+	case uint8:
+		return 'i', strconv.FormatUint(uint64(v), 10)
+	case uint16:
+		return 'i', strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return 'i', strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return 'i', strconv.FormatUint(v, 10)
+	case float32:
+		return 'f', strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return 'f', strconv.FormatFloat(v, 'g', -1, 64)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return 'i', strconv.FormatInt(i, 10)
+		}
+		if f, err := v.Float64(); err == nil {
+			return 'f', strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return 'n', v.String()
+	case bool:
+		return 'b', strconv.FormatBool(v)
+	case string:
+		return 's', v
+	case []byte:
+		return 's', string(v)
+	case time.Time:
+		return 't', v.UTC().Format(time.RFC3339Nano)
+	default:
+		return 'v', fmt.Sprintf("%T:%v", v, v)
+	}
+}
+
+type cacheEntry struct {
+	key     string
+	row     []interface{}
+	found   bool
+	expires time.Time
+}
+
+// lruCache keeps the most recently used join keys, it is not safe for concurrent use
+type lruCache struct {
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newLRUCache(size int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached row and whether it exists in the dimension table, ok is false for uncached keys
+func (c *lruCache) get(key string) (row []interface{}, found bool, ok bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.ttl > 0 && time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.row, entry.found, true
+}
+
+func (c *lruCache) put(key string, row []interface{}, found bool) {
+	entry := &cacheEntry{
+		key:     key,
+		row:     row,
+		found:   found,
+		expires: time.Now().Add(c.ttl),
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
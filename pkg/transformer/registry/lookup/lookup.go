@@ -0,0 +1,508 @@
+package lookup
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/storage"
+	"github.com/transferia/transferia/pkg/transformer"
+	"github.com/transferia/transferia/pkg/transformer/registry/filter"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const Type = abstract.TransformerType("lookup")
+
+type MissPolicy string
+
+const (
+	// MissNull appends NULL dimension columns to rows without a match.
+	MissNull = MissPolicy("null")
+	// MissDrop drops rows without a match.
+	MissDrop = MissPolicy("drop")
+	// MissError marks rows without a match as errored.
+	MissError = MissPolicy("error")
+)
+
+type LoadMode string
+
+const (
+	// LoadFull loads the whole dimension table into memory.
+	LoadFull = LoadMode("full")
+	// LoadLazy queries the dimension table by key and caches the results.
+	LoadLazy = LoadMode("lazy")
+)
+
+const (
+	defaultCacheSize = 100000
+)
+
+func init() {
+	transformer.Register[Config](Type, func(cfg Config, lgr log.Logger, _ abstract.TransformationRuntimeOpts) (abstract.Transformer, error) {
+		return New(cfg, lgr)
+	})
+}
+
+var (
+	//go:embed README.md
+	readme []byte
+	_      model.Describable = (*Config)(nil)
+)
+
+type Endpoint struct {
+	Type   abstract.ProviderType  `json:"type" yaml:"type"`
+	Params map[string]interface{} `json:"params" yaml:"params"`
+}
+
+type JoinKey struct {
+	// Column is a column of the transformed table.
+	Column string `json:"column" yaml:"column"`
+	// DimensionColumn is a column of the dimension table, the same as Column if empty.
+	DimensionColumn string `json:"dimension_column" yaml:"dimension_column"`
+}
+
+type Column struct {
+	// Name is a column of the dimension table.
+	Name string `json:"name" yaml:"name"`
+	// Alias is a name of the appended column, the same as Name if empty.
+	Alias string `json:"alias" yaml:"alias"`
+}
+
+type Config struct {
+	Tables filter.Tables `json:"tables" yaml:"tables"`
+	// Source is an endpoint of any provider able to make snapshots.
+	Source Endpoint `json:"source" yaml:"source"`
+	// Table is the dimension table in the source.
+	Table   string     `json:"table" yaml:"table"`
+	Keys    []JoinKey  `json:"keys" yaml:"keys"`
+	Columns []Column   `json:"columns" yaml:"columns"`
+	OnMiss  MissPolicy `json:"on_miss" yaml:"on_miss"`
+	Mode    LoadMode   `json:"mode" yaml:"mode"`
+	// CacheSize limits the number of cached keys in the lazy mode.
+	CacheSize int `json:"cache_size" yaml:"cache_size"`
+	// CacheTTL is the time after which cached keys are queried again in the lazy mode
+	// or the whole table is reloaded in the full mode. Zero means forever.
+	CacheTTL time.Duration `json:"cache_ttl" yaml:"cache_ttl"`
+}
+
+func (c Config) Describe() model.Doc {
+	return model.Doc{
+		Usage: string(readme),
+		Example: `
+tables:
+	include_tables:
+	- '"public"."events"'
+source:
+	type: pg
+	params:
+		Hosts: [pg.example.com]
+		Database: crm
+		User: reader
+		Password: secret
+table: public.users
+keys:
+	- column: user_id
+		dimension_column: id
+columns:
+	- name: country
+	- name: plan
+		alias: user_plan
+on_miss: "null"
+mode: lazy
+cache_size: 100000
+`,
+	}
+}
+
+func (c *Config) validate() error {
+	if c.Source.Type == "" {
+		return xerrors.New("source type must be set")
+	}
+	if c.Table == "" {
+		return xerrors.New("table must be set")
+	}
+	if len(c.Keys) == 0 {
+		return xerrors.New("at least one join key must be set")
+	}
+	for i, key := range c.Keys {
+		if key.Column == "" {
+			return xerrors.Errorf("column of join key %d must be set", i)
+		}
+	}
+	if len(c.Columns) == 0 {
+		return xerrors.New("at least one column must be set")
+	}
+	for i, col := range c.Columns {
+		if col.Name == "" {
+			return xerrors.Errorf("name of column %d must be set", i)
+		}
+	}
+	switch c.OnMiss {
+	case MissNull, MissDrop, MissError:
+	default:
+		return xerrors.Errorf("unknown on_miss policy %q, expected one of: %s, %s, %s", c.OnMiss, MissNull, MissDrop, MissError)
+	}
+	switch c.Mode {
+	case LoadFull, LoadLazy:
+	default:
+		return xerrors.Errorf("unknown mode %q, expected one of: %s, %s", c.Mode, LoadFull, LoadLazy)
+	}
+	if c.CacheSize < 0 || c.CacheTTL < 0 {
+		return xerrors.New("cache_size and cache_ttl must not be negative")
+	}
+	return nil
+}
+
+func (c *Config) withDefaults() {
+	if c.OnMiss == "" {
+		c.OnMiss = MissNull
+	}
+	if c.Mode == "" {
+		c.Mode = LoadFull
+	}
+	if c.CacheSize == 0 {
+		c.CacheSize = defaultCacheSize
+	}
+	for i := range c.Keys {
+		if c.Keys[i].DimensionColumn == "" {
+			c.Keys[i].DimensionColumn = c.Keys[i].Column
+		}
+	}
+	for i := range c.Columns {
+		if c.Columns[i].Alias == "" {
+			c.Columns[i].Alias = c.Columns[i].Name
+		}
+	}
+}
+
+type LookupTransformer struct {
+	cfg    Config
+	tables filter.Filter
+	table  abstract.TableID
+	keys   []JoinKey
+	onMiss MissPolicy
+	logger log.Logger
+	// openStorage opens the dimension storage on the first use of the transformer
+	openStorage func() (abstract.Storage, error)
+
+	mutex     sync.Mutex
+	dimension *dimension
+	// columns are the appended columns in the order of the config
+	columns abstract.TableColumns
+}
+
+func (t *LookupTransformer) Type() abstract.TransformerType {
+	return Type
+}
+
+func (t *LookupTransformer) Apply(input []abstract.ChangeItem) abstract.TransformerResult {
+	transformed := make([]abstract.ChangeItem, 0, len(input))
+	errors := make([]abstract.TransformerError, 0)
+
+	dim, columns, err := t.open()
+	if err != nil {
+		for _, item := range input {
+			errors = append(errors, abstract.TransformerError{
+				Input: item,
+				Error: err,
+			})
+		}
+		return abstract.TransformerResult{
+			Transformed: nil,
+			Errors:      errors,
+		}
+	}
+
+	keys := make([]string, len(input))
+	values := make([][]interface{}, len(input))
+	for i := range input {
+		if input[i].IsRowEvent() && input[i].Kind != abstract.DeleteKind {
+			keys[i], values[i] = t.rowKey(&input[i])
+		}
+	}
+	found, err := dim.lookup(keys, values)
+	if err != nil {
+		for _, item := range input {
+			errors = append(errors, abstract.TransformerError{
+				Input: item,
+				Error: xerrors.Errorf("unable to look up dimension rows: %w", err),
+			})
+		}
+		return abstract.TransformerResult{
+			Transformed: nil,
+			Errors:      errors,
+		}
+	}
+
+	for i, item := range input {
+		if !item.IsRowEvent() {
+			transformed = append(transformed, item)
+			continue
+		}
+		resultSchema, err := t.ResultSchema(item.TableSchema)
+		if err != nil {
+			errors = append(errors, abstract.TransformerError{
+				Input: item,
+				Error: err,
+			})
+			continue
+		}
+		if item.Kind == abstract.DeleteKind {
+			item.SetTableSchema(resultSchema)
+			transformed = append(transformed, item)
+			continue
+		}
+
+		row, ok := found[keys[i]]
+		if !ok {
+			switch t.onMiss {
+			case MissDrop:
+				continue
+			case MissError:
+				errors = append(errors, abstract.TransformerError{
+					Input: item,
+					Error: xerrors.Errorf("no row with key %v in the dimension table", values[i]),
+				})
+				continue
+			default:
+				row = make([]interface{}, len(columns))
+			}
+		}
+		item.ColumnNames = append(append(make([]string, 0, len(item.ColumnNames)+len(columns)), item.ColumnNames...), columns.ColumnNames()...)
+		item.ColumnValues = append(append(make([]interface{}, 0, len(item.ColumnValues)+len(columns)), item.ColumnValues...), row...)
+		item.SetTableSchema(resultSchema)
+		transformed = append(transformed, item)
+	}
+	return abstract.TransformerResult{
+		Transformed: transformed,
+		Errors:      errors,
+	}
+}
+
+// rowKey returns the join key of the item, the key is empty if any of its values is NULL
+func (t *LookupTransformer) rowKey(item *abstract.ChangeItem) (string, []interface{}) {
+	indices := item.ColumnNameIndices()
+	values := make([]interface{}, len(t.keys))
+	for i, key := range t.keys {
+		idx, ok := indices[key.Column]
+		if !ok || item.ColumnValues[idx] == nil {
+			return "", nil
+		}
+		values[i] = item.ColumnValues[idx]
+	}
+	return joinKey(values), values
+}
+
+func (t *LookupTransformer) Suitable(table abstract.TableID, schema *abstract.TableSchema) bool {
+	if !filter.MatchAnyTableNameVariant(t.tables, table) {
+		return false
+	}
+	columns := schema.FastColumns()
+	for _, key := range t.keys {
+		if _, ok := columns[abstract.ColumnName(key.Column)]; !ok {
+			return false
+		}
+	}
+	for _, col := range t.cfg.Columns {
+		if _, ok := columns[abstract.ColumnName(col.Alias)]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *LookupTransformer) ResultSchema(original *abstract.TableSchema) (*abstract.TableSchema, error) {
+	_, appended, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+	columns := original.Columns().Copy()
+	existing := original.FastColumns()
+	for _, col := range appended {
+		if _, ok := existing[abstract.ColumnName(col.ColumnName)]; ok {
+			return nil, xerrors.Errorf("column %q already exists in the table", col.ColumnName)
+		}
+		columns = append(columns, col)
+	}
+	return abstract.NewTableSchema(columns), nil
+}
+
+func (t *LookupTransformer) Description() string {
+	keys := make([]string, len(t.keys))
+	for i, key := range t.keys {
+		keys[i] = fmt.Sprintf("%s=%s", key.Column, key.DimensionColumn)
+	}
+	columns := make([]string, len(t.cfg.Columns))
+	for i, col := range t.cfg.Columns {
+		columns[i] = col.Alias
+	}
+	return fmt.Sprintf("Lookup %s from %s by %s", strings.Join(columns, ", "), t.table.Fqtn(), strings.Join(keys, ", "))
+}
+
+// open connects to the dimension source and resolves the appended columns on the first call,
+// a failed attempt is repeated by the next call.
+func (t *LookupTransformer) open() (*dimension, abstract.TableColumns, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.dimension != nil {
+		return t.dimension, t.columns, nil
+	}
+	sourceStorage, err := t.openStorage()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("unable to init dimension source: %w", err)
+	}
+	columns, err := appendedColumns(t.cfg, t.table, sourceStorage)
+	if err != nil {
+		sourceStorage.Close()
+		return nil, nil, err
+	}
+	t.dimension = newDimension(t.cfg, t.table, sourceStorage, t.logger)
+	t.columns = columns
+	return t.dimension, t.columns, nil
+}
+
+// Close releases the dimension storage if it was opened.
+func (t *LookupTransformer) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.dimension != nil {
+		t.dimension.storage.Close()
+	}
+	return nil
+}
+
+// appendedColumns resolves the appended columns by the schema of the dimension table
+func appendedColumns(cfg Config, table abstract.TableID, sourceStorage abstract.Storage) (abstract.TableColumns, error) {
+	dimensionSchema, err := sourceStorage.TableSchema(context.Background(), table)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get schema of dimension table %s: %w", table.Fqtn(), err)
+	}
+
+	dimensionColumns := dimensionSchema.FastColumns()
+	for _, key := range cfg.Keys {
+		if _, ok := dimensionColumns[abstract.ColumnName(key.DimensionColumn)]; !ok {
+			return nil, xerrors.Errorf("join key column %q is not found in dimension table %s", key.DimensionColumn, table.Fqtn())
+		}
+	}
+	columns := make(abstract.TableColumns, 0, len(cfg.Columns))
+	for _, col := range cfg.Columns {
+		dimensionCol, ok := dimensionColumns[abstract.ColumnName(col.Name)]
+		if !ok {
+			return nil, xerrors.Errorf("column %q is not found in dimension table %s", col.Name, table.Fqtn())
+		}
+		dimensionCol.ColumnName = col.Alias
+		dimensionCol.PrimaryKey = false
+		// rows without a match get NULLs
+		dimensionCol.Required = false
+		dimensionCol.TableSchema = ""
+		dimensionCol.TableName = ""
+		columns = append(columns, dimensionCol)
+	}
+	return columns, nil
+}
+
+func newSourceStorage(endpoint Endpoint) (abstract.Storage, error) {
+	params, err := json.Marshal(endpoint.Params)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal source params: %w", err)
+	}
+	source, err := model.NewSource(endpoint.Type, string(params))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to create %s source: %w", endpoint.Type, err)
+	}
+	if err := source.Validate(); err != nil {
+		return nil, xerrors.Errorf("invalid %s source: %w", endpoint.Type, err)
+	}
+	transfer := &model.Transfer{
+		ID:                string(Type),
+		TransferName:      "",
+		Description:       "",
+		Labels:            "",
+		Status:            model.Running,
+		Type:              abstract.TransferTypeSnapshotOnly,
+		Runtime:           nil,
+		Src:               source,
+		Dst:               nil,
+		RegularSnapshot:   nil,
+		Transformation:    nil,
+		DataObjects:       nil,
+		TypeSystemVersion: 0,
+		TmpPolicy:         nil,
+		FolderID:          "",
+		CloudID:           "",
+		Author:            "",
+	}
+	result, err := storage.NewStorage(transfer, coordinator.NewFakeClient(), solomon.NewRegistry(solomon.NewRegistryOpts()))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to create %s storage: %w", endpoint.Type, err)
+	}
+	return result, nil
+}
+
+// New makes the transformer, the dimension source is connected on the first use,
+// so that validation of the transfer does not depend on its availability.
+func New(cfg Config, lgr log.Logger) (*LookupTransformer, error) {
+	return newLookup(cfg, func() (abstract.Storage, error) {
+		return newSourceStorage(cfg.Source)
+	}, lgr)
+}
+
+// NewWithStorage makes the transformer over an already opened dimension storage.
+func NewWithStorage(cfg Config, sourceStorage abstract.Storage, lgr log.Logger) (*LookupTransformer, error) {
+	result, err := newLookup(cfg, func() (abstract.Storage, error) {
+		return sourceStorage, nil
+	}, lgr)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := result.open(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func newLookup(cfg Config, openStorage func() (abstract.Storage, error), lgr log.Logger) (*LookupTransformer, error) {
+	cfg.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return nil, xerrors.Errorf("invalid config: %w", err)
+	}
+	tables, err := filter.NewFilter(cfg.Tables.IncludeTables, cfg.Tables.ExcludeTables)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to init table filter: %w", err)
+	}
+	table, err := abstract.ParseTableID(cfg.Table)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to parse dimension table %q: %w", cfg.Table, err)
+	}
+	seen := make(map[string]bool)
+	for _, col := range cfg.Columns {
+		if seen[col.Alias] {
+			return nil, xerrors.Errorf("column %q is appended more than once", col.Alias)
+		}
+		seen[col.Alias] = true
+	}
+
+	return &LookupTransformer{
+		cfg:         cfg,
+		tables:      tables,
+		table:       *table,
+		keys:        cfg.Keys,
+		onMiss:      cfg.OnMiss,
+		logger:      lgr,
+		openStorage: openStorage,
+		mutex:       sync.Mutex{},
+		dimension:   nil,
+		columns:     nil,
+	}, nil
+}
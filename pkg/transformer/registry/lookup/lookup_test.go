@@ -0,0 +1,201 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/transformer/registry/filter"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+var usersSchema = abstract.NewTableSchema([]abstract.ColSchema{
+	abstract.NewColSchema("id", schema.TypeInt64, true),
+	abstract.NewColSchema("country", schema.TypeString, false),
+	abstract.NewColSchema("plan", schema.TypeString, false),
+})
+
+var eventsSchema = abstract.NewTableSchema([]abstract.ColSchema{
+	abstract.NewColSchema("event_id", schema.TypeInt64, true),
+	abstract.NewColSchema("user_id", schema.TypeInt32, false),
+})
+
+// usersStorage serves the dimension table and records the filters of the loads
+type usersStorage struct {
+	abstract.Storage
+	filters []abstract.WhereStatement
+	closed  bool
+}
+
+func (s *usersStorage) Close() {
+	s.closed = true
+}
+
+func (s *usersStorage) TableSchema(_ context.Context, _ abstract.TableID) (*abstract.TableSchema, error) {
+	return usersSchema, nil
+}
+
+func (s *usersStorage) LoadTable(_ context.Context, table abstract.TableDescription, pusher abstract.Pusher) error {
+	s.filters = append(s.filters, table.Filter)
+	var items []abstract.ChangeItem
+	for _, row := range [][]interface{}{{int64(1), "NL", "free"}, {int64(2), "DE", "pro"}} {
+		if table.Filter != abstract.NoFilter && !strings.Contains(string(table.Filter), fmt.Sprint(row[0])) {
+			continue
+		}
+		items = append(items, abstract.ChangeItem{
+			Kind:         abstract.InsertKind,
+			Schema:       table.Schema,
+			Table:        table.Name,
+			ColumnNames:  []string{"id", "country", "plan"},
+			ColumnValues: row,
+			TableSchema:  usersSchema,
+		})
+	}
+	return pusher(items)
+}
+
+func testConfig(mode LoadMode, onMiss MissPolicy) Config {
+	return Config{
+		Tables:    filter.Tables{IncludeTables: []string{"public.events"}},
+		Source:    Endpoint{Type: "pg", Params: nil},
+		Table:     "public.users",
+		Keys:      []JoinKey{{Column: "user_id", DimensionColumn: "id"}},
+		Columns:   []Column{{Name: "country", Alias: ""}, {Name: "plan", Alias: "user_plan"}},
+		OnMiss:    onMiss,
+		Mode:      mode,
+		CacheSize: 0,
+		CacheTTL:  0,
+	}
+}
+
+func event(id int64, userID interface{}) abstract.ChangeItem {
+	return abstract.ChangeItem{
+		Kind:         abstract.InsertKind,
+		Schema:       "public",
+		Table:        "events",
+		ColumnNames:  []string{"event_id", "user_id"},
+		ColumnValues: []interface{}{id, userID},
+		TableSchema:  eventsSchema,
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, mode := range []LoadMode{LoadFull, LoadLazy} {
+		t.Run(string(mode), func(t *testing.T) {
+			storage := &usersStorage{Storage: nil, filters: nil}
+			tr, err := NewWithStorage(testConfig(mode, MissNull), storage, logger.Log)
+			require.NoError(t, err)
+
+			require.True(t, tr.Suitable(abstract.TableID{Namespace: "public", Name: "events"}, eventsSchema))
+			require.False(t, tr.Suitable(abstract.TableID{Namespace: "public", Name: "users"}, usersSchema))
+
+			resultSchema, err := tr.ResultSchema(eventsSchema)
+			require.NoError(t, err)
+			require.Equal(t, []string{"event_id", "user_id", "country", "user_plan"}, resultSchema.Columns().ColumnNames())
+			require.False(t, resultSchema.Columns()[2].PrimaryKey)
+
+			for i := 0; i < 2; i++ {
+				result := tr.Apply([]abstract.ChangeItem{event(10, int32(1)), event(11, int32(3)), event(12, nil), event(13, int32(2))})
+				require.Empty(t, result.Errors)
+				require.Len(t, result.Transformed, 4)
+				require.Equal(t, []interface{}{int64(10), int32(1), "NL", "free"}, result.Transformed[0].ColumnValues)
+				require.Equal(t, []interface{}{int64(11), int32(3), nil, nil}, result.Transformed[1].ColumnValues)
+				require.Equal(t, []interface{}{int64(12), nil, nil, nil}, result.Transformed[2].ColumnValues)
+				require.Equal(t, []interface{}{int64(13), int32(2), "DE", "pro"}, result.Transformed[3].ColumnValues)
+				require.Equal(t, resultSchema.Columns(), result.Transformed[0].TableSchema.Columns())
+			}
+			// the second batch is served from memory
+			require.Len(t, storage.filters, 1)
+			if mode == LoadLazy {
+				require.Contains(t, string(storage.filters[0]), `"id" IN (`)
+			}
+			require.NoError(t, tr.Close())
+			require.True(t, storage.closed)
+		})
+	}
+}
+
+func TestLookupMissPolicies(t *testing.T) {
+	tr, err := NewWithStorage(testConfig(LoadFull, MissDrop), &usersStorage{Storage: nil, filters: nil}, logger.Log)
+	require.NoError(t, err)
+	result := tr.Apply([]abstract.ChangeItem{event(10, int32(1)), event(11, int32(3))})
+	require.Empty(t, result.Errors)
+	require.Len(t, result.Transformed, 1)
+
+	tr, err = NewWithStorage(testConfig(LoadFull, MissError), &usersStorage{Storage: nil, filters: nil}, logger.Log)
+	require.NoError(t, err)
+	result = tr.Apply([]abstract.ChangeItem{event(10, int32(1)), event(11, int32(3))})
+	require.Len(t, result.Transformed, 1)
+	require.Len(t, result.Errors, 1)
+	require.Equal(t, int64(11), result.Errors[0].Input.ColumnValues[0])
+}
+
+func TestLookupBadConfig(t *testing.T) {
+	cfg := testConfig(LoadFull, "skip")
+	_, err := NewWithStorage(cfg, &usersStorage{Storage: nil, filters: nil}, logger.Log)
+	require.Error(t, err)
+
+	cfg = testConfig(LoadFull, MissNull)
+	cfg.Columns = []Column{{Name: "unknown", Alias: ""}}
+	_, err = NewWithStorage(cfg, &usersStorage{Storage: nil, filters: nil}, logger.Log)
+	require.Error(t, err)
+}
+
+func TestLookupConnectsOnFirstUse(t *testing.T) {
+	cfg := testConfig(LoadFull, MissNull)
+	cfg.Source = Endpoint{Type: "unknown", Params: nil}
+	tr, err := New(cfg, logger.Log)
+	require.NoError(t, err)
+	require.True(t, tr.Suitable(abstract.TableID{Namespace: "public", Name: "events"}, eventsSchema))
+	require.Equal(t, "Lookup country, user_plan from \"public\".\"users\" by user_id=id", tr.Description())
+
+	_, err = tr.ResultSchema(eventsSchema)
+	require.Error(t, err)
+	result := tr.Apply([]abstract.ChangeItem{event(10, int32(1))})
+	require.Len(t, result.Errors, 1)
+	require.NoError(t, tr.Close())
+}
+
+func TestKeysFilter(t *testing.T) {
+	d := newDimension(Config{
+		Keys:      []JoinKey{{Column: "a", DimensionColumn: "a"}, {Column: "b", DimensionColumn: "b"}},
+		Columns:   nil,
+		Mode:      LoadLazy,
+		CacheSize: 1,
+	}, abstract.TableID{Namespace: "public", Name: "dim"}, nil, logger.Log)
+	filter, err := d.keysFilter([][]interface{}{{1, "it's"}, {2, "x"}})
+	require.NoError(t, err)
+	require.Equal(t, abstract.WhereStatement(`("a" = 1 AND "b" = 'it''s') OR ("a" = 2 AND "b" = 'x')`), filter)
+}
+
+func TestJoinKey(t *testing.T) {
+	require.Equal(t, joinKey([]interface{}{int32(1), "a"}), joinKey([]interface{}{int64(1), []byte("a")}))
+	require.Equal(t, joinKey([]interface{}{uint8(1)}), joinKey([]interface{}{json.Number("1")}))
+	require.Equal(t, joinKey([]interface{}{float32(1.5)}), joinKey([]interface{}{1.5}))
+	require.NotEqual(t, joinKey([]interface{}{int64(1)}), joinKey([]interface{}{"1"}))
+	require.NotEqual(t, joinKey([]interface{}{"a\x00", "b"}), joinKey([]interface{}{"a", "\x00b"}))
+	moment := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.Equal(t, joinKey([]interface{}{moment}), joinKey([]interface{}{moment.In(time.FixedZone("UTC+3", 3*60*60))}))
+}
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache(2, 0)
+	c.put("a", []interface{}{1}, true)
+	c.put("b", nil, false)
+	_, _, ok := c.get("a")
+	require.True(t, ok)
+	c.put("c", []interface{}{3}, true)
+	_, found, ok := c.get("b")
+	require.False(t, ok)
+	require.False(t, found)
+	row, found, ok := c.get("a")
+	require.True(t, ok)
+	require.True(t, found)
+	require.Equal(t, []interface{}{1}, row)
+}
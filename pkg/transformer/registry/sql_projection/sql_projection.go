@@ -0,0 +1,322 @@
+package sqlprojection
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/transformer"
+	"github.com/transferia/transferia/pkg/transformer/registry/filter"
+	"github.com/transferia/transferia/pkg/transformer/sqlexpr"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const Type = abstract.TransformerType("sql_projection")
+
+func init() {
+	transformer.Register[Config](Type, func(cfg Config, lgr log.Logger, _ abstract.TransformationRuntimeOpts) (abstract.Transformer, error) {
+		return New(cfg, lgr)
+	})
+}
+
+var (
+	//go:embed README.md
+	readme []byte
+	_      model.Describable = (*Config)(nil)
+)
+
+type Config struct {
+	Tables filter.Tables `json:"tables" yaml:"tables"`
+	Query  string        `json:"query" yaml:"query,flow"`
+}
+
+func (c Config) Describe() model.Doc {
+	return model.Doc{
+		Usage: string(readme),
+		Example: `
+tables:
+	include_tables:
+	- '"public"."users"'
+query: |
+	select
+		id,
+		lower(email) as email,
+		concat(first_name, ' ', last_name) as full_name,
+		toDate(created_at) as created_date
+	from table
+	where not deleted
+`,
+	}
+}
+
+// program is the query compiled against a single source table schema
+type program struct {
+	columns []*sqlexpr.Expression
+	where   *sqlexpr.Expression
+	schema  *abstract.TableSchema
+	// keys maps source key columns to their names in the result
+	keys map[string]string
+}
+
+type SQLProjectionTransformer struct {
+	tables filter.Filter
+	query  *sqlexpr.Select
+	logger log.Logger
+
+	cacheMu sync.RWMutex
+	cache   map[string]*program
+}
+
+func (t *SQLProjectionTransformer) Type() abstract.TransformerType {
+	return Type
+}
+
+func (t *SQLProjectionTransformer) Apply(input []abstract.ChangeItem) abstract.TransformerResult {
+	transformed := make([]abstract.ChangeItem, 0, len(input))
+	errors := make([]abstract.TransformerError, 0)
+	for _, item := range input {
+		if !item.IsRowEvent() {
+			transformed = append(transformed, item)
+			continue
+		}
+		result, ok, err := t.transformItem(item)
+		if err != nil {
+			errors = append(errors, abstract.TransformerError{
+				Input: item,
+				Error: err,
+			})
+			continue
+		}
+		if ok {
+			transformed = append(transformed, result)
+		}
+	}
+	return abstract.TransformerResult{
+		Transformed: transformed,
+		Errors:      errors,
+	}
+}
+
+// transformItem returns false for items filtered out by the WHERE clause
+func (t *SQLProjectionTransformer) transformItem(item abstract.ChangeItem) (abstract.ChangeItem, bool, error) {
+	prog, err := t.program(item.TableSchema)
+	if err != nil {
+		return item, false, xerrors.Errorf("unable to compile query for table %s: %w", item.TableID().Fqtn(), err)
+	}
+	oldKeys, err := prog.remapOldKeys(item.OldKeys)
+	if err != nil {
+		return item, false, xerrors.Errorf("unable to remap old keys: %w", err)
+	}
+	item.OldKeys = oldKeys
+	// deletes are passed through by key, the WHERE clause cannot be evaluated on them
+	if item.Kind == abstract.DeleteKind && len(item.ColumnNames) == 0 {
+		item.SetTableSchema(prog.schema)
+		return item, true, nil
+	}
+
+	row := sqlexpr.NewRow(&item)
+	if prog.where != nil && item.Kind != abstract.DeleteKind {
+		matched, err := prog.where.EvalBool(row)
+		if err != nil {
+			return item, false, xerrors.Errorf("unable to evaluate WHERE clause: %w", err)
+		}
+		if !matched {
+			return item, false, nil
+		}
+	}
+	names := make([]string, len(prog.columns))
+	values := make([]interface{}, len(prog.columns))
+	for i, expr := range prog.columns {
+		value, err := expr.Eval(row)
+		if err != nil {
+			return item, false, xerrors.Errorf("unable to evaluate column %s: %w", prog.schema.Columns()[i].ColumnName, err)
+		}
+		names[i] = prog.schema.Columns()[i].ColumnName
+		values[i] = value
+	}
+	item.ColumnNames = names
+	item.ColumnValues = values
+	item.SetTableSchema(prog.schema)
+	return item, true, nil
+}
+
+func (p *program) remapOldKeys(oldKeys abstract.OldKeysType) (abstract.OldKeysType, error) {
+	if len(oldKeys.KeyNames) == 0 {
+		return oldKeys, nil
+	}
+	result := abstract.OldKeysType{
+		KeyNames:  make([]string, len(oldKeys.KeyNames)),
+		KeyTypes:  oldKeys.KeyTypes,
+		KeyValues: oldKeys.KeyValues,
+	}
+	for i, name := range oldKeys.KeyNames {
+		projected, ok := p.keys[name]
+		if !ok {
+			return abstract.OldKeysType{}, xerrors.Errorf("key column %q is not selected by the query as is", name)
+		}
+		result.KeyNames[i] = projected
+	}
+	return result, nil
+}
+
+func (t *SQLProjectionTransformer) program(tableSchema *abstract.TableSchema) (*program, error) {
+	hash, err := tableSchema.Hash()
+	if err != nil {
+		t.logger.Warnf("getting schema hash failed, thus cannot use cache for schema: %v", err)
+		return t.compile(tableSchema.Columns())
+	}
+
+	t.cacheMu.RLock()
+	prog, ok := t.cache[hash]
+	t.cacheMu.RUnlock()
+	if ok {
+		return prog, nil
+	}
+
+	prog, err = t.compile(tableSchema.Columns())
+	if err != nil {
+		return nil, err
+	}
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+	t.cache[hash] = prog
+	return prog, nil
+}
+
+func (t *SQLProjectionTransformer) compile(columns abstract.TableColumns) (*program, error) {
+	prog := &program{
+		columns: make([]*sqlexpr.Expression, 0, len(t.query.Items)),
+		where:   nil,
+		schema:  nil,
+		keys:    make(map[string]string),
+	}
+	resultColumns := make(abstract.TableColumns, 0, len(t.query.Items))
+	seen := make(map[string]bool)
+	addColumn := func(expr *sqlexpr.Expression, alias string) error {
+		var col abstract.ColSchema
+		if source, ok := expr.Column(); ok {
+			col = source
+			if alias != "" {
+				col.ColumnName = alias
+			}
+			if source.PrimaryKey {
+				prog.keys[source.ColumnName] = col.ColumnName
+			}
+		} else {
+			name := alias
+			if name == "" {
+				name = expr.String()
+			}
+			col = abstract.NewColSchema(name, expr.Type(), false)
+		}
+		if seen[col.ColumnName] {
+			return xerrors.Errorf("column %q is selected more than once, use an alias", col.ColumnName)
+		}
+		seen[col.ColumnName] = true
+		prog.columns = append(prog.columns, expr)
+		resultColumns = append(resultColumns, col)
+		return nil
+	}
+
+	for _, item := range t.query.Items {
+		if item.Star {
+			for _, col := range columns {
+				node, err := sqlexpr.ParseExpression(quoteIdent(col.ColumnName))
+				if err != nil {
+					return nil, xerrors.Errorf("unable to reference column %q: %w", col.ColumnName, err)
+				}
+				expr, err := sqlexpr.Bind(node, columns)
+				if err != nil {
+					return nil, xerrors.Errorf("unable to bind column %q: %w", col.ColumnName, err)
+				}
+				if err := addColumn(expr, ""); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		expr, err := sqlexpr.Bind(item.Expr, columns)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to bind select item: %w", err)
+		}
+		if err := addColumn(expr, item.Alias); err != nil {
+			return nil, err
+		}
+	}
+	if t.query.Where != nil {
+		where, err := sqlexpr.Bind(t.query.Where, columns)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to bind WHERE clause: %w", err)
+		}
+		prog.where = where
+	}
+	prog.schema = abstract.NewTableSchema(resultColumns)
+	return prog, nil
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (t *SQLProjectionTransformer) Suitable(table abstract.TableID, schema *abstract.TableSchema) bool {
+	if !filter.MatchAnyTableNameVariant(t.tables, table) {
+		return false
+	}
+	if _, err := t.program(schema); err != nil {
+		t.logger.Warn("query is not applicable to the table", log.String("table", table.Fqtn()), log.Error(err))
+		return false
+	}
+	return true
+}
+
+func (t *SQLProjectionTransformer) ResultSchema(original *abstract.TableSchema) (*abstract.TableSchema, error) {
+	prog, err := t.program(original)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to compile query: %w", err)
+	}
+	return prog.schema, nil
+}
+
+func (t *SQLProjectionTransformer) Description() string {
+	items := make([]string, len(t.query.Items))
+	for i, item := range t.query.Items {
+		switch {
+		case item.Star:
+			items[i] = "*"
+		case item.Alias != "":
+			items[i] = fmt.Sprintf("%s AS %s", item.Expr.String(), item.Alias)
+		default:
+			items[i] = item.Expr.String()
+		}
+	}
+	description := fmt.Sprintf("SQL projection: SELECT %s", strings.Join(items, ", "))
+	if t.query.Where != nil {
+		description += fmt.Sprintf(" WHERE %s", t.query.Where.String())
+	}
+	if len(description) > 200 {
+		description = description[:200] + "..."
+	}
+	return description
+}
+
+func New(cfg Config, lgr log.Logger) (*SQLProjectionTransformer, error) {
+	tables, err := filter.NewFilter(cfg.Tables.IncludeTables, cfg.Tables.ExcludeTables)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to init table filter: %w", err)
+	}
+	query, err := sqlexpr.ParseSelect(cfg.Query)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to parse query: %w", err)
+	}
+	return &SQLProjectionTransformer{
+		tables:  tables,
+		query:   query,
+		logger:  lgr,
+		cacheMu: sync.RWMutex{},
+		cache:   make(map[string]*program),
+	}, nil
+}
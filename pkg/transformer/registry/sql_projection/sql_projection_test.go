@@ -0,0 +1,123 @@
+package sqlprojection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/transformer/registry/filter"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+var testSchema = abstract.NewTableSchema([]abstract.ColSchema{
+	abstract.NewColSchema("id", schema.TypeInt64, true),
+	abstract.NewColSchema("email", schema.TypeString, false),
+	abstract.NewColSchema("first_name", schema.TypeString, false),
+	abstract.NewColSchema("last_name", schema.TypeString, false),
+	abstract.NewColSchema("created_at", schema.TypeTimestamp, false),
+	abstract.NewColSchema("deleted", schema.TypeBoolean, false),
+})
+
+func testItem(kind abstract.Kind, id int64, deleted bool) abstract.ChangeItem {
+	return abstract.ChangeItem{
+		Kind:         kind,
+		Schema:       "public",
+		Table:        "users",
+		ColumnNames:  []string{"id", "email", "first_name", "last_name", "created_at", "deleted"},
+		ColumnValues: []interface{}{id, "John@Example.com", "John", "Smith", time.Date(2024, 2, 29, 13, 45, 10, 0, time.UTC), deleted},
+		TableSchema:  testSchema,
+		OldKeys: abstract.OldKeysType{
+			KeyNames:  []string{"id"},
+			KeyTypes:  nil,
+			KeyValues: []interface{}{id},
+		},
+	}
+}
+
+func newTransformer(t *testing.T, query string) *SQLProjectionTransformer {
+	tr, err := New(Config{
+		Tables: filter.Tables{IncludeTables: []string{"public.users"}},
+		Query:  query,
+	}, logger.Log)
+	require.NoError(t, err)
+	return tr
+}
+
+func TestSQLProjection(t *testing.T) {
+	tr := newTransformer(t, `
+		SELECT
+			id AS user_id,
+			lower(email) AS email,
+			concat(first_name, ' ', last_name) AS full_name,
+			toDate(created_at) AS created_date,
+			CASE WHEN length(email) > 10 THEN 'long' ELSE 'short' END AS kind
+		FROM table
+		WHERE NOT deleted`)
+
+	require.True(t, tr.Suitable(abstract.TableID{Namespace: "public", Name: "users"}, testSchema))
+	require.False(t, tr.Suitable(abstract.TableID{Namespace: "public", Name: "orders"}, testSchema))
+
+	resultSchema, err := tr.ResultSchema(testSchema)
+	require.NoError(t, err)
+	require.Equal(t, []string{"user_id", "email", "full_name", "created_date", "kind"}, resultSchema.Columns().ColumnNames())
+	require.True(t, resultSchema.Columns()[0].PrimaryKey)
+	require.Equal(t, string(schema.TypeInt64), resultSchema.Columns()[0].DataType)
+	require.Equal(t, string(schema.TypeDate), resultSchema.Columns()[3].DataType)
+	require.Equal(t, string(schema.TypeString), resultSchema.Columns()[4].DataType)
+
+	result := tr.Apply([]abstract.ChangeItem{
+		testItem(abstract.InsertKind, 1, false),
+		testItem(abstract.UpdateKind, 2, true),
+		{Kind: abstract.DeleteKind, Schema: "public", Table: "users", TableSchema: testSchema, OldKeys: abstract.OldKeysType{KeyNames: []string{"id"}, KeyTypes: nil, KeyValues: []interface{}{int64(3)}}},
+		{Kind: abstract.DoneTableLoad, Schema: "public", Table: "users", TableSchema: testSchema},
+	})
+	require.Empty(t, result.Errors)
+	require.Len(t, result.Transformed, 3)
+
+	inserted := result.Transformed[0]
+	require.Equal(t, []string{"user_id", "email", "full_name", "created_date", "kind"}, inserted.ColumnNames)
+	require.Equal(t, []interface{}{int64(1), "john@example.com", "John Smith", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), "long"}, inserted.ColumnValues)
+	require.Equal(t, []string{"user_id"}, inserted.OldKeys.KeyNames)
+	require.Equal(t, resultSchema.Columns(), inserted.TableSchema.Columns())
+
+	deleted := result.Transformed[1]
+	require.Equal(t, abstract.DeleteKind, deleted.Kind)
+	require.Equal(t, []string{"user_id"}, deleted.OldKeys.KeyNames)
+	require.Equal(t, []interface{}{int64(3)}, deleted.OldKeys.KeyValues)
+
+	require.Equal(t, abstract.DoneTableLoad, result.Transformed[2].Kind)
+}
+
+func TestSQLProjectionStar(t *testing.T) {
+	tr := newTransformer(t, `SELECT *, upper(last_name) AS last_name_upper FROM users`)
+
+	result := tr.Apply([]abstract.ChangeItem{testItem(abstract.InsertKind, 1, false)})
+	require.Empty(t, result.Errors)
+	require.Len(t, result.Transformed, 1)
+	require.Equal(t, append(testSchema.Columns().ColumnNames(), "last_name_upper"), result.Transformed[0].ColumnNames)
+	require.Equal(t, "SMITH", result.Transformed[0].ColumnValues[6])
+}
+
+func TestSQLProjectionKeyNotSelected(t *testing.T) {
+	tr := newTransformer(t, `SELECT email FROM table`)
+
+	result := tr.Apply([]abstract.ChangeItem{testItem(abstract.UpdateKind, 1, false)})
+	require.Empty(t, result.Transformed)
+	require.Len(t, result.Errors, 1)
+}
+
+func TestSQLProjectionBadQuery(t *testing.T) {
+	_, err := New(Config{Tables: filter.Tables{}, Query: `SELECT FROM table`}, logger.Log)
+	require.Error(t, err)
+
+	tr := newTransformer(t, `SELECT id, unknown_column FROM table`)
+	require.False(t, tr.Suitable(abstract.TableID{Namespace: "public", Name: "users"}, testSchema))
+	_, err = tr.ResultSchema(testSchema)
+	require.Error(t, err)
+
+	tr = newTransformer(t, `SELECT id, email, lower(email) AS email FROM table`)
+	_, err = tr.ResultSchema(testSchema)
+	require.Error(t, err)
+}
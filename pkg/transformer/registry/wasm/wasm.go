@@ -0,0 +1,419 @@
+package wasm
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/transformer"
+	"github.com/transferia/transferia/pkg/transformer/registry/filter"
+	"github.com/transferia/transferia/pkg/util/jsonx"
+	"go.ytsaurus.tech/library/go/core/log"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+const (
+	Type = abstract.TransformerType("wasm")
+
+	exportMemory       = "memory"
+	exportAlloc        = "alloc"
+	exportDealloc      = "dealloc"
+	exportTransform    = "transform"
+	exportResultSchema = "result_schema"
+	exportInitialize   = "_initialize"
+
+	defaultCallTimeout = time.Minute
+)
+
+func init() {
+	transformer.Register[Config](Type, func(cfg Config, lgr log.Logger, _ abstract.TransformationRuntimeOpts) (abstract.Transformer, error) {
+		return New(cfg, lgr)
+	})
+}
+
+var (
+	//go:embed README.md
+	readme []byte
+	_      model.Describable = (*Config)(nil)
+)
+
+type Config struct {
+	Tables filter.Tables `json:"tables" yaml:"tables"`
+	// ModulePath is a path to the WASM module binary on the worker.
+	ModulePath string `json:"module_path" yaml:"module_path"`
+	// Module is a base64-encoded WASM module binary, alternative to ModulePath.
+	Module string `json:"module" yaml:"module"`
+	// CallTimeout limits a single call into the module.
+	CallTimeout time.Duration `json:"call_timeout" yaml:"call_timeout"`
+}
+
+func (c Config) Describe() model.Doc {
+	return model.Doc{
+		Usage: string(readme),
+		Example: `
+tables:
+	include_tables:
+	- '"public"."events"'
+module_path: /opt/transforms/enrich.wasm
+call_timeout: 10s
+`,
+	}
+}
+
+func (c Config) moduleBinary() ([]byte, error) {
+	switch {
+	case c.ModulePath != "" && c.Module != "":
+		return nil, xerrors.New("only one of module_path and module must be set")
+	case c.ModulePath != "":
+		binary, err := os.ReadFile(c.ModulePath)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to read module %q: %w", c.ModulePath, err)
+		}
+		return binary, nil
+	case c.Module != "":
+		binary, err := base64.StdEncoding.DecodeString(c.Module)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to decode base64 module: %w", err)
+		}
+		return binary, nil
+	default:
+		return nil, xerrors.New("one of module_path and module must be set")
+	}
+}
+
+type transformInput struct {
+	Items []abstract.ChangeItem `json:"items"`
+}
+
+type itemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+type transformOutput struct {
+	Items  []abstract.ChangeItem `json:"items"`
+	Errors []itemError           `json:"errors"`
+}
+
+type WasmTransformer struct {
+	tables      filter.Filter
+	modulePath  string
+	callTimeout time.Duration
+	logger      log.Logger
+
+	runtime      wazero.Runtime
+	compiled     wazero.CompiledModule
+	moduleConfig wazero.ModuleConfig
+	// module is closed by the runtime once a call exceeds its timeout, then it is instantiated again.
+	// Module instance is not safe for concurrent use.
+	module api.Module
+	mutex  sync.Mutex
+}
+
+func (t *WasmTransformer) Type() abstract.TransformerType {
+	return Type
+}
+
+func (t *WasmTransformer) Apply(input []abstract.ChangeItem) abstract.TransformerResult {
+	output, err := t.transform(input)
+	if err != nil {
+		return abstract.TransformerResult{
+			Transformed: nil,
+			Errors:      allWithError(input, err),
+		}
+	}
+
+	errors := make([]abstract.TransformerError, 0, len(output.Errors))
+	for _, itemErr := range output.Errors {
+		if itemErr.Index < 0 || itemErr.Index >= len(input) {
+			return abstract.TransformerResult{
+				Transformed: nil,
+				Errors:      allWithError(input, xerrors.Errorf("module returned an error for unknown item %d: %s", itemErr.Index, itemErr.Error)),
+			}
+		}
+		errors = append(errors, abstract.TransformerError{
+			Input: input[itemErr.Index],
+			Error: xerrors.New(itemErr.Error),
+		})
+	}
+
+	transformed := make([]abstract.ChangeItem, 0, len(output.Items))
+	schemas := inputSchemas(input)
+	for _, item := range output.Items {
+		if item.TableSchema == nil || len(item.TableSchema.Columns()) == 0 {
+			item.TableSchema = schemas[item.TableID()]
+		}
+		if err := restoreValues(&item); err != nil {
+			return abstract.TransformerResult{
+				Transformed: nil,
+				Errors:      allWithError(input, xerrors.Errorf("module returned malformed item: %w", err)),
+			}
+		}
+		transformed = append(transformed, item)
+	}
+	return abstract.TransformerResult{
+		Transformed: transformed,
+		Errors:      errors,
+	}
+}
+
+func (t *WasmTransformer) Suitable(table abstract.TableID, _ *abstract.TableSchema) bool {
+	return filter.MatchAnyTableNameVariant(t.tables, table)
+}
+
+func (t *WasmTransformer) ResultSchema(original *abstract.TableSchema) (*abstract.TableSchema, error) {
+	if _, ok := t.compiled.ExportedFunctions()[exportResultSchema]; !ok {
+		return original, nil
+	}
+	input, err := json.Marshal(original.Columns())
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal table schema: %w", err)
+	}
+	output, err := t.call(exportResultSchema, input)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to call %s: %w", exportResultSchema, err)
+	}
+	var columns abstract.TableColumns
+	if err := jsonx.Unmarshal(output, &columns); err != nil {
+		return nil, xerrors.Errorf("unable to unmarshal result schema: %w", err)
+	}
+	return abstract.NewTableSchema(columns), nil
+}
+
+func (t *WasmTransformer) Description() string {
+	if t.modulePath != "" {
+		return fmt.Sprintf("WASM transformer: %s", t.modulePath)
+	}
+	return "WASM transformer: inline module"
+}
+
+func (t *WasmTransformer) Close() error {
+	return t.runtime.Close(context.Background())
+}
+
+func (t *WasmTransformer) transform(input []abstract.ChangeItem) (*transformOutput, error) {
+	data, err := json.Marshal(transformInput{Items: input})
+	if err != nil {
+		return nil, xerrors.Errorf("unable to marshal items: %w", err)
+	}
+	rawOutput, err := t.call(exportTransform, data)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to call %s: %w", exportTransform, err)
+	}
+	var output transformOutput
+	if err := jsonx.Unmarshal(rawOutput, &output); err != nil {
+		return nil, xerrors.Errorf("unable to unmarshal module output: %w", err)
+	}
+	return &output, nil
+}
+
+// call passes the input into the module function with the (ptr, len) -> ptr<<32|len convention and returns a copy of the output
+func (t *WasmTransformer) call(function string, input []byte) ([]byte, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.callTimeout)
+	defer cancel()
+
+	if t.module.IsClosed() {
+		t.logger.Warn("module was closed by the previous call, instantiate it again, module state is reset")
+		if err := t.instantiate(ctx); err != nil {
+			return nil, xerrors.Errorf("unable to instantiate module again: %w", err)
+		}
+	}
+
+	res, err := t.module.ExportedFunction(exportAlloc).Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to allocate %d bytes: %w", len(input), err)
+	}
+	inputPtr := uint32(res[0])
+	defer t.dealloc(ctx, inputPtr, uint32(len(input)))
+	if !t.module.Memory().Write(inputPtr, input) {
+		return nil, xerrors.Errorf("input of %d bytes at %d is out of module memory", len(input), inputPtr)
+	}
+
+	res, err = t.module.ExportedFunction(function).Call(ctx, uint64(inputPtr), uint64(len(input)))
+	if err != nil {
+		return nil, xerrors.Errorf("module call failed: %w", err)
+	}
+	outputPtr, outputLen := uint32(res[0]>>32), uint32(res[0])
+	defer t.dealloc(ctx, outputPtr, outputLen)
+	output, ok := t.module.Memory().Read(outputPtr, outputLen)
+	if !ok {
+		return nil, xerrors.Errorf("output of %d bytes at %d is out of module memory", outputLen, outputPtr)
+	}
+	// output is a view of the module memory, it is reused by the next call
+	return append([]byte(nil), output...), nil
+}
+
+func (t *WasmTransformer) instantiate(ctx context.Context) error {
+	module, err := t.runtime.InstantiateModule(ctx, t.compiled, t.moduleConfig)
+	if err != nil {
+		return xerrors.Errorf("unable to instantiate module: %w", err)
+	}
+	if module.Memory() == nil {
+		_ = module.Close(ctx)
+		return xerrors.Errorf("module must export %q", exportMemory)
+	}
+	t.module = module
+	return nil
+}
+
+func (t *WasmTransformer) dealloc(ctx context.Context, ptr uint32, size uint32) {
+	dealloc := t.module.ExportedFunction(exportDealloc)
+	if dealloc == nil || size == 0 || t.module.IsClosed() {
+		return
+	}
+	if _, err := dealloc.Call(ctx, uint64(ptr), uint64(size)); err != nil {
+		t.logger.Warn("unable to deallocate module memory", log.Error(err))
+	}
+}
+
+func inputSchemas(input []abstract.ChangeItem) map[abstract.TableID]*abstract.TableSchema {
+	schemas := make(map[abstract.TableID]*abstract.TableSchema)
+	for _, item := range input {
+		schemas[item.TableID()] = item.TableSchema
+	}
+	return schemas
+}
+
+// restoreValues brings JSON-decoded values back to the types declared in the table schema
+func restoreValues(item *abstract.ChangeItem) (err error) {
+	if item.TableSchema == nil {
+		return nil
+	}
+	// abstract.Restore panics on values which do not match the column type
+	defer func() {
+		if r := recover(); r != nil {
+			err = xerrors.Errorf("values of %s do not match table schema: %v", item.TableID().String(), r)
+		}
+	}()
+	columns := item.TableSchema.FastColumns()
+	for i, name := range item.ColumnNames {
+		if col, ok := columns[abstract.ColumnName(name)]; ok && i < len(item.ColumnValues) {
+			if item.ColumnValues[i], err = restoreValue(col, item.ColumnValues[i]); err != nil {
+				return xerrors.Errorf("unable to restore column %q: %w", name, err)
+			}
+		}
+	}
+	for i, name := range item.OldKeys.KeyNames {
+		if col, ok := columns[abstract.ColumnName(name)]; ok && i < len(item.OldKeys.KeyValues) {
+			if item.OldKeys.KeyValues[i], err = restoreValue(col, item.OldKeys.KeyValues[i]); err != nil {
+				return xerrors.Errorf("unable to restore old key %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// restoreValue is abstract.Restore which also brings JSON numbers of floating point columns back to floats
+func restoreValue(col abstract.ColSchema, value interface{}) (interface{}, error) {
+	value = abstract.Restore(col, value)
+	number, ok := value.(json.Number)
+	if !ok {
+		return value, nil
+	}
+	switch strings.ToLower(col.DataType) {
+	case string(schema.TypeFloat64):
+		return strconv.ParseFloat(number.String(), 64)
+	case string(schema.TypeFloat32):
+		res, err := strconv.ParseFloat(number.String(), 32)
+		return float32(res), err
+	}
+	return value, nil
+}
+
+func allWithError(input []abstract.ChangeItem, err error) []abstract.TransformerError {
+	res := make([]abstract.TransformerError, len(input))
+	for i, row := range input {
+		res[i] = abstract.TransformerError{
+			Input: row,
+			Error: err,
+		}
+	}
+	return res
+}
+
+func New(cfg Config, lgr log.Logger) (*WasmTransformer, error) {
+	tables, err := filter.NewFilter(cfg.Tables.IncludeTables, cfg.Tables.ExcludeTables)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to init table filter: %w", err)
+	}
+	binary, err := cfg.moduleBinary()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to load module: %w", err)
+	}
+	callTimeout := cfg.CallTimeout
+	if callTimeout <= 0 {
+		callTimeout = defaultCallTimeout
+	}
+
+	ctx := context.Background()
+	// module is closed once a call exceeds its timeout, since running wasm code can not be interrupted otherwise
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, xerrors.Errorf("unable to instantiate WASI: %w", err)
+	}
+	compiled, err := runtime.CompileModule(ctx, binary)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, xerrors.Errorf("unable to compile module: %w", err)
+	}
+	for _, export := range []string{exportAlloc, exportTransform} {
+		if _, ok := compiled.ExportedFunctions()[export]; !ok {
+			_ = runtime.Close(ctx)
+			return nil, xerrors.Errorf("module must export function %q", export)
+		}
+	}
+
+	tr := &WasmTransformer{
+		tables:      tables,
+		modulePath:  cfg.ModulePath,
+		callTimeout: callTimeout,
+		logger:      lgr,
+		runtime:     runtime,
+		compiled:    compiled,
+		// anonymous, so the module may be instantiated again while the closed instance is not released yet
+		moduleConfig: wazero.NewModuleConfig().
+			WithName("").
+			WithStartFunctions(exportInitialize).
+			WithStdout(&logWriter{log: lgr.Info, stream: "stdout"}).
+			WithStderr(&logWriter{log: lgr.Warn, stream: "stderr"}).
+			WithSysWalltime().
+			WithSysNanotime(),
+		module: nil,
+		mutex:  sync.Mutex{},
+	}
+	if err := tr.instantiate(ctx); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, err
+	}
+	return tr, nil
+}
+
+// logWriter writes module stdout and stderr into the transfer log instead of the worker stdout
+type logWriter struct {
+	log    func(msg string, fields ...log.Field)
+	stream string
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		w.log("wasm module output", log.String("stream", w.stream), log.String("line", line))
+	}
+	return len(p), nil
+}
@@ -0,0 +1,265 @@
+package wasm
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/transformer/registry/filter"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// identityModule is a minimal module implementing the ABI, it returns its input as is:
+//
+//	(module
+//	  (memory (export "memory") 1)
+//	  (global $heap (mut i32) (i32.const 1024))
+//	  (func (export "alloc") (param $size i32) (result i32)
+//	    global.get $heap
+//	    (global.set $heap (i32.add (global.get $heap) (local.get $size))))
+//	  (func (export "transform") (param $ptr i32) (param $len i32) (result i64)
+//	    (i64.or
+//	      (i64.shl (i64.extend_i32_u (local.get $ptr)) (i64.const 32))
+//	      (i64.extend_i32_u (local.get $len)))))
+var identityModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // magic, version
+	0x01, 0x0c, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e, // types
+	0x03, 0x03, 0x02, 0x00, 0x01, // functions
+	0x05, 0x03, 0x01, 0x00, 0x01, // memory
+	0x06, 0x07, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b, // globals
+	0x07, 0x1e, 0x03, // exports
+	0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+	0x05, 'a', 'l', 'l', 'o', 'c', 0x00, 0x00,
+	0x09, 't', 'r', 'a', 'n', 's', 'f', 'o', 'r', 'm', 0x00, 0x01,
+	0x0a, 0x1a, 0x02, // code
+	0x0b, 0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b,
+	0x0c, 0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b,
+}
+
+const (
+	transformOutputOffset    = 1024
+	resultSchemaOutputOffset = 4096
+)
+
+// testModule assembles a module implementing the ABI with the given bodies of transform and result_schema,
+// alloc is the same bump allocator as in identityModule and data segments are placed at the given offsets
+func testModule(transform, resultSchema []byte, data map[int]string) []byte {
+	vec := func(items ...[]byte) []byte {
+		res := uleb(uint64(len(items)))
+		for _, item := range items {
+			res = append(res, item...)
+		}
+		return res
+	}
+	name := func(s string) []byte { return append(uleb(uint64(len(s))), s...) }
+	section := func(id byte, payload []byte) []byte {
+		return append(append([]byte{id}, uleb(uint64(len(payload)))...), payload...)
+	}
+	code := func(body []byte) []byte { return append(uleb(uint64(len(body))), body...) }
+	cat := func(parts ...[]byte) []byte {
+		var res []byte
+		for _, part := range parts {
+			res = append(res, part...)
+		}
+		return res
+	}
+
+	var segments [][]byte
+	for offset, content := range data {
+		segments = append(segments, cat([]byte{0x00, 0x41}, sleb(int64(offset)), []byte{0x0b}, name(content)))
+	}
+	return cat(
+		[]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		section(0x01, vec([]byte{0x60, 0x01, 0x7f, 0x01, 0x7f}, []byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e})),
+		section(0x03, vec([]byte{0x00}, []byte{0x01}, []byte{0x01})),
+		section(0x05, vec([]byte{0x00, 0x01})),
+		section(0x06, vec(cat([]byte{0x7f, 0x01, 0x41}, sleb(8192), []byte{0x0b}))),
+		section(0x07, vec(
+			cat(name("memory"), []byte{0x02, 0x00}),
+			cat(name("alloc"), []byte{0x00, 0x00}),
+			cat(name("transform"), []byte{0x00, 0x01}),
+			cat(name("result_schema"), []byte{0x00, 0x02}),
+		)),
+		section(0x0a, vec(
+			code([]byte{0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b}),
+			code(transform),
+			code(resultSchema),
+		)),
+		section(0x0b, vec(segments...)),
+	)
+}
+
+func uleb(v uint64) []byte {
+	var res []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(res, b)
+		}
+		res = append(res, b|0x80)
+	}
+}
+
+func sleb(v int64) []byte {
+	var res []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(res, b)
+		}
+		res = append(res, b|0x80)
+	}
+}
+
+// identityBody returns the input as is, the same as transform of identityModule
+var identityBody = []byte{0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b}
+
+// loopBody never returns
+var loopBody = []byte{0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x42, 0x00, 0x0b}
+
+// constBody returns output placed at offset by a data segment
+func constBody(offset int, output string) []byte {
+	body := append([]byte{0x00, 0x42}, sleb(int64(offset)<<32|int64(len(output)))...)
+	return append(body, 0x0b)
+}
+
+func newTestTransformer(t *testing.T, module []byte, callTimeout time.Duration) *WasmTransformer {
+	tr, err := New(Config{
+		Tables:      filter.Tables{},
+		ModulePath:  "",
+		Module:      base64.StdEncoding.EncodeToString(module),
+		CallTimeout: callTimeout,
+	}, logger.Log)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, tr.Close()) })
+	return tr
+}
+
+func testItem() abstract.ChangeItem {
+	return abstract.ChangeItem{
+		Kind:         abstract.InsertKind,
+		Schema:       "public",
+		Table:        "events",
+		ColumnNames:  []string{"id", "amount", "created_at"},
+		ColumnValues: []interface{}{int64(42), 1.5, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		TableSchema: abstract.NewTableSchema([]abstract.ColSchema{
+			abstract.NewColSchema("id", schema.TypeInt64, true),
+			abstract.NewColSchema("amount", schema.TypeFloat64, false),
+			abstract.NewColSchema("created_at", schema.TypeTimestamp, false),
+		}),
+	}
+}
+
+func TestWasmTransformer(t *testing.T) {
+	modulePath := filepath.Join(t.TempDir(), "identity.wasm")
+	require.NoError(t, os.WriteFile(modulePath, identityModule, 0o644))
+
+	tr, err := New(Config{
+		Tables:      filter.Tables{IncludeTables: []string{"public.events"}},
+		ModulePath:  modulePath,
+		CallTimeout: 0,
+	}, logger.Log)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, tr.Close()) }()
+
+	item := testItem()
+	require.True(t, tr.Suitable(item.TableID(), item.TableSchema))
+	require.False(t, tr.Suitable(abstract.TableID{Namespace: "public", Name: "other"}, item.TableSchema))
+
+	resultSchema, err := tr.ResultSchema(item.TableSchema)
+	require.NoError(t, err)
+	require.Equal(t, item.TableSchema, resultSchema)
+
+	for i := 0; i < 3; i++ {
+		result := tr.Apply([]abstract.ChangeItem{item, item})
+		require.Empty(t, result.Errors)
+		require.Len(t, result.Transformed, 2)
+		require.Equal(t, item.ColumnValues, result.Transformed[0].ColumnValues)
+		require.Equal(t, item.TableSchema.Columns(), result.Transformed[1].TableSchema.Columns())
+	}
+}
+
+func TestWasmTransformerInlineModule(t *testing.T) {
+	tr, err := New(Config{
+		Tables:      filter.Tables{},
+		ModulePath:  "",
+		Module:      base64.StdEncoding.EncodeToString(identityModule),
+		CallTimeout: time.Second,
+	}, logger.Log)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, tr.Close()) }()
+
+	result := tr.Apply([]abstract.ChangeItem{testItem()})
+	require.Empty(t, result.Errors)
+	require.Len(t, result.Transformed, 1)
+}
+
+func TestWasmTransformerBadConfig(t *testing.T) {
+	_, err := New(Config{Tables: filter.Tables{}, ModulePath: "", Module: "", CallTimeout: 0}, logger.Log)
+	require.Error(t, err)
+
+	_, err = New(Config{Tables: filter.Tables{}, ModulePath: "", Module: base64.StdEncoding.EncodeToString([]byte("not a module")), CallTimeout: 0}, logger.Log)
+	require.Error(t, err)
+}
+
+func TestWasmTransformerItemErrors(t *testing.T) {
+	output := `{"items":[],"errors":[{"index":1,"error":"amount is negative"}]}`
+	tr := newTestTransformer(t, testModule(constBody(transformOutputOffset, output), loopBody, map[int]string{transformOutputOffset: output}), time.Second)
+
+	first, second := testItem(), testItem()
+	second.ColumnValues[0] = int64(43)
+	result := tr.Apply([]abstract.ChangeItem{first, second})
+	require.Empty(t, result.Transformed)
+	require.Len(t, result.Errors, 1)
+	require.Equal(t, second, result.Errors[0].Input)
+	require.EqualError(t, result.Errors[0].Error, "amount is negative")
+
+	output = `{"items":[],"errors":[{"index":2,"error":"unknown"}]}`
+	tr = newTestTransformer(t, testModule(constBody(transformOutputOffset, output), loopBody, map[int]string{transformOutputOffset: output}), time.Second)
+	result = tr.Apply([]abstract.ChangeItem{first, second})
+	require.Empty(t, result.Transformed)
+	require.Len(t, result.Errors, 2)
+	require.ErrorContains(t, result.Errors[0].Error, "unknown item 2")
+}
+
+func TestWasmTransformerResultSchema(t *testing.T) {
+	output := `[{"name":"id","type":"int64","key":true},{"name":"amount","type":"float"}]`
+	tr := newTestTransformer(t, testModule(identityBody, constBody(resultSchemaOutputOffset, output), map[int]string{resultSchemaOutputOffset: output}), time.Second)
+
+	resultSchema, err := tr.ResultSchema(testItem().TableSchema)
+	require.NoError(t, err)
+	require.Len(t, resultSchema.Columns(), 2)
+	require.Equal(t, "id", resultSchema.Columns()[0].ColumnName)
+	require.True(t, resultSchema.Columns()[0].PrimaryKey)
+	require.Equal(t, string(schema.TypeFloat32), resultSchema.Columns()[1].DataType)
+
+	// values of items with the result schema are restored to its types
+	item := testItem()
+	item.ColumnNames = []string{"id", "amount"}
+	item.ColumnValues = []interface{}{int64(42), float32(1.5)}
+	item.TableSchema = resultSchema
+	result := tr.Apply([]abstract.ChangeItem{item})
+	require.Empty(t, result.Errors)
+	require.Equal(t, []interface{}{int64(42), float32(1.5)}, result.Transformed[0].ColumnValues)
+}
+
+func TestWasmTransformerCallTimeout(t *testing.T) {
+	tr := newTestTransformer(t, testModule(identityBody, loopBody, nil), 100*time.Millisecond)
+
+	_, err := tr.ResultSchema(testItem().TableSchema)
+	require.Error(t, err)
+
+	// module closed by the timeout is instantiated again
+	for i := 0; i < 2; i++ {
+		result := tr.Apply([]abstract.ChangeItem{testItem()})
+		require.Empty(t, result.Errors)
+		require.Len(t, result.Transformed, 1)
+	}
+}
@@ -0,0 +1,103 @@
+package sqlexpr
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+)
+
+// The constructors below build expressions without parsing SQL, they are used by other expression syntaxes.
+
+// Literal makes a constant of nil, bool, int64, float64, string or time.Time value.
+func Literal(value interface{}) (Node, error) {
+	switch v := value.(type) {
+	case nil:
+		return &literalNode{value: nil, text: "NULL"}, nil
+	case bool:
+		return &literalNode{value: v, text: strings.ToUpper(strconv.FormatBool(v))}, nil
+	case int64:
+		return &literalNode{value: v, text: strconv.FormatInt(v, 10)}, nil
+	case float64:
+		return &literalNode{value: v, text: strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case string:
+		return &literalNode{value: v, text: quoteString(v)}, nil
+	case time.Time:
+		return &literalNode{value: v, text: quoteString(v.Format(time.RFC3339Nano))}, nil
+	default:
+		return nil, xerrors.Errorf("unsupported literal of type %T", value)
+	}
+}
+
+// ColumnRef makes a reference to the column with the given name.
+func ColumnRef(name string) Node {
+	return &columnNode{name: name}
+}
+
+// Call makes a call of the function with the given name.
+func Call(function string, args ...Node) Node {
+	return &callNode{name: function, args: args}
+}
+
+// Compare makes a comparison with one of =, !=, <, <=, > and >= operators.
+func Compare(op string, left, right Node) (Node, error) {
+	switch op {
+	case "=", "!=", "<", "<=", ">", ">=":
+		return &binaryNode{op: op, left: left, right: right}, nil
+	default:
+		return nil, xerrors.Errorf("unknown comparison operator %s", op)
+	}
+}
+
+// In makes an [NOT] IN check of the expression against the list.
+func In(expr Node, list []Node, not bool) Node {
+	return &inNode{expr: expr, list: list, not: not}
+}
+
+// IsNull makes an IS [NOT] NULL check.
+func IsNull(expr Node, not bool) Node {
+	return &isNullNode{expr: expr, not: not}
+}
+
+// Not makes a logical negation.
+func Not(expr Node) Node {
+	return &unaryNode{op: "NOT", expr: expr}
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// operatorFunctions are functions equivalent to binary operators, they are useful for syntaxes without operators
+var operatorFunctions = map[string]string{
+	"plus":     "+",
+	"minus":    "-",
+	"multiply": "*",
+	"divide":   "/",
+	"modulo":   "%",
+	"and":      "AND",
+	"or":       "OR",
+}
+
+// operatorCall rewrites a call of an operator function into the operator
+func operatorCall(n *callNode) (Node, bool, error) {
+	if strings.EqualFold(n.name, "not") {
+		if len(n.args) != 1 {
+			return nil, true, xerrors.Errorf("wrong number of arguments for %s: %d", n.name, len(n.args))
+		}
+		return &unaryNode{op: "NOT", expr: n.args[0]}, true, nil
+	}
+	op, ok := operatorFunctions[strings.ToLower(n.name)]
+	if !ok {
+		return nil, false, nil
+	}
+	if len(n.args) < 2 {
+		return nil, true, xerrors.Errorf("wrong number of arguments for %s: %d", n.name, len(n.args))
+	}
+	result := n.args[0]
+	for _, arg := range n.args[1:] {
+		result = &binaryNode{op: op, left: result, right: arg}
+	}
+	return result, true, nil
+}
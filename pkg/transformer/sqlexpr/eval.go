@@ -0,0 +1,818 @@
+package sqlexpr
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// Row gives access to the values of a single change item by column name.
+type Row struct {
+	indices map[string]int
+	values  []interface{}
+}
+
+// NewRow wraps the current values of the change item.
+func NewRow(item *abstract.ChangeItem) *Row {
+	return &Row{
+		indices: item.ColumnNameIndices(),
+		values:  item.ColumnValues,
+	}
+}
+
+// NewRowFromValues wraps arbitrary named values, e.g. old keys of a change item.
+func NewRowFromValues(names []string, values []interface{}) *Row {
+	indices := make(map[string]int, len(names))
+	for i, name := range names {
+		indices[name] = i
+	}
+	return &Row{indices: indices, values: values}
+}
+
+func (r *Row) get(name string) (interface{}, bool) {
+	idx, ok := r.indices[name]
+	if !ok || idx >= len(r.values) {
+		return nil, false
+	}
+	return r.values[idx], true
+}
+
+type evaluator func(row *Row) (interface{}, error)
+
+type bound struct {
+	eval evaluator
+	typ  evalType
+	// column is set for plain column references, their values are passed through as is
+	column *abstract.ColSchema
+}
+
+// Expression is an expression bound to a table schema.
+type Expression struct {
+	node   Node
+	bound  bound
+	ytType schema.Type
+}
+
+// Bind resolves the columns and the types of the expression against the table columns.
+func Bind(node Node, columns abstract.TableColumns) (*Expression, error) {
+	b := &binder{columns: make(map[string]abstract.ColSchema, len(columns))}
+	for _, col := range columns {
+		b.columns[col.ColumnName] = col
+	}
+	result, err := b.bind(node)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to bind expression %s: %w", node.String(), err)
+	}
+	expr := &Expression{node: node, bound: result, ytType: result.typ.ytType()}
+	if result.column != nil {
+		expr.ytType = schema.Type(result.column.DataType)
+	}
+	if c, ok := node.(*castNode); ok {
+		expr.ytType = castTypes[strings.ToLower(c.typeName)]
+	}
+	return expr, nil
+}
+
+// Type is the schema type of the expression values.
+func (e *Expression) Type() schema.Type {
+	return e.ytType
+}
+
+// Column returns the source column if the expression is a plain column reference.
+func (e *Expression) Column() (abstract.ColSchema, bool) {
+	if e.bound.column == nil {
+		return abstract.ColSchema{}, false
+	}
+	return *e.bound.column, true
+}
+
+func (e *Expression) String() string {
+	return e.node.String()
+}
+
+// Eval evaluates the expression, the result has the Go type matching Type.
+func (e *Expression) Eval(row *Row) (interface{}, error) {
+	if e.bound.column != nil {
+		value, _ := row.get(e.bound.column.ColumnName)
+		return value, nil
+	}
+	value, err := e.bound.eval(row)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to evaluate %s: %w", e.node.String(), err)
+	}
+	result, err := outputValue(value, e.ytType)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to convert result of %s to %s: %w", e.node.String(), e.ytType, err)
+	}
+	return result, nil
+}
+
+// EvalBool evaluates the expression as a condition, NULL is treated as false.
+func (e *Expression) EvalBool(row *Row) (bool, error) {
+	value, err := e.bound.eval(row)
+	if err != nil {
+		return false, xerrors.Errorf("unable to evaluate %s: %w", e.node.String(), err)
+	}
+	if value == nil {
+		return false, nil
+	}
+	return toBool(value)
+}
+
+type binder struct {
+	columns map[string]abstract.ColSchema
+}
+
+func constant(value interface{}, typ evalType) bound {
+	return bound{
+		eval:   func(*Row) (interface{}, error) { return value, nil },
+		typ:    typ,
+		column: nil,
+	}
+}
+
+func (b *binder) bind(node Node) (bound, error) {
+	switch n := node.(type) {
+	case *literalNode:
+		switch n.value.(type) {
+		case nil:
+			return constant(nil, typeNull), nil
+		case bool:
+			return constant(n.value, typeBool), nil
+		case int64:
+			return constant(n.value, typeInt), nil
+		case float64:
+			return constant(n.value, typeFloat), nil
+		case time.Time:
+			return constant(n.value, typeTimestamp), nil
+		default:
+			return constant(n.value, typeString), nil
+		}
+	case *columnNode:
+		col, ok := b.columns[n.name]
+		if !ok {
+			return bound{}, xerrors.Errorf("unknown column %q", n.name)
+		}
+		typ := evalTypeOf(col.DataType)
+		name := col.ColumnName
+		return bound{
+			eval: func(row *Row) (interface{}, error) {
+				value, _ := row.get(name)
+				result, err := canonicalValue(value, typ)
+				if err != nil {
+					return nil, xerrors.Errorf("column %q: %w", name, err)
+				}
+				return result, nil
+			},
+			typ:    typ,
+			column: &col,
+		}, nil
+	case *unaryNode:
+		return b.bindUnary(n)
+	case *binaryNode:
+		return b.bindBinary(n)
+	case *isNullNode:
+		expr, err := b.bind(n.expr)
+		if err != nil {
+			return bound{}, err
+		}
+		not := n.not
+		return bound{
+			eval: func(row *Row) (interface{}, error) {
+				value, err := expr.eval(row)
+				if err != nil {
+					return nil, err
+				}
+				return (value == nil) != not, nil
+			},
+			typ:    typeBool,
+			column: nil,
+		}, nil
+	case *inNode:
+		return b.bindIn(n)
+	case *likeNode:
+		return b.bindLike(n)
+	case *betweenNode:
+		low := &binaryNode{op: ">=", left: n.expr, right: n.low}
+		high := &binaryNode{op: "<=", left: n.expr, right: n.high}
+		var result Node = &binaryNode{op: "AND", left: low, right: high}
+		if n.not {
+			result = &unaryNode{op: "NOT", expr: result}
+		}
+		return b.bind(result)
+	case *caseNode:
+		return b.bindCase(n)
+	case *castNode:
+		return b.bindCast(n)
+	case *callNode:
+		return b.bindCall(n)
+	default:
+		return bound{}, xerrors.Errorf("unsupported expression %s", node.String())
+	}
+}
+
+func (b *binder) bindUnary(n *unaryNode) (bound, error) {
+	expr, err := b.bind(n.expr)
+	if err != nil {
+		return bound{}, err
+	}
+	switch n.op {
+	case "NOT":
+		return bound{
+			eval: func(row *Row) (interface{}, error) {
+				value, err := expr.eval(row)
+				if err != nil || value == nil {
+					return nil, err
+				}
+				v, err := toBool(value)
+				if err != nil {
+					return nil, err
+				}
+				return !v, nil
+			},
+			typ:    typeBool,
+			column: nil,
+		}, nil
+	case "-":
+		if !expr.typ.isNumeric() && expr.typ != typeNull && expr.typ != typeAny {
+			return bound{}, xerrors.Errorf("unary minus is not applicable to %s", expr.typ)
+		}
+		return bound{
+			eval: func(row *Row) (interface{}, error) {
+				value, err := expr.eval(row)
+				if err != nil || value == nil {
+					return nil, err
+				}
+				if i, ok := value.(int64); ok {
+					return -i, nil
+				}
+				f, err := toFloat(value)
+				if err != nil {
+					return nil, err
+				}
+				return -f, nil
+			},
+			typ:    expr.typ,
+			column: nil,
+		}, nil
+	default:
+		return bound{}, xerrors.Errorf("unknown unary operator %s", n.op)
+	}
+}
+
+func (b *binder) bindBinary(n *binaryNode) (bound, error) {
+	left, err := b.bind(n.left)
+	if err != nil {
+		return bound{}, err
+	}
+	right, err := b.bind(n.right)
+	if err != nil {
+		return bound{}, err
+	}
+	switch n.op {
+	case "AND", "OR":
+		isAnd := n.op == "AND"
+		return bound{
+			eval: func(row *Row) (interface{}, error) {
+				return evalLogic(row, left, right, isAnd)
+			},
+			typ:    typeBool,
+			column: nil,
+		}, nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		op := n.op
+		return bound{
+			eval: func(row *Row) (interface{}, error) {
+				l, r, err := evalPair(row, left, right)
+				if err != nil || l == nil || r == nil {
+					return nil, err
+				}
+				cmp, err := compareValues(l, left.typ, r, right.typ)
+				if err != nil {
+					return nil, err
+				}
+				return compareResult(op, cmp), nil
+			},
+			typ:    typeBool,
+			column: nil,
+		}, nil
+	case "||":
+		return bound{
+			eval: func(row *Row) (interface{}, error) {
+				l, r, err := evalPair(row, left, right)
+				if err != nil || l == nil || r == nil {
+					return nil, err
+				}
+				return toString(l, left.typ) + toString(r, right.typ), nil
+			},
+			typ:    typeString,
+			column: nil,
+		}, nil
+	case "+", "-", "*", "/", "%":
+		return bindArithmetic(n.op, left, right)
+	default:
+		return bound{}, xerrors.Errorf("unknown operator %s", n.op)
+	}
+}
+
+func evalPair(row *Row, left, right bound) (interface{}, interface{}, error) {
+	l, err := left.eval(row)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := right.eval(row)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, r, nil
+}
+
+// evalLogic implements three-valued AND/OR
+func evalLogic(row *Row, left, right bound, isAnd bool) (interface{}, error) {
+	l, err := left.eval(row)
+	if err != nil {
+		return nil, err
+	}
+	if l != nil {
+		lb, err := toBool(l)
+		if err != nil {
+			return nil, err
+		}
+		if lb != isAnd {
+			return lb, nil
+		}
+	}
+	r, err := right.eval(row)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, nil
+	}
+	rb, err := toBool(r)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil && rb == isAnd {
+		return nil, nil
+	}
+	return rb, nil
+}
+
+func compareResult(op string, cmp int) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func compareValues(l interface{}, lt evalType, r interface{}, rt evalType) (int, error) {
+	switch {
+	case lt == typeInt && rt == typeInt:
+		li, lok := l.(int64)
+		ri, rok := r.(int64)
+		if lok && rok {
+			return compareOrdered(li, ri), nil
+		}
+		fallthrough
+	case lt.isNumeric() || rt.isNumeric():
+		lf, err := toFloat(l)
+		if err != nil {
+			return 0, err
+		}
+		rf, err := toFloat(r)
+		if err != nil {
+			return 0, err
+		}
+		return compareOrdered(lf, rf), nil
+	case lt.isTime() || rt.isTime():
+		ltime, err := toTime(l)
+		if err != nil {
+			return 0, err
+		}
+		rtime, err := toTime(r)
+		if err != nil {
+			return 0, err
+		}
+		if lt == typeDate || rt == typeDate {
+			ltime, rtime = truncateToDay(ltime), truncateToDay(rtime)
+		}
+		return ltime.Compare(rtime), nil
+	case lt == typeBool || rt == typeBool:
+		lb, err := toBool(l)
+		if err != nil {
+			return 0, err
+		}
+		rb, err := toBool(r)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case lb == rb:
+			return 0, nil
+		case rb:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	default:
+		return strings.Compare(toString(l, lt), toString(r, rt)), nil
+	}
+}
+
+func compareOrdered[T int64 | float64](l, r T) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func bindArithmetic(op string, left, right bound) (bound, error) {
+	for _, operand := range []bound{left, right} {
+		if !operand.typ.isNumeric() && operand.typ != typeNull && operand.typ != typeAny && operand.typ != typeString {
+			return bound{}, xerrors.Errorf("operator %s is not applicable to %s", op, operand.typ)
+		}
+	}
+	resultType := typeFloat
+	if left.typ == typeInt && right.typ == typeInt && op != "/" {
+		resultType = typeInt
+	}
+	return bound{
+		eval: func(row *Row) (interface{}, error) {
+			l, r, err := evalPair(row, left, right)
+			if err != nil || l == nil || r == nil {
+				return nil, err
+			}
+			if resultType == typeInt {
+				li, err := toInt(l)
+				if err != nil {
+					return nil, err
+				}
+				ri, err := toInt(r)
+				if err != nil {
+					return nil, err
+				}
+				switch op {
+				case "+":
+					return li + ri, nil
+				case "-":
+					return li - ri, nil
+				case "*":
+					return li * ri, nil
+				default:
+					if ri == 0 {
+						return nil, nil
+					}
+					return li % ri, nil
+				}
+			}
+			lf, err := toFloat(l)
+			if err != nil {
+				return nil, err
+			}
+			rf, err := toFloat(r)
+			if err != nil {
+				return nil, err
+			}
+			switch op {
+			case "+":
+				return lf + rf, nil
+			case "-":
+				return lf - rf, nil
+			case "*":
+				return lf * rf, nil
+			case "/":
+				if rf == 0 {
+					return nil, nil
+				}
+				return lf / rf, nil
+			default:
+				if rf == 0 {
+					return nil, nil
+				}
+				return math.Mod(lf, rf), nil
+			}
+		},
+		typ:    resultType,
+		column: nil,
+	}, nil
+}
+
+func (b *binder) bindIn(n *inNode) (bound, error) {
+	expr, err := b.bind(n.expr)
+	if err != nil {
+		return bound{}, err
+	}
+	list := make([]bound, len(n.list))
+	for i, item := range n.list {
+		if list[i], err = b.bind(item); err != nil {
+			return bound{}, err
+		}
+	}
+	not := n.not
+	return bound{
+		eval: func(row *Row) (interface{}, error) {
+			value, err := expr.eval(row)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			sawNull := false
+			for _, item := range list {
+				itemValue, err := item.eval(row)
+				if err != nil {
+					return nil, err
+				}
+				if itemValue == nil {
+					sawNull = true
+					continue
+				}
+				cmp, err := compareValues(value, expr.typ, itemValue, item.typ)
+				if err != nil {
+					return nil, err
+				}
+				if cmp == 0 {
+					return !not, nil
+				}
+			}
+			if sawNull {
+				return nil, nil
+			}
+			return not, nil
+		},
+		typ:    typeBool,
+		column: nil,
+	}, nil
+}
+
+// likeToRegexp converts a LIKE pattern into an anchored regular expression
+func likeToRegexp(pattern string, caseInsensitive bool) (*regexp.Regexp, error) {
+	var out strings.Builder
+	if caseInsensitive {
+		out.WriteString("(?is)")
+	} else {
+		out.WriteString("(?s)")
+	}
+	out.WriteString("^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			out.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			out.WriteString(".*")
+		case r == '_':
+			out.WriteString(".")
+		default:
+			out.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	out.WriteString("$")
+	return regexp.Compile(out.String())
+}
+
+func (b *binder) bindLike(n *likeNode) (bound, error) {
+	expr, err := b.bind(n.expr)
+	if err != nil {
+		return bound{}, err
+	}
+	pattern, err := b.bind(n.pattern)
+	if err != nil {
+		return bound{}, err
+	}
+	var compiled *regexp.Regexp
+	if literal, ok := n.pattern.(*literalNode); ok {
+		if s, ok := literal.value.(string); ok {
+			if compiled, err = likeToRegexp(s, n.caseInsensitive); err != nil {
+				return bound{}, xerrors.Errorf("invalid LIKE pattern %q: %w", s, err)
+			}
+		}
+	}
+	not := n.not
+	caseInsensitive := n.caseInsensitive
+	return bound{
+		eval: func(row *Row) (interface{}, error) {
+			value, p, err := evalPair(row, expr, pattern)
+			if err != nil || value == nil || p == nil {
+				return nil, err
+			}
+			re := compiled
+			if re == nil {
+				if re, err = likeToRegexp(toString(p, pattern.typ), caseInsensitive); err != nil {
+					return nil, err
+				}
+			}
+			return re.MatchString(toString(value, expr.typ)) != not, nil
+		},
+		typ:    typeBool,
+		column: nil,
+	}, nil
+}
+
+func (b *binder) bindCase(n *caseNode) (bound, error) {
+	var operand *bound
+	if n.operand != nil {
+		op, err := b.bind(n.operand)
+		if err != nil {
+			return bound{}, err
+		}
+		operand = &op
+	}
+	conditions := make([]bound, len(n.whens))
+	results := make([]bound, len(n.whens)+1)
+	for i, when := range n.whens {
+		var err error
+		if conditions[i], err = b.bind(when.condition); err != nil {
+			return bound{}, err
+		}
+		if results[i], err = b.bind(when.result); err != nil {
+			return bound{}, err
+		}
+	}
+	results[len(n.whens)] = constant(nil, typeNull)
+	if n.elseExpr != nil {
+		var err error
+		if results[len(n.whens)], err = b.bind(n.elseExpr); err != nil {
+			return bound{}, err
+		}
+	}
+	resultType, err := commonType(results)
+	if err != nil {
+		return bound{}, xerrors.Errorf("CASE branches have incompatible types: %w", err)
+	}
+	return bound{
+		eval: func(row *Row) (interface{}, error) {
+			var operandValue interface{}
+			if operand != nil {
+				var err error
+				if operandValue, err = operand.eval(row); err != nil {
+					return nil, err
+				}
+			}
+			chosen := results[len(results)-1]
+			for i, condition := range conditions {
+				value, err := condition.eval(row)
+				if err != nil {
+					return nil, err
+				}
+				if value == nil {
+					continue
+				}
+				var matched bool
+				if operand != nil {
+					if operandValue == nil {
+						continue
+					}
+					cmp, err := compareValues(operandValue, operand.typ, value, condition.typ)
+					if err != nil {
+						return nil, err
+					}
+					matched = cmp == 0
+				} else if matched, err = toBool(value); err != nil {
+					return nil, err
+				}
+				if matched {
+					chosen = results[i]
+					break
+				}
+			}
+			value, err := chosen.eval(row)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			return canonicalValue(value, resultType)
+		},
+		typ:    resultType,
+		column: nil,
+	}, nil
+}
+
+// commonType finds the type all the values can be converted into
+func commonType(values []bound) (evalType, error) {
+	result := typeNull
+	for _, value := range values {
+		switch {
+		case value.typ == typeNull || value.typ == result:
+		case result == typeNull:
+			result = value.typ
+		case result.isNumeric() && value.typ.isNumeric():
+			result = typeFloat
+		case result.isTime() && value.typ.isTime():
+			result = typeTimestamp
+		default:
+			return typeNull, xerrors.Errorf("%s and %s", result, value.typ)
+		}
+	}
+	return result, nil
+}
+
+func (b *binder) bindCast(n *castNode) (bound, error) {
+	expr, err := b.bind(n.expr)
+	if err != nil {
+		return bound{}, err
+	}
+	ytType, ok := castTypes[strings.ToLower(n.typeName)]
+	if !ok {
+		return bound{}, xerrors.Errorf("unknown type %s", n.typeName)
+	}
+	typ := evalTypeOf(string(ytType))
+	return bound{
+		eval: func(row *Row) (interface{}, error) {
+			value, err := expr.eval(row)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			if typ == typeString {
+				return toString(value, expr.typ), nil
+			}
+			if ytType == schema.TypeInterval {
+				if s, ok := value.(string); ok {
+					d, err := time.ParseDuration(s)
+					if err != nil {
+						return nil, xerrors.Errorf("unable to parse %q as interval: %w", s, err)
+					}
+					return int64(d), nil
+				}
+			}
+			return canonicalValue(value, typ)
+		},
+		typ:    typ,
+		column: nil,
+	}, nil
+}
+
+func (b *binder) bindCall(n *callNode) (bound, error) {
+	if operator, ok, err := operatorCall(n); ok {
+		if err != nil {
+			return bound{}, err
+		}
+		return b.bind(operator)
+	}
+	fn, ok := lookupFunction(n.name)
+	if !ok {
+		return bound{}, xerrors.Errorf("unknown function %s", n.name)
+	}
+	if len(n.args) < fn.minArgs || (fn.maxArgs >= 0 && len(n.args) > fn.maxArgs) {
+		return bound{}, xerrors.Errorf("wrong number of arguments for %s: %d", n.name, len(n.args))
+	}
+	args := make([]bound, len(n.args))
+	types := make([]evalType, len(n.args))
+	for i, arg := range n.args {
+		var err error
+		if args[i], err = b.bind(arg); err != nil {
+			return bound{}, err
+		}
+		types[i] = args[i].typ
+	}
+	resultType, err := fn.returns(types)
+	if err != nil {
+		return bound{}, xerrors.Errorf("invalid arguments of %s: %w", n.name, err)
+	}
+	name := n.name
+	return bound{
+		eval: func(row *Row) (interface{}, error) {
+			values := make([]interface{}, len(args))
+			for i, arg := range args {
+				value, err := arg.eval(row)
+				if err != nil {
+					return nil, err
+				}
+				if value == nil && !fn.handlesNull {
+					return nil, nil
+				}
+				values[i] = value
+			}
+			result, err := fn.call(values, types)
+			if err != nil {
+				return nil, xerrors.Errorf("%s: %w", name, err)
+			}
+			if result == nil {
+				return nil, nil
+			}
+			return canonicalValue(result, resultType)
+		},
+		typ:    resultType,
+		column: nil,
+	}, nil
+}
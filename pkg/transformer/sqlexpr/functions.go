@@ -0,0 +1,815 @@
+package sqlexpr
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/util/jsonx"
+)
+
+type function struct {
+	minArgs int
+	maxArgs int // negative for variadic functions
+	returns func(args []evalType) (evalType, error)
+	call    func(args []interface{}, types []evalType) (interface{}, error)
+	// handlesNull is set for functions which are called with NULL arguments, otherwise any NULL argument gives NULL
+	handlesNull bool
+}
+
+var functions = map[string]*function{}
+
+// registerFunction registers a function under all the given names, lookup is case-insensitive
+func registerFunction(fn *function, names ...string) {
+	for _, name := range names {
+		functions[strings.ToLower(name)] = fn
+	}
+}
+
+func lookupFunction(name string) (*function, bool) {
+	fn, ok := functions[strings.ToLower(name)]
+	return fn, ok
+}
+
+// FunctionNames lists names of all the supported functions.
+func FunctionNames() []string {
+	names := make([]string, 0, len(functions)+len(operatorFunctions)+1)
+	for name := range functions {
+		names = append(names, name)
+	}
+	for name := range operatorFunctions {
+		names = append(names, name)
+	}
+	names = append(names, "not")
+	sort.Strings(names)
+	return names
+}
+
+func returns(t evalType) func([]evalType) (evalType, error) {
+	return func([]evalType) (evalType, error) {
+		return t, nil
+	}
+}
+
+func returnsCommon(args []evalType) (evalType, error) {
+	values := make([]bound, len(args))
+	for i, arg := range args {
+		values[i] = bound{eval: nil, typ: arg, column: nil}
+	}
+	return commonType(values)
+}
+
+func returnsNumeric(args []evalType) (evalType, error) {
+	if args[0] == typeInt {
+		return typeInt, nil
+	}
+	return typeFloat, nil
+}
+
+func stringFunction(fn func(s string) string) *function {
+	return &function{
+		minArgs: 1,
+		maxArgs: 1,
+		returns: returns(typeString),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			return fn(toString(args[0], types[0])), nil
+		},
+		handlesNull: false,
+	}
+}
+
+func floatFunction(fn func(f float64) float64) *function {
+	return &function{
+		minArgs: 1,
+		maxArgs: 1,
+		returns: returns(typeFloat),
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			f, err := toFloat(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return fn(f), nil
+		},
+		handlesNull: false,
+	}
+}
+
+func timeFunction[T any](result evalType, fn func(t time.Time) T) *function {
+	return &function{
+		minArgs: 1,
+		maxArgs: 1,
+		returns: returns(result),
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			t, err := toTime(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return fn(t.UTC()), nil
+		},
+		handlesNull: false,
+	}
+}
+
+func init() {
+	registerStringFunctions()
+	registerConditionalFunctions()
+	registerMathFunctions()
+	registerDateFunctions()
+	registerJSONFunctions()
+}
+
+func registerStringFunctions() {
+	registerFunction(stringFunction(strings.ToLower), "lower", "lowerUTF8")
+	registerFunction(stringFunction(strings.ToUpper), "upper", "upperUTF8")
+	registerFunction(stringFunction(strings.TrimSpace), "trim", "trimBoth")
+	registerFunction(stringFunction(func(s string) string { return strings.TrimLeft(s, " \t\n\r") }), "ltrim", "trimLeft")
+	registerFunction(stringFunction(func(s string) string { return strings.TrimRight(s, " \t\n\r") }), "rtrim", "trimRight")
+	registerFunction(stringFunction(func(s string) string {
+		runes := []rune(s)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	}), "reverse")
+	registerFunction(stringFunction(func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}), "md5")
+	registerFunction(stringFunction(func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}), "sha256")
+	registerFunction(stringFunction(func(s string) string { return s }), "toString", "to_string")
+	registerFunction(&function{
+		minArgs: 1,
+		maxArgs: 1,
+		returns: returns(typeInt),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			return int64(utf8.RuneCountInString(toString(args[0], types[0]))), nil
+		},
+		handlesNull: false,
+	}, "length", "char_length", "lengthUTF8")
+	registerFunction(&function{
+		minArgs: 1,
+		maxArgs: -1,
+		returns: returns(typeString),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			var out strings.Builder
+			for i, arg := range args {
+				out.WriteString(toString(arg, types[i]))
+			}
+			return out.String(), nil
+		},
+		handlesNull: false,
+	}, "concat")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 3,
+		returns: returns(typeString),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			runes := []rune(toString(args[0], types[0]))
+			start, err := toInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+			// positions are 1-based, negative positions are counted from the end
+			if start < 0 {
+				start = int64(len(runes)) + start + 1
+			}
+			if start < 1 {
+				start = 1
+			}
+			if start > int64(len(runes)) {
+				return "", nil
+			}
+			end := int64(len(runes))
+			if len(args) == 3 {
+				length, err := toInt(args[2])
+				if err != nil {
+					return nil, err
+				}
+				end = min(end, start-1+max(length, 0))
+			}
+			return string(runes[start-1 : end]), nil
+		},
+		handlesNull: false,
+	}, "substring", "substr", "substringUTF8")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: returns(typeString),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			runes := []rune(toString(args[0], types[0]))
+			n, err := toInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return string(runes[:min(max(n, 0), int64(len(runes)))]), nil
+		},
+		handlesNull: false,
+	}, "left")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: returns(typeString),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			runes := []rune(toString(args[0], types[0]))
+			n, err := toInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return string(runes[int64(len(runes))-min(max(n, 0), int64(len(runes))):]), nil
+		},
+		handlesNull: false,
+	}, "right")
+	registerFunction(&function{
+		minArgs: 3,
+		maxArgs: 3,
+		returns: returns(typeString),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			return strings.ReplaceAll(toString(args[0], types[0]), toString(args[1], types[1]), toString(args[2], types[2])), nil
+		},
+		handlesNull: false,
+	}, "replace", "replaceAll")
+	registerFunction(&function{
+		minArgs: 3,
+		maxArgs: 3,
+		returns: returns(typeString),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			re, err := cachedRegexp(toString(args[1], types[1]))
+			if err != nil {
+				return nil, err
+			}
+			return re.ReplaceAllString(toString(args[0], types[0]), toString(args[2], types[2])), nil
+		},
+		handlesNull: false,
+	}, "regexp_replace", "replaceRegexpAll")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: returns(typeBool),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			re, err := cachedRegexp(toString(args[1], types[1]))
+			if err != nil {
+				return nil, err
+			}
+			return re.MatchString(toString(args[0], types[0])), nil
+		},
+		handlesNull: false,
+	}, "regexp_like", "match")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: returns(typeBool),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			return strings.HasPrefix(toString(args[0], types[0]), toString(args[1], types[1])), nil
+		},
+		handlesNull: false,
+	}, "startsWith", "starts_with")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: returns(typeBool),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			return strings.HasSuffix(toString(args[0], types[0]), toString(args[1], types[1])), nil
+		},
+		handlesNull: false,
+	}, "endsWith", "ends_with")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: returns(typeInt),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			s := toString(args[0], types[0])
+			idx := strings.Index(s, toString(args[1], types[1]))
+			if idx < 0 {
+				return int64(0), nil
+			}
+			return int64(utf8.RuneCountInString(s[:idx]) + 1), nil
+		},
+		handlesNull: false,
+	}, "position", "strpos")
+	registerFunction(&function{
+		minArgs: 3,
+		maxArgs: 3,
+		returns: returns(typeString),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			parts := strings.Split(toString(args[0], types[0]), toString(args[1], types[1]))
+			n, err := toInt(args[2])
+			if err != nil {
+				return nil, err
+			}
+			if n < 1 || n > int64(len(parts)) {
+				return "", nil
+			}
+			return parts[n-1], nil
+		},
+		handlesNull: false,
+	}, "split_part", "splitPart")
+}
+
+var (
+	regexpCache      = map[string]*regexp.Regexp{}
+	regexpCacheMutex sync.Mutex
+)
+
+func cachedRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpCacheMutex.Lock()
+	defer regexpCacheMutex.Unlock()
+	if re, ok := regexpCache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid regular expression %q: %w", pattern, err)
+	}
+	regexpCache[pattern] = re
+	return re, nil
+}
+
+func registerConditionalFunctions() {
+	registerFunction(&function{
+		minArgs: 1,
+		maxArgs: -1,
+		returns: returnsCommon,
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			for _, arg := range args {
+				if arg != nil {
+					return arg, nil
+				}
+			}
+			return nil, nil
+		},
+		handlesNull: true,
+	}, "coalesce", "ifNull")
+	registerFunction(&function{
+		minArgs: 3,
+		maxArgs: 3,
+		returns: func(args []evalType) (evalType, error) {
+			return returnsCommon(args[1:])
+		},
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			if args[0] == nil {
+				return args[2], nil
+			}
+			condition, err := toBool(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if condition {
+				return args[1], nil
+			}
+			return args[2], nil
+		},
+		handlesNull: true,
+	}, "if")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: func(args []evalType) (evalType, error) {
+			return args[0], nil
+		},
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			if args[0] == nil || args[1] == nil {
+				return args[0], nil
+			}
+			cmp, err := compareValues(args[0], types[0], args[1], types[1])
+			if err != nil {
+				return nil, err
+			}
+			if cmp == 0 {
+				return nil, nil
+			}
+			return args[0], nil
+		},
+		handlesNull: true,
+	}, "nullif")
+}
+
+func registerMathFunctions() {
+	registerFunction(&function{
+		minArgs: 1,
+		maxArgs: 1,
+		returns: returnsNumeric,
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			if i, ok := args[0].(int64); ok {
+				if i < 0 {
+					return -i, nil
+				}
+				return i, nil
+			}
+			f, err := toFloat(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return math.Abs(f), nil
+		},
+		handlesNull: false,
+	}, "abs")
+	registerFunction(&function{
+		minArgs: 1,
+		maxArgs: 2,
+		returns: returnsNumeric,
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			if i, ok := args[0].(int64); ok {
+				return i, nil
+			}
+			f, err := toFloat(args[0])
+			if err != nil {
+				return nil, err
+			}
+			var precision int64
+			if len(args) == 2 {
+				if precision, err = toInt(args[1]); err != nil {
+					return nil, err
+				}
+			}
+			scale := math.Pow(10, float64(precision))
+			return math.Round(f*scale) / scale, nil
+		},
+		handlesNull: false,
+	}, "round")
+	registerFunction(floatFunction(math.Floor), "floor")
+	registerFunction(floatFunction(math.Ceil), "ceil", "ceiling")
+	registerFunction(floatFunction(math.Sqrt), "sqrt")
+	registerFunction(floatFunction(math.Exp), "exp")
+	registerFunction(floatFunction(math.Log), "ln", "log")
+	registerFunction(floatFunction(math.Log10), "log10")
+	registerFunction(&function{
+		minArgs: 1,
+		maxArgs: 1,
+		returns: returns(typeInt),
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			f, err := toFloat(args[0])
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case f > 0:
+				return int64(1), nil
+			case f < 0:
+				return int64(-1), nil
+			default:
+				return int64(0), nil
+			}
+		},
+		handlesNull: false,
+	}, "sign")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: returns(typeFloat),
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			x, err := toFloat(args[0])
+			if err != nil {
+				return nil, err
+			}
+			y, err := toFloat(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return math.Pow(x, y), nil
+		},
+		handlesNull: false,
+	}, "pow", "power")
+	for _, greatest := range []bool{true, false} {
+		greatest := greatest
+		fn := &function{
+			minArgs: 1,
+			maxArgs: -1,
+			returns: returnsCommon,
+			call: func(args []interface{}, types []evalType) (interface{}, error) {
+				resultIdx := 0
+				for i := 1; i < len(args); i++ {
+					cmp, err := compareValues(args[i], types[i], args[resultIdx], types[resultIdx])
+					if err != nil {
+						return nil, err
+					}
+					if (cmp > 0) == greatest && cmp != 0 {
+						resultIdx = i
+					}
+				}
+				return args[resultIdx], nil
+			},
+			handlesNull: false,
+		}
+		if greatest {
+			registerFunction(fn, "greatest")
+		} else {
+			registerFunction(fn, "least")
+		}
+	}
+}
+
+var dateTruncUnits = map[string]func(t time.Time) time.Time{
+	"second": func(t time.Time) time.Time { return t.Truncate(time.Second) },
+	"minute": func(t time.Time) time.Time { return t.Truncate(time.Minute) },
+	"hour":   func(t time.Time) time.Time { return t.Truncate(time.Hour) },
+	"day":    truncateToDay,
+	"week": func(t time.Time) time.Time {
+		day := truncateToDay(t)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	},
+	"month":   func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC) },
+	"quarter": func(t time.Time) time.Time { return time.Date(t.Year(), (t.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC) },
+	"year":    func(t time.Time) time.Time { return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC) },
+}
+
+var dateUnitDurations = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+func dateUnit(unit interface{}) string {
+	return strings.TrimSuffix(strings.ToLower(toString(unit, typeString)), "s")
+}
+
+func registerDateFunctions() {
+	registerFunction(&function{
+		minArgs: 0,
+		maxArgs: 0,
+		returns: returns(typeTimestamp),
+		call: func([]interface{}, []evalType) (interface{}, error) {
+			return time.Now().UTC(), nil
+		},
+		handlesNull: false,
+	}, "now", "current_timestamp")
+	registerFunction(timeFunction(typeDate, truncateToDay), "toDate", "to_date", "date")
+	registerFunction(timeFunction(typeTimestamp, func(t time.Time) time.Time { return t }), "toDateTime", "to_datetime", "to_timestamp", "parseDateTimeBestEffort")
+	registerFunction(timeFunction(typeTimestamp, dateTruncUnits["minute"]), "toStartOfMinute")
+	registerFunction(timeFunction(typeTimestamp, dateTruncUnits["hour"]), "toStartOfHour")
+	registerFunction(timeFunction(typeTimestamp, truncateToDay), "toStartOfDay")
+	registerFunction(timeFunction(typeDate, dateTruncUnits["week"]), "toMonday", "toStartOfWeek")
+	registerFunction(timeFunction(typeDate, dateTruncUnits["month"]), "toStartOfMonth")
+	registerFunction(timeFunction(typeDate, dateTruncUnits["quarter"]), "toStartOfQuarter")
+	registerFunction(timeFunction(typeDate, dateTruncUnits["year"]), "toStartOfYear")
+	registerFunction(timeFunction(typeInt, func(t time.Time) int64 { return int64(t.Year()) }), "toYear", "year")
+	registerFunction(timeFunction(typeInt, func(t time.Time) int64 { return int64(t.Month()) }), "toMonth", "month")
+	registerFunction(timeFunction(typeInt, func(t time.Time) int64 { return int64(t.Day()) }), "toDayOfMonth", "day")
+	registerFunction(timeFunction(typeInt, func(t time.Time) int64 { return int64((int(t.Weekday())+6)%7 + 1) }), "toDayOfWeek", "day_of_week")
+	registerFunction(timeFunction(typeInt, func(t time.Time) int64 { return int64(t.YearDay()) }), "toDayOfYear", "day_of_year")
+	registerFunction(timeFunction(typeInt, func(t time.Time) int64 { return int64(t.Hour()) }), "toHour", "hour")
+	registerFunction(timeFunction(typeInt, func(t time.Time) int64 { return int64(t.Minute()) }), "toMinute", "minute")
+	registerFunction(timeFunction(typeInt, func(t time.Time) int64 { return int64(t.Second()) }), "toSecond", "second")
+	registerFunction(timeFunction(typeInt, func(t time.Time) int64 { return t.Unix() }), "toUnixTimestamp", "to_unix_timestamp")
+	registerFunction(&function{
+		minArgs: 1,
+		maxArgs: 1,
+		returns: returns(typeTimestamp),
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			seconds, err := toFloat(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return toTime(seconds)
+		},
+		handlesNull: false,
+	}, "fromUnixTimestamp", "from_unix_timestamp")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: func(args []evalType) (evalType, error) {
+			return typeTimestamp, nil
+		},
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			truncate, ok := dateTruncUnits[dateUnit(args[0])]
+			if !ok {
+				return nil, xerrors.Errorf("unknown unit %v", args[0])
+			}
+			t, err := toTime(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return truncate(t.UTC()), nil
+		},
+		handlesNull: false,
+	}, "date_trunc", "dateTrunc")
+	registerFunction(&function{
+		minArgs: 3,
+		maxArgs: 3,
+		returns: func(args []evalType) (evalType, error) {
+			if args[2] == typeDate {
+				return typeDate, nil
+			}
+			return typeTimestamp, nil
+		},
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			n, err := toInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+			t, err := toTime(args[2])
+			if err != nil {
+				return nil, err
+			}
+			switch unit := dateUnit(args[0]); unit {
+			case "month":
+				return t.AddDate(0, int(n), 0), nil
+			case "quarter":
+				return t.AddDate(0, 3*int(n), 0), nil
+			case "year":
+				return t.AddDate(int(n), 0, 0), nil
+			default:
+				duration, ok := dateUnitDurations[unit]
+				if !ok {
+					return nil, xerrors.Errorf("unknown unit %v", args[0])
+				}
+				return t.Add(time.Duration(n) * duration), nil
+			}
+		},
+		handlesNull: false,
+	}, "date_add", "dateAdd")
+	registerFunction(&function{
+		minArgs: 3,
+		maxArgs: 3,
+		returns: returns(typeInt),
+		call: func(args []interface{}, _ []evalType) (interface{}, error) {
+			from, err := toTime(args[1])
+			if err != nil {
+				return nil, err
+			}
+			to, err := toTime(args[2])
+			if err != nil {
+				return nil, err
+			}
+			switch unit := dateUnit(args[0]); unit {
+			case "month":
+				return int64((to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())), nil
+			case "year":
+				return int64(to.Year() - from.Year()), nil
+			default:
+				duration, ok := dateUnitDurations[unit]
+				if !ok {
+					return nil, xerrors.Errorf("unknown unit %v", args[0])
+				}
+				return int64(to.Sub(from) / duration), nil
+			}
+		},
+		handlesNull: false,
+	}, "date_diff", "dateDiff")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: 2,
+		returns: returns(typeString),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			t, err := toTime(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return formatDateTime(t.UTC(), toString(args[1], types[1])), nil
+		},
+		handlesNull: false,
+	}, "formatDateTime", "format_datetime")
+}
+
+// formatDateTime formats time with strftime-like specifiers as ClickHouse does
+func formatDateTime(t time.Time, format string) string {
+	specifiers := map[rune]string{
+		'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2", 'H': "15", 'I': "03", 'M': "04", 'S': "05",
+		'p': "PM", 'b': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday", 'F': "2006-01-02", 'T': "15:04:05",
+		'D': "01/02/06", 'R': "15:04", 'z': "-0700", 'Z': "MST",
+	}
+	var out strings.Builder
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' || i+1 == len(runes) {
+			out.WriteRune(runes[i])
+			continue
+		}
+		i++
+		switch runes[i] {
+		case '%':
+			out.WriteRune('%')
+		case 'j':
+			out.WriteString(leftPad(t.YearDay(), 3))
+		case 'u':
+			out.WriteString(leftPad((int(t.Weekday())+6)%7+1, 1))
+		case 'f':
+			out.WriteString(leftPad(t.Nanosecond()/1000, 6))
+		default:
+			if layout, ok := specifiers[runes[i]]; ok {
+				out.WriteString(t.Format(layout))
+			} else {
+				out.WriteRune('%')
+				out.WriteRune(runes[i])
+			}
+		}
+	}
+	return out.String()
+}
+
+func leftPad(n int, width int) string {
+	s := []byte(strings.Repeat("0", width))
+	for i := width - 1; i >= 0 && n > 0; i-- {
+		s[i] = byte('0' + n%10)
+		n /= 10
+	}
+	return string(s)
+}
+
+// jsonPath walks a JSON document by keys and 1-based indexes (negative indexes are counted from the end)
+func jsonPath(args []interface{}, types []evalType) (interface{}, bool, error) {
+	var doc interface{}
+	switch v := args[0].(type) {
+	case map[string]interface{}, []interface{}:
+		doc = v
+	default:
+		if err := jsonx.Unmarshal([]byte(toString(v, types[0])), &doc); err != nil {
+			return nil, false, nil
+		}
+	}
+	for i, key := range args[1:] {
+		if key == nil {
+			return nil, false, nil
+		}
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[toString(key, types[i+1])]
+			if !ok {
+				return nil, false, nil
+			}
+			doc = value
+		case []interface{}:
+			idx, err := toInt(key)
+			if err != nil {
+				return nil, false, err
+			}
+			if idx < 0 {
+				idx = int64(len(node)) + idx + 1
+			}
+			if idx < 1 || idx > int64(len(node)) {
+				return nil, false, nil
+			}
+			doc = node[idx-1]
+		default:
+			return nil, false, nil
+		}
+	}
+	return doc, true, nil
+}
+
+func jsonFunction(result evalType, convert func(value interface{}) (interface{}, error)) *function {
+	return &function{
+		minArgs: 1,
+		maxArgs: -1,
+		returns: returns(result),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			value, found, err := jsonPath(args, types)
+			if err != nil || !found || value == nil {
+				return nil, err
+			}
+			return convert(value)
+		},
+		handlesNull: false,
+	}
+}
+
+func registerJSONFunctions() {
+	registerFunction(jsonFunction(typeString, func(value interface{}) (interface{}, error) {
+		if _, ok := value.(string); ok {
+			return value, nil
+		}
+		return toString(value, typeAny), nil
+	}), "JSONExtractString", "json_extract_string")
+	registerFunction(jsonFunction(typeInt, func(value interface{}) (interface{}, error) {
+		return toInt(normalizeNumber(value))
+	}), "JSONExtractInt", "json_extract_int")
+	registerFunction(jsonFunction(typeFloat, func(value interface{}) (interface{}, error) {
+		return toFloat(normalizeNumber(value))
+	}), "JSONExtractFloat", "json_extract_float")
+	registerFunction(jsonFunction(typeBool, func(value interface{}) (interface{}, error) {
+		return toBool(normalizeNumber(value))
+	}), "JSONExtractBool", "json_extract_bool")
+	registerFunction(jsonFunction(typeString, func(value interface{}) (interface{}, error) {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return string(raw), nil
+	}), "JSONExtractRaw", "json_extract_raw")
+	registerFunction(jsonFunction(typeAny, func(value interface{}) (interface{}, error) {
+		return value, nil
+	}), "JSONExtract", "json_extract")
+	registerFunction(&function{
+		minArgs: 2,
+		maxArgs: -1,
+		returns: returns(typeBool),
+		call: func(args []interface{}, types []evalType) (interface{}, error) {
+			_, found, err := jsonPath(args, types)
+			return found, err
+		},
+		handlesNull: false,
+	}, "JSONHas", "json_has")
+}
@@ -0,0 +1,142 @@
+package sqlexpr
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// is reports whether the token is the given keyword or symbol. Keywords are case-insensitive.
+func (t token) is(value string) bool {
+	switch t.kind {
+	case tokenIdent:
+		return strings.EqualFold(t.value, value)
+	case tokenSymbol:
+		return t.value == value
+	default:
+		return false
+	}
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return "'" + t.value + "'"
+	default:
+		return t.value
+	}
+}
+
+var symbols = []string{"<=", ">=", "!=", "<>", "||", "::", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", ".", ";"}
+
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '\'':
+			value, next, err := readQuoted(runes, i, '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: i})
+			i = next
+		case r == '"' || r == '`':
+			value, next, err := readQuoted(runes, i, r)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenQuotedIdent, value: value, pos: i})
+			i = next
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, value: string(runes[start:i]), pos: start})
+		default:
+			matched := false
+			for _, symbol := range symbols {
+				if strings.HasPrefix(string(runes[i:min(i+2, len(runes))]), symbol) {
+					tokens = append(tokens, token{kind: tokenSymbol, value: symbol, pos: i})
+					i += len(symbol)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, xerrors.Errorf("unexpected symbol %q at position %d", r, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, value: "", pos: len(runes)}), nil
+}
+
+// readQuoted reads a quoted literal, the quote is escaped by doubling it or by a backslash
+func readQuoted(runes []rune, start int, quote rune) (string, int, error) {
+	var out strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\' && i+1 < len(runes):
+			i++
+			switch runes[i] {
+			case 'n':
+				out.WriteRune('\n')
+			case 't':
+				out.WriteRune('\t')
+			default:
+				out.WriteRune(runes[i])
+			}
+		case runes[i] == quote && i+1 < len(runes) && runes[i+1] == quote:
+			out.WriteRune(quote)
+			i++
+		case runes[i] == quote:
+			return out.String(), i + 1, nil
+		default:
+			out.WriteRune(runes[i])
+		}
+	}
+	return "", 0, xerrors.Errorf("unterminated quoted literal at position %d", start)
+}
@@ -0,0 +1,635 @@
+package sqlexpr
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/transferia/transferia/library/go/core/xerrors"
+)
+
+// Node is a parsed, not yet bound expression.
+type Node interface {
+	String() string
+}
+
+type (
+	literalNode struct {
+		value interface{}
+		text  string
+	}
+	columnNode struct {
+		name string
+	}
+	unaryNode struct {
+		op   string
+		expr Node
+	}
+	binaryNode struct {
+		op          string
+		left, right Node
+	}
+	isNullNode struct {
+		expr Node
+		not  bool
+	}
+	inNode struct {
+		expr Node
+		list []Node
+		not  bool
+	}
+	likeNode struct {
+		expr            Node
+		pattern         Node
+		not             bool
+		caseInsensitive bool
+	}
+	betweenNode struct {
+		expr, low, high Node
+		not             bool
+	}
+	whenClause struct {
+		condition, result Node
+	}
+	caseNode struct {
+		operand  Node
+		whens    []whenClause
+		elseExpr Node
+	}
+	castNode struct {
+		expr     Node
+		typeName string
+	}
+	callNode struct {
+		name string
+		args []Node
+	}
+)
+
+func (n *literalNode) String() string { return n.text }
+func (n *columnNode) String() string  { return n.name }
+func (n *unaryNode) String() string   { return n.op + " " + n.expr.String() }
+func (n *binaryNode) String() string {
+	return "(" + n.left.String() + " " + n.op + " " + n.right.String() + ")"
+}
+func (n *isNullNode) String() string {
+	if n.not {
+		return n.expr.String() + " IS NOT NULL"
+	}
+	return n.expr.String() + " IS NULL"
+}
+func (n *inNode) String() string {
+	op := " IN "
+	if n.not {
+		op = " NOT IN "
+	}
+	return n.expr.String() + op + "(" + joinNodes(n.list) + ")"
+}
+func (n *likeNode) String() string {
+	op := " LIKE "
+	if n.caseInsensitive {
+		op = " ILIKE "
+	}
+	if n.not {
+		op = " NOT" + op
+	}
+	return n.expr.String() + op + n.pattern.String()
+}
+func (n *betweenNode) String() string {
+	op := " BETWEEN "
+	if n.not {
+		op = " NOT BETWEEN "
+	}
+	return n.expr.String() + op + n.low.String() + " AND " + n.high.String()
+}
+func (n *caseNode) String() string {
+	var out strings.Builder
+	out.WriteString("CASE")
+	if n.operand != nil {
+		out.WriteString(" " + n.operand.String())
+	}
+	for _, when := range n.whens {
+		out.WriteString(" WHEN " + when.condition.String() + " THEN " + when.result.String())
+	}
+	if n.elseExpr != nil {
+		out.WriteString(" ELSE " + n.elseExpr.String())
+	}
+	out.WriteString(" END")
+	return out.String()
+}
+func (n *castNode) String() string { return "CAST(" + n.expr.String() + " AS " + n.typeName + ")" }
+func (n *callNode) String() string { return n.name + "(" + joinNodes(n.args) + ")" }
+
+func joinNodes(nodes []Node) string {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = node.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SelectItem is a single projection of a SELECT. Star items select all source columns.
+type SelectItem struct {
+	Star  bool
+	Expr  Node
+	Alias string
+}
+
+// Select is a parsed single-table `SELECT <items> FROM <table> [WHERE <condition>]` query.
+type Select struct {
+	Items []SelectItem
+	Table string
+	Where Node
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// ParseSelect parses a single-table SELECT query.
+func ParseSelect(query string) (*Select, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to tokenize query: %w", err)
+	}
+	p := &parser{tokens: tokens, pos: 0}
+	result, err := p.parseSelect()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to parse query: %w", err)
+	}
+	return result, nil
+}
+
+// ParseExpression parses a standalone scalar expression.
+func ParseExpression(expression string) (Node, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to tokenize expression: %w", err)
+	}
+	p := &parser{tokens: tokens, pos: 0}
+	node, err := p.parseExpr()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to parse expression: %w", err)
+	}
+	if !p.peek().is(";") && p.peek().kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(values ...string) bool {
+	for i, value := range values {
+		if p.pos+i >= len(p.tokens) || !p.tokens[p.pos+i].is(value) {
+			return false
+		}
+	}
+	p.pos += len(values)
+	return true
+}
+
+func (p *parser) expect(value string) error {
+	if !p.accept(value) {
+		return xerrors.Errorf("expected %s, got %s at position %d", value, p.peek().String(), p.peek().pos)
+	}
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	return xerrors.Errorf("unexpected %s at position %d", p.peek().String(), p.peek().pos)
+}
+
+var reservedWords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AS": true, "AND": true, "OR": true, "NOT": true,
+	"IS": true, "NULL": true, "IN": true, "LIKE": true, "ILIKE": true, "BETWEEN": true, "CASE": true,
+	"WHEN": true, "THEN": true, "ELSE": true, "END": true, "TRUE": true, "FALSE": true, "CAST": true,
+}
+
+func (p *parser) parseIdent() (string, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenQuotedIdent:
+		p.next()
+		return t.value, nil
+	case t.kind == tokenIdent && !reservedWords[strings.ToUpper(t.value)]:
+		p.next()
+		return t.value, nil
+	default:
+		return "", xerrors.Errorf("expected identifier, got %s at position %d", t.String(), t.pos)
+	}
+}
+
+func (p *parser) parseSelect() (*Select, error) {
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	result := new(Select)
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, item)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	table, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(".") {
+		part, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		table += "." + part
+	}
+	result.Table = table
+	if p.accept("WHERE") {
+		if result.Where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	p.accept(";")
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+	return result, nil
+}
+
+func (p *parser) parseSelectItem() (SelectItem, error) {
+	if p.accept("*") {
+		return SelectItem{Star: true, Expr: nil, Alias: ""}, nil
+	}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return SelectItem{}, err
+	}
+	item := SelectItem{Star: false, Expr: expr, Alias: ""}
+	if p.accept("AS") {
+		if item.Alias, err = p.parseIdent(); err != nil {
+			return SelectItem{}, err
+		}
+	} else if t := p.peek(); t.kind == tokenQuotedIdent || (t.kind == tokenIdent && !reservedWords[strings.ToUpper(t.value)]) {
+		item.Alias, _ = p.parseIdent()
+	}
+	return item, nil
+}
+
+func (p *parser) parseExpr() (Node, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if p.accept("NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "NOT", expr: expr}, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *parser) parsePredicate() (Node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "!=", "<>", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if op == "<>" {
+				op = "!="
+			}
+			return &binaryNode{op: op, left: left, right: right}, nil
+		}
+	}
+	if p.accept("IS") {
+		not := p.accept("NOT")
+		if err := p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNullNode{expr: left, not: not}, nil
+	}
+	not := p.accept("NOT")
+	switch {
+	case p.accept("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &inNode{expr: left, list: list, not: not}, nil
+	case p.peek().is("LIKE") || p.peek().is("ILIKE"):
+		caseInsensitive := p.next().is("ILIKE")
+		pattern, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &likeNode{expr: left, pattern: pattern, not: not, caseInsensitive: caseInsensitive}, nil
+	case p.accept("BETWEEN"):
+		low, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &betweenNode{expr: left, low: low, high: high, not: not}, nil
+	case not:
+		return nil, p.unexpected()
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (Node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op string
+		switch {
+		case p.accept("+"):
+			op = "+"
+		case p.accept("-"):
+			op = "-"
+		case p.accept("||"):
+			op = "||"
+		default:
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op string
+		switch {
+		case p.accept("*"):
+			op = "*"
+		case p.accept("/"):
+			op = "/"
+		case p.accept("%"):
+			op = "%"
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	switch {
+	case p.accept("-"):
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "-", expr: expr}, nil
+	case p.accept("+"):
+		return p.parseUnary()
+	}
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("::") {
+		typeName, err := p.parseTypeName()
+		if err != nil {
+			return nil, err
+		}
+		expr = &castNode{expr: expr, typeName: typeName}
+	}
+	return expr, nil
+}
+
+func (p *parser) parseList() ([]Node, error) {
+	var list []Node
+	if p.accept(")") {
+		return list, nil
+	}
+	for {
+		item, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+		if p.accept(")") {
+			return list, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenNumber:
+		p.next()
+		if i, err := strconv.ParseInt(t.value, 10, 64); err == nil {
+			return &literalNode{value: i, text: t.value}, nil
+		}
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid number %s at position %d", t.value, t.pos)
+		}
+		return &literalNode{value: f, text: t.value}, nil
+	case t.kind == tokenString:
+		p.next()
+		return &literalNode{value: t.value, text: "'" + strings.ReplaceAll(t.value, "'", "''") + "'"}, nil
+	case t.is("TRUE"), t.is("FALSE"):
+		p.next()
+		return &literalNode{value: t.is("TRUE"), text: strings.ToUpper(t.value)}, nil
+	case t.is("NULL"):
+		p.next()
+		return &literalNode{value: nil, text: "NULL"}, nil
+	case t.is("("):
+		p.next()
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case t.is("CASE"):
+		p.next()
+		return p.parseCase()
+	case t.is("CAST"):
+		p.next()
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AS"); err != nil {
+			return nil, err
+		}
+		typeName, err := p.parseTypeName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &castNode{expr: expr, typeName: typeName}, nil
+	}
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind == tokenIdent && p.accept("(") {
+		args, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &callNode{name: name, args: args}, nil
+	}
+	// qualified columns refer to the only table of the query, so the qualifier is dropped
+	for p.accept(".") {
+		if name, err = p.parseIdent(); err != nil {
+			return nil, err
+		}
+	}
+	return &columnNode{name: name}, nil
+}
+
+func (p *parser) parseCase() (Node, error) {
+	result := &caseNode{operand: nil, whens: nil, elseExpr: nil}
+	if !p.peek().is("WHEN") {
+		operand, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		result.operand = operand
+	}
+	for p.accept("WHEN") {
+		condition, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("THEN"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		result.whens = append(result.whens, whenClause{condition: condition, result: value})
+	}
+	if len(result.whens) == 0 {
+		return nil, xerrors.Errorf("CASE without WHEN at position %d", p.peek().pos)
+	}
+	if p.accept("ELSE") {
+		elseExpr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		result.elseExpr = elseExpr
+	}
+	if err := p.expect("END"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseTypeName parses type names like `Int64`, `Nullable(String)` or `DateTime64(3)`
+func (p *parser) parseTypeName() (string, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return "", xerrors.Errorf("expected type name, got %s at position %d", t.String(), t.pos)
+	}
+	name := t.value
+	if p.accept("(") {
+		if strings.EqualFold(name, "Nullable") {
+			inner, err := p.parseTypeName()
+			if err != nil {
+				return "", err
+			}
+			return inner, p.expect(")")
+		}
+		// type parameters such as precision are not used
+		for !p.accept(")") {
+			if p.peek().kind == tokenEOF {
+				return "", p.unexpected()
+			}
+			p.next()
+		}
+	}
+	return name, nil
+}
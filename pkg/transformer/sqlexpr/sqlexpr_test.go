@@ -0,0 +1,171 @@
+package sqlexpr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/pkg/abstract"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+var testColumns = abstract.TableColumns{
+	abstract.NewColSchema("id", schema.TypeInt32, true),
+	abstract.NewColSchema("first", schema.TypeString, false),
+	abstract.NewColSchema("last", schema.TypeString, false),
+	abstract.NewColSchema("amount", schema.TypeFloat64, false),
+	abstract.NewColSchema("created_at", schema.TypeTimestamp, false),
+	abstract.NewColSchema("payload", schema.TypeAny, false),
+	abstract.NewColSchema("comment", schema.TypeString, false),
+}
+
+func testRow() *Row {
+	return NewRowFromValues(
+		[]string{"id", "first", "last", "amount", "created_at", "payload", "comment"},
+		[]interface{}{int32(7), "John", "Smith", 1234.5, time.Date(2024, 2, 29, 13, 45, 10, 0, time.UTC), `{"user": {"tags": ["a", "b"], "age": 42}}`, nil},
+	)
+}
+
+func evalExpression(t *testing.T, expression string) (interface{}, schema.Type) {
+	node, err := ParseExpression(expression)
+	require.NoError(t, err, expression)
+	expr, err := Bind(node, testColumns)
+	require.NoError(t, err, expression)
+	value, err := expr.Eval(testRow())
+	require.NoError(t, err, expression)
+	return value, expr.Type()
+}
+
+func TestExpressions(t *testing.T) {
+	cases := []struct {
+		expression string
+		value      interface{}
+		ytType     schema.Type
+	}{
+		{expression: "id", value: int32(7), ytType: schema.TypeInt32},
+		{expression: "id * 2 + 1", value: int64(15), ytType: schema.TypeInt64},
+		{expression: "id / 2", value: 3.5, ytType: schema.TypeFloat64},
+		{expression: "id % 4", value: int64(3), ytType: schema.TypeInt64},
+		{expression: "-amount", value: -1234.5, ytType: schema.TypeFloat64},
+		{expression: "1 / 0", value: nil, ytType: schema.TypeFloat64},
+		{expression: "concat(first, ' ', last)", value: "John Smith", ytType: schema.TypeString},
+		{expression: "first || '-' || id", value: "John-7", ytType: schema.TypeString},
+		{expression: "upper(substring(last, 2, 3))", value: "MIT", ytType: schema.TypeString},
+		{expression: "length(first) + position(last, 'it')", value: int64(7), ytType: schema.TypeInt64},
+		{expression: "replace(last, 'mi', 'MI')", value: "SMIth", ytType: schema.TypeString},
+		{expression: "split_part('a,b,c', ',', 2)", value: "b", ytType: schema.TypeString},
+		{expression: "amount > 1000", value: true, ytType: schema.TypeBoolean},
+		{expression: "amount BETWEEN 0 AND 1000", value: false, ytType: schema.TypeBoolean},
+		{expression: "first IN ('Jane', 'John')", value: true, ytType: schema.TypeBoolean},
+		{expression: "first NOT LIKE 'J%n'", value: false, ytType: schema.TypeBoolean},
+		{expression: "last ILIKE 'sm_th'", value: true, ytType: schema.TypeBoolean},
+		{expression: "comment IS NULL AND NOT (id = 8)", value: true, ytType: schema.TypeBoolean},
+		{expression: "comment = 'x' OR id = 7", value: true, ytType: schema.TypeBoolean},
+		{expression: "comment = 'x' AND id = 7", value: nil, ytType: schema.TypeBoolean},
+		{expression: "coalesce(comment, 'none')", value: "none", ytType: schema.TypeString},
+		{expression: "upper(comment)", value: nil, ytType: schema.TypeString},
+		{expression: "if(amount > 100, 'big', 'small')", value: "big", ytType: schema.TypeString},
+		{expression: "CASE WHEN amount < 10 THEN 'low' WHEN amount < 10000 THEN 'mid' ELSE 'high' END", value: "mid", ytType: schema.TypeString},
+		{expression: "CASE id WHEN 1 THEN 'one' WHEN 7 THEN 'seven' END", value: "seven", ytType: schema.TypeString},
+		{expression: "CAST(amount AS Int32)", value: int32(1234), ytType: schema.TypeInt32},
+		{expression: "CAST(id AS String)", value: "7", ytType: schema.TypeString},
+		{expression: "'42'::Int64 + 1", value: int64(43), ytType: schema.TypeInt64},
+		{expression: "round(amount / 7, 2)", value: 176.36, ytType: schema.TypeFloat64},
+		{expression: "greatest(id, 3, 10)", value: int64(10), ytType: schema.TypeInt64},
+		{expression: "toDate(created_at)", value: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), ytType: schema.TypeDate},
+		{expression: "toYear(created_at) * 100 + toMonth(created_at)", value: int64(202402), ytType: schema.TypeInt64},
+		{expression: "date_trunc('hour', created_at)", value: time.Date(2024, 2, 29, 13, 0, 0, 0, time.UTC), ytType: schema.TypeTimestamp},
+		{expression: "date_add('month', 1, created_at)", value: time.Date(2024, 3, 29, 13, 45, 10, 0, time.UTC), ytType: schema.TypeTimestamp},
+		{expression: "date_diff('day', '2024-02-01', created_at)", value: int64(28), ytType: schema.TypeInt64},
+		{expression: "formatDateTime(created_at, '%Y/%m/%d %H:%M %j')", value: "2024/02/29 13:45 060", ytType: schema.TypeString},
+		{expression: "created_at > '2024-01-01'", value: true, ytType: schema.TypeBoolean},
+		{expression: "JSONExtractInt(payload, 'user', 'age')", value: int64(42), ytType: schema.TypeInt64},
+		{expression: "JSONExtractString(payload, 'user', 'tags', -1)", value: "b", ytType: schema.TypeString},
+		{expression: "JSONExtractRaw(payload, 'user', 'tags')", value: `["a","b"]`, ytType: schema.TypeString},
+		{expression: "JSONHas(payload, 'user', 'name')", value: false, ytType: schema.TypeBoolean},
+		{expression: "md5(first)", value: "61409aa1fd47d4a5332de23cbf59a36f", ytType: schema.TypeString},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expression, func(t *testing.T) {
+			value, ytType := evalExpression(t, tc.expression)
+			require.Equal(t, tc.ytType, ytType)
+			if f, ok := tc.value.(float64); ok {
+				require.InDelta(t, f, value, 1e-9)
+				return
+			}
+			require.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	for _, expression := range []string{
+		"unknown_column + 1",
+		"unknown_function(id)",
+		"lower()",
+		"CAST(id AS Decimal128)",
+		"created_at * 2",
+		"CASE WHEN id = 1 THEN 'a' ELSE 2 END",
+	} {
+		node, err := ParseExpression(expression)
+		require.NoError(t, err, expression)
+		_, err = Bind(node, testColumns)
+		require.Error(t, err, expression)
+	}
+}
+
+func TestParseSelect(t *testing.T) {
+	query, err := ParseSelect(`
+		SELECT *, lower(first) AS first_lower, "last" last_name, t.id
+		FROM db.table t
+		WHERE amount > 10 -- only big ones
+	`)
+	require.Error(t, err, "table aliases are not supported")
+	require.Nil(t, query)
+
+	query, err = ParseSelect(`SELECT *, lower(first) AS first_lower, "last" last_name, table.id FROM table WHERE amount > 10;`)
+	require.NoError(t, err)
+	require.Len(t, query.Items, 4)
+	require.True(t, query.Items[0].Star)
+	require.Equal(t, "first_lower", query.Items[1].Alias)
+	require.Equal(t, "last_name", query.Items[2].Alias)
+	require.Equal(t, "id", query.Items[3].Expr.String())
+	require.Equal(t, "table", query.Table)
+	require.Equal(t, "(amount > 10)", query.Where.String())
+
+	for _, bad := range []string{
+		"SELECT FROM table",
+		"SELECT id FROM",
+		"SELECT id FROM table WHERE",
+		"SELECT 'unterminated FROM table",
+		"SELECT id FROM table ORDER BY id",
+		"SELECT CASE END FROM table",
+	} {
+		_, err := ParseSelect(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestBuildExpression(t *testing.T) {
+	threshold, err := Literal(int64(1000))
+	require.NoError(t, err)
+	isBig, err := Compare(">", Call("multiply", ColumnRef("amount"), threshold), threshold)
+	require.NoError(t, err)
+	since, err := Literal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	recent, err := Compare(">=", ColumnRef("created_at"), since)
+	require.NoError(t, err)
+	node := Call("and", isBig, recent, Not(In(ColumnRef("id"), []Node{threshold}, false)))
+
+	expr, err := Bind(node, testColumns)
+	require.NoError(t, err)
+	require.Equal(t, schema.TypeBoolean, expr.Type())
+	value, err := expr.Eval(testRow())
+	require.NoError(t, err)
+	require.Equal(t, true, value)
+
+	_, err = Literal(int32(1))
+	require.Error(t, err)
+	_, err = Bind(Call("plus", ColumnRef("id")), testColumns)
+	require.Error(t, err)
+}
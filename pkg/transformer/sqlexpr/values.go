@@ -0,0 +1,382 @@
+package sqlexpr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// During evaluation values are kept in a canonical form: nil, bool, int64, float64, string, time.Time
+// or a decoded JSON value for `any` columns. evalType is the static type of such a value.
+type evalType int
+
+const (
+	typeNull evalType = iota
+	typeBool
+	typeInt
+	typeFloat
+	typeString
+	typeDate
+	typeTimestamp
+	typeAny
+)
+
+func (t evalType) String() string {
+	switch t {
+	case typeNull:
+		return "null"
+	case typeBool:
+		return "boolean"
+	case typeInt:
+		return "int64"
+	case typeFloat:
+		return "double"
+	case typeString:
+		return "string"
+	case typeDate:
+		return "date"
+	case typeTimestamp:
+		return "timestamp"
+	default:
+		return "any"
+	}
+}
+
+func (t evalType) isNumeric() bool {
+	return t == typeInt || t == typeFloat
+}
+
+func (t evalType) isTime() bool {
+	return t == typeDate || t == typeTimestamp
+}
+
+// ytType is the schema type of values of the evaluation type
+func (t evalType) ytType() schema.Type {
+	switch t {
+	case typeBool:
+		return schema.TypeBoolean
+	case typeInt:
+		return schema.TypeInt64
+	case typeFloat:
+		return schema.TypeFloat64
+	case typeString:
+		return schema.TypeString
+	case typeDate:
+		return schema.TypeDate
+	case typeTimestamp:
+		return schema.TypeTimestamp
+	default:
+		return schema.TypeAny
+	}
+}
+
+func evalTypeOf(ytType string) evalType {
+	switch schema.Type(strings.ToLower(ytType)) {
+	case schema.TypeBoolean:
+		return typeBool
+	case schema.TypeInt8, schema.TypeInt16, schema.TypeInt32, schema.TypeInt64,
+		schema.TypeUint8, schema.TypeUint16, schema.TypeUint32, schema.TypeUint64, schema.TypeInterval:
+		return typeInt
+	case schema.TypeFloat32, schema.TypeFloat64:
+		return typeFloat
+	case schema.TypeString, schema.TypeBytes:
+		return typeString
+	case schema.TypeDate:
+		return typeDate
+	case schema.TypeDatetime, schema.TypeTimestamp:
+		return typeTimestamp
+	default:
+		return typeAny
+	}
+}
+
+// canonicalValue converts a change item value into the canonical form of the given type
+func canonicalValue(value interface{}, t evalType) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch t {
+	case typeBool:
+		return toBool(value)
+	case typeInt:
+		return toInt(value)
+	case typeFloat:
+		return toFloat(value)
+	case typeString:
+		return toString(value, typeAny), nil
+	case typeDate:
+		ts, err := toTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return truncateToDay(ts), nil
+	case typeTimestamp:
+		return toTime(value)
+	default:
+		return value, nil
+	}
+}
+
+// outputValue converts a canonical value into the Go type expected for the schema type.
+func outputValue(value interface{}, ytType schema.Type) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch ytType {
+	case schema.TypeInt8, schema.TypeInt16, schema.TypeInt32, schema.TypeUint8, schema.TypeUint16, schema.TypeUint32, schema.TypeUint64:
+		i, err := toInt(value)
+		if err != nil {
+			return nil, err
+		}
+		switch ytType {
+		case schema.TypeInt8:
+			return int8(i), nil
+		case schema.TypeInt16:
+			return int16(i), nil
+		case schema.TypeInt32:
+			return int32(i), nil
+		case schema.TypeUint8:
+			return uint8(i), nil
+		case schema.TypeUint16:
+			return uint16(i), nil
+		case schema.TypeUint32:
+			return uint32(i), nil
+		default:
+			return uint64(i), nil
+		}
+	case schema.TypeFloat32:
+		f, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		return float32(f), nil
+	case schema.TypeBytes:
+		return []byte(toString(value, typeAny)), nil
+	case schema.TypeDatetime:
+		return toTime(value)
+	default:
+		return canonicalValue(value, evalTypeOf(string(ytType)))
+	}
+}
+
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "t", "yes", "y", "1":
+			return true, nil
+		case "false", "f", "no", "n", "0", "":
+			return false, nil
+		}
+		return false, xerrors.Errorf("unable to convert %q to boolean", v)
+	default:
+		result, err := cast.ToBoolE(normalizeNumber(value))
+		if err != nil {
+			return false, xerrors.Errorf("unable to convert %T to boolean: %w", value, err)
+		}
+		return result, nil
+	}
+}
+
+func toInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0, xerrors.Errorf("unable to convert %v to integer", v)
+		}
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, xerrors.Errorf("value %d overflows int64", v)
+		}
+		return int64(v), nil
+	case time.Time:
+		return v.Unix(), nil
+	case time.Duration:
+		return int64(v), nil
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if i, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return 0, xerrors.Errorf("unable to convert %q to integer", v)
+		}
+		return int64(f), nil
+	case json.Number:
+		return toInt(string(v))
+	default:
+		result, err := cast.ToInt64E(value)
+		if err != nil {
+			return 0, xerrors.Errorf("unable to convert %T to integer: %w", value, err)
+		}
+		return result, nil
+	}
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, xerrors.Errorf("unable to convert %q to float", v)
+		}
+		return f, nil
+	case json.Number:
+		return v.Float64()
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case time.Time:
+		return float64(v.UnixNano()) / float64(time.Second), nil
+	default:
+		result, err := cast.ToFloat64E(value)
+		if err != nil {
+			return 0, xerrors.Errorf("unable to convert %T to float: %w", value, err)
+		}
+		return result, nil
+	}
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, xerrors.Errorf("unable to parse %q as time", v)
+	case int64:
+		return time.Unix(v, 0).UTC(), nil
+	case float64:
+		seconds, fraction := math.Modf(v)
+		return time.Unix(int64(seconds), int64(fraction*float64(time.Second))).UTC(), nil
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return time.Time{}, xerrors.Errorf("unable to convert %v to time", v)
+		}
+		return toTime(i)
+	default:
+		if i, err := cast.ToInt64E(value); err == nil {
+			return toTime(i)
+		}
+	}
+	return time.Time{}, xerrors.Errorf("unable to convert %T to time", value)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func toString(value interface{}, t evalType) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		if t == typeDate {
+			return v.UTC().Format("2006-01-02")
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(out)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// normalizeNumber turns json.Number into a native number so that cast can handle it
+func normalizeNumber(value interface{}) interface{} {
+	if n, ok := value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// castTypes maps SQL type names onto schema types
+var castTypes = map[string]schema.Type{
+	"string":      schema.TypeString,
+	"text":        schema.TypeString,
+	"varchar":     schema.TypeString,
+	"utf8":        schema.TypeString,
+	"bytes":       schema.TypeBytes,
+	"int8":        schema.TypeInt8,
+	"int16":       schema.TypeInt16,
+	"int32":       schema.TypeInt32,
+	"int":         schema.TypeInt32,
+	"integer":     schema.TypeInt32,
+	"int64":       schema.TypeInt64,
+	"bigint":      schema.TypeInt64,
+	"uint8":       schema.TypeUint8,
+	"uint16":      schema.TypeUint16,
+	"uint32":      schema.TypeUint32,
+	"uint64":      schema.TypeUint64,
+	"float32":     schema.TypeFloat32,
+	"float":       schema.TypeFloat32,
+	"float64":     schema.TypeFloat64,
+	"double":      schema.TypeFloat64,
+	"bool":        schema.TypeBoolean,
+	"boolean":     schema.TypeBoolean,
+	"date":        schema.TypeDate,
+	"datetime":    schema.TypeDatetime,
+	"datetime64":  schema.TypeTimestamp,
+	"timestamp":   schema.TypeTimestamp,
+	"json":        schema.TypeAny,
+	"any":         schema.TypeAny,
+	"interval":    schema.TypeInterval,
+	"timestamptz": schema.TypeTimestamp,
+}
@@ -2,6 +2,7 @@ package transformer
 
 import (
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -21,6 +22,8 @@ type transformation struct {
 	transformers []abstract.Transformer
 	plan         map[abstract.TableID]map[string][]abstract.Transformer
 	sink         abstract.Sinker
+	// owned transformers are closed together with the sink
+	owned []abstract.Transformer
 
 	registry metrics.Registry
 	sta      *stats.MiddlewareTransformerStats
@@ -37,6 +40,7 @@ func (u *transformation) clone() *transformation {
 		transformers: u.transformers,
 		plan:         make(map[abstract.TableID]map[string][]abstract.Transformer),
 		sink:         nil,
+		owned:        nil,
 
 		registry: u.registry,
 		sta:      stats.NewMiddlewareTransformerStats(u.registry),
@@ -177,8 +181,33 @@ func (u *transformation) Push(items []abstract.ChangeItem) error {
 }
 
 func (u *transformation) Close() error {
+	var errs util.Errors
 	if u.sink != nil {
-		return u.sink.Close()
+		if err := u.sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := CloseTransformers(u.owned); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// CloseTransformers releases resources of transformers implementing io.Closer, e.g. connections or runtimes of modules
+func CloseTransformers(transformers []abstract.Transformer) error {
+	var errs util.Errors
+	for _, tr := range transformers {
+		if closer, ok := tr.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, xerrors.Errorf("unable to close %s: %w", tr.Type(), err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
 	}
 	return nil
 }
@@ -326,10 +355,12 @@ func (u *transformation) RuntimeOpts() abstract.TransformationRuntimeOpts {
 	return u.runtimeOpts
 }
 
+// Sinker makes transformation sink, owned transformers are closed by the sink, so the option must be applied once
 func Sinker(
 	config *Transformers,
 	runtime abstract.TransformationRuntimeOpts,
 	transformers []abstract.Transformer,
+	owned []abstract.Transformer,
 	lgr log.Logger,
 	registry metrics.Registry,
 ) abstract.SinkOption {
@@ -339,6 +370,7 @@ func Sinker(
 			transformers: transformers,
 			plan:         make(map[abstract.TableID]map[string][]abstract.Transformer),
 			sink:         s,
+			owned:        nil,
 
 			registry: registry,
 			sta:      stats.NewMiddlewareTransformerStats(registry),
@@ -348,6 +380,8 @@ func Sinker(
 
 			runtimeOpts: runtime,
 		}
-		return tt.MakeSinkMiddleware()(s)
+		executor := tt.MakeSinkMiddleware()(s).(*transformation)
+		executor.owned = owned
+		return executor
 	}
 }
@@ -109,3 +109,37 @@ func TestMultipleTransformers(t *testing.T) {
 	require.Equal(t, mockSinker.gotItems[1].ColumnValues, []interface{}{"test", 2, "{}"})
 
 }
+
+type closableTransformer struct {
+	closed int
+}
+
+func (c *closableTransformer) Apply(input []abstract.ChangeItem) abstract.TransformerResult {
+	return abstract.TransformerResult{Transformed: input, Errors: nil}
+}
+func (c *closableTransformer) Suitable(abstract.TableID, *abstract.TableSchema) bool { return true }
+func (c *closableTransformer) ResultSchema(original *abstract.TableSchema) (*abstract.TableSchema, error) {
+	return original, nil
+}
+func (c *closableTransformer) Description() string            { return "closable" }
+func (c *closableTransformer) Type() abstract.TransformerType { return "closable" }
+func (c *closableTransformer) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestSinkerClosesOwnedTransformers(t *testing.T) {
+	owned := new(closableTransformer)
+	shared := new(closableTransformer)
+	s := transformers_registry.Sinker(
+		nil,
+		abstract.TransformationRuntimeOpts{JobIndex: 0},
+		[]abstract.Transformer{owned, shared},
+		[]abstract.Transformer{owned},
+		logger.Log,
+		solomon.NewRegistry(solomon.NewRegistryOpts()),
+	)(new(mockSinker))
+	require.NoError(t, s.Close())
+	require.Equal(t, 1, owned.closed)
+	require.Equal(t, 0, shared.closed)
+}
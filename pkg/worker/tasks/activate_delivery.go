@@ -49,12 +49,23 @@ func ActivateDelivery(ctx context.Context, task *model.TransferOperation, cp coo
 		return nil
 	}
 
-	notFirstRun, err := snapshotLoader.OperationStateExists(ctx)
-	if err != nil {
-		return errors.CategorizedErrorf(categories.Internal, "failed to check existence of operation state: %w", err)
+	resumed := false
+	if transfer.SnapshotOnly() {
+		var err error
+		resumed, err = snapshotLoader.ResumeSnapshot()
+		if err != nil {
+			return errors.CategorizedErrorf(categories.Internal, "failed to check if snapshot can be resumed: %w", err)
+		}
 	}
-	if notFirstRun {
-		return xerrors.New("main worker job was terminated by runtime. Check logs to see the cause")
+
+	if !resumed {
+		notFirstRun, err := snapshotLoader.OperationStateExists(ctx)
+		if err != nil {
+			return errors.CategorizedErrorf(categories.Internal, "failed to check existence of operation state: %w", err)
+		}
+		if notFirstRun {
+			return xerrors.New("main worker job was terminated by runtime. Check logs to see the cause")
+		}
 	}
 
 	logger.Log.Info("ActivateDelivery starts on primary worker")
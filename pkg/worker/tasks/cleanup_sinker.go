@@ -29,6 +29,10 @@ func (l *SnapshotLoader) CleanupSinker(tables abstract.TableMap) error {
 	if l.transfer.Dst.CleanupMode() == model.DisabledCleanup {
 		return nil
 	}
+	if l.resumed {
+		logger.Log.Info("sink cleanup skipped due to resumed snapshot, unfinished tables parts are cleaned up on reload")
+		return nil
+	}
 
 	if l.transfer.TmpPolicy != nil {
 		if err := model.EnsureTmpPolicySupported(l.transfer.Dst, l.transfer); err != nil {
@@ -48,6 +48,9 @@ type SnapshotLoader struct {
 	shardedState           string
 	slotKiller             abstract.SlotKiller
 	slotKillerErrorChannel <-chan error
+	resumed                bool
+	resumedParts           []*model.OperationTablePart
+	resumedTables          string
 
 	// Progress, metrics
 	progressUpdateMutex sync.Mutex
@@ -69,6 +72,9 @@ func NewSnapshotLoader(cp coordinator.Coordinator, operationID string, transfer
 		shardedState:           "",
 		slotKiller:             abstract.MakeStubSlotKiller(),
 		slotKillerErrorChannel: make(<-chan error),
+		resumed:                false,
+		resumedParts:           nil,
+		resumedTables:          "",
 
 		progressUpdateMutex: sync.Mutex{},
 
@@ -435,14 +441,22 @@ func (l *SnapshotLoader) uploadMain(ctx context.Context, tables []abstract.Table
 		return err
 	}
 
-	parts, err := l.SplitTables(ctx, logger.Log, tables, sourceStorage)
-	if err != nil {
-		return errors.CategorizedErrorf(categories.Source, "unable to shard tables for operation '%v': %w", l.operationID, err)
+	if err := l.checkResumedTables(tables); err != nil {
+		return err
+	}
+	parts := l.resumedParts
+	if !l.resumed {
+		parts, err = l.SplitTables(ctx, logger.Log, tables, sourceStorage)
+		if err != nil {
+			return errors.CategorizedErrorf(categories.Source, "unable to shard tables for operation '%v': %w", l.operationID, err)
+		}
 	}
 	l.dumpTablePartsToLogs(parts)
 
-	if err := l.cp.CreateOperationTablesParts(l.operationID, parts); err != nil {
-		return errors.CategorizedErrorf(categories.Internal, "unable to store operation tables: %w", err)
+	if !l.resumed {
+		if err := l.cp.CreateOperationTablesParts(l.operationID, parts); err != nil {
+			return errors.CategorizedErrorf(categories.Internal, "unable to store operation tables: %w", err)
+		}
 	}
 
 	if err := l.GetShardedStateFromSource(sourceStorage); err != nil {
@@ -458,14 +472,24 @@ func (l *SnapshotLoader) uploadMain(ctx context.Context, tables []abstract.Table
 		return errors.CategorizedErrorf(categories.Internal, "unable to start metrics tracker: %w", err)
 	}
 
-	if err := l.sendTableControlEvent(ctx, sourceStorage, abstract.InitShardedTableLoad, parts...); err != nil {
-		return errors.CategorizedErrorf(categories.Target, "unable to start loading tables: %w", err)
+	if !l.resumed {
+		if err := l.sendTableControlEvent(ctx, sourceStorage, abstract.InitShardedTableLoad, parts...); err != nil {
+			return errors.CategorizedErrorf(categories.Target, "unable to start loading tables: %w", err)
+		}
+		if err := l.storeResumableState(1, TablesFingerprint(tables)); err != nil {
+			return errors.CategorizedErrorf(categories.Internal, "unable to store resumable snapshot state: %w", err)
+		}
 	}
 
 	// Start load tables on secondary workers
 	if err := l.cp.CreateOperationWorkers(l.operationID, runtime.WorkersNum()); err != nil {
 		return errors.CategorizedErrorf(categories.Internal, "unable to create operation workers for operation '%v': %w", l.operationID, err)
 	}
+	stopSecondaryWorkers, err := l.launchSecondaryWorkers(ctx)
+	if err != nil {
+		return err
+	}
+	defer stopSecondaryWorkers()
 
 	waitErrCh := make(chan error)
 	go func() {
@@ -502,6 +526,7 @@ func (l *SnapshotLoader) uploadMain(ctx context.Context, tables []abstract.Table
 	if err := l.endDestination(); err != nil {
 		return errors.CategorizedErrorf(categories.Target, "unable to end snapshot on sink: %v", err)
 	}
+	l.removeResumableState()
 
 	if err := l.updateIncrementalState(updateIncrementalState, nextIncrementalState); err != nil {
 		return errors.CategorizedErrorf(categories.Internal, "unable to update incremental state: %w", err)
@@ -582,6 +607,10 @@ func (l *SnapshotLoader) uploadSecondary(ctx context.Context) error {
 		return errors.CategorizedErrorf(categories.Internal, "unable to get shard state: %w", err)
 	}
 
+	if err := l.checkResumedOnSecondary(); err != nil {
+		return errors.CategorizedErrorf(categories.Internal, "unable to check if snapshot is resumed: %w", err)
+	}
+
 	prevAssignedTablesParts, err := l.cp.ClearAssignedTablesParts(ctx, l.operationID, l.workerIndex)
 	if err != nil {
 		return errors.CategorizedErrorf(categories.Internal, "unable clear assigned tables parts for worker %v: %w", l.workerIndex, err)
@@ -609,6 +638,10 @@ func (l *SnapshotLoader) uploadSecondary(ctx context.Context) error {
 	logger.Log.Infof("Start uploading tables on worker %v", l.workerIndex)
 
 	err = l.DoUploadTables(ctx, sourceStorage, l.GetRemoteTablePartProvider())
+	if ctx.Err() != nil {
+		l.releaseAssignedTablesParts()
+		return xerrors.Errorf("upload of tables interrupted on worker '%v': %w", l.workerIndex, ctx.Err())
+	}
 	if err != nil {
 		return xerrors.Errorf("upload of tables failed on worker '%v': %w", l.workerIndex, err)
 	}
@@ -620,6 +653,17 @@ func (l *SnapshotLoader) uploadSecondary(ctx context.Context) error {
 	return nil
 }
 
+// releaseAssignedTablesParts returns unfinished table parts of an interrupted worker back to the coordinator,
+// so that another worker can pick them up
+func (l *SnapshotLoader) releaseAssignedTablesParts() {
+	released, err := l.cp.ClearAssignedTablesParts(context.Background(), l.operationID, l.workerIndex)
+	if err != nil {
+		logger.Log.Warn(fmt.Sprintf("Unable to release assigned tables parts of worker %v", l.workerIndex), log.Error(err))
+		return
+	}
+	logger.Log.Infof("Worker %v interrupted, released assigned tables parts count %v", l.workerIndex, released)
+}
+
 func (l *SnapshotLoader) uploadSingle(ctx context.Context, tables []abstract.TableDescription, updateIncrementalState bool) error {
 	if len(tables) == 0 {
 		return abstract.NewFatalError(xerrors.New("no tables in snapshot"))
@@ -649,9 +693,15 @@ func (l *SnapshotLoader) uploadSingle(ctx context.Context, tables []abstract.Tab
 		return err
 	}
 
-	parts, err := l.SplitTables(ctx, logger.Log, tables, sourceStorage)
-	if err != nil {
-		return errors.CategorizedErrorf(categories.Source, "unable to shard tables for operation '%v': %w", l.operationID, err)
+	if err := l.checkResumedTables(tables); err != nil {
+		return err
+	}
+	parts := l.resumedParts
+	if !l.resumed {
+		parts, err = l.SplitTables(ctx, logger.Log, tables, sourceStorage)
+		if err != nil {
+			return errors.CategorizedErrorf(categories.Source, "unable to shard tables for operation '%v': %w", l.operationID, err)
+		}
 	}
 	for _, table := range parts {
 		table.WorkerIndex = new(int)
@@ -659,8 +709,10 @@ func (l *SnapshotLoader) uploadSingle(ctx context.Context, tables []abstract.Tab
 	}
 	l.dumpTablePartsToLogs(parts)
 
-	if err := l.cp.CreateOperationTablesParts(l.operationID, parts); err != nil {
-		return errors.CategorizedErrorf(categories.Internal, "unable to store operation tables: %w", err)
+	if !l.resumed {
+		if err := l.cp.CreateOperationTablesParts(l.operationID, parts); err != nil {
+			return errors.CategorizedErrorf(categories.Internal, "unable to store operation tables: %w", err)
+		}
 	}
 
 	metricsTracker, err := NewNotShardedSnapshotTableMetricsTracker(ctx, l.transfer, l.registry, parts, &l.progressUpdateMutex)
@@ -668,8 +720,13 @@ func (l *SnapshotLoader) uploadSingle(ctx context.Context, tables []abstract.Tab
 		return errors.CategorizedErrorf(categories.Internal, "unable to start metrics tracker: %w", err)
 	}
 
-	if err := l.sendTableControlEvent(ctx, sourceStorage, abstract.InitShardedTableLoad, parts...); err != nil {
-		return errors.CategorizedErrorf(categories.Source, "unable to start loading tables: %w", err)
+	if !l.resumed {
+		if err := l.sendTableControlEvent(ctx, sourceStorage, abstract.InitShardedTableLoad, parts...); err != nil {
+			return errors.CategorizedErrorf(categories.Source, "unable to start loading tables: %w", err)
+		}
+		if err := l.storeResumableState(1, TablesFingerprint(tables)); err != nil {
+			return errors.CategorizedErrorf(categories.Internal, "unable to store resumable snapshot state: %w", err)
+		}
 	}
 
 	uploadDoneCh := make(chan error)
@@ -714,6 +771,7 @@ func (l *SnapshotLoader) uploadSingle(ctx context.Context, tables []abstract.Tab
 	if err := l.endDestination(); err != nil {
 		return errors.CategorizedErrorf(categories.Target, "unable to end snapshot on sink: %v", err)
 	}
+	l.removeResumableState()
 
 	logger.Log.Infof("Will update next incremental state for transfer: %v", nextIncrementalState)
 	if err := l.updateIncrementalState(updateIncrementalState, nextIncrementalState); err != nil {
@@ -896,6 +954,14 @@ func (l *SnapshotLoader) DoUploadTables(ctx context.Context, source abstract.Sto
 			parallelismSemaphore.Release(1)
 			break // No more tables to transfer
 		}
+		if nextPart.Completed {
+			logger.Log.Info(
+				fmt.Sprintf("Table part '%v' is already completed by previous attempt, skip it", nextPart),
+				log.Any("table_part", nextPart), log.Int("worker_index", l.workerIndex))
+			waitToComplete.Done()
+			parallelismSemaphore.Release(1)
+			continue
+		}
 
 		logger.Log.Info(
 			fmt.Sprintf("Assigned table part '%v' to worker %v", nextPart, l.workerIndex),
@@ -914,6 +980,12 @@ func (l *SnapshotLoader) DoUploadTables(ctx context.Context, source abstract.Sto
 					fmt.Sprintf("Start load table '%v' on worker %v", nextPart, l.workerIndex),
 					log.Any("table_part", nextPart), log.Int("worker_index", l.workerIndex))
 
+				if l.resumed {
+					if err := l.cleanupResumedPart(ctx, nextPart); err != nil {
+						return errors.CategorizedErrorf(categories.Target, "unable to cleanup table part '%v' before reload: %w", nextPart, err)
+					}
+				}
+
 				l.progressUpdateMutex.Lock()
 				nextPart.CompletedRows = 0
 				nextPart.Completed = false
@@ -0,0 +1,196 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/errors"
+	"github.com/transferia/transferia/pkg/errors/categories"
+	"github.com/transferia/transferia/pkg/middlewares"
+	"github.com/transferia/transferia/pkg/providers"
+	"github.com/transferia/transferia/pkg/sink"
+	"github.com/transferia/transferia/pkg/util"
+	"github.com/transferia/transferia/pkg/worker/tasks/cleanup"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+const resumableSnapshotStateKey = "resumable_snapshot"
+
+// resumableSnapshotState is stored in transfer state while snapshot operation is in progress,
+// so a restarted operation with the same ID knows that its table parts are already stored in coordinator
+type resumableSnapshotState struct {
+	OperationID string `json:"operation_id"`
+	Attempt     int    `json:"attempt"`
+	Tables      string `json:"tables"`
+}
+
+// TablesFingerprint identifies the set of tables requested for a snapshot,
+// so that a resumed operation does not reuse table parts of another set of tables
+func TablesFingerprint(tables []abstract.TableDescription) string {
+	keys := make([]string, 0, len(tables))
+	for _, table := range tables {
+		keys = append(keys, fmt.Sprintf("%v/%v/%v", table.Fqtn(), table.Filter, table.Offset))
+	}
+	sort.Strings(keys)
+	return util.Hash(strings.Join(keys, "\n"))
+}
+
+// resumable reports whether snapshot of the transfer can be resumed after restart.
+// Incremental snapshots are not resumable, since their cursors are recalculated on every start,
+// abstract2 transfers and transfers with tmp policy are not resumable as well.
+func (l *SnapshotLoader) resumable() bool {
+	return l.operationID != "" &&
+		!l.transfer.IsIncremental() &&
+		!l.transfer.IsAbstract2() &&
+		l.transfer.TmpPolicy == nil
+}
+
+func (l *SnapshotLoader) loadResumableState() (*resumableSnapshotState, error) {
+	state, err := l.cp.GetTransferState(l.transfer.ID)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get transfer state: %w", err)
+	}
+	value, ok := state[resumableSnapshotStateKey]
+	if !ok || value.Generic == nil {
+		return nil, nil
+	}
+	var result resumableSnapshotState
+	if err := util.MapFromJSON(value.Generic, &result); err != nil {
+		return nil, xerrors.Errorf("unable to parse resumable snapshot state: %w", err)
+	}
+	if result.OperationID != l.operationID {
+		return nil, nil
+	}
+	return &result, nil
+}
+
+func (l *SnapshotLoader) storeResumableState(attempt int, tables string) error {
+	if !l.resumable() {
+		return nil
+	}
+	return l.cp.SetTransferState(l.transfer.ID, map[string]*coordinator.TransferStateData{
+		resumableSnapshotStateKey: {Generic: resumableSnapshotState{OperationID: l.operationID, Attempt: attempt, Tables: tables}},
+	})
+}
+
+// checkResumedTables fails if the resumed operation was started for another set of tables,
+// since its stored table parts do not correspond to the requested ones
+func (l *SnapshotLoader) checkResumedTables(tables []abstract.TableDescription) error {
+	if !l.resumed || l.resumedTables == "" {
+		return nil
+	}
+	if l.resumedTables != TablesFingerprint(tables) {
+		return errors.CategorizedErrorf(categories.Internal,
+			"tables of operation '%v' differ from the ones of the previous attempt, start a new operation to upload them", l.operationID)
+	}
+	return nil
+}
+
+func (l *SnapshotLoader) removeResumableState() {
+	if !l.resumable() {
+		return
+	}
+	if err := l.cp.RemoveTransferState(l.transfer.ID, []string{resumableSnapshotStateKey}); err != nil {
+		logger.Log.Warn("unable to remove resumable snapshot state", log.Error(err))
+	}
+}
+
+// ResumeSnapshot checks whether the snapshot of the current operation was started earlier and was not finished.
+// If so, table parts of the previous attempt are taken from coordinator: completed parts are skipped,
+// others are cleaned up on destination and loaded again. Must be called on main worker before CleanupSinker.
+func (l *SnapshotLoader) ResumeSnapshot() (bool, error) {
+	if !l.resumable() {
+		return false, nil
+	}
+	state, err := l.loadResumableState()
+	if err != nil {
+		return false, xerrors.Errorf("unable to load resumable snapshot state: %w", err)
+	}
+	if state == nil {
+		return false, nil
+	}
+	parts, err := l.cp.GetOperationTablesParts(l.operationID)
+	if err != nil {
+		return false, xerrors.Errorf("unable to get tables parts of operation '%v': %w", l.operationID, err)
+	}
+	if len(parts) == 0 {
+		logger.Log.Infof("No tables parts stored for operation '%v', snapshot will be started from scratch", l.operationID)
+		return false, nil
+	}
+	if err := l.storeResumableState(state.Attempt+1, state.Tables); err != nil {
+		return false, xerrors.Errorf("unable to store resumable snapshot state: %w", err)
+	}
+
+	completed := 0
+	for _, part := range parts {
+		if part.Completed {
+			completed++
+		}
+	}
+	logger.Log.Infof("Resume snapshot of operation '%v' (attempt %v), %v of %v tables parts are already completed",
+		l.operationID, state.Attempt+1, completed, len(parts))
+
+	l.resumed = true
+	l.resumedParts = parts
+	l.resumedTables = state.Tables
+	return true, nil
+}
+
+// checkResumedOnSecondary marks secondary worker as resumed if main worker resumes the operation,
+// so that partially uploaded table parts are cleaned up before reload
+func (l *SnapshotLoader) checkResumedOnSecondary() error {
+	if !l.resumable() {
+		return nil
+	}
+	state, err := l.loadResumableState()
+	if err != nil {
+		return xerrors.Errorf("unable to load resumable snapshot state: %w", err)
+	}
+	l.resumed = state != nil && state.Attempt > 1
+	return nil
+}
+
+// cleanupResumedPart removes data of a table part which may be left on destination by previous attempt.
+// Whole table is cleaned up according to the destination cleanup policy, while a shard of a table
+// is cleaned up only if destination provider supports it, otherwise it relies on idempotent writes of destination.
+func (l *SnapshotLoader) cleanupResumedPart(ctx context.Context, part *model.OperationTablePart) error {
+	if l.transfer.Dst.CleanupMode() == model.DisabledCleanup {
+		return nil
+	}
+
+	if partCleaner, ok := providers.Destination[providers.PartCleaner](logger.Log, l.registry, l.cp, l.transfer); ok {
+		err := partCleaner.CleanupPart(ctx, part)
+		if err == nil {
+			logger.Log.Infof("Table part '%v' is cleaned up before reload", part)
+			return nil
+		}
+		if !xerrors.Is(err, providers.PartCleanupUnsupportedErr) {
+			return xerrors.Errorf("unable to cleanup table part: %w", err)
+		}
+	}
+
+	if part.Sharded() {
+		logger.Log.Warnf("Cleanup of table part '%v' is not supported by destination, rows of previous attempt may be duplicated", part)
+		return nil
+	}
+
+	cleanupSink, err := sink.MakeAsyncSink(l.transfer, logger.Log, l.registry, l.cp, middlewares.MakeConfig(middlewares.WithNoData))
+	if err != nil {
+		return xerrors.Errorf("failed to create sink: %w", err)
+	}
+	defer cleanupSink.Close()
+
+	tables := abstract.TableMap{*part.ToTableID(): abstract.TableInfo{EtaRow: part.ETARows, IsView: false, Schema: nil}}
+	if err := cleanup.CleanupTables(cleanupSink, tables, l.transfer.Dst.CleanupMode()); err != nil {
+		return xerrors.Errorf("unable to cleanup table: %w", err)
+	}
+	logger.Log.Infof("Table '%v' is cleaned up before reload", part.TableFQTN())
+	return nil
+}
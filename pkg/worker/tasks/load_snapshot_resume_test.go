@@ -0,0 +1,197 @@
+package tasks
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/library/go/core/metrics/solomon"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+)
+
+type resumeCoordinator struct {
+	*coordinator.CoordinatorInMemory
+	parts map[string][]*model.OperationTablePart
+}
+
+func newResumeCoordinator() *resumeCoordinator {
+	return &resumeCoordinator{
+		CoordinatorInMemory: coordinator.NewStatefulFakeClient(),
+		parts:               map[string][]*model.OperationTablePart{},
+	}
+}
+
+func (c *resumeCoordinator) CreateOperationTablesParts(operationID string, tables []*model.OperationTablePart) error {
+	for _, table := range tables {
+		c.parts[operationID] = append(c.parts[operationID], table.Copy())
+	}
+	return nil
+}
+
+func (c *resumeCoordinator) GetOperationTablesParts(operationID string) ([]*model.OperationTablePart, error) {
+	return c.parts[operationID], nil
+}
+
+type resumeStorage struct {
+	mockStorage
+	mu     sync.Mutex
+	loaded []abstract.TableID
+}
+
+func (s *resumeStorage) LoadTable(ctx context.Context, table abstract.TableDescription, pusher abstract.Pusher) error {
+	s.mu.Lock()
+	s.loaded = append(s.loaded, table.ID())
+	s.mu.Unlock()
+	return pusher([]abstract.ChangeItem{InsertRow(table.ID())})
+}
+
+type resumeSinker struct {
+	mu    sync.Mutex
+	kinds map[abstract.TableID][]abstract.Kind
+}
+
+func (s *resumeSinker) Close() error {
+	return nil
+}
+
+func (s *resumeSinker) Push(input []abstract.ChangeItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range input {
+		s.kinds[item.TableID()] = append(s.kinds[item.TableID()], item.Kind)
+	}
+	return nil
+}
+
+func newResumeTransfer(storage abstract.Storage, sinker abstract.Sinker) *model.Transfer {
+	return &model.Transfer{
+		ID:   "test_transfer_id",
+		Type: abstract.TransferTypeSnapshotOnly,
+		Runtime: &abstract.LocalRuntime{
+			ShardingUpload: abstract.ShardUploadParams{
+				ProcessCount: 1,
+			},
+		},
+		Src: &model.MockSource{
+			StorageFactory:   func() abstract.Storage { return storage },
+			AllTablesFactory: func() abstract.TableMap { return nil },
+		},
+		Dst: &model.MockDestination{
+			SinkerFactory: func() abstract.Sinker { return sinker },
+			Cleanup:       model.Drop,
+		},
+	}
+}
+
+func TestResumeSnapshot(t *testing.T) {
+	cp := newResumeCoordinator()
+	transfer := newResumeTransfer(&resumeStorage{}, &resumeSinker{kinds: map[abstract.TableID][]abstract.Kind{}})
+
+	loader := NewSnapshotLoader(cp, "test-operation", transfer, solomon.NewRegistry(nil))
+	resumed, err := loader.ResumeSnapshot()
+	require.NoError(t, err)
+	require.False(t, resumed, "no state stored yet")
+
+	require.NoError(t, loader.storeResumableState(1, ""))
+	resumed, err = loader.ResumeSnapshot()
+	require.NoError(t, err)
+	require.False(t, resumed, "no tables parts stored yet")
+
+	require.NoError(t, cp.CreateOperationTablesParts("test-operation", []*model.OperationTablePart{
+		{OperationID: "test-operation", Schema: table1.Namespace, Name: table1.Name, PartsCount: 1},
+	}))
+	resumed, err = loader.ResumeSnapshot()
+	require.NoError(t, err)
+	require.True(t, resumed)
+	require.Len(t, loader.resumedParts, 1)
+
+	state, err := loader.loadResumableState()
+	require.NoError(t, err)
+	require.Equal(t, 2, state.Attempt)
+
+	otherLoader := NewSnapshotLoader(cp, "other-operation", transfer, solomon.NewRegistry(nil))
+	resumed, err = otherLoader.ResumeSnapshot()
+	require.NoError(t, err)
+	require.False(t, resumed, "state of another operation")
+
+	loader.removeResumableState()
+	resumed, err = NewSnapshotLoader(cp, "test-operation", transfer, solomon.NewRegistry(nil)).ResumeSnapshot()
+	require.NoError(t, err)
+	require.False(t, resumed, "state removed")
+}
+
+func TestResumeSnapshotSkipsCompletedParts(t *testing.T) {
+	cp := newResumeCoordinator()
+	storage := &resumeStorage{}
+	sinker := &resumeSinker{kinds: map[abstract.TableID][]abstract.Kind{}}
+	transfer := newResumeTransfer(storage, sinker)
+
+	tables := []abstract.TableDescription{TableDescription(table1), TableDescription(table2)}
+	require.NoError(t, cp.CreateOperationTablesParts("test-operation", []*model.OperationTablePart{
+		{OperationID: "test-operation", Schema: table1.Namespace, Name: table1.Name, PartsCount: 1, ETARows: 1, CompletedRows: 1, Completed: true},
+		{OperationID: "test-operation", Schema: table2.Namespace, Name: table2.Name, PartsCount: 1, ETARows: 1, CompletedRows: 0, Completed: false},
+	}))
+	require.NoError(t, NewSnapshotLoader(cp, "test-operation", transfer, solomon.NewRegistry(nil)).storeResumableState(1, TablesFingerprint(tables)))
+
+	loader := NewSnapshotLoader(cp, "test-operation", transfer, solomon.NewRegistry(nil))
+	resumed, err := loader.ResumeSnapshot()
+	require.NoError(t, err)
+	require.True(t, resumed)
+	require.NoError(t, loader.UploadTables(context.Background(), tables, false))
+
+	require.Equal(t, []abstract.TableID{table2}, storage.loaded)
+	require.Equal(t, []abstract.Kind{abstract.DoneShardedTableLoad}, sinker.kinds[table1])
+	require.Equal(t, []abstract.Kind{
+		abstract.DropTableKind,
+		abstract.InitTableLoad,
+		abstract.InsertKind,
+		abstract.DoneTableLoad,
+		abstract.DoneShardedTableLoad,
+	}, sinker.kinds[table2])
+
+	state, err := loader.loadResumableState()
+	require.NoError(t, err)
+	require.Nil(t, state, "state is removed after snapshot completion")
+}
+
+func TestResumeSnapshotWithOtherTables(t *testing.T) {
+	cp := newResumeCoordinator()
+	storage := &resumeStorage{}
+	sinker := &resumeSinker{kinds: map[abstract.TableID][]abstract.Kind{}}
+	transfer := newResumeTransfer(storage, sinker)
+
+	require.NoError(t, cp.CreateOperationTablesParts("test-operation", []*model.OperationTablePart{
+		{OperationID: "test-operation", Schema: table1.Namespace, Name: table1.Name, PartsCount: 1, ETARows: 1, CompletedRows: 0, Completed: false},
+	}))
+	tables := []abstract.TableDescription{TableDescription(table1)}
+	require.NoError(t, NewSnapshotLoader(cp, "test-operation", transfer, solomon.NewRegistry(nil)).storeResumableState(1, TablesFingerprint(tables)))
+
+	loader := NewSnapshotLoader(cp, "test-operation", transfer, solomon.NewRegistry(nil))
+	resumed, err := loader.ResumeSnapshot()
+	require.NoError(t, err)
+	require.True(t, resumed)
+	err = loader.UploadTables(context.Background(), []abstract.TableDescription{TableDescription(table2)}, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "differ from the ones of the previous attempt")
+	require.Empty(t, storage.loaded)
+}
+
+func TestTablesFingerprint(t *testing.T) {
+	require.Equal(t,
+		TablesFingerprint([]abstract.TableDescription{TableDescription(table1), TableDescription(table2)}),
+		TablesFingerprint([]abstract.TableDescription{TableDescription(table2), TableDescription(table1)}),
+	)
+	require.NotEqual(t,
+		TablesFingerprint([]abstract.TableDescription{TableDescription(table1)}),
+		TablesFingerprint([]abstract.TableDescription{TableDescription(table2)}),
+	)
+	filtered := TableDescription(table1)
+	filtered.Filter = "id > 10"
+	require.NotEqual(t,
+		TablesFingerprint([]abstract.TableDescription{TableDescription(table1)}),
+		TablesFingerprint([]abstract.TableDescription{filtered}),
+	)
+}
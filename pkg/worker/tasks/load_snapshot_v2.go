@@ -428,6 +428,11 @@ func (l *SnapshotLoader) uploadV2Main(ctx context.Context, snapshotProvider base
 	if err := l.cp.CreateOperationWorkers(l.operationID, paralleledRuntime.WorkersNum()); err != nil {
 		return xerrors.Errorf("unable to create operation workers for operation '%v': %w", l.operationID, err)
 	}
+	stopSecondaryWorkers, err := l.launchSecondaryWorkers(ctx)
+	if err != nil {
+		return err
+	}
+	defer stopSecondaryWorkers()
 
 	if err := l.WaitWorkersCompleted(ctx, paralleledRuntime.WorkersNum()); err != nil {
 		return xerrors.Errorf("unable to wait shard completed: %w", err)
@@ -505,7 +510,12 @@ func (l *SnapshotLoader) uploadV2Secondary(ctx context.Context, snapshotProvider
 
 	logger.Log.Infof("Start uploading tables on worker %v", l.workerIndex)
 
-	if err := l.doUploadTablesV2(ctx, snapshotProvider, l.GetRemoteTablePartProvider()); err != nil {
+	err = l.doUploadTablesV2(ctx, snapshotProvider, l.GetRemoteTablePartProvider())
+	if ctx.Err() != nil {
+		l.releaseAssignedTablesParts()
+		return xerrors.Errorf("upload of data objects interrupted on worker '%v': %w", l.workerIndex, ctx.Err())
+	}
+	if err != nil {
 		return xerrors.Errorf("unable to upload data objects: %w", err)
 	}
 
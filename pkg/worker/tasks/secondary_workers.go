@@ -0,0 +1,72 @@
+package tasks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/library/go/core/xerrors"
+	"github.com/transferia/transferia/pkg/abstract/coordinator"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/errors"
+	"github.com/transferia/transferia/pkg/errors/categories"
+	"go.ytsaurus.tech/library/go/core/log"
+)
+
+// operationHealthInterval is how often workers signal they are alive by OperationHealth
+const operationHealthInterval = 15 * time.Second
+
+// SecondaryWorkersLauncher is opt-in interface of sharding runtimes which start secondary workers by themselves,
+// otherwise secondary workers must be started externally with the same transfer and operation
+type SecondaryWorkersLauncher interface {
+	// LaunchSecondaryWorkers is called by *main* worker once operation workers are created in coordinator.
+	// Secondary workers are watched and replaced on failures until returned stop is called, stop tears them down.
+	LaunchSecondaryWorkers(ctx context.Context, cp coordinator.Coordinator, transfer *model.Transfer, operationID string) (stop func(), err error)
+}
+
+func (l *SnapshotLoader) launchSecondaryWorkers(ctx context.Context) (func(), error) {
+	launcher, ok := l.transfer.Runtime.(SecondaryWorkersLauncher)
+	if !ok {
+		return func() {}, nil
+	}
+	stop, err := launcher.LaunchSecondaryWorkers(ctx, l.cp, l.transfer, l.operationID)
+	if err != nil {
+		return nil, errors.CategorizedErrorf(categories.Internal, "unable to launch secondary workers for operation '%v': %w", l.operationID, err)
+	}
+	return stop, nil
+}
+
+// RunSecondaryWorker runs secondary worker of sharded operation: it signals the worker is alive while f runs
+// and reports the result of f to *main* worker by FinishOperation.
+func RunSecondaryWorker(ctx context.Context, cp coordinator.Coordinator, operationID string, workerIndex int, f func() error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(operationHealthInterval)
+		defer ticker.Stop()
+		for {
+			if err := cp.OperationHealth(ctx, operationID, workerIndex, time.Now().UTC()); err != nil {
+				logger.Log.Error("unable send operation health", log.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	err := f()
+	cancel()
+	wg.Wait()
+	if finishErr := cp.FinishOperation(operationID, workerIndex, err); finishErr != nil {
+		if err != nil {
+			return xerrors.Errorf("unable to finish operation: %v, worker failed: %w", finishErr, err)
+		}
+		return xerrors.Errorf("unable to finish operation: %w", finishErr)
+	}
+	return err
+}
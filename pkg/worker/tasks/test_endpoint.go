@@ -73,7 +73,10 @@ func SniffReplicationData(
 			if err != nil {
 				return tr.NotOk(ConfigCheckType, xerrors.Errorf("unable to assign transformer: %w", err))
 			}
-			if err := wrapper(collector).Push(rows); err != nil {
+			sink := wrapper(collector)
+			err = sink.Push(rows)
+			_ = sink.Close()
+			if err != nil {
 				return tr.NotOk(DataSampleCheckType, xerrors.Errorf("unable to push items to transformation: %w", err))
 			}
 			previewMap[table] = collector.res[table]
@@ -137,14 +140,15 @@ func SniffSnapshotData(ctx context.Context, tr *abstract.TestResult, transfer *m
 			ctx:    cctx,
 			cancel: cancel,
 		}
+		if abstract.IsSystemTable(table.Name) {
+			cancel()
+			continue
+		}
 		wrapper, err := middlewares.Transformation(transfer, logger.Log, metrics)
 		if err != nil {
 			return tr.NotOk(ConfigCheckType, xerrors.Errorf("unable to assign transformer: %w", err))
 		}
 		sinker := wrapper(collector)
-		if abstract.IsSystemTable(table.Name) {
-			continue
-		}
 		tdesc := abstract.TableDescription{
 			Name:   table.Name,
 			Schema: table.Namespace,
@@ -167,6 +171,7 @@ func SniffSnapshotData(ctx context.Context, tr *abstract.TestResult, transfer *m
 		} else {
 			err = sourceStorage.LoadTable(cctx, tdesc, sinker.Push)
 		}
+		_ = sinker.Close()
 
 		if err != nil && len(collector.res) == 0 {
 			logger.Log.Warnf("unable to load: %v: %v", table, err)
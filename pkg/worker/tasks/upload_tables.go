@@ -109,6 +109,9 @@ func Upload(ctx context.Context, cp coordinator.Coordinator, transfer model.Tran
 				cleanupTableMap[t.ID()] = abstract.TableInfo{EtaRow: t.EtaRow, IsView: false, Schema: nil}
 			}
 		}
+		if _, err := snapshotLoader.ResumeSnapshot(); err != nil {
+			return xerrors.Errorf("Failed to check if upload can be resumed (%v): %w", transfer.ID, err)
+		}
 		if err := snapshotLoader.CleanupSinker(cleanupTableMap); err != nil {
 			return xerrors.Errorf("Failed to clean up pusher: %w", err)
 		}
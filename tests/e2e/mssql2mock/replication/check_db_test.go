@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transferia/transferia/internal/logger"
+	"github.com/transferia/transferia/pkg/abstract"
+	"github.com/transferia/transferia/pkg/abstract/model"
+	"github.com/transferia/transferia/pkg/providers/mssql"
+	"github.com/transferia/transferia/pkg/providers/mssql/mssqlrecipe"
+	"github.com/transferia/transferia/tests/helpers"
+)
+
+var Source = mssqlrecipe.RecipeSource()
+
+func exec(t *testing.T, query string) {
+	require.NoError(t, mssqlrecipe.Exec(Source.Database, query))
+}
+
+func TestSharding(t *testing.T) {
+	exec(t, `CREATE TABLE dbo.events (id nvarchar(10) PRIMARY KEY, payload int)`)
+	var values []string
+	for i := 0; i < 25; i++ {
+		values = append(values, fmt.Sprintf("(N'key%02d', %d)", i, i))
+	}
+	exec(t, "INSERT INTO dbo.events VALUES "+strings.Join(values, ", "))
+
+	src := *Source
+	src.DesiredShardRows = 10
+	storage, err := mssql.NewStorage(&src, logger.Log)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	parts, err := storage.ShardTable(context.Background(), abstract.TableDescription{Name: "events", Schema: "dbo"})
+	require.NoError(t, err)
+	require.Len(t, parts, 3)
+	rows := 0
+	for _, part := range parts {
+		require.NoError(t, storage.LoadTable(context.Background(), part, func(items []abstract.ChangeItem) error {
+			rows += len(items)
+			return nil
+		}))
+	}
+	require.Equal(t, 25, rows)
+}
+
+func TestSnapshotAndReplication(t *testing.T) {
+	exec(t, `CREATE TABLE dbo.users (id int PRIMARY KEY, name nvarchar(50), balance decimal(10,2), created datetime2)`)
+	exec(t, `INSERT INTO dbo.users VALUES (1, N'Alice', 10.50, '2024-01-01'), (2, N'Bob', 0, NULL)`)
+
+	src := *Source
+	src.IncludeTables = []string{"dbo.users"}
+	sinker := &helpers.MockSink{}
+	target := model.MockDestination{
+		SinkerFactory: func() abstract.Sinker { return sinker },
+		Cleanup:       model.DisabledCleanup,
+	}
+	transfer := helpers.MakeTransfer("mssql", &src, &target, abstract.TransferTypeSnapshotAndIncrement)
+
+	mutex := sync.Mutex{}
+	var items []abstract.ChangeItem
+	sinker.PushCallback = func(input []abstract.ChangeItem) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, item := range input {
+			if item.IsRowEvent() && item.Table == "users" {
+				items = append(items, item)
+			}
+		}
+	}
+	waitItems := func(count int) []abstract.ChangeItem {
+		require.Eventually(t, func() bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return len(items) >= count
+		}, 2*time.Minute, 100*time.Millisecond)
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]abstract.ChangeItem{}, items...)
+	}
+
+	worker := helpers.Activate(t, transfer)
+	snapshot := waitItems(2)
+	require.Equal(t, abstract.InsertKind, snapshot[0].Kind)
+	require.Equal(t, "10.50", fmt.Sprint(snapshot[0].AsMap()["balance"]))
+
+	exec(t, `INSERT INTO dbo.users VALUES (3, N'Carol', 1, NULL)`)
+	exec(t, `UPDATE dbo.users SET name = N'Bobby' WHERE id = 2`)
+	exec(t, `DELETE FROM dbo.users WHERE id = 1`)
+
+	changes := waitItems(2 + 3)[2:]
+	require.Equal(t, abstract.InsertKind, changes[0].Kind)
+	require.Equal(t, int32(3), changes[0].AsMap()["id"])
+	require.Equal(t, abstract.UpdateKind, changes[1].Kind)
+	require.Equal(t, "Bobby", changes[1].AsMap()["name"])
+	require.Equal(t, []interface{}{int32(2)}, changes[1].OldKeys.KeyValues)
+	require.Equal(t, abstract.DeleteKind, changes[2].Kind)
+	require.Equal(t, []string{"id"}, changes[2].OldKeys.KeyNames)
+	require.Equal(t, []interface{}{int32(1)}, changes[2].OldKeys.KeyValues)
+
+	worker.Close(t)
+	require.NoError(t, helpers.Deactivate(t, transfer, worker))
+	require.NoError(t, mssqlrecipe.Exec(Source.Database, `IF EXISTS (SELECT 1 FROM cdc.change_tables WHERE capture_instance = 'transferia_mssql_dbo_users') THROW 50000, 'capture instance is not removed', 1`))
+}